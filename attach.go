@@ -0,0 +1,127 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// attachFile returns pdf with data embedded as a file attachment named
+// name (ISO 32000-1, 7.11.4), listed in the catalog's EmbeddedFiles name
+// tree and its associated files (/AF, ISO 32000-2, 14.13). An existing
+// attachment with the same name is replaced. mime, if not empty, is
+// recorded as the file's subtype.
+func attachFile(pdf []byte, name string, data []byte, mime string, now time.Time) ([]byte, error) {
+	if name == "" {
+		return nil, fmt.Errorf("attachment has no name")
+	}
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	sum := md5.Sum(data)
+	file := Dict{
+		"Type":   nameObj("EmbeddedFile"),
+		"Filter": nameObj("FlateDecode"),
+		"Params": dictObj(Dict{
+			"Size":     intObj(len(data)),
+			"ModDate":  textStringObj(formatPDFDate(now)),
+			"CheckSum": {Type: ObjString, Str: sum[:]},
+		}),
+	}
+	if mime != "" {
+		file["Subtype"] = nameObj(mime)
+	}
+	fileRef := u.add(&Object{Type: ObjStream, Dict: file, Stream: compressed.Bytes()})
+	specRef := u.add(dictObj(Dict{
+		"Type":           nameObj("Filespec"),
+		"F":              textStringObj(name),
+		"UF":             textStringObj(name),
+		"EF":             dictObj(Dict{"F": refObj(fileRef), "UF": refObj(fileRef)}),
+		"AFRelationship": nameObj("Unspecified"),
+	}))
+
+	catRef, cat, err := u.catalog()
+	if err != nil {
+		return nil, err
+	}
+	names := Dict{}
+	namesRef, indirect := Reference{}, false
+	if obj := cat["Names"]; obj != nil {
+		if obj.Type == ObjRef {
+			namesRef, indirect = obj.Ref, true
+		}
+		for k, v := range resolveDict(doc, obj) {
+			names[k] = v
+		}
+	}
+	tree := resolveDict(doc, names["EmbeddedFiles"])
+	if _, ok := tree["Kids"]; ok {
+		return nil, fmt.Errorf("cannot add to an EmbeddedFiles name tree with intermediate nodes")
+	}
+	var entries []*Object
+	if arr, ok := tree.GetArray("Names"); ok {
+		entries = arr
+	}
+	key := textStringObj(name)
+	var replaced Reference
+	entries, replaced = insertName(entries, key, refObj(specRef))
+	names["EmbeddedFiles"] = dictObj(Dict{"Names": arrayObj(entries...)})
+	if indirect {
+		u.set(namesRef, dictObj(names))
+	} else {
+		cat["Names"] = dictObj(names)
+	}
+
+	var af []*Object
+	if arr, err := doc.Resolve(cat["AF"]); err == nil && arr != nil && arr.Type == ObjArray {
+		for _, spec := range arr.Array {
+			if spec.Type != ObjRef || spec.Ref != replaced {
+				af = append(af, spec)
+			}
+		}
+	}
+	cat["AF"] = arrayObj(append(af, refObj(specRef))...)
+	u.set(catRef, dictObj(cat))
+	return u.bytes(), nil
+}
+
+// insertName returns the key-value pairs of a name tree leaf with key set
+// to value, keeping the keys sorted, and the reference the key held
+// before, if any.
+func insertName(entries []*Object, key, value *Object) ([]*Object, Reference) {
+	pairs := make([][2]*Object, 0, len(entries)/2+1)
+	var old Reference
+	for i := 0; i+1 < len(entries); i += 2 {
+		if bytes.Equal(entries[i].Str, key.Str) {
+			if entries[i+1].Type == ObjRef {
+				old = entries[i+1].Ref
+			}
+			continue
+		}
+		pairs = append(pairs, [2]*Object{entries[i], entries[i+1]})
+	}
+	pairs = append(pairs, [2]*Object{key, value})
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i][0].Str, pairs[j][0].Str) < 0
+	})
+	out := make([]*Object, 0, 2*len(pairs))
+	for _, p := range pairs {
+		out = append(out, p[0], p[1])
+	}
+	return out, old
+}
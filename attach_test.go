@@ -0,0 +1,93 @@
+package htmlpdf
+
+import (
+	"testing"
+	"time"
+)
+
+// attachments returns the EmbeddedFiles name tree entries of doc as
+// names mapped to their filespec dictionaries.
+func attachments(t *testing.T, doc *Document) ([]string, map[string]Dict) {
+	t.Helper()
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := resolveDict(doc, resolveDict(doc, cat["Names"])["EmbeddedFiles"])
+	entries, _ := tree.GetArray("Names")
+	var names []string
+	specs := make(map[string]Dict)
+	for i := 0; i+1 < len(entries); i += 2 {
+		name := decodeTextString(entries[i].Str)
+		names = append(names, name)
+		specs[name] = resolveDict(doc, entries[i+1])
+	}
+	return names, specs
+}
+
+func TestAttachFile(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	pdf, err := attachFile(pdf, "invoice.xml", []byte("<Invoice/>"), "text/xml", now)
+	if err != nil {
+		t.Fatalf("attachFile: %v", err)
+	}
+	pdf, err = attachFile(pdf, "factur-x.xml", []byte("<old/>"), "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err = attachFile(pdf, "factur-x.xml", []byte("<new/>"), "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := mustLoad(t, pdf)
+	names, specs := attachments(t, doc)
+	if len(names) != 2 || names[0] != "factur-x.xml" || names[1] != "invoice.xml" {
+		t.Fatalf("attachments = %q, want [factur-x.xml invoice.xml] in order", names)
+	}
+
+	spec := specs["invoice.xml"]
+	if f := decodeTextString(spec["UF"].Str); f != "invoice.xml" {
+		t.Errorf("/UF = %q", f)
+	}
+	file, err := doc.Resolve(resolveDict(doc, spec["EF"])["F"])
+	if err != nil || file.Type != ObjStream {
+		t.Fatalf("embedded file = %v, %v", file, err)
+	}
+	if st, _ := file.Dict.GetName("Subtype"); st != "text/xml" {
+		t.Errorf("/Subtype = %q, want text/xml", st)
+	}
+	if size, _ := resolveDict(doc, file.Dict["Params"]).GetInt("Size"); size != int64(len("<Invoice/>")) {
+		t.Errorf("/Size = %d", size)
+	}
+	data, err := DecompressStream(file.Dict, file.Stream)
+	if err != nil || string(data) != "<Invoice/>" {
+		t.Errorf("embedded data = %q, %v", data, err)
+	}
+
+	replaced, _ := doc.Resolve(resolveDict(doc, specs["factur-x.xml"]["EF"])["F"])
+	if data, _ := DecompressStream(replaced.Dict, replaced.Stream); string(data) != "<new/>" {
+		t.Errorf("replaced attachment holds %q, want <new/>", data)
+	}
+
+	cat, _ := doc.Catalog()
+	af, _ := cat.GetArray("AF")
+	if len(af) != 2 {
+		t.Errorf("/AF has %d entries, want 2 after replacing one", len(af))
+	}
+}
+
+func TestAttachFile_Errors(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	if _, err := attachFile(pdf, "", []byte("x"), "", time.Now()); err == nil {
+		t.Error("expected error for an empty name")
+	}
+	enc, err := encryptPDF(pdf, "owner", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := attachFile(enc, "a.txt", []byte("x"), "", time.Now()); err == nil {
+		t.Error("expected error for an encrypted document")
+	}
+}
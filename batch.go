@@ -0,0 +1,196 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultBatchConcurrency is the number of inputs [Converter.ConvertMany]
+// converts at once when [WithMaxConcurrent] is not set.
+const defaultBatchConcurrency = 4
+
+// ConvertInput is one document for [Converter.ConvertMany],
+// [Converter.ConvertCombined] or [Converter.Screenshot]. Exactly one of
+// HTML, URL and File must be set.
+type ConvertInput struct {
+	HTML   string            // HTML source, as for [Converter.ConvertHTML]
+	Assets map[string][]byte // assets for HTML, as for [Converter.ConvertHTMLWithAssets]
+	URL    string            // web page, as for [Converter.ConvertURL]
+	File   string            // local HTML file, as for [Converter.ConvertFile]
+
+	// Page overrides the batch's page configuration for this input.
+	Page *PageConfig
+
+	// Options controls request-scoped behaviour for this input.
+	Options *ConvertOptions
+
+	// Title, if set, adds a top-level bookmark with this title at the
+	// input's first page of a [Converter.ConvertCombined] document.
+	Title string
+}
+
+// errInvalidInput is returned for a ConvertInput without exactly one source.
+var errInvalidInput = errors.New("htmlpdf: input must set exactly one of HTML, URL and File")
+
+// source returns the source in describes.
+func (in ConvertInput) source() (source, error) {
+	n := 0
+	for _, set := range []bool{in.HTML != "", in.URL != "", in.File != ""} {
+		if set {
+			n++
+		}
+	}
+	switch {
+	case n != 1:
+		return source{}, errInvalidInput
+	case in.HTML != "":
+		return source{document: []byte(in.HTML), assets: newAssetMap(in.Assets)}, nil
+	case in.URL != "":
+		return urlSource(in.URL)
+	default:
+		return fileSource(in.File)
+	}
+}
+
+// convert converts in with c, using pg unless in.Page is set.
+func (in ConvertInput) convert(ctx context.Context, c *Converter, pg *PageConfig) (*Result, error) {
+	if in.Page != nil {
+		pg = in.Page
+	}
+	src, err := in.source()
+	if err != nil {
+		return nil, err
+	}
+	return c.convert(ctx, src, pg, in.Options)
+}
+
+// ConvertMany converts every input, several at a time, and returns their
+// results in input order. It suits batch jobs rendering many documents.
+// If pg is nil, [DefaultPageConfig] values are used for inputs that do not
+// set their own Page.
+//
+// Inputs are converted with the limit set by [WithMaxConcurrent] in
+// parallel, or 4 if it is not set. One input failing does not stop the
+// others: if any fail, ConvertMany returns the results that succeeded,
+// with nil for the rest, and a *[BatchError] holding each input's error.
+func (c *Converter) ConvertMany(ctx context.Context, inputs []ConvertInput, pg *PageConfig) ([]*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	workers := c.cfg.maxTabs
+	if workers <= 0 {
+		workers = defaultBatchConcurrency
+	}
+	workers = min(workers, len(inputs))
+
+	results := make([]*Result, len(inputs))
+	errs := make([]error, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = inputs[i].convert(ctx, c, pg)
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return results, &BatchError{Errors: errs}
+		}
+	}
+	return results, nil
+}
+
+// ConvertCombined converts every input, as [Converter.ConvertMany] does,
+// and concatenates their pages in input order into a single PDF, such as
+// a cover page, a body and an appendix rendered from separate documents.
+// If pg is nil, [DefaultPageConfig] values are used for inputs that do
+// not set their own Page.
+//
+// The Metadata, PDFA, Linearize and Reproducible settings of pg apply to
+// the combined document; those of the inputs' own pages are ignored. Bookmarks and
+// document outlines are not carried over from the inputs; set their
+// Title to bookmark each part instead. FillableForms is not supported:
+// the inputs' form controls are printed as usual. If any input fails,
+// ConvertCombined returns a *[BatchError]. The limit of
+// [WithMaxOutputSize] applies to each input and to the combined PDF.
+func (c *Converter) ConvertCombined(ctx context.Context, inputs []ConvertInput, pg *PageConfig) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("htmlpdf: no inputs to combine")
+	}
+	page := pg.resolved()
+	parts := make([]ConvertInput, len(inputs))
+	for i, in := range inputs {
+		part := page
+		if in.Page != nil {
+			part = in.Page.resolved()
+		}
+		part.Metadata, part.PDFA, part.Linearize, part.Reproducible = Metadata{}, false, false, false
+		part.FillableForms = false
+		in.Page = &part
+		parts[i] = in
+	}
+
+	results, err := c.ConvertMany(ctx, parts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pdfs := make([][]byte, len(results))
+	titles := make([]string, len(results))
+	combined := &Result{}
+	for i, res := range results {
+		pdfs[i], titles[i] = res.data, inputs[i].Title
+		combined.failed = append(combined.failed, res.failed...)
+		combined.console = append(combined.console, res.console...)
+		combined.pageErrors = append(combined.pageErrors, res.pageErrors...)
+		combined.network = append(combined.network, res.network...)
+	}
+	if combined.data, err = mergePDFs(pdfs, titles); err != nil {
+		return nil, fmt.Errorf("htmlpdf: combining PDFs: %w", err)
+	}
+
+	if page.Reproducible {
+		if combined.data, err = makeReproducible(combined.data); err != nil {
+			return nil, fmt.Errorf("htmlpdf: normalizing PDF: %w", err)
+		}
+	}
+	if page.PDFA {
+		if combined.data, err = makePDFA(combined.data, page.Metadata, postProcessTime(page.Reproducible)); err != nil {
+			var pdfaErr *PDFAError
+			if errors.As(err, &pdfaErr) {
+				return nil, pdfaErr
+			}
+			return nil, fmt.Errorf("htmlpdf: converting to PDF/A: %w", err)
+		}
+	} else if !page.Metadata.isZero() {
+		if combined.data, err = setMetadata(combined.data, page.Metadata, postProcessTime(page.Reproducible)); err != nil {
+			return nil, fmt.Errorf("htmlpdf: setting metadata: %w", err)
+		}
+	}
+	if page.Linearize {
+		if combined.data, err = linearizePDF(combined.data); err != nil {
+			return nil, fmt.Errorf("htmlpdf: linearizing: %w", err)
+		}
+	}
+	if c.cfg.maxOutput > 0 && int64(len(combined.data)) > c.cfg.maxOutput {
+		c.cfg.logger.Warn("htmlpdf: combined output too large", "inputs", len(inputs), "limit", c.cfg.maxOutput)
+		return nil, fmt.Errorf("%w of %d bytes", ErrOutputTooLarge, c.cfg.maxOutput)
+	}
+	return combined, nil
+}
@@ -0,0 +1,78 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestConvertMany_PerInputErrors(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	inputs := []ConvertInput{
+		{},
+		{HTML: "<p>x</p>", URL: "https://example.com"},
+		{File: "testdata/does-not-exist.html"},
+	}
+	results, err := c.ConvertMany(context.Background(), inputs, nil)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want *BatchError", err)
+	}
+	if len(results) != len(inputs) || len(batchErr.Errors) != len(inputs) {
+		t.Fatalf("got %d results and %d errors, want %d of each", len(results), len(batchErr.Errors), len(inputs))
+	}
+	for i, e := range batchErr.Errors[:2] {
+		if e != errInvalidInput {
+			t.Errorf("input %d: err = %v, want errInvalidInput", i, e)
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("errors.Is(err, fs.ErrNotExist) = false for %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "htmlpdf: 3 of 3 conversions failed; input 0: ") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestConvertMany_Closed(t *testing.T) {
+	c := &Converter{cfg: defaultConfig(), closed: true}
+	if _, err := c.ConvertMany(context.Background(), []ConvertInput{{HTML: "x"}}, nil); err != ErrClosed {
+		t.Errorf("err = %v, want ErrClosed", err)
+	}
+}
+
+func TestConvertMany_Empty(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	results, err := c.ConvertMany(context.Background(), nil, nil)
+	if err != nil || len(results) != 0 {
+		t.Errorf("ConvertMany(nil) = %v, %v; want no results and no error", results, err)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	boom := errors.New("boom")
+	err := &BatchError{Errors: []error{nil, boom, nil}}
+	if got, want := err.Error(), "htmlpdf: 1 of 3 conversions failed; input 1: boom"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, boom) {
+		t.Error("errors.Is(err, boom) = false")
+	}
+}
+
+func TestConvertCombined_Closed(t *testing.T) {
+	c := &Converter{cfg: defaultConfig(), closed: true}
+	if _, err := c.ConvertCombined(context.Background(), []ConvertInput{{HTML: "x"}}, nil); err != ErrClosed {
+		t.Errorf("err = %v, want ErrClosed", err)
+	}
+}
+
+func TestConvertCombined_NoInputs(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	if _, err := c.ConvertCombined(context.Background(), nil, nil); err == nil {
+		t.Error("ConvertCombined(nil) succeeded")
+	}
+}
@@ -1,18 +1,283 @@
 package htmlpdf
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
 	"github.com/go-rod/rod/lib/launcher"
 )
 
+// snapshotStorage is the base URL of Google's Chromium snapshot storage,
+// which [WithBrowserDownloadHost] replaces.
+const snapshotStorage = "https://storage.googleapis.com/chromium-browser-snapshots"
+
 // resolveBrowser downloads a compatible Chromium binary if one is not
 // already cached and returns the path to the executable. The binary is
-// stored in ~/.cache/rod/browser (Unix) or %APPDATA%\rod\browser (Windows).
-func resolveBrowser() (string, error) {
-	path, err := launcher.NewBrowser().Get()
+// stored in ~/.cache/rod/browser (Unix) or %APPDATA%\rod\browser (Windows)
+// unless [WithBrowserCacheDir] is set.
+func resolveBrowser(cfg *converterConfig) (string, error) {
+	path, err := browserDownloader(cfg).Get()
 	if err != nil {
 		return "", fmt.Errorf("htmlpdf: downloading browser: %w", err)
 	}
 	return path, nil
 }
+
+// DefaultBrowserCandidates returns the browser executables looked for,
+// in order, when neither [WithChromePath] nor [WithAutoDownload] is set:
+// names searched for in PATH and absolute paths of the usual install
+// locations for the current platform. Use it with
+// [WithBrowserCandidates] to add to the list rather than replace it.
+func DefaultBrowserCandidates() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		}
+	case "windows":
+		profile := os.Getenv("USERPROFILE")
+		return []string{
+			"chrome",
+			"chrome.exe", // in case PATHEXT is misconfigured
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			filepath.Join(profile, `AppData\Local\Google\Chrome\Application\chrome.exe`),
+			filepath.Join(profile, `AppData\Local\Chromium\Application\chrome.exe`),
+		}
+	default:
+		return []string{
+			"headless_shell",
+			"headless-shell",
+			"chromium",
+			"chromium-browser",
+			"google-chrome",
+			"google-chrome-stable",
+			"google-chrome-beta",
+			"google-chrome-unstable",
+			"/usr/bin/google-chrome",
+			"/usr/local/bin/chrome",
+			"/snap/bin/chromium",
+			"chrome",
+		}
+	}
+}
+
+// FindBrowser returns the path of the browser executable a [Converter]
+// created with opts would launch, without launching it, e.g. to report it
+// at startup. With [WithAutoDownload], it is the path the browser is, or
+// will be, downloaded to. It fails with [ErrBrowserNotFound] if none of
+// the candidates (see [WithBrowserCandidates]) is installed.
+func FindBrowser(opts ...Option) (string, error) {
+	cfg := defaultConfig()
+	for _, o := range opts {
+		o(&cfg)
+	}
+	switch {
+	case cfg.remoteURL != "":
+		return "", errors.New("htmlpdf: no browser is launched with WithRemoteBrowser")
+	case cfg.chromePath != "":
+		return cfg.chromePath, nil
+	case cfg.autoDownload:
+		return browserDownloader(&cfg).BinPath(), nil
+	}
+	return findBrowser(&cfg)
+}
+
+// findBrowser returns the first of the browser candidates for cfg that is
+// installed.
+func findBrowser(cfg *converterConfig) (string, error) {
+	candidates := cfg.browserNames
+	if candidates == nil {
+		candidates = DefaultBrowserCandidates()
+	}
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w (looked for %s)", ErrBrowserNotFound, strings.Join(candidates, ", "))
+}
+
+// browserDownloader returns the launcher that fetches the browser for cfg.
+func browserDownloader(cfg *converterConfig) *launcher.Browser {
+	b := launcher.NewBrowser()
+	if cfg.browserRev > 0 {
+		b.Revision = cfg.browserRev
+	}
+	if cfg.browserCache != "" {
+		b.RootDir = cfg.browserCache
+	}
+	if cfg.browserHost != "" {
+		b.Hosts = []launcher.Host{mirrorHost(cfg.browserHost)}
+	}
+	return b
+}
+
+// mirrorHost returns a launcher host that downloads from a mirror of the
+// snapshot storage at baseURL.
+func mirrorHost(baseURL string) launcher.Host {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return func(revision int) string {
+		return baseURL + strings.TrimPrefix(launcher.HostGoogle(revision), snapshotStorage)
+	}
+}
+
+// newAllocator returns a chromedp allocator context for cfg: a connection
+// to a remote browser if one is configured, otherwise a local browser
+// process.
+func newAllocator(cfg *converterConfig) (context.Context, context.CancelFunc, error) {
+	if cfg.remoteURL != "" {
+		var opts []chromedp.RemoteAllocatorOption
+		if !remoteURLNeedsDiscovery(cfg.remoteURL) {
+			opts = append(opts, chromedp.NoModifyURL)
+		}
+		ctx, cancel := chromedp.NewRemoteAllocator(context.Background(), cfg.remoteURL, opts...)
+		return ctx, cancel, nil
+	}
+
+	// Resolve browser path: explicit > auto-download > candidates.
+	if cfg.chromePath == "" {
+		resolve := findBrowser
+		if cfg.autoDownload {
+			resolve = resolveBrowser
+		}
+		path, err := resolve(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.chromePath = path
+	}
+
+	allocOpts := append(
+		chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-background-networking", true),
+		chromedp.Flag("disable-sync", true),
+		chromedp.Flag("disable-translate", true),
+		chromedp.Flag("no-first-run", true),
+		chromedp.Flag("headless", cfg.headless),
+	)
+	allocOpts = append(allocOpts, chromedp.ExecPath(cfg.chromePath))
+	if cfg.noSandbox {
+		allocOpts = append(allocOpts, chromedp.Flag("no-sandbox", true))
+	}
+	if cfg.jsHeapLimit > 0 {
+		allocOpts = append(allocOpts, chromedp.Flag("js-flags", fmt.Sprintf("--max-old-space-size=%d", cfg.jsHeapLimit)))
+	}
+	if cfg.rendererLimit > 0 {
+		allocOpts = append(allocOpts, chromedp.Flag("renderer-process-limit", cfg.rendererLimit))
+	}
+	if cfg.proxy != "" {
+		allocOpts = append(allocOpts, chromedp.ProxyServer(cfg.proxy))
+		if len(cfg.proxyBypass) > 0 {
+			allocOpts = append(allocOpts, chromedp.Flag("proxy-bypass-list", proxyBypassList(cfg.proxyBypass)))
+		}
+	}
+
+	// Directories to remove once the browser has exited.
+	var remove []string
+	if len(cfg.fontDirs) > 0 {
+		confDir, err := writeFontConfig(cfg.tempDir, cfg.fontDirs)
+		if err != nil {
+			return nil, nil, err
+		}
+		remove = append(remove, confDir)
+		allocOpts = append(allocOpts, chromedp.Env("FONTCONFIG_FILE="+filepath.Join(confDir, "fonts.conf")))
+	}
+	if cfg.userDataDir != "" {
+		allocOpts = append(allocOpts, chromedp.UserDataDir(cfg.userDataDir))
+	}
+	if cfg.tempDir != "" {
+		if cfg.userDataDir == "" {
+			// chromedp only removes profile directories it created itself.
+			dataDir, err := os.MkdirTemp(cfg.tempDir, "htmlpdf-chrome-*")
+			if err != nil {
+				for _, dir := range remove {
+					os.RemoveAll(dir)
+				}
+				return nil, nil, fmt.Errorf("htmlpdf: creating browser profile directory: %w", err)
+			}
+			remove = append(remove, dataDir)
+			allocOpts = append(allocOpts, chromedp.UserDataDir(dataDir))
+		}
+		allocOpts = append(allocOpts, chromedp.Env("TMPDIR="+cfg.tempDir))
+	}
+
+	ctx, cancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+	if len(remove) == 0 {
+		return ctx, cancel, nil
+	}
+	return ctx, func() {
+		// The cancel function waits for the browser process to exit.
+		cancel()
+		for _, dir := range remove {
+			os.RemoveAll(dir)
+		}
+	}, nil
+}
+
+// remoteURLNeedsDiscovery reports whether the WebSocket debugger URL must
+// be looked up from the browser's /json/version endpoint. HTTP endpoints
+// and DevTools browser URLs go through chromedp's discovery, which also
+// resolves host names to IPs as Chrome requires. Other WebSocket URLs,
+// such as browserless endpoints carrying a token, are dialled as-is.
+func remoteURLNeedsDiscovery(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return true
+	}
+	if strings.Contains(u.Path, "/devtools/browser/") {
+		return true
+	}
+	// A bare ws://host:port is a plain Chrome debugging port.
+	return (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
+
+// tabOptions returns the chromedp context options for a conversion tab.
+// A tab that needs a proxy the browser was not launched with, that sets
+// cookies, or whose converter was configured with [WithIncognito] gets
+// its own browser context carrying the proxy settings. The context is
+// discarded with the tab, so cookies do not leak into other conversions.
+func tabOptions(cfg *converterConfig, o ConvertOptions) []chromedp.ContextOption {
+	proxy, bypass := o.Proxy, o.ProxyBypass
+	if proxy == "" && cfg.remoteURL != "" {
+		// A local browser was launched with the converter's proxy; a
+		// remote one was not.
+		proxy, bypass = cfg.proxy, cfg.proxyBypass
+	}
+	if proxy == "" && len(o.Cookies) == 0 && !cfg.incognito {
+		return nil
+	}
+	return []chromedp.ContextOption{
+		chromedp.WithNewBrowserContext(func(p *target.CreateBrowserContextParams) *target.CreateBrowserContextParams {
+			if proxy != "" {
+				p = p.WithProxyServer(proxy)
+				if len(bypass) > 0 {
+					p = p.WithProxyBypassList(proxyBypassList(bypass))
+				}
+			}
+			return p
+		}),
+	}
+}
+
+// proxyBypassList formats bypass rules in Chrome's semicolon-separated
+// --proxy-bypass-list syntax.
+func proxyBypassList(rules []string) string {
+	return strings.Join(rules, ";")
+}
@@ -0,0 +1,240 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+func TestRemoteURLNeedsDiscovery(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://chrome:9222", true},
+		{"http://127.0.0.1:9222/", true},
+		{"ws://127.0.0.1:9222", true},
+		{"ws://127.0.0.1:9222/", true},
+		{"ws://chrome:9222/devtools/browser/6a3e1c", true},
+		{"ws://browserless:3000?token=secret", false},
+		{"wss://chrome.example.com/playwright/chromium", false},
+	}
+	for _, tt := range tests {
+		if got := remoteURLNeedsDiscovery(tt.url); got != tt.want {
+			t.Errorf("remoteURLNeedsDiscovery(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTabOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  converterConfig
+		o    ConvertOptions
+		want int
+	}{
+		{"no proxy", converterConfig{}, ConvertOptions{}, 0},
+		{"local launch proxy", converterConfig{proxy: "http://p:3128"}, ConvertOptions{}, 0},
+		{"remote proxy", converterConfig{remoteURL: "ws://c:9222", proxy: "http://p:3128"}, ConvertOptions{}, 1},
+		{"per-conversion proxy", converterConfig{}, ConvertOptions{Proxy: "http://p:3128"}, 1},
+		{"cookies", converterConfig{}, ConvertOptions{Cookies: []*http.Cookie{{Name: "session", Value: "x"}}}, 1},
+	}
+	for _, tt := range tests {
+		if got := len(tabOptions(&tt.cfg, tt.o)); got != tt.want {
+			t.Errorf("%s: len(tabOptions) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProxyBypassList(t *testing.T) {
+	got := proxyBypassList([]string{"*.internal", "10.0.0.0/8", "<local>"})
+	if want := "*.internal;10.0.0.0/8;<local>"; got != want {
+		t.Errorf("proxyBypassList = %q, want %q", got, want)
+	}
+}
+
+func TestBrowserDownloader(t *testing.T) {
+	def := browserDownloader(&converterConfig{})
+	if def.Revision != launcher.RevisionDefault || def.RootDir != launcher.DefaultBrowserDir {
+		t.Errorf("default downloader = revision %d in %q, want go-rod's defaults", def.Revision, def.RootDir)
+	}
+
+	cfg := defaultConfig()
+	for _, o := range []Option{WithBrowserRevision(1300000), WithBrowserCacheDir("/var/cache/chromium")} {
+		o(&cfg)
+	}
+	b := browserDownloader(&cfg)
+	if b.Revision != 1300000 {
+		t.Errorf("Revision = %d, want 1300000", b.Revision)
+	}
+	if want := filepath.Join("/var/cache/chromium", "chromium-1300000"); b.Dir() != want {
+		t.Errorf("Dir = %q, want %q", b.Dir(), want)
+	}
+}
+
+func TestBrowserDownloader_Mirror(t *testing.T) {
+	cfg := defaultConfig()
+	WithBrowserDownloadHost("https://artifacts.example.com/chromium/")(&cfg)
+	b := browserDownloader(&cfg)
+	if len(b.Hosts) != 1 {
+		t.Fatalf("got %d hosts, want only the mirror", len(b.Hosts))
+	}
+	got := b.Hosts[0](1321438)
+	want := "https://artifacts.example.com/chromium" + strings.TrimPrefix(launcher.HostGoogle(1321438), snapshotStorage)
+	if got != want {
+		t.Errorf("mirror URL = %q, want %q", got, want)
+	}
+	if !strings.Contains(got, "/1321438/") {
+		t.Errorf("mirror URL %q does not contain the revision", got)
+	}
+}
+
+func TestFindBrowser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake browser is a shell script")
+	}
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "thorium-browser")
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	got, err := FindBrowser(WithBrowserCandidates("not-a-browser", "thorium-browser", "/usr/bin/env"))
+	if err != nil || got != fake {
+		t.Errorf("FindBrowser = %q, %v; want %q", got, err, fake)
+	}
+	if got, err := FindBrowser(WithBrowserCandidates("/usr/bin/env", "thorium-browser")); err != nil || got != "/usr/bin/env" {
+		t.Errorf("FindBrowser with a path first = %q, %v; want /usr/bin/env", got, err)
+	}
+	if _, err := FindBrowser(WithBrowserCandidates("not-a-browser")); !errors.Is(err, ErrBrowserNotFound) {
+		t.Errorf("FindBrowser with no candidate installed = %v, want ErrBrowserNotFound", err)
+	}
+	if got, err := FindBrowser(WithChromePath("/opt/chrome/chrome"), WithBrowserCandidates("thorium-browser")); err != nil || got != "/opt/chrome/chrome" {
+		t.Errorf("FindBrowser with WithChromePath = %q, %v; want the path", got, err)
+	}
+	cache := t.TempDir()
+	if got, err := FindBrowser(WithAutoDownload(), WithBrowserCacheDir(cache)); err != nil || !strings.HasPrefix(got, cache) {
+		t.Errorf("FindBrowser with WithAutoDownload = %q, %v; want a path in the cache", got, err)
+	}
+	if _, err := FindBrowser(WithRemoteBrowser("ws://127.0.0.1:9222")); err == nil {
+		t.Error("FindBrowser with WithRemoteBrowser succeeded")
+	}
+}
+
+func TestNewAllocator_BrowserNotFound(t *testing.T) {
+	cfg := defaultConfig()
+	WithBrowserCandidates("not-a-browser")(&cfg)
+	if _, _, err := newAllocator(&cfg); !errors.Is(err, ErrBrowserNotFound) {
+		t.Errorf("newAllocator = %v, want ErrBrowserNotFound", err)
+	}
+}
+
+func TestDefaultBrowserCandidates(t *testing.T) {
+	a := DefaultBrowserCandidates()
+	if len(a) == 0 {
+		t.Fatal("no default candidates")
+	}
+	a[0] = "changed"
+	if DefaultBrowserCandidates()[0] == "changed" {
+		t.Error("DefaultBrowserCandidates returned a shared slice")
+	}
+}
+
+func TestNewAllocator_TempDir(t *testing.T) {
+	dir := t.TempDir()
+	cfg := defaultConfig()
+	cfg.chromePath = "/nonexistent/chrome"
+	WithTempDir(dir)(&cfg)
+
+	_, cancel, err := newAllocator(&cfg)
+	if err != nil {
+		t.Fatalf("newAllocator: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "htmlpdf-chrome-") {
+		t.Fatalf("temp dir holds %v, want one profile directory", entries)
+	}
+	cancel()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("temp dir holds %v after cancel, want it empty", entries)
+	}
+
+	WithTempDir(filepath.Join(dir, "missing"))(&cfg)
+	if _, _, err := newAllocator(&cfg); err == nil {
+		t.Error("expected error for a missing temp dir")
+	}
+}
+
+func TestNewAllocator_UserDataDir(t *testing.T) {
+	tmp, profile := t.TempDir(), t.TempDir()
+	cfg := defaultConfig()
+	cfg.chromePath = "/nonexistent/chrome"
+	WithTempDir(tmp)(&cfg)
+	WithUserDataDir(profile)(&cfg)
+
+	_, cancel, err := newAllocator(&cfg)
+	if err != nil {
+		t.Fatalf("newAllocator: %v", err)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("temp dir holds %v, want no temporary profile", entries)
+	}
+	cancel()
+	if _, err := os.Stat(profile); err != nil {
+		t.Errorf("profile directory removed on cancel: %v", err)
+	}
+}
+
+func TestTabOptions_Incognito(t *testing.T) {
+	cfg := defaultConfig()
+	if opts := tabOptions(&cfg, ConvertOptions{}); opts != nil {
+		t.Errorf("tabOptions = %v, want the default browser context", opts)
+	}
+	WithIncognito()(&cfg)
+	if opts := tabOptions(&cfg, ConvertOptions{}); len(opts) != 1 {
+		t.Errorf("tabOptions with WithIncognito = %v, want a new browser context", opts)
+	}
+}
+
+func TestConverter_RelaunchesBrowser(t *testing.T) {
+	found := false
+	for _, name := range []string{"chromium-browser", "chromium", "google-chrome", "google-chrome-stable", "chrome"} {
+		if _, err := exec.LookPath(name); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		t.Skip("skipping: Chrome/Chromium not found in PATH")
+	}
+
+	c, err := NewConverter(WithNoSandbox())
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	// Simulate the browser dying: chromedp cancels the browser context
+	// when it loses the connection.
+	c.mu.Lock()
+	c.browserCancel()
+	c.mu.Unlock()
+	if c.healthy() {
+		t.Fatal("converter healthy after its browser exited")
+	}
+
+	if _, err := c.ConvertHTML(context.Background(), "<p>after restart</p>", nil); err != nil {
+		t.Fatalf("ConvertHTML after browser exit: %v", err)
+	}
+	if !c.healthy() {
+		t.Error("converter not healthy after relaunch")
+	}
+}
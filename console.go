@@ -0,0 +1,94 @@
+package htmlpdf
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// ConsoleMessage is a call to a console method (console.log,
+// console.error, ...) made by the page during conversion.
+type ConsoleMessage struct {
+	Type string // the console method, e.g. "log", "warning" or "error"
+	Text string // the arguments, formatted and separated by spaces
+}
+
+// PageError is an uncaught JavaScript exception thrown by the page during
+// conversion.
+type PageError struct {
+	Message string // the exception, including its stack trace if available
+	URL     string // script URL, if known
+	Line    int    // 1-based line number
+	Column  int    // 1-based column number
+}
+
+// pageConsole records the console output and uncaught exceptions of a tab.
+type pageConsole struct {
+	mu       sync.Mutex
+	messages []ConsoleMessage
+	errors   []PageError
+}
+
+// listen is a chromedp target listener. It must not block.
+func (p *pageConsole) listen(ev any) {
+	switch e := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		args := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			args[i] = formatRemoteObject(a)
+		}
+		p.mu.Lock()
+		p.messages = append(p.messages, ConsoleMessage{Type: string(e.Type), Text: strings.Join(args, " ")})
+		p.mu.Unlock()
+	case *runtime.EventExceptionThrown:
+		d := e.ExceptionDetails
+		msg := d.Text
+		if d.Exception != nil && d.Exception.Description != "" {
+			msg = d.Exception.Description
+		}
+		p.mu.Lock()
+		p.errors = append(p.errors, PageError{
+			Message: msg,
+			URL:     d.URL,
+			Line:    int(d.LineNumber) + 1,
+			Column:  int(d.ColumnNumber) + 1,
+		})
+		p.mu.Unlock()
+	}
+}
+
+// snapshot returns copies of the messages and errors recorded so far.
+func (p *pageConsole) snapshot() ([]ConsoleMessage, []PageError) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var msgs []ConsoleMessage
+	var errs []PageError
+	if len(p.messages) > 0 {
+		msgs = append(msgs, p.messages...)
+	}
+	if len(p.errors) > 0 {
+		errs = append(errs, p.errors...)
+	}
+	return msgs, errs
+}
+
+// formatRemoteObject renders a console argument the way the DevTools
+// console would print it on one line.
+func formatRemoteObject(o *runtime.RemoteObject) string {
+	if len(o.Value) > 0 {
+		var s string
+		if json.Unmarshal(o.Value, &s) == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.UnserializableValue != "" {
+		return string(o.UnserializableValue)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return string(o.Type) // "undefined"
+}
@@ -0,0 +1,59 @@
+package htmlpdf
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestPageConsole_Messages(t *testing.T) {
+	var p pageConsole
+	p.listen(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeLog,
+		Args: []*runtime.RemoteObject{
+			{Type: runtime.TypeString, Value: []byte(`"chart data"`)},
+			{Type: runtime.TypeNumber, Value: []byte(`42`)},
+			{Type: runtime.TypeNumber, UnserializableValue: "NaN"},
+			{Type: runtime.TypeObject, Description: "Array(3)"},
+			{Type: runtime.TypeUndefined},
+		},
+	})
+	p.listen(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeError,
+		Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"failed"`)}},
+	})
+
+	msgs, errs := p.snapshot()
+	want := []ConsoleMessage{
+		{Type: "log", Text: "chart data 42 NaN Array(3) undefined"},
+		{Type: "error", Text: "failed"},
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("got %d messages, want %d: %+v", len(msgs), len(want), msgs)
+	}
+	for i := range want {
+		if msgs[i] != want[i] {
+			t.Errorf("message %d = %+v, want %+v", i, msgs[i], want[i])
+		}
+	}
+	if errs != nil {
+		t.Errorf("errors = %+v, want nil", errs)
+	}
+}
+
+func TestPageConsole_Exceptions(t *testing.T) {
+	var p pageConsole
+	p.listen(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text:         "Uncaught",
+		URL:          "https://example.com/chart.js",
+		LineNumber:   9,
+		ColumnNumber: 4,
+		Exception:    &runtime.RemoteObject{Type: runtime.TypeObject, Description: "TypeError: x is undefined"},
+	}})
+
+	_, errs := p.snapshot()
+	want := PageError{Message: "TypeError: x is undefined", URL: "https://example.com/chart.js", Line: 10, Column: 5}
+	if len(errs) != 1 || errs[0] != want {
+		t.Errorf("errors = %+v, want [%+v]", errs, want)
+	}
+}
@@ -1,14 +1,26 @@
 package htmlpdf
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 )
 
@@ -26,8 +38,28 @@ type Converter struct {
 	browserCtx    context.Context
 	browserCancel context.CancelFunc
 
-	mu     sync.Mutex
-	closed bool
+	active atomic.Int64  // conversions in progress, for Metrics
+	tabs   chan struct{} // conversion slots, if WithMaxConcurrent is set
+	warm   chan *warmTab // pre-created tabs, if WithWarmTabs is set
+
+	mu        sync.Mutex
+	closed    bool          // no new conversions are accepted
+	released  bool          // browser shut down by Close or Shutdown
+	busy      int           // conversions under way
+	drained   chan struct{} // closed when busy drops to 0, if Shutdown waits
+	idleTimer *time.Timer   // stops the browser once idle
+	stopped   bool          // browser stopped for being idle
+	relaunch  chan struct{} // closed when a relaunch under way is done
+
+	// State read without c.mu by healthy, which the pool calls while
+	// holding its own lock.
+	retired     atomic.Bool  // closed or released
+	launchable  atomic.Bool  // stopped for being idle, or being relaunched
+	browserDone atomic.Value // <-chan struct{}, the Done channel of browserCtx
+
+	overMemory context.Context // browser stopped by WithBrowserMemoryLimit
+	product    string          // browser version, e.g. "HeadlessChrome/126.0.6478.126"
+	major      int             // major version of product, or 0 if unknown
 }
 
 // NewConverter creates a Converter with the given options.
@@ -39,86 +71,226 @@ func NewConverter(opts ...Option) (*Converter, error) {
 	for _, o := range opts {
 		o(&cfg)
 	}
-
-	// Resolve browser path: explicit > auto-download > system PATH.
-	if cfg.chromePath == "" && cfg.autoDownload {
-		path, err := resolveBrowser()
-		if err != nil {
-			return nil, err
+	for _, p := range cfg.blockedHosts {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("htmlpdf: invalid blocked host pattern %q: %w", p, err)
 		}
-		cfg.chromePath = path
+	}
+	policy, err := newURLPolicy(cfg.allowURLs, cfg.denyURLs)
+	if err != nil {
+		return nil, err
+	}
+	cfg.policy = policy
+
+	if cfg.autoSandbox && !cfg.noSandbox && cfg.remoteURL == "" {
+		cfg.noSandbox = autoSandbox(hostSandboxProbe(), cfg.logger)
 	}
 
-	allocOpts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.Flag("disable-sync", true),
-		chromedp.Flag("disable-translate", true),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("headless", cfg.headless),
-	)
-	if cfg.chromePath != "" {
-		allocOpts = append(allocOpts, chromedp.ExecPath(cfg.chromePath))
+	_, span := cfg.tracer.Start(context.Background(), spanNewConverter)
+	c := &Converter{cfg: cfg}
+	if cfg.maxTabs > 0 {
+		c.tabs = make(chan struct{}, cfg.maxTabs)
 	}
-	if cfg.noSandbox {
-		allocOpts = append(allocOpts, chromedp.Flag("no-sandbox", true))
+	if cfg.warmTabs > 0 && !cfg.incognito {
+		// Every incognito conversion needs a new browser context, so
+		// warm tabs would never be used.
+		c.warm = make(chan *warmTab, cfg.warmTabs)
 	}
+	err = c.launch()
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.idleTimeout > 0 {
+		c.mu.Lock()
+		c.armIdleTimer()
+		c.mu.Unlock()
+	}
+	return c, nil
+}
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+// launch starts the browser, or connects to the remote one, and records
+// its contexts in c. The caller must not hold c.mu. If the Converter is
+// released meanwhile, the new browser is shut down again.
+func (c *Converter) launch() error {
+	allocCtx, allocCancel, err := newAllocator(&c.cfg)
+	if err != nil {
+		return err
+	}
 	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
 
 	// Start the browser eagerly so errors surface at creation time.
 	if err := chromedp.Run(browserCtx); err != nil {
 		browserCancel()
 		allocCancel()
-		return nil, fmt.Errorf("htmlpdf: starting browser: %w", err)
+		return fmt.Errorf("htmlpdf: starting browser: %w", err)
+	}
+	product, major, err := browserVersion(cdp.WithExecutor(browserCtx, chromedp.FromContext(browserCtx).Browser))
+	if err == nil {
+		err = c.checkBrowserVersion(product, major)
+	} else {
+		err = fmt.Errorf("htmlpdf: querying browser version: %w", err)
+	}
+	if err != nil {
+		browserCancel()
+		allocCancel()
+		return err
 	}
 
-	return &Converter{
-		cfg:           cfg,
-		allocCtx:      allocCtx,
-		allocCancel:   allocCancel,
-		browserCtx:    browserCtx,
-		browserCancel: browserCancel,
-	}, nil
+	c.mu.Lock()
+	if c.released {
+		c.mu.Unlock()
+		browserCancel()
+		allocCancel()
+		return ErrClosed
+	}
+	c.allocCtx, c.allocCancel = allocCtx, allocCancel
+	c.product, c.major = product, major
+	c.browserCtx, c.browserCancel = browserCtx, browserCancel
+	c.browserDone.Store(browserCtx.Done())
+	c.mu.Unlock()
+	if c.warm != nil {
+		c.warmTabs(browserCtx)
+	}
+	if c.cfg.memoryLimit > 0 && c.cfg.remoteURL == "" {
+		c.startMemoryWatch(browserCtx)
+	}
+	if c.cfg.remoteURL != "" {
+		// The URL is not logged as it may carry an access token.
+		c.cfg.logger.Info("htmlpdf: connected to remote browser")
+	} else {
+		c.cfg.logger.Info("htmlpdf: browser started", "path", c.cfg.chromePath)
+	}
+	return nil
+}
+
+// browser returns the browser context for a new conversion. If the
+// browser has exited or lost its connection since the last conversion,
+// or was stopped for being idle, it is relaunched first, so a Chrome
+// crash fails only the conversions in flight at the time. The relaunch
+// is traced as a child of ctx.
+//
+// Conversions already under way when [Converter.Shutdown] is called keep
+// the browser until it is released.
+//
+// The relaunch runs without holding c.mu; conversions starting meanwhile
+// wait for it, or until their ctx is done.
+func (c *Converter) browser(ctx context.Context) (context.Context, error) {
+	c.mu.Lock()
+	for c.relaunch != nil {
+		done := c.relaunch
+		c.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+	}
+	if c.released {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if c.browserCtx.Err() == nil {
+		browserCtx := c.browserCtx
+		c.mu.Unlock()
+		return browserCtx, nil
+	}
+	if c.stopped {
+		c.cfg.logger.Info("htmlpdf: relaunching idle browser")
+		c.stopped = false
+	} else {
+		c.cfg.logger.Warn("htmlpdf: browser exited, relaunching")
+	}
+	browserCancel, allocCancel := c.browserCancel, c.allocCancel
+	done := make(chan struct{})
+	c.relaunch = done
+	c.launchable.Store(true)
+	c.mu.Unlock()
+
+	browserCancel()
+	allocCancel()
+	err := traced(ctx, c.cfg.tracer, spanRelaunch, c.launch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.relaunch = nil
+	c.launchable.Store(false)
+	close(done)
+	if err != nil {
+		return nil, err
+	}
+	return c.browserCtx, nil
 }
 
 // Close releases all resources held by the Converter, including the
-// browser process. Close is idempotent.
+// browser process. Conversions in progress fail. Close is idempotent, and
+// may be called to cut short a [Converter.Shutdown].
 func (c *Converter) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.closed {
-		return nil
-	}
 	c.closed = true
-	c.browserCancel()
-	c.allocCancel()
+	c.retired.Store(true)
+	c.release()
 	return nil
 }
 
 // ConvertHTML converts an HTML string to a PDF document.
-// If page is nil, [DefaultPageConfig] values are used.
-func (c *Converter) ConvertHTML(ctx context.Context, html string, pg *PageConfig) (*Result, error) {
+// If page is nil, [DefaultPageConfig] values are used. An optional
+// [ConvertOptions] controls request-scoped behaviour such as wait
+// conditions.
+//
+// The document is handed to the browser through request interception and
+// is never written to disk. Relative references have no directory to
+// resolve against; use [Converter.ConvertHTMLWithAssets] to supply them.
+func (c *Converter) ConvertHTML(ctx context.Context, html string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return c.ConvertHTMLWithAssets(ctx, html, nil, pg, opts...)
+}
+
+// ConvertHTMLBytes converts HTML held in a byte slice to a PDF document,
+// as [Converter.ConvertHTML] does. The slice is served to the browser as
+// it is, without being copied into a string first, which saves
+// allocations for large generated documents; it must not be modified
+// until the conversion returns.
+func (c *Converter) ConvertHTMLBytes(ctx context.Context, html []byte, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
 	if err := c.checkClosed(); err != nil {
 		return nil, err
 	}
+	if html == nil {
+		// A nil document would mean navigating to a URL instead.
+		html = []byte{}
+	}
+	return c.convert(ctx, source{document: html}, pg, firstConvertOptions(opts))
+}
 
-	f, err := os.CreateTemp("", "htmlpdf-*.html")
+// ConvertReader converts HTML read from r to a PDF document. The input is
+// streamed to a temporary file (see [WithTempDir]) rather than held in
+// memory, which suits large generated documents. As with
+// [Converter.ConvertHTML], the document cannot load other local files.
+// If page is nil, [DefaultPageConfig] values are used. An optional
+// [ConvertOptions] controls request-scoped behaviour.
+func (c *Converter) ConvertReader(ctx context.Context, r io.Reader, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(c.cfg.tempDir, "htmlpdf-*.html")
 	if err != nil {
 		return nil, fmt.Errorf("htmlpdf: creating temp file: %w", err)
 	}
 	name := f.Name()
-	defer os.Remove(name)
+	defer func() {
+		err := os.Remove(name)
+		c.cfg.logger.Debug("htmlpdf: removed temp file", "path", name, "error", err)
+	}()
 
-	if _, err := f.WriteString(html); err != nil {
+	n, err := io.Copy(f, r)
+	if err != nil {
 		f.Close()
 		return nil, fmt.Errorf("htmlpdf: writing temp file: %w", err)
 	}
+	c.cfg.logger.Debug("htmlpdf: wrote temp file", "path", name, "bytes", n)
 	if err := f.Close(); err != nil {
 		return nil, fmt.Errorf("htmlpdf: closing temp file: %w", err)
 	}
@@ -127,58 +299,367 @@ func (c *Converter) ConvertHTML(ctx context.Context, html string, pg *PageConfig
 	if err != nil {
 		return nil, fmt.Errorf("htmlpdf: resolving path: %w", err)
 	}
-	return c.convert(ctx, "file://"+abs, pg)
+	// The input is as untrusted as an HTML string, which cannot read
+	// files either.
+	return c.convert(ctx, source{url: "file://" + abs, blockFiles: true}, pg, firstConvertOptions(opts))
+}
+
+// ConvertHTMLWithAssets converts an HTML string to a PDF document, serving
+// the images, stylesheets, fonts and scripts it references by relative
+// path from assets instead of from disk. Keys are paths relative to the
+// document, such as "css/style.css" or "img/logo.png".
+//
+// The document and its assets are delivered through request interception,
+// so nothing is written to disk. References to assets that are not in the
+// map get a 404 response; absolute URLs are fetched from the network as
+// usual. If page is nil, [DefaultPageConfig] values are used.
+func (c *Converter) ConvertHTMLWithAssets(ctx context.Context, html string, assets map[string][]byte, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	src := source{
+		document: []byte(html),
+		assets:   newAssetMap(assets),
+	}
+	return c.convert(ctx, src, pg, firstConvertOptions(opts))
 }
 
 // ConvertURL converts the web page at rawURL to a PDF document.
-// If page is nil, [DefaultPageConfig] values are used.
-func (c *Converter) ConvertURL(ctx context.Context, rawURL string, pg *PageConfig) (*Result, error) {
+// If page is nil, [DefaultPageConfig] values are used. An optional
+// [ConvertOptions] controls request-scoped behaviour.
+func (c *Converter) ConvertURL(ctx context.Context, rawURL string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
 	if err := c.checkClosed(); err != nil {
 		return nil, err
 	}
-	if _, err := url.ParseRequestURI(rawURL); err != nil {
-		return nil, fmt.Errorf("htmlpdf: invalid URL %q: %w", rawURL, err)
+	src, err := urlSource(rawURL)
+	if err != nil {
+		return nil, err
 	}
-	return c.convert(ctx, rawURL, pg)
+	return c.convert(ctx, src, pg, firstConvertOptions(opts))
 }
 
 // ConvertFile converts a local HTML file to a PDF document.
-// If page is nil, [DefaultPageConfig] values are used.
-func (c *Converter) ConvertFile(ctx context.Context, path string, pg *PageConfig) (*Result, error) {
+// If page is nil, [DefaultPageConfig] values are used. An optional
+// [ConvertOptions] controls request-scoped behaviour.
+func (c *Converter) ConvertFile(ctx context.Context, path string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
 	if err := c.checkClosed(); err != nil {
 		return nil, err
 	}
+	src, err := fileSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.convert(ctx, src, pg, firstConvertOptions(opts))
+}
 
+// urlSource returns the source for the web page at rawURL.
+func urlSource(rawURL string) (source, error) {
+	if _, err := url.ParseRequestURI(rawURL); err != nil {
+		return source{}, fmt.Errorf("htmlpdf: invalid URL %q: %w", rawURL, err)
+	}
+	return source{url: rawURL}, nil
+}
+
+// fileSource returns the source for the local HTML file at path, which
+// must exist.
+func fileSource(path string) (source, error) {
 	abs, err := filepath.Abs(path)
 	if err != nil {
-		return nil, fmt.Errorf("htmlpdf: resolving path: %w", err)
+		return source{}, fmt.Errorf("htmlpdf: resolving path: %w", err)
 	}
 	if _, err := os.Stat(abs); err != nil {
-		return nil, fmt.Errorf("htmlpdf: %w", err)
+		return source{}, fmt.Errorf("htmlpdf: %w", err)
+	}
+	return source{url: "file://" + abs}, nil
+}
+
+// checkResponse returns an [*HTTPError] if the main document was served
+// with a non-2xx status. A nil response or status 0 (file: URLs) is not
+// an error.
+func checkResponse(resp *network.Response) error {
+	if resp == nil || resp.Status == 0 || (resp.Status >= 200 && resp.Status < 300) {
+		return nil
+	}
+	return &HTTPError{URL: resp.URL, StatusCode: int(resp.Status), Status: resp.StatusText}
+}
+
+// injectCSS returns an action that appends css to the document as a
+// <style> element, after the page's own stylesheets.
+func injectCSS(css string) chromedp.Action {
+	lit, _ := json.Marshal(css) // a JSON string is a valid JS string literal
+	return chromedp.Evaluate(`(() => {
+	const style = document.createElement("style");
+	style.textContent = `+string(lit)+`;
+	(document.head || document.documentElement).appendChild(style);
+})()`, nil)
+}
+
+// isolateScript hides everything in the document except the element
+// matching the selector it is called with, along with the element's
+// ancestors, so that only the element is printed. It returns whether the
+// selector matched.
+const isolateScript = `(sel => {
+	const el = document.querySelector(sel);
+	if (!el) return false;
+	for (let node = el; node.parentElement && node !== document.body; node = node.parentElement) {
+		for (const sibling of node.parentElement.children) {
+			if (sibling !== node) sibling.style.setProperty("display", "none", "important");
+		}
 	}
-	return c.convert(ctx, "file://"+abs, pg)
+	return true;
+})`
+
+// isolateSelector returns an action that hides everything but the
+// element matching selector.
+func isolateSelector(selector string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		lit, _ := json.Marshal(selector)
+		var found bool
+		if err := chromedp.Evaluate(isolateScript+"("+string(lit)+")", &found).Do(ctx); err != nil {
+			return fmt.Errorf("isolating selector %q: %w", selector, err)
+		}
+		if !found {
+			return fmt.Errorf("selector %q matched no element", selector)
+		}
+		return nil
+	})
+}
+
+// runScript returns an action that evaluates a user script, awaiting
+// the result if it is a promise. i identifies the script in errors.
+func runScript(i int, script string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		err := chromedp.Evaluate(script, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("script %d: %w", i, err)
+		}
+		return nil
+	})
+}
+
+// runAction returns an action that runs a user action. i identifies it
+// in errors.
+func runAction(i int, action chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := action.Do(ctx); err != nil {
+			return fmt.Errorf("action %d: %w", i, err)
+		}
+		return nil
+	})
+}
+
+// readySignalScript defines window.__htmlpdfReady for
+// [ConvertOptions].WaitForReadySignal, along with the promise it resolves.
+const readySignalScript = `window.__htmlpdfReadySignal = new Promise(resolve => {
+	window.__htmlpdfReady = () => resolve();
+});`
+
+// waitForPromise waits for the promise expr evaluates to, once it is
+// defined, to resolve.
+func waitForPromise(expr string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		err := chromedp.Evaluate(`new Promise((resolve, reject) => {
+	const check = () => {
+		let p;
+		try {
+			p = (`+expr+`);
+		} catch (err) {
+			return reject(err);
+		}
+		if (p === undefined || p === null) return requestAnimationFrame(check);
+		Promise.resolve(p).then(() => resolve(true), reject);
+	};
+	check();
+})`, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for page ready signal: %w", err)
+		}
+		return nil
+	})
 }
 
-// convert performs the actual navigation and PDF generation.
-func (c *Converter) convert(ctx context.Context, targetURL string, pg *PageConfig) (*Result, error) {
+// convertOnce makes a single conversion attempt. Failures a retry may
+// not repeat are wrapped in a *transientError.
+func (c *Converter) convertOnce(ctx context.Context, src source, pg *PageConfig, opts *ConvertOptions) (*Result, error) {
 	resolved := pg.resolved()
+	if len(src.routes) > 1 {
+		// Headings and form controls cannot be told apart across the
+		// routes' pages.
+		resolved.Bookmarks, resolved.FillableForms = false, false
+	}
+	o := opts.resolved()
+	log := c.cfg.logger
 
-	if c.cfg.timeout > 0 {
+	timeout := c.cfg.timeout
+	if o.Timeout > 0 {
+		timeout = o.Timeout
+	}
+	if timeout > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, c.cfg.timeout)
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
-	tabCtx, tabCancel := chromedp.NewContext(c.browserCtx)
+	browserCtx, err := c.browser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if src.shot == nil {
+		if err := c.checkBrowserFeatures(&resolved); err != nil {
+			return nil, err
+		}
+	}
+	tabCtx, tabCancel := c.newTab(browserCtx, o)
 	defer tabCancel()
 
+	// The tab derives from the browser context, so propagate the caller's
+	// cancellation and the conversion timeout explicitly. When capturing
+	// diagnostics they only stop the conversion's actions, leaving the
+	// tab open to be inspected.
+	runCtx, runCancel := tabCtx, tabCancel
+	if o.CaptureDiagnostics {
+		runCtx, runCancel = context.WithCancel(tabCtx)
+		defer runCancel()
+	}
+	stop := context.AfterFunc(ctx, runCancel)
+	defer stop()
+
+	var crashed atomic.Bool
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			crashed.Store(true)
+			tabCancel()
+		}
+	})
+
+	ic := newInterceptor(&c.cfg, src, o)
+	if ic != nil {
+		chromedp.ListenTarget(tabCtx, ic.listen(tabCtx))
+	}
+
+	failures := newRequestFailures()
+	chromedp.ListenTarget(tabCtx, failures.listen)
+	console := &pageConsole{}
+	chromedp.ListenTarget(tabCtx, console.listen)
+	var netlog *networkLog
+	if o.RecordNetwork {
+		netlog = newNetworkLog()
+		chromedp.ListenTarget(tabCtx, netlog.listen)
+	}
+
+	var idle *networkIdle
+	if o.WaitNetworkIdle > 0 {
+		idle = newNetworkIdle()
+		chromedp.ListenTarget(tabCtx, idle.listen)
+	}
+
 	width, height := resolved.paperDimensions()
 	marginTop, marginRight, marginBottom, marginLeft := resolved.marginInches()
 
+	ua := o.UserAgent
+	if ua == "" {
+		ua = c.cfg.userAgent
+	}
+
 	var buf []byte
-	if err := chromedp.Run(tabCtx,
-		chromedp.Navigate(targetURL),
-		chromedp.WaitReady("body", chromedp.ByQuery),
+	var nav chromedp.Tasks
+	if ua != "" {
+		nav = append(nav, emulation.SetUserAgentOverride(ua))
+	}
+	if resolved.EmulateMedia == MediaScreen {
+		nav = append(nav, emulation.SetEmulatedMedia().WithMedia("screen"))
+	}
+	if src.shot != nil {
+		nav = append(nav, setViewport(src.shot))
+	} else if resolved.DeviceScaleFactor > 0 {
+		// A zero width and height keep the window's size.
+		nav = append(nav, emulation.SetDeviceMetricsOverride(0, 0, resolved.DeviceScaleFactor, false))
+	}
+	if len(o.Headers) > 0 {
+		nav = append(nav, network.SetExtraHTTPHeaders(headerParams(o.Headers)))
+	}
+	if len(o.Cookies) > 0 {
+		nav = append(nav, network.SetCookies(cookieParams(o.Cookies, src.target())))
+	}
+	if ic != nil {
+		nav = append(nav, ic.enable())
+	}
+	if o.WaitForReadySignal {
+		nav = append(nav, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(readySignalScript).Do(ctx)
+			return err
+		}))
+	}
+	var navResp *network.Response
+	var loaded atomic.Bool
+	nav = append(nav, chromedp.ActionFunc(func(ctx context.Context) error {
+		log.Debug("htmlpdf: navigating", "url", src.target())
+		var err error
+		navResp, err = chromedp.RunResponse(ctx, chromedp.Navigate(src.target()))
+		loaded.Store(err == nil)
+		if err == nil && navResp != nil {
+			log.Debug("htmlpdf: page loaded", "url", src.target(), "status", navResp.Status)
+		}
+		return err
+	}))
+	if !o.IgnoreHTTPErrors {
+		nav = append(nav, chromedp.ActionFunc(func(context.Context) error {
+			return checkResponse(navResp)
+		}))
+	}
+
+	// ready holds the waits and scripts that are repeated for every route
+	// of a single-page app.
+	var ready chromedp.Tasks
+	if o.WaitForSelector != "" {
+		ready = append(ready, chromedp.WaitReady(o.WaitForSelector, chromedp.ByQuery))
+	}
+	if o.WaitForExpression != "" {
+		// The conversion timeout bounds the wait; disable Poll's own.
+		ready = append(ready, chromedp.Poll(o.WaitForExpression, nil, chromedp.WithPollingTimeout(0)))
+	}
+	if o.WaitForReadySignal {
+		ready = append(ready, waitForPromise("window.__htmlpdfReadySignal"))
+	}
+	if o.WaitForPromise != "" {
+		ready = append(ready, waitForPromise(o.WaitForPromise))
+	}
+	if idle != nil {
+		ready = append(ready, idle.wait(o.WaitNetworkIdle))
+	}
+	for i, script := range o.Scripts {
+		ready = append(ready, runScript(i, script))
+	}
+	for i, action := range o.Actions {
+		ready = append(ready, runAction(i, action))
+	}
+	if o.Selector != "" {
+		ready = append(ready, isolateSelector(o.Selector))
+	}
+	wait := chromedp.Tasks{chromedp.WaitReady("body", chromedp.ByQuery)}
+	if o.ExtraCSS != "" {
+		wait = append(wait, injectCSS(o.ExtraCSS))
+	}
+	wait = append(wait, ready...)
+	var headings []heading
+	if resolved.Bookmarks {
+		wait = append(wait, chromedp.Evaluate(headingsScript, &headings))
+	}
+	var fields []formField
+	if resolved.FillableForms {
+		wait = append(wait, chromedp.Evaluate(formFieldsScript, &fields))
+	}
+
+	// Stream the PDF to the caller's writer unless it must be rewritten
+	// once complete.
+	stream := src.sink != nil && !resolved.Bookmarks && !resolved.FillableForms && !resolved.PDFA &&
+		resolved.Metadata.isZero() && resolved.Images.isZero() && !resolved.Linearize && !resolved.Reproducible
+	var written atomic.Int64
+	printPDF := chromedp.Tasks{
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			params := page.PrintToPDF().
 				WithPaperWidth(width).
@@ -191,24 +672,217 @@ func (c *Converter) convert(ctx context.Context, targetURL string, pg *PageConfi
 				WithPrintBackground(resolved.PrintBackground).
 				WithLandscape(resolved.Orientation == Landscape).
 				WithPreferCSSPageSize(resolved.PreferCSSPageSize).
-				WithDisplayHeaderFooter(resolved.DisplayHeaderFooter)
+				WithDisplayHeaderFooter(resolved.DisplayHeaderFooter).
+				WithGenerateTaggedPDF(resolved.GenerateTaggedPDF).
+				WithGenerateDocumentOutline(resolved.GenerateDocumentOutline)
 
 			if resolved.HeaderTemplate != "" {
-				params = params.WithHeaderTemplate(resolved.HeaderTemplate)
+				params = params.WithHeaderTemplate(expandTemplate(resolved.HeaderTemplate, o.HeaderFooterData))
 			}
 			if resolved.FooterTemplate != "" {
-				params = params.WithFooterTemplate(resolved.FooterTemplate)
+				params = params.WithFooterTemplate(expandTemplate(resolved.FooterTemplate, o.HeaderFooterData))
 			}
 
+			log.Debug("htmlpdf: printing", "width", width, "height", height)
+			if stream || c.cfg.maxOutput > 0 {
+				// A size limit needs the PDF in chunks, so that printing
+				// stops once it is exceeded.
+				var w io.Writer = src.sink
+				count := &written
+				var pdf bytes.Buffer
+				if !stream {
+					w, count = &pdf, new(atomic.Int64)
+				}
+				if c.cfg.maxOutput > 0 {
+					w = &limitWriter{w: w, remaining: c.cfg.maxOutput}
+				}
+				_, handle, err := params.WithTransferMode(page.PrintToPDFTransferModeReturnAsStream).Do(ctx)
+				if err != nil {
+					return err
+				}
+				if err := copyStream(ctx, handle, w, count); err != nil {
+					return err
+				}
+				if stream {
+					log.Debug("htmlpdf: streamed PDF", "bytes", written.Load())
+					return nil
+				}
+				buf = pdf.Bytes()
+				log.Debug("htmlpdf: printed PDF", "bytes", len(buf))
+				return nil
+			}
 			var err error
 			buf, _, err = params.Do(ctx)
+			if err == nil {
+				log.Debug("htmlpdf: printed PDF", "bytes", len(buf))
+			}
 			return err
 		}),
-	); err != nil {
-		return nil, fmt.Errorf("htmlpdf: conversion failed: %w", err)
 	}
 
-	return &Result{data: buf}, nil
+	// Each phase runs in its own span so traces show where the time goes.
+	err = traced(ctx, c.cfg.tracer, spanNavigate, func() error { return chromedp.Run(runCtx, nav) })
+	if err == nil {
+		err = traced(ctx, c.cfg.tracer, spanWait, func() error { return chromedp.Run(runCtx, wait) })
+	}
+	if err == nil && stream && o.FailOnRequestFailure {
+		// Once streaming starts the PDF cannot be withheld, so check
+		// for failed requests first.
+		if failed := failures.list(); len(failed) > 0 {
+			return nil, &RequestFailureError{Failures: failed}
+		}
+	}
+	if err == nil {
+		output, span := printPDF, spanPrint
+		if src.shot != nil {
+			output, span = chromedp.Tasks{captureScreenshot(src.shot, &buf)}, spanCapture
+		}
+		err = traced(ctx, c.cfg.tracer, span, func() error { return chromedp.Run(runCtx, output) })
+	}
+	// The further routes of a single-page app are shown in the same tab
+	// and printed in turn.
+	pdfs := [][]byte{buf}
+	for i := 1; i < len(src.routes) && err == nil; i++ {
+		route := src.routes[i]
+		log.Debug("htmlpdf: showing route", "url", route)
+		if idle != nil {
+			idle.reset()
+		}
+		show := append(chromedp.Tasks{showRoute(route, o.WaitForReadySignal)}, ready...)
+		err = traced(ctx, c.cfg.tracer, spanWait, func() error { return chromedp.Run(runCtx, show) })
+		if err == nil {
+			err = traced(ctx, c.cfg.tracer, spanPrint, func() error { return chromedp.Run(runCtx, printPDF) })
+		}
+		pdfs = append(pdfs, buf)
+	}
+	if err != nil {
+		// The bundle records the error as the browser reported it, before
+		// it is replaced by the context's.
+		var diag *DiagnosticBundle
+		if o.CaptureDiagnostics {
+			diag = captureDiagnostics(tabCtx, src.target(), err, console, failures)
+		}
+		fail := func(err error) (*Result, error) {
+			if diag != nil {
+				return nil, &DiagnosticError{Err: err, Diagnostics: diag}
+			}
+			return nil, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			return fail(httpErr)
+		}
+		if errors.Is(err, ErrOutputTooLarge) {
+			log.Warn("htmlpdf: output too large", "url", src.target(), "limit", c.cfg.maxOutput)
+			return fail(fmt.Errorf("%w of %d bytes", ErrOutputTooLarge, c.cfg.maxOutput))
+		}
+		if c.exceededMemory(browserCtx) {
+			// Not retried: the page would most likely exhaust the memory
+			// of the relaunched browser too.
+			return fail(fmt.Errorf("%w of %d MB", ErrMemoryLimit, c.cfg.memoryLimit))
+		}
+		switch {
+		case written.Load() > 0:
+			// Part of the PDF has been written; a retry would repeat it.
+		case browserCtx.Err() != nil:
+			err = &transientError{errBrowserExited}
+		case crashed.Load():
+			err = &transientError{errPageCrashed}
+		case errors.Is(err, context.DeadlineExceeded) && !loaded.Load():
+			err = &transientError{err}
+		case isTargetClosed(err):
+			err = &transientError{err}
+		}
+		log.Warn("htmlpdf: conversion failed", "url", src.target(), "error", err)
+		return fail(fmt.Errorf("htmlpdf: conversion failed: %w", err))
+	}
+	if len(src.routes) > 0 {
+		if buf, err = mergePDFs(pdfs, o.RouteTitles); err != nil {
+			return nil, fmt.Errorf("htmlpdf: combining routes: %w", err)
+		}
+	}
+
+	// Images are recompressed first, as doing so rewrites the whole file
+	// rather than appending an update.
+	if !resolved.Images.isZero() {
+		var err error
+		if buf, err = compressImages(buf, resolved.Images); err != nil {
+			return nil, fmt.Errorf("htmlpdf: compressing images: %w", err)
+		}
+	}
+
+	if resolved.FillableForms {
+		var err error
+		if buf, err = addFormFields(buf, fields); err != nil {
+			return nil, fmt.Errorf("htmlpdf: adding form fields: %w", err)
+		}
+	}
+
+	if resolved.Reproducible {
+		var err error
+		if buf, err = makeReproducible(buf); err != nil {
+			return nil, fmt.Errorf("htmlpdf: normalizing PDF: %w", err)
+		}
+	}
+
+	if resolved.Bookmarks {
+		var err error
+		if buf, err = addHeadingBookmarks(buf, headings); err != nil {
+			return nil, fmt.Errorf("htmlpdf: adding bookmarks: %w", err)
+		}
+	}
+
+	if resolved.PDFA {
+		var err error
+		if buf, err = makePDFA(buf, resolved.Metadata, postProcessTime(resolved.Reproducible)); err != nil {
+			var pdfaErr *PDFAError
+			if errors.As(err, &pdfaErr) {
+				return nil, pdfaErr
+			}
+			return nil, fmt.Errorf("htmlpdf: converting to PDF/A: %w", err)
+		}
+	} else if !resolved.Metadata.isZero() {
+		var err error
+		if buf, err = setMetadata(buf, resolved.Metadata, postProcessTime(resolved.Reproducible)); err != nil {
+			return nil, fmt.Errorf("htmlpdf: setting metadata: %w", err)
+		}
+	}
+
+	// Linearization comes last, as any later update would undo it.
+	if resolved.Linearize {
+		var err error
+		if buf, err = linearizePDF(buf); err != nil {
+			return nil, fmt.Errorf("htmlpdf: linearizing: %w", err)
+		}
+	}
+
+	// Post-processing may have grown the PDF past the limit, and
+	// screenshots are not checked while they are captured.
+	if c.cfg.maxOutput > 0 && int64(len(buf)) > c.cfg.maxOutput {
+		log.Warn("htmlpdf: output too large", "url", src.target(), "limit", c.cfg.maxOutput)
+		return nil, fmt.Errorf("%w of %d bytes", ErrOutputTooLarge, c.cfg.maxOutput)
+	}
+
+	res := &Result{data: buf, failed: failures.list()}
+	res.console, res.pageErrors = console.snapshot()
+	if netlog != nil {
+		res.network = netlog.list()
+	}
+	if o.FailOnRequestFailure && len(res.failed) > 0 {
+		return nil, &RequestFailureError{Failures: res.failed}
+	}
+	if src.sink != nil {
+		res.data, res.streamed = nil, int(written.Load())
+		if !stream {
+			if res.streamed, err = src.sink.Write(buf); err != nil {
+				return nil, fmt.Errorf("htmlpdf: writing PDF: %w", err)
+			}
+		}
+	}
+	return res, nil
 }
 
 func (c *Converter) checkClosed() error {
@@ -220,6 +894,26 @@ func (c *Converter) checkClosed() error {
 	return nil
 }
 
+// healthy reports whether the Converter is open and its browser is still
+// running, or is being relaunched, or was stopped for being idle and so is
+// relaunched on demand. It takes no lock, so that a relaunch does not hold
+// up the pool.
+func (c *Converter) healthy() bool {
+	if c.retired.Load() {
+		return false
+	}
+	if c.launchable.Load() {
+		return true
+	}
+	done, _ := c.browserDone.Load().(<-chan struct{})
+	select {
+	case <-done:
+		return false
+	default:
+		return true
+	}
+}
+
 // --- Package-level convenience functions ---
 
 // ConvertHTML converts an HTML string to PDF using a temporary [Converter].
@@ -234,6 +928,38 @@ func ConvertHTML(ctx context.Context, html string, pg *PageConfig, opts ...Optio
 	return conv.ConvertHTML(ctx, html, pg)
 }
 
+// ConvertHTMLBytes converts HTML held in a byte slice to PDF using a
+// temporary [Converter].
+func ConvertHTMLBytes(ctx context.Context, html []byte, pg *PageConfig, opts ...Option) (*Result, error) {
+	conv, err := NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer conv.Close()
+	return conv.ConvertHTMLBytes(ctx, html, pg)
+}
+
+// ConvertReader converts HTML read from r to PDF using a temporary [Converter].
+func ConvertReader(ctx context.Context, r io.Reader, pg *PageConfig, opts ...Option) (*Result, error) {
+	conv, err := NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer conv.Close()
+	return conv.ConvertReader(ctx, r, pg)
+}
+
+// ConvertHTMLWithAssets converts HTML with an in-memory asset bundle to PDF
+// using a temporary [Converter].
+func ConvertHTMLWithAssets(ctx context.Context, html string, assets map[string][]byte, pg *PageConfig, opts ...Option) (*Result, error) {
+	conv, err := NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer conv.Close()
+	return conv.ConvertHTMLWithAssets(ctx, html, assets, pg)
+}
+
 // ConvertURL converts a web page to PDF using a temporary [Converter].
 func ConvertURL(ctx context.Context, rawURL string, pg *PageConfig, opts ...Option) (*Result, error) {
 	conv, err := NewConverter(opts...)
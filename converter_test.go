@@ -1,12 +1,29 @@
 package htmlpdf_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
 
+	"github.com/chromedp/chromedp"
 	htmlpdf "github.com/porticus-lab/go-html-pdf"
 )
 
@@ -61,6 +78,39 @@ func TestConvertHTML_Basic(t *testing.T) {
 	}
 }
 
+func TestConvertHTMLBytes(t *testing.T) {
+	c := newTestConverter(t)
+
+	res, err := c.ConvertHTMLBytes(context.Background(), []byte("<h1>Hello Bytes</h1>"), nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLBytes: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "Hello Bytes") {
+		t.Errorf("text = %q, want the document's heading", pages)
+	}
+
+	// A nil slice is an empty document, not a navigation.
+	res, err = c.ConvertHTMLBytes(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLBytes(nil): %v", err)
+	}
+	if !isPDF(res.Bytes()) {
+		t.Error("output is not a valid PDF")
+	}
+}
+
+func TestConverter_BrowserVersion(t *testing.T) {
+	c := newTestConverter(t)
+
+	if v := c.BrowserVersion(); !strings.Contains(v, "Chrome/") {
+		t.Errorf("BrowserVersion() = %q, want a Chrome product string", v)
+	}
+}
+
 func TestConvertHTML_WithPageConfig(t *testing.T) {
 	c := newTestConverter(t)
 
@@ -208,6 +258,82 @@ func TestConverter_CloseIdempotent(t *testing.T) {
 	}
 }
 
+// startDebuggableChrome starts a headless Chrome with a DevTools port
+// that other clients can connect to, as a shared remote browser would
+// be, and returns the port's ws:// URL.
+func startDebuggableChrome(t *testing.T) string {
+	t.Helper()
+	skipIfNoChrome(t)
+	path, err := htmlpdf.FindBrowser()
+	if err != nil {
+		t.Fatalf("FindBrowser: %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	cmd := exec.Command(path, "--headless", "--no-sandbox", "--disable-gpu",
+		fmt.Sprintf("--remote-debugging-port=%d", port), "--user-data-dir="+t.TempDir(), "about:blank")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting Chrome: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	versionURL := fmt.Sprintf("http://127.0.0.1:%d/json/version", port)
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		resp, err := http.Get(versionURL)
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Chrome's DevTools port did not open: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Sprintf("ws://127.0.0.1:%d", port)
+}
+
+func TestConverter_CloseRemoteBrowser(t *testing.T) {
+	wsURL := startDebuggableChrome(t)
+
+	// Another client of the shared browser, with a tab of its own.
+	allocCtx, allocCancel := chromedp.NewRemoteAllocator(context.Background(), wsURL)
+	defer allocCancel()
+	other, otherCancel := chromedp.NewContext(allocCtx)
+	defer otherCancel()
+	if err := chromedp.Run(other, chromedp.Navigate("data:text/html,<p>other client</p>")); err != nil {
+		t.Fatalf("opening the other client's tab: %v", err)
+	}
+
+	c, err := htmlpdf.NewConverter(htmlpdf.WithRemoteBrowser(wsURL))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	if _, err := c.ConvertHTML(context.Background(), "<p>remote</p>", nil); err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Close detaches: the browser and the other client's tab live on.
+	var text string
+	if err := chromedp.Run(other, chromedp.Text("p", &text)); err != nil || text != "other client" {
+		t.Fatalf("other client's tab after Close: text %q, err %v", text, err)
+	}
+	c2, err := htmlpdf.NewConverter(htmlpdf.WithRemoteBrowser(wsURL))
+	if err != nil {
+		t.Fatalf("reconnecting to the remote browser after Close: %v", err)
+	}
+	c2.Close()
+}
+
 func TestConverter_UsedAfterClose(t *testing.T) {
 	skipIfNoChrome(t)
 
@@ -326,3 +452,1668 @@ func TestResult_WriteToFile(t *testing.T) {
 		t.Errorf("written %d bytes, expected %d", len(data), res.Len())
 	}
 }
+
+func TestNewConverterPool_InvalidSize(t *testing.T) {
+	if _, err := htmlpdf.NewConverterPool(0); err == nil {
+		t.Fatal("expected error for pool size 0")
+	}
+}
+
+func TestConverterPool_RoundRobin(t *testing.T) {
+	skipIfNoChrome(t)
+
+	p, err := htmlpdf.NewConverterPool(2, htmlpdf.WithNoSandbox())
+	if err != nil {
+		t.Fatalf("NewConverterPool: %v", err)
+	}
+	defer p.Close()
+
+	if p.Size() != 2 {
+		t.Fatalf("Size() = %d, want 2", p.Size())
+	}
+	for i := 0; i < 4; i++ {
+		res, err := p.ConvertHTML(context.Background(), "<p>pooled</p>", nil)
+		if err != nil {
+			t.Fatalf("ConvertHTML #%d: %v", i, err)
+		}
+		if !isPDF(res.Bytes()) {
+			t.Fatalf("conversion #%d: output is not a valid PDF", i)
+		}
+	}
+}
+
+func TestConverterPool_UsedAfterClose(t *testing.T) {
+	skipIfNoChrome(t)
+
+	p, err := htmlpdf.NewConverterPool(1, htmlpdf.WithNoSandbox())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	_, err = p.ConvertHTML(context.Background(), "<p>test</p>", nil)
+	if err != htmlpdf.ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestConvertHTML_WaitForSelector(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<body><script>
+  setTimeout(() => {
+    const d = document.createElement("div");
+    d.id = "chart";
+    d.textContent = "chart rendered";
+    document.body.appendChild(d);
+  }, 200);
+</script></body>`
+
+	res, err := c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitForSelector: "#chart",
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "chart rendered") {
+		t.Errorf("text = %q, want the element waited for", pages)
+	}
+}
+
+func TestConvertHTML_WaitForExpression(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<body><script>
+  setTimeout(() => {
+    document.body.append("data loaded");
+    window.ready = true;
+  }, 200);
+</script></body>`
+
+	res, err := c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitForExpression: "window.ready === true",
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "data loaded") {
+		t.Errorf("text = %q, want the text written before the expression held", pages)
+	}
+}
+
+func TestConvertHTML_WaitForExpressionTimeout(t *testing.T) {
+	c := newTestConverter(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err := c.ConvertHTML(ctx, "<p>never ready</p>", nil, &htmlpdf.ConvertOptions{
+		WaitForExpression: "false",
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestConvertHTML_WaitForReadySignal(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<body><p id="status">loading</p><script>
+  setTimeout(() => {
+    document.getElementById("status").textContent = "report ready";
+    window.__htmlpdfReady();
+  }, 200);
+</script></body>`
+
+	res, err := c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitForReadySignal: true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "report ready") || strings.Contains(pages[0], "loading") {
+		t.Errorf("text = %q, want the text set before the ready signal", pages)
+	}
+}
+
+func TestConvertHTML_WaitForPromise(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<body><script>
+  setTimeout(() => {
+    window.pdfReady = new Promise(resolve => setTimeout(resolve, 100));
+  }, 100);
+</script></body>`
+	res, err := c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitForPromise: "window.pdfReady",
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if !isPDF(res.Bytes()) {
+		t.Fatal("output is not a valid PDF")
+	}
+
+	html = `<body><script>
+  window.pdfReady = Promise.reject(new Error("no data"));
+</script></body>`
+	_, err = c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitForPromise: "window.pdfReady",
+	})
+	if err == nil || !strings.Contains(err.Error(), "no data") {
+		t.Errorf("err = %v, want the rejection", err)
+	}
+}
+
+func TestConvertHTML_WaitNetworkIdle(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<body><p id="out">loading</p><script>
+  setTimeout(() => {
+    fetch("data:text/plain,loaded")
+      .then(r => r.text())
+      .then(t => { document.getElementById("out").textContent = t; });
+  }, 100);
+</script></body>`
+
+	res, err := c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitNetworkIdle: 300 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "loaded") {
+		t.Errorf("text = %q, want the fetched text printed", pages)
+	}
+}
+
+func TestConvertTemplate(t *testing.T) {
+	c := newTestConverter(t)
+
+	tmpl := template.Must(template.New("report").Parse(`<h1>{{.Title}}</h1>`))
+	res, err := c.ConvertTemplate(context.Background(), tmpl, map[string]string{"Title": "Q3"}, nil)
+	if err != nil {
+		t.Fatalf("ConvertTemplate: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "Q3") {
+		t.Errorf("text = %q, want the executed template", pages)
+	}
+}
+
+func TestConvertTemplateFS_Partials(t *testing.T) {
+	c := newTestConverter(t)
+
+	fsys := fstest.MapFS{
+		"tmpl/invoice.html":       {Data: []byte(`<body>{{template "header" .}}<p>Total: {{.Total}}</p></body>`)},
+		"tmpl/partials/head.html": {Data: []byte(`{{define "header"}}<h1>Invoice {{.ID}}</h1>{{end}}`)},
+	}
+	data := map[string]any{"ID": 42, "Total": "10.00"}
+
+	res, err := c.ConvertTemplateFS(context.Background(), fsys,
+		[]string{"tmpl/*.html", "tmpl/partials/*.html"}, "invoice.html", data, nil)
+	if err != nil {
+		t.Fatalf("ConvertTemplateFS: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "Invoice 42") || !strings.Contains(pages[0], "Total: 10.00") {
+		t.Errorf("text = %q, want the page and its partial", pages)
+	}
+}
+
+func TestConvertTemplateFS_UnknownName(t *testing.T) {
+	c := newTestConverter(t)
+
+	fsys := fstest.MapFS{"a.html": {Data: []byte(`<p>a</p>`)}}
+	_, err := c.ConvertTemplateFS(context.Background(), fsys, []string{"*.html"}, "missing.html", nil, nil)
+	if err == nil {
+		t.Fatal("expected error for unknown template name")
+	}
+}
+
+func TestConvertMarkdown(t *testing.T) {
+	c := newTestConverter(t)
+
+	res, err := c.ConvertMarkdown(context.Background(), "# Release notes\n\n- fixed *everything*\n- added tables", nil)
+	if err != nil {
+		t.Fatalf("ConvertMarkdown: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("got %d pages, want 1", len(pages))
+	}
+	for _, want := range []string{"Release notes", "fixed", "everything", "added tables"} {
+		if !strings.Contains(pages[0], want) {
+			t.Errorf("text %q is missing %q", pages[0], want)
+		}
+	}
+	if strings.ContainsAny(pages[0], "#*") {
+		t.Errorf("text %q contains raw markup", pages[0])
+	}
+}
+
+func TestConverter_IdleTimeout(t *testing.T) {
+	skipIfNoChrome(t)
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithIdleTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.ConvertHTML(context.Background(), "<h1>Before</h1>", nil); err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	time.Sleep(time.Second) // the browser stops
+	if _, err := c.ConvertHTML(context.Background(), "<h1>After</h1>", nil); err != nil {
+		t.Fatalf("ConvertHTML after idle shutdown: %v", err)
+	}
+}
+
+func TestConverter_Shutdown(t *testing.T) {
+	skipIfNoChrome(t)
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox())
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	html := `<h1>Slow</h1><script>
+		const start = Date.now();
+		while (Date.now() - start < 500) {}
+	</script>`
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.ConvertHTML(context.Background(), html, nil)
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond) // the conversion starts
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("conversion in progress failed during Shutdown: %v", err)
+	}
+	if _, err := c.ConvertHTML(context.Background(), "<h1>Late</h1>", nil); !errors.Is(err, htmlpdf.ErrClosed) {
+		t.Errorf("ConvertHTML after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestConvertReader(t *testing.T) {
+	c := newTestConverter(t)
+
+	r := strings.NewReader("<h1>Streamed</h1>" + strings.Repeat("<p>row</p>", 1000) + "<p>End of stream</p>")
+	res, err := c.ConvertReader(context.Background(), r, nil)
+	if err != nil {
+		t.Fatalf("ConvertReader: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) < 2 {
+		t.Fatalf("got %d pages, want the rows to span several", len(pages))
+	}
+	if !strings.Contains(pages[0], "Streamed") {
+		t.Errorf("first page %q is missing the heading", pages[0])
+	}
+	if last := pages[len(pages)-1]; !strings.Contains(last, "End of stream") {
+		t.Errorf("last page %q is missing the end of the document", last)
+	}
+	if n := strings.Count(strings.Join(pages, "\n"), "row"); n != 1000 {
+		t.Errorf("text has %d rows, want 1000", n)
+	}
+}
+
+func TestConvertReader_TempDir(t *testing.T) {
+	skipIfNoChrome(t)
+	dir := t.TempDir()
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithTempDir(dir))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp dir holds %d entries while running, want the browser profile", len(entries))
+	}
+	if _, err := c.ConvertReader(context.Background(), strings.NewReader("<h1>Temp</h1>"), nil); err != nil {
+		t.Fatalf("ConvertReader: %v", err)
+	}
+	c.Close()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("temp dir holds %v after Close, want it empty", entries)
+	}
+}
+
+func TestNewConverter_FontDir(t *testing.T) {
+	skipIfNoChrome(t)
+	tmp := t.TempDir()
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithTempDir(tmp), htmlpdf.WithFontDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	if _, err := c.ConvertHTML(context.Background(), "<p>Fonts</p>", nil); err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	c.Close()
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("temp dir holds %v after Close, want the font configuration removed", entries)
+	}
+
+	if _, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithFontDir(filepath.Join(tmp, "missing"))); err == nil {
+		t.Error("NewConverter succeeded with a missing font directory")
+	}
+}
+
+func TestConvertReader_ReadError(t *testing.T) {
+	c := newTestConverter(t)
+
+	r := iotest.ErrReader(errors.New("boom"))
+	if _, err := c.ConvertReader(context.Background(), r, nil); err == nil {
+		t.Fatal("expected error from failing reader")
+	}
+}
+
+func TestConvertHTMLWithAssets(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<link rel="stylesheet" href="css/site.css"><h1>Styled</h1><p id="out"></p>` +
+		`<script src="js/app.js"></script><img src="./missing.png">`
+	assets := map[string][]byte{
+		"css/site.css": []byte(`h1::before { content: "From stylesheet: "; }`),
+		"js/app.js":    []byte(`document.getElementById("out").textContent = "From script";`),
+	}
+
+	res, err := c.ConvertHTMLWithAssets(context.Background(), html, assets, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLWithAssets: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "From stylesheet: Styled") || !strings.Contains(pages[0], "From script") {
+		t.Errorf("text = %q, want the stylesheet and script assets applied", pages)
+	}
+	failed := res.FailedRequests()
+	if len(failed) != 1 || !strings.HasSuffix(failed[0].URL, "/missing.png") || failed[0].StatusCode != http.StatusNotFound {
+		t.Errorf("FailedRequests() = %+v, want a 404 for missing.png", failed)
+	}
+}
+
+func TestConvertHTML_NoTempFiles(t *testing.T) {
+	c := newTestConverter(t)
+
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	if _, err := c.ConvertHTML(context.Background(), "<p>secret invoice</p>", nil); err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("ConvertHTML left %d entries in the temp directory", len(entries))
+	}
+}
+
+func TestConvertURL_Credentials(t *testing.T) {
+	c := newTestConverter(t)
+
+	var authorized atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "alice" || pass != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		authorized.Store(true)
+		w.Write([]byte("<h1>Protected</h1>"))
+	}))
+	defer srv.Close()
+
+	res, err := c.ConvertURL(context.Background(), srv.URL, nil, &htmlpdf.ConvertOptions{
+		Credentials: &htmlpdf.Credentials{Username: "alice", Password: "s3cret"},
+	})
+	if err != nil {
+		t.Fatalf("ConvertURL: %v", err)
+	}
+	if !isPDF(res.Bytes()) {
+		t.Fatal("output is not a valid PDF")
+	}
+	if !authorized.Load() {
+		t.Error("server never received valid credentials")
+	}
+}
+
+func TestConvertURL_UserAgent(t *testing.T) {
+	skipIfNoChrome(t)
+
+	uas := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			uas <- r.UserAgent()
+		}
+		w.Write([]byte("<p>ok</p>"))
+	}))
+	defer srv.Close()
+
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithUserAgent("converter-ua"))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.ConvertURL(ctx, srv.URL, nil); err != nil {
+		t.Fatalf("ConvertURL: %v", err)
+	}
+	if got := <-uas; got != "converter-ua" {
+		t.Errorf("User-Agent = %q, want converter-ua", got)
+	}
+
+	if _, err := c.ConvertURL(ctx, srv.URL, nil, &htmlpdf.ConvertOptions{UserAgent: "override-ua"}); err != nil {
+		t.Fatalf("ConvertURL: %v", err)
+	}
+	if got := <-uas; got != "override-ua" {
+		t.Errorf("User-Agent = %q, want override-ua", got)
+	}
+}
+
+func TestConvertHTML_EmulateScreenMedia(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<style>
+  @media print { .screen-only { display: none } }
+  @media screen { .print-only { display: none } }
+</style>
+<body><p class="screen-only">Visible on screen</p><p class="print-only">Visible in print</p></body>`
+
+	for _, tt := range []struct {
+		name       string
+		media      htmlpdf.Media
+		want, hide string
+	}{
+		{"screen", htmlpdf.MediaScreen, "Visible on screen", "Visible in print"},
+		{"print", htmlpdf.MediaPrint, "Visible in print", "Visible on screen"},
+	} {
+		res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{EmulateMedia: tt.media})
+		if err != nil {
+			t.Fatalf("ConvertHTML(%s): %v", tt.name, err)
+		}
+		pages, err := res.ExtractText()
+		if err != nil {
+			t.Fatalf("ExtractText: %v", err)
+		}
+		text := strings.Join(pages, "\n")
+		if !strings.Contains(text, tt.want) || strings.Contains(text, tt.hide) {
+			t.Errorf("%s media: text = %q, want %q without %q", tt.name, text, tt.want, tt.hide)
+		}
+	}
+}
+
+func TestConvertHTML_DeviceScaleFactor(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<p id="dpr"></p><script>document.getElementById("dpr").textContent = "ratio " + devicePixelRatio</script>`
+	res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{DeviceScaleFactor: 2})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	text, err := htmlpdf.NewExtractor(doc).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if !strings.Contains(text, "ratio 2") {
+		t.Errorf("text = %q, want the page to see a device pixel ratio of 2", text)
+	}
+}
+
+func TestConvertHTML_HeaderFooterData(t *testing.T) {
+	c := newTestConverter(t)
+
+	pg := htmlpdf.DefaultPageConfig()
+	pg.DisplayHeaderFooter = true
+	pg.HeaderTemplate = `<div style="font-size:10px">Customer: {{customer}}</div>`
+	pg.FooterTemplate = `<div style="font-size:10px">{{docID}} page <span class="pageNumber"></span></div>`
+	res, err := c.ConvertHTML(context.Background(), "<p>body</p>", &pg, &htmlpdf.ConvertOptions{
+		HeaderFooterData: map[string]string{"customer": "ACME", "docID": "INV-42"},
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	for _, want := range []string{"Customer: ACME", "INV-42 page 1"} {
+		if len(pages) != 1 || !strings.Contains(pages[0], want) {
+			t.Errorf("text = %q, want it to contain %q", pages, want)
+		}
+	}
+}
+
+func TestConvertHTML_Scripts(t *testing.T) {
+	c := newTestConverter(t)
+
+	// The promise of the second script is awaited before printing.
+	opts := &htmlpdf.ConvertOptions{Scripts: []string{
+		`document.body.textContent = "rewritten"`,
+		`new Promise(r => setTimeout(() => { document.body.textContent += " later"; r(); }, 50))`,
+	}}
+	res, err := c.ConvertHTML(context.Background(), "<p>original</p>", nil, opts)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0], "rewritten later") || strings.Contains(pages[0], "original") {
+		t.Errorf("text = %q, want the text set by the scripts", pages)
+	}
+}
+
+func TestConvertHTML_ScriptError(t *testing.T) {
+	c := newTestConverter(t)
+
+	opts := &htmlpdf.ConvertOptions{Scripts: []string{`throw new Error("boom")`}}
+	if _, err := c.ConvertHTML(context.Background(), "<p>x</p>", nil, opts); err == nil {
+		t.Fatal("expected error from throwing script")
+	}
+}
+
+func TestConvertHTML_Actions(t *testing.T) {
+	c := newTestConverter(t)
+
+	errBoom := errors.New("boom")
+	var ran []string
+	opts := &htmlpdf.ConvertOptions{
+		Scripts: []string{`document.body.textContent = "from script"`},
+		Actions: []chromedp.Action{
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				var text string
+				if err := chromedp.Evaluate(`document.body.textContent`, &text).Do(ctx); err != nil {
+					return err
+				}
+				ran = append(ran, text)
+				return nil
+			}),
+			chromedp.Evaluate(`document.body.textContent = "from action"`, nil),
+		},
+	}
+	res, err := c.ConvertHTML(context.Background(), "<p>original</p>", nil, opts)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "from script" {
+		t.Errorf("action saw %q, want the text set by the script", ran)
+	}
+	if pages, _ := res.ExtractText(); len(pages) != 1 || !strings.Contains(pages[0], "from action") {
+		t.Errorf("text = %q, want the text set by the action", pages)
+	}
+
+	opts = &htmlpdf.ConvertOptions{Actions: []chromedp.Action{
+		chromedp.ActionFunc(func(context.Context) error { return errBoom }),
+	}}
+	if _, err := c.ConvertHTML(context.Background(), "<p>x</p>", nil, opts); !errors.Is(err, errBoom) {
+		t.Errorf("error = %v, want the action's error", err)
+	}
+}
+
+func TestConvertHTML_ExtraCSS(t *testing.T) {
+	c := newTestConverter(t)
+
+	opts := &htmlpdf.ConvertOptions{
+		ExtraCSS: `.hide { display: none } /* </style> "quotes" */`,
+		Scripts: []string{`if (getComputedStyle(document.querySelector(".hide")).display !== "none") {
+  throw new Error("ExtraCSS not applied");
+}`},
+	}
+	res, err := c.ConvertHTML(context.Background(), `<p class="hide">hidden</p>`, nil, opts)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if !isPDF(res.Bytes()) {
+		t.Fatal("output is not a valid PDF")
+	}
+}
+
+func TestNewConverter_InvalidBlockedHost(t *testing.T) {
+	if _, err := htmlpdf.NewConverter(htmlpdf.WithBlockedHosts("[")); err == nil {
+		t.Fatal("expected error for malformed host pattern")
+	}
+}
+
+func TestConvertURL_HTTPError(t *testing.T) {
+	c := newTestConverter(t)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := c.ConvertURL(context.Background(), srv.URL, nil)
+	var httpErr *htmlpdf.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("StatusCode = %d, want 404", httpErr.StatusCode)
+	}
+
+	// Options that set other fields keep failing on HTTP errors.
+	_, err = c.ConvertURL(context.Background(), srv.URL, nil, &htmlpdf.ConvertOptions{Timeout: 10 * time.Second})
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err with explicit options = %v, want *HTTPError", err)
+	}
+
+	res, err := c.ConvertURL(context.Background(), srv.URL, nil, &htmlpdf.ConvertOptions{IgnoreHTTPErrors: true})
+	if err != nil {
+		t.Fatalf("ConvertURL: %v", err)
+	}
+	if !isPDF(res.Bytes()) {
+		t.Fatal("output is not a valid PDF")
+	}
+}
+
+func TestConvertReader_BlocksLocalFiles(t *testing.T) {
+	c := newTestConverter(t)
+
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	html := `<iframe src="file://` + secret + `"></iframe>`
+	res, err := c.ConvertReader(context.Background(), strings.NewReader(html), nil)
+	if err != nil {
+		t.Fatalf("ConvertReader: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if text, _ := htmlpdf.NewExtractor(doc).ExtractPage(0); strings.Contains(text, "s3cret") {
+		t.Error("the PDF contains the local file")
+	}
+	if len(res.FailedRequests()) == 0 {
+		t.Error("FailedRequests() is empty, want the blocked file")
+	}
+}
+
+func TestConvertURL_URLPolicy(t *testing.T) {
+	skipIfNoChrome(t)
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithURLPolicy(nil, []string{"127.0.0.0/8"}))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<h1>Internal</h1>"))
+	}))
+	defer srv.Close()
+
+	if _, err := c.ConvertURL(context.Background(), srv.URL, nil); !errors.Is(err, htmlpdf.ErrURLNotAllowed) {
+		t.Errorf("ConvertURL = %v, want ErrURLNotAllowed", err)
+	}
+
+	// Resources of an allowed page are checked too.
+	res, err := c.ConvertHTML(context.Background(), `<img src="`+srv.URL+`/logo.png">`, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if failed := res.FailedRequests(); len(failed) != 1 {
+		t.Errorf("FailedRequests() = %+v, want the denied image", failed)
+	}
+}
+
+func TestConvertHTML_MaxOutputSize(t *testing.T) {
+	skipIfNoChrome(t)
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithMaxOutputSize(64<<10))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.ConvertHTML(context.Background(), "<h1>Small</h1>", nil); err != nil {
+		t.Fatalf("ConvertHTML(small): %v", err)
+	}
+	big := strings.Repeat(`<p style="break-after: page">page</p>`, 2000)
+	if _, err := c.ConvertHTML(context.Background(), big, nil); !errors.Is(err, htmlpdf.ErrOutputTooLarge) {
+		t.Errorf("ConvertHTML(big) = %v, want ErrOutputTooLarge", err)
+	}
+}
+
+func TestConvertHTML_FailedRequests(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<img src="missing.png">`
+	res, err := c.ConvertHTMLWithAssets(context.Background(), html, nil, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLWithAssets: %v", err)
+	}
+	failed := res.FailedRequests()
+	if len(failed) != 1 || failed[0].StatusCode != http.StatusNotFound {
+		t.Errorf("FailedRequests() = %+v, want one 404", failed)
+	}
+
+	opts := htmlpdf.DefaultConvertOptions()
+	opts.FailOnRequestFailure = true
+	_, err = c.ConvertHTMLWithAssets(context.Background(), html, nil, nil, &opts)
+	var reqErr *htmlpdf.RequestFailureError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("err = %v, want *RequestFailureError", err)
+	}
+}
+
+func TestConvertHTML_ConsoleAndPageErrors(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<script>
+  console.log("rendering", 3, "charts");
+  setTimeout(() => { throw new Error("chart failed"); });
+</script><p>x</p>`
+	opts := htmlpdf.DefaultConvertOptions()
+	opts.WaitNetworkIdle = 100 * time.Millisecond
+	res, err := c.ConvertHTML(context.Background(), html, nil, &opts)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+
+	logs := res.ConsoleLogs()
+	if len(logs) != 1 || logs[0].Text != "rendering 3 charts" {
+		t.Errorf("ConsoleLogs() = %+v", logs)
+	}
+	errs := res.PageErrors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "chart failed") {
+		t.Errorf("PageErrors() = %+v", errs)
+	}
+}
+
+func TestConvertHTML_RecordNetwork(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<img src="missing.png"><p>x</p>`
+	opts := htmlpdf.DefaultConvertOptions()
+	opts.RecordNetwork = true
+	res, err := c.ConvertHTMLWithAssets(context.Background(), html, nil, nil, &opts)
+	if err != nil {
+		t.Fatalf("ConvertHTMLWithAssets: %v", err)
+	}
+	var image *htmlpdf.NetworkEntry
+	for _, e := range res.NetworkLog() {
+		if strings.HasSuffix(e.URL, "/missing.png") {
+			image = &e
+		}
+	}
+	if image == nil || image.StatusCode != http.StatusNotFound || image.Method != "GET" {
+		t.Fatalf("NetworkLog() = %+v, want the missing image", res.NetworkLog())
+	}
+	har, err := res.HAR()
+	if err != nil {
+		t.Fatalf("HAR: %v", err)
+	}
+	if !bytes.Contains(har, []byte("missing.png")) {
+		t.Errorf("HAR does not mention the image:\n%s", har)
+	}
+
+	res, err = c.ConvertHTMLWithAssets(context.Background(), html, nil, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLWithAssets: %v", err)
+	}
+	if log := res.NetworkLog(); log != nil {
+		t.Errorf("NetworkLog() without RecordNetwork = %+v, want nil", log)
+	}
+}
+
+func TestConvertHTML_TaggedPDF(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<h1>Report</h1><h2>Summary</h2><p>Body text.</p><img src="data:," alt="Chart">`
+	res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{
+		GenerateTaggedPDF:       true,
+		GenerateDocumentOutline: true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	for _, key := range []string{"StructTreeRoot", "MarkInfo", "Outlines"} {
+		if _, ok := cat[key]; !ok {
+			t.Errorf("catalog has no /%s", key)
+		}
+	}
+}
+
+func TestConvertHTML_Bookmarks(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<h1>Report</h1><p>Intro</p>
+<h2 style="break-before: page">Findings</h2><p>Details</p>`
+	res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{Bookmarks: true})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	outlines, err := doc.Resolve(cat["Outlines"])
+	if err != nil || outlines == nil || outlines.Type != htmlpdf.ObjDict {
+		t.Fatalf("/Outlines = %v, %v", outlines, err)
+	}
+	if n, _ := outlines.Dict.GetInt("Count"); n != 2 {
+		t.Errorf("outline Count = %d, want 2", n)
+	}
+}
+
+func TestConvertHTML_FillableForms(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<form>
+<input name="customer" value="Ada Lovelace">
+<input type="checkbox" name="agree" checked>
+<label><input type="radio" name="plan" value="basic"> Basic</label>
+<label><input type="radio" name="plan" value="pro" checked> Pro</label>
+<select name="country"><option>France</option><option selected>Japan</option></select>
+<textarea name="notes">Call first</textarea>
+<input type="submit" value="Send">
+</form>`
+	res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{FillableForms: true})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	acro, err := doc.Resolve(cat["AcroForm"])
+	if err != nil || acro == nil || acro.Type != htmlpdf.ObjDict {
+		t.Fatalf("/AcroForm = %v, %v", acro, err)
+	}
+	fields, _ := acro.Dict.GetArray("Fields")
+	if len(fields) != 5 {
+		t.Errorf("got %d fields, want 5 (customer, agree, plan, country, notes)", len(fields))
+	}
+	if pages, _ := res.ExtractText(); strings.Contains(strings.Join(pages, "\n"), "Ada Lovelace") {
+		t.Error("input value printed in the page content as well as the field")
+	}
+}
+
+func TestConvertHTML_Metadata(t *testing.T) {
+	c := newTestConverter(t)
+
+	res, err := c.ConvertHTML(context.Background(), "<p>Invoice</p>", &htmlpdf.PageConfig{
+		Metadata: htmlpdf.Metadata{Title: "Invoice 42", Author: "Billing"},
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if !bytes.Contains(res.Bytes(), []byte("(Invoice 42)")) {
+		t.Error("title not written to the PDF")
+	}
+	if _, err := htmlpdf.Load(res.Bytes()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+}
+
+func TestConvertHTML_PDFA(t *testing.T) {
+	c := newTestConverter(t)
+
+	res, err := c.ConvertHTML(context.Background(), `<p>Archived <a href="https://example.com">link</a></p>`, &htmlpdf.PageConfig{
+		PDFA: true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	for _, want := range []string{"<pdfaid:part>2</pdfaid:part>", "/GTS_PDFA1"} {
+		if !bytes.Contains(res.Bytes(), []byte(want)) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if _, err := htmlpdf.Load(res.Bytes()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+}
+
+func TestConvertHTML_ImageCompression(t *testing.T) {
+	c := newTestConverter(t)
+
+	// A 1200×1200 photo-like image shown 2 inches wide.
+	img := image.NewRGBA(image.Rect(0, 0, 1200, 1200))
+	x := uint32(1)
+	for i := range img.Pix {
+		x = x*1664525 + 1013904223
+		img.Pix[i] = byte(x >> 24)
+	}
+	var photo bytes.Buffer
+	if err := png.Encode(&photo, img); err != nil {
+		t.Fatal(err)
+	}
+	html := `<img src="photo.png" style="width: 2in; height: 2in">`
+	assets := map[string][]byte{"photo.png": photo.Bytes()}
+
+	full, err := c.ConvertHTMLWithAssets(context.Background(), html, assets, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLWithAssets: %v", err)
+	}
+	small, err := c.ConvertHTMLWithAssets(context.Background(), html, assets, &htmlpdf.PageConfig{
+		Images: htmlpdf.ImageCompression{MaxDPI: 96, JPEGQuality: 80},
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTMLWithAssets with Images: %v", err)
+	}
+	if small.Len()*4 > full.Len() {
+		t.Errorf("compressed output is %d bytes, want well under the original %d", small.Len(), full.Len())
+	}
+	if _, err := htmlpdf.Load(small.Bytes()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+}
+
+func TestConvertHTML_Linearize(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<h1>One</h1><h1 style="break-before: page">Two</h1>`
+	res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{
+		Metadata:  htmlpdf.Metadata{Title: "Streamed"},
+		Linearize: true,
+	})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if head := res.Bytes()[:min(res.Len(), 1024)]; !bytes.Contains(head, []byte("/Linearized 1")) {
+		t.Error("output does not start with a linearization dictionary")
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if pages, err := doc.Pages(); err != nil || len(pages) != 2 {
+		t.Errorf("Pages = %d, %v; want 2", len(pages), err)
+	}
+}
+
+func TestConvertHTML_Reproducible(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<h1>Invoice 42</h1><p>Total: 10.00</p>`
+	pg := &htmlpdf.PageConfig{
+		Metadata:     htmlpdf.Metadata{Title: "Invoice 42"},
+		Reproducible: true,
+	}
+	first, err := c.ConvertHTML(context.Background(), html, pg)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	// PDF dates have a resolution of one second.
+	time.Sleep(1100 * time.Millisecond)
+	second, err := c.ConvertHTML(context.Background(), html, pg)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("converting the same HTML twice produced different PDFs")
+	}
+}
+
+func TestResult_Attach(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := "<p>Invoice 42</p>"
+	res, err := c.ConvertHTML(context.Background(), html, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	res, err = res.Attach("source.html", []byte(html), "text/html")
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	if _, ok := cat["AF"]; !ok {
+		t.Error("catalog does not list the attachment")
+	}
+}
+
+func TestResult_Watermark(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<p>One</p><p style="break-before: page">Two</p>`
+	res, err := c.ConvertHTML(context.Background(), html, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	res, err = res.Watermark(htmlpdf.Watermark{Text: "CONFIDENTIAL", Rotation: 45, Pages: "2"})
+	if err != nil {
+		t.Fatalf("Watermark: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	texts, err := htmlpdf.NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatalf("ExtractAll: %v", err)
+	}
+	if len(texts) != 2 || strings.Contains(texts[0], "CONFIDENTIAL") || !strings.Contains(texts[1], "CONFIDENTIAL") {
+		t.Errorf("pages = %q, want only the second stamped", texts)
+	}
+}
+
+func TestResult_ExtractText(t *testing.T) {
+	c := newTestConverter(t)
+
+	res, err := c.ConvertHTML(context.Background(), `<h1>Invoice #123</h1><p style="break-before: page">Total due</p>`, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	pages, err := res.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 2 || !strings.Contains(pages[0], "Invoice #123") || !strings.Contains(pages[1], "Total due") {
+		t.Errorf("ExtractText() = %q", pages)
+	}
+}
+
+func TestOverlay_Letterhead(t *testing.T) {
+	c := newTestConverter(t)
+	ctx := context.Background()
+
+	head, err := c.ConvertHTML(ctx, `<p style="text-align: right">ACME Corp.</p>`, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML letterhead: %v", err)
+	}
+	body, err := c.ConvertHTML(ctx, `<p>One</p><p style="break-before: page">Two</p>`, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML body: %v", err)
+	}
+	base, err := htmlpdf.Load(body.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	stamp, err := htmlpdf.Load(head.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	out, err := htmlpdf.Overlay(base, stamp, htmlpdf.OverlayOptions{})
+	if err != nil {
+		t.Fatalf("Overlay: %v", err)
+	}
+	doc, err := htmlpdf.Load(out)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pages, err := doc.Pages()
+	if err != nil || len(pages) != 2 {
+		t.Fatalf("Pages = %d, %v; want 2", len(pages), err)
+	}
+	for i, page := range pages {
+		content, _ := doc.ContentStreams(page)
+		if !bytes.Contains(content, []byte("/Overlay Do")) {
+			t.Errorf("page %d does not draw the letterhead", i+1)
+		}
+	}
+}
+
+func TestConvertURL_HeadersAndCookies(t *testing.T) {
+	c := newTestConverter(t)
+
+	type seen struct{ tenant, session string }
+	requests := make(chan seen, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			var s seen
+			s.tenant = r.Header.Get("X-Tenant")
+			if ck, err := r.Cookie("session"); err == nil {
+				s.session = ck.Value
+			}
+			requests <- s
+		}
+		w.Write([]byte("<p>ok</p>"))
+	}))
+	defer srv.Close()
+
+	_, err := c.ConvertURL(context.Background(), srv.URL, nil, &htmlpdf.ConvertOptions{
+		Headers: map[string]string{"X-Tenant": "acme"},
+		Cookies: []*http.Cookie{{Name: "session", Value: "abc123"}},
+	})
+	if err != nil {
+		t.Fatalf("ConvertURL: %v", err)
+	}
+	if got := <-requests; got.tenant != "acme" || got.session != "abc123" {
+		t.Errorf("server saw X-Tenant %q, session %q", got.tenant, got.session)
+	}
+
+	// Cookies do not leak into later conversions.
+	if _, err := c.ConvertURL(context.Background(), srv.URL, nil); err != nil {
+		t.Fatalf("ConvertURL: %v", err)
+	}
+	if got := <-requests; got.session != "" || got.tenant != "" {
+		t.Errorf("second conversion sent X-Tenant %q, session %q", got.tenant, got.session)
+	}
+}
+
+func TestConvertHTML_TimeoutOverride(t *testing.T) {
+	c := newTestConverter(t)
+
+	_, err := c.ConvertHTML(context.Background(), "<p>never ready</p>", nil, &htmlpdf.ConvertOptions{
+		WaitForExpression: "false",
+		Timeout:           200 * time.Millisecond,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestConvertHTML_CaptureDiagnostics(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<p>still loading</p><script>console.log("fetching data")</script>`
+	_, err := c.ConvertHTML(context.Background(), html, nil, &htmlpdf.ConvertOptions{
+		WaitForExpression:  "false",
+		Timeout:            500 * time.Millisecond,
+		CaptureDiagnostics: true,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	diag := htmlpdf.Diagnostics(err)
+	if diag == nil {
+		t.Fatal("Diagnostics(err) = nil, want a bundle")
+	}
+	if _, err := png.DecodeConfig(bytes.NewReader(diag.Screenshot)); err != nil {
+		t.Errorf("Screenshot: %v; capture errors: %v", err, diag.Errors)
+	}
+	if !strings.Contains(diag.HTML, "still loading") {
+		t.Errorf("HTML = %q, want the page's content", diag.HTML)
+	}
+	if len(diag.ConsoleLogs) != 1 || diag.ConsoleLogs[0].Text != "fetching data" {
+		t.Errorf("ConsoleLogs = %+v, want the page's message", diag.ConsoleLogs)
+	}
+
+	_, err = c.ConvertHTML(context.Background(), "<p>never ready</p>", nil, &htmlpdf.ConvertOptions{
+		WaitForExpression: "false",
+		Timeout:           200 * time.Millisecond,
+	})
+	if diag := htmlpdf.Diagnostics(err); diag != nil {
+		t.Errorf("Diagnostics without CaptureDiagnostics = %+v, want nil", diag)
+	}
+}
+
+func TestConvertURL_WarmTabs(t *testing.T) {
+	skipIfNoChrome(t)
+
+	headers := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			headers <- r.Header.Get("X-Run")
+		}
+		w.Write([]byte("<p>ok</p>"))
+	}))
+	defer srv.Close()
+
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithWarmTabs(2))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	// Warm tabs are used once, so headers from one conversion must not
+	// reach the next.
+	ctx := context.Background()
+	for i, want := range []string{"first", "", "third", ""} {
+		var opts *htmlpdf.ConvertOptions
+		if want != "" {
+			opts = &htmlpdf.ConvertOptions{Headers: map[string]string{"X-Run": want}}
+		}
+		res, err := c.ConvertURL(ctx, srv.URL, nil, opts)
+		if err != nil {
+			t.Fatalf("conversion %d: %v", i, err)
+		}
+		if !isPDF(res.Bytes()) {
+			t.Errorf("conversion %d: output is not a PDF", i)
+		}
+		if got := <-headers; got != want {
+			t.Errorf("conversion %d: X-Run = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestConvertMany(t *testing.T) {
+	c := newTestConverter(t)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	inputs := []htmlpdf.ConvertInput{
+		{HTML: "<h1>One</h1>"},
+		{URL: srv.URL},
+		{HTML: "<h1>Three</h1>", Page: &htmlpdf.PageConfig{Size: htmlpdf.Letter}},
+	}
+	results, err := c.ConvertMany(context.Background(), inputs, nil)
+
+	var batchErr *htmlpdf.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want *BatchError", err)
+	}
+	var httpErr *htmlpdf.HTTPError
+	if !errors.As(batchErr.Errors[1], &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("input 1: err = %v, want HTTP 404", batchErr.Errors[1])
+	}
+	for _, i := range []int{0, 2} {
+		if batchErr.Errors[i] != nil || results[i] == nil || !isPDF(results[i].Bytes()) {
+			t.Errorf("input %d: result %v, err %v; want a PDF", i, results[i], batchErr.Errors[i])
+		}
+	}
+	if results[1] != nil {
+		t.Error("input 1: got a result for a failed conversion")
+	}
+}
+
+func TestConvertCombined(t *testing.T) {
+	c := newTestConverter(t)
+
+	inputs := []htmlpdf.ConvertInput{
+		{HTML: "<h1>Cover</h1>"},
+		{HTML: `<h1>Body</h1><p style="break-before: page">Second body page</p>`},
+		{HTML: "<h1>Appendix</h1>", Page: &htmlpdf.PageConfig{Orientation: htmlpdf.Landscape}, Title: "Appendix A"},
+	}
+	pg := &htmlpdf.PageConfig{Metadata: htmlpdf.Metadata{Title: "Combined"}}
+	res, err := c.ConvertCombined(context.Background(), inputs, pg)
+	if err != nil {
+		t.Fatalf("ConvertCombined: %v", err)
+	}
+
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pages, err := doc.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 4 {
+		t.Fatalf("got %d pages, want 4", len(pages))
+	}
+	ext := htmlpdf.NewExtractor(doc)
+	for i, want := range []string{"Cover", "Body", "Second body page", "Appendix"} {
+		if text, _ := ext.ExtractPage(i); !strings.Contains(text, want) {
+			t.Errorf("page %d: text %q, want %q", i, text, want)
+		}
+	}
+	if info := doc.GetPageInfo(pages[3]); info.Width <= info.Height {
+		t.Errorf("appendix page is %vx%v, want landscape", info.Width, info.Height)
+	}
+	if !bytes.Contains(res.Bytes(), []byte("Combined")) {
+		t.Error("combined document is missing the title")
+	}
+	if cat, _ := doc.Catalog(); cat["Outlines"] == nil || !bytes.Contains(res.Bytes(), []byte("Appendix A")) {
+		t.Error("combined document has no bookmark for the appendix")
+	}
+}
+
+func TestConvertCombined_MaxOutputSize(t *testing.T) {
+	part := htmlpdf.ConvertInput{HTML: strings.Repeat(`<p style="break-after: page">page</p>`, 20)}
+	res, err := newTestConverter(t).ConvertHTML(context.Background(), part.HTML, nil)
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+
+	// Each part fits within the limit, the two combined do not.
+	c, err := htmlpdf.NewConverter(htmlpdf.WithNoSandbox(), htmlpdf.WithMaxOutputSize(int64(res.Len())*3/2))
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.ConvertCombined(context.Background(), []htmlpdf.ConvertInput{part}, nil); err != nil {
+		t.Fatalf("ConvertCombined(one part): %v", err)
+	}
+	if _, err := c.ConvertCombined(context.Background(), []htmlpdf.ConvertInput{part, part}, nil); !errors.Is(err, htmlpdf.ErrOutputTooLarge) {
+		t.Errorf("ConvertCombined(two parts) = %v, want ErrOutputTooLarge", err)
+	}
+}
+
+func TestConvertCombined_NumberPages(t *testing.T) {
+	c := newTestConverter(t)
+
+	inputs := []htmlpdf.ConvertInput{
+		{HTML: "<h1>Cover</h1>"},
+		{HTML: `<p>Body</p><p style="break-before: page">More</p>`},
+	}
+	res, err := c.ConvertCombined(context.Background(), inputs, nil)
+	if err != nil {
+		t.Fatalf("ConvertCombined: %v", err)
+	}
+	res, err = res.NumberPages(htmlpdf.PageNumbers{Pages: "2-"})
+	if err != nil {
+		t.Fatalf("NumberPages: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	texts, err := htmlpdf.NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatalf("ExtractAll: %v", err)
+	}
+	for i, want := range []string{"", "Page 2 of 3", "Page 3 of 3"} {
+		if got := strings.Contains(texts[i], "Page "); got != (want != "") || !strings.Contains(texts[i], want) {
+			t.Errorf("page %d = %q, want %q", i+1, texts[i], want)
+		}
+	}
+}
+
+func TestConvertRoutes(t *testing.T) {
+	c := newTestConverter(t)
+
+	var loads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/favicon.ico" {
+			http.NotFound(w, r)
+			return
+		}
+		loads.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<body><h1 id="view"></h1><script>
+  const render = () => {
+    document.getElementById("view").textContent = "View " + location.pathname + location.hash;
+    setTimeout(() => window.__htmlpdfReady(), 50);
+  };
+  addEventListener("popstate", render);
+  addEventListener("hashchange", render);
+  render();
+</script></body>`)
+	}))
+	defer srv.Close()
+
+	opts := htmlpdf.DefaultConvertOptions()
+	opts.WaitForReadySignal = true
+	opts.RouteTitles = []string{"Sales", "Costs"}
+	res, err := c.ConvertRoutes(context.Background(), srv.URL, []string{"/sales", "/costs", "#/details"}, nil, &opts)
+	if err != nil {
+		t.Fatalf("ConvertRoutes: %v", err)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("app loaded %d times, want once", n)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	ext := htmlpdf.NewExtractor(doc)
+	for i, want := range []string{"View /sales", "View /costs", "View /costs#/details"} {
+		if text, _ := ext.ExtractPage(i); !strings.Contains(text, want) {
+			t.Errorf("page %d: text %q, want %q", i, text, want)
+		}
+	}
+	if cat, _ := doc.Catalog(); cat["Outlines"] == nil {
+		t.Error("no bookmarks for the route titles")
+	}
+}
+
+func TestOptimize_Combined(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := "<h1>Quarterly report</h1><p>Revenue grew.</p>"
+	inputs := []htmlpdf.ConvertInput{{HTML: html}, {HTML: html}, {HTML: html}}
+	res, err := c.ConvertCombined(context.Background(), inputs, nil)
+	if err != nil {
+		t.Fatalf("ConvertCombined: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	out, err := htmlpdf.Optimize(doc)
+	if err != nil {
+		t.Fatalf("Optimize: %v", err)
+	}
+	if len(out) > res.Len() {
+		t.Errorf("optimized PDF is %d bytes, larger than the original %d", len(out), res.Len())
+	}
+	doc, err = htmlpdf.Load(out)
+	if err != nil {
+		t.Fatalf("Load optimized: %v", err)
+	}
+	texts, err := htmlpdf.NewExtractor(doc).ExtractAll()
+	if err != nil || len(texts) != 3 {
+		t.Fatalf("ExtractAll = %d pages, %v; want 3", len(texts), err)
+	}
+	for i, text := range texts {
+		if !strings.Contains(text, "Revenue grew.") {
+			t.Errorf("page %d = %q", i+1, text)
+		}
+	}
+}
+
+func TestConvertHTMLTo(t *testing.T) {
+	c := newTestConverter(t)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	res, err := c.ConvertHTMLTo(ctx, &out, "<h1>Streamed</h1>", nil)
+	if err != nil {
+		t.Fatalf("ConvertHTMLTo: %v", err)
+	}
+	if !isPDF(out.Bytes()) {
+		t.Error("output is not a PDF")
+	}
+	if res.Len() != out.Len() || res.Bytes() != nil {
+		t.Errorf("Len() = %d with %d bytes of data, want %d and none", res.Len(), len(res.Bytes()), out.Len())
+	}
+
+	// Metadata needs the whole document, so it is buffered and written
+	// once complete.
+	out.Reset()
+	pg := &htmlpdf.PageConfig{Metadata: htmlpdf.Metadata{Title: "Buffered"}}
+	if _, err := c.ConvertHTMLTo(ctx, &out, "<h1>Buffered</h1>", pg); err != nil {
+		t.Fatalf("ConvertHTMLTo: %v", err)
+	}
+	if !isPDF(out.Bytes()) || !bytes.Contains(out.Bytes(), []byte("Buffered")) {
+		t.Error("buffered output is not a PDF with the title")
+	}
+}
+
+func TestScreenshot(t *testing.T) {
+	c := newTestConverter(t)
+	ctx := context.Background()
+	in := htmlpdf.ConvertInput{HTML: `<body style="margin:0"><div style="height:2000px;background:#c00"></div></body>`}
+
+	img, err := c.Screenshot(ctx, in, &htmlpdf.ScreenshotOptions{Width: 400, Height: 300})
+	if err != nil {
+		t.Fatalf("Screenshot: %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("decoding screenshot: %v", err)
+	}
+	if format != "png" || cfg.Width != 400 || cfg.Height != 300 {
+		t.Errorf("got %s %dx%d, want png 400x300", format, cfg.Width, cfg.Height)
+	}
+
+	img, err = c.Screenshot(ctx, in, &htmlpdf.ScreenshotOptions{Format: htmlpdf.JPEG, Width: 400, Height: 300, FullPage: true})
+	if err != nil {
+		t.Fatalf("Screenshot: %v", err)
+	}
+	cfg, format, err = image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("decoding screenshot: %v", err)
+	}
+	if format != "jpeg" || cfg.Height < 2000 {
+		t.Errorf("got %s %dx%d, want a full-page jpeg at least 2000px tall", format, cfg.Width, cfg.Height)
+	}
+}
+
+func TestConvertHTML_Selector(t *testing.T) {
+	c := newTestConverter(t)
+	ctx := context.Background()
+
+	html := `<header>App navigation</header>
+<main><aside>Sidebar</aside><section><div id="invoice">Invoice 42</div><p>Footer links</p></section></main>`
+	res, err := c.ConvertHTML(ctx, html, nil, &htmlpdf.ConvertOptions{Selector: "#invoice"})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pages, err := htmlpdf.NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Join(pages, "\n")
+	if !strings.Contains(text, "Invoice 42") {
+		t.Errorf("text %q is missing the selected element", text)
+	}
+	for _, hidden := range []string{"App navigation", "Sidebar", "Footer links"} {
+		if strings.Contains(text, hidden) {
+			t.Errorf("text %q contains %q from outside the selected element", text, hidden)
+		}
+	}
+
+	if _, err := c.ConvertHTML(ctx, html, nil, &htmlpdf.ConvertOptions{Selector: "#missing"}); err == nil || !strings.Contains(err.Error(), "matched no element") {
+		t.Errorf("err = %v, want a no-match error", err)
+	}
+}
+
+func TestConvertEML(t *testing.T) {
+	c := newTestConverter(t)
+
+	eml := "From: ana@example.com\r\nTo: legal@example.com\r\nSubject: Contract draft\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n\r\n" +
+		"<html><body><p>Please review the attached draft.</p></body></html>\r\n"
+	res, err := c.ConvertEML(context.Background(), strings.NewReader(eml), nil)
+	if err != nil {
+		t.Fatalf("ConvertEML: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	text, _ := htmlpdf.NewExtractor(doc).ExtractPage(0)
+	for _, want := range []string{"Contract draft", "ana@example.com", "Please review"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text %q is missing %q", text, want)
+		}
+	}
+}
+
+func TestConvertSVG(t *testing.T) {
+	c := newTestConverter(t)
+
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 400 200">` +
+		`<rect width="400" height="200" fill="#eee"/><text x="20" y="100">Architecture</text></svg>`)
+	res, err := c.ConvertSVG(context.Background(), svg, &htmlpdf.PageConfig{PreferCSSPageSize: true})
+	if err != nil {
+		t.Fatalf("ConvertSVG: %v", err)
+	}
+	doc, err := htmlpdf.Load(res.Bytes())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pages, err := doc.Pages()
+	if err != nil {
+		t.Fatalf("Pages: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("got %d pages, want 1", len(pages))
+	}
+	// 400×200 CSS pixels is 300×150 points.
+	if info := doc.GetPageInfo(pages[0]); math.Abs(info.Width-300) > 1 || math.Abs(info.Height-150) > 1 {
+		t.Errorf("page is %.1f×%.1f pt, want 300×150", info.Width, info.Height)
+	}
+	text, _ := htmlpdf.NewExtractor(doc).ExtractPage(0)
+	if !strings.Contains(text, "Architecture") {
+		t.Errorf("text %q is missing the SVG's text", text)
+	}
+}
+
+func TestResult_Metadata(t *testing.T) {
+	c := newTestConverter(t)
+
+	html := `<html><head><title>Quarterly Report</title></head><body><p>1</p>` +
+		`<p style="break-before: page">2</p></body></html>`
+	res, err := c.ConvertHTML(context.Background(), html, &htmlpdf.PageConfig{Size: htmlpdf.Letter})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	info, err := res.Metadata()
+	if err != nil {
+		t.Fatalf("Metadata: %v", err)
+	}
+	if info.Title != "Quarterly Report" {
+		t.Errorf("Title = %q, want Quarterly Report", info.Title)
+	}
+	if info.Producer == "" || info.Created.IsZero() {
+		t.Errorf("Producer, Created = %q, %v, want Chrome's values", info.Producer, info.Created)
+	}
+	if len(info.Pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(info.Pages))
+	}
+	if p := info.Pages[0]; math.Abs(p.Width-htmlpdf.Letter.Width) > 0.1 || math.Abs(p.Height-htmlpdf.Letter.Height) > 0.1 {
+		t.Errorf("page size = %v, want Letter", p)
+	}
+	if info.Encrypted {
+		t.Error("Encrypted = true")
+	}
+}
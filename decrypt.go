@@ -0,0 +1,387 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// passwordPadding pads passwords to 32 bytes for the RC4 and AES-128
+// revisions of the standard security handler (ISO 32000-1, 7.6.3.3).
+var passwordPadding = []byte{
+	0x28, 0xbf, 0x4e, 0x5e, 0x4e, 0x75, 0x8a, 0x41, 0x64, 0x00, 0x4e, 0x56, 0xff, 0xfa, 0x01, 0x08,
+	0x2e, 0x2e, 0x00, 0xb6, 0xd0, 0x68, 0x3e, 0x80, 0x2f, 0x0c, 0xa9, 0xfe, 0x64, 0x53, 0x69, 0x7a,
+}
+
+// Crypt filter methods (ISO 32000-1, Table 25). cryptNone leaves data
+// as it is, for the Identity filter.
+const (
+	cryptNone  = ""
+	cryptRC4   = "V2"
+	cryptAESV2 = "AESV2"
+	cryptAESV3 = "AESV3"
+)
+
+// decryptor decrypts the strings and streams of a document encrypted with
+// the standard security handler, revisions 2 to 6: RC4 with keys of 40
+// to 128 bits, AES-128 and AES-256.
+type decryptor struct {
+	key             []byte // file encryption key
+	stm, str        string // crypt filter methods for streams and strings
+	encryptMetadata bool
+	encRef          int // object number of the encryption dictionary, if indirect
+}
+
+// newDecryptor authenticates password, as the user or the owner password,
+// against the encryption dictionary of doc and derives the file key.
+func newDecryptor(doc *Document, password []byte) (*decryptor, error) {
+	encObj := doc.trailer["Encrypt"]
+	enc := resolveDict(doc, encObj)
+	if enc == nil {
+		return nil, fmt.Errorf("invalid /Encrypt dictionary")
+	}
+	if filter, _ := enc.GetName("Filter"); filter != "Standard" {
+		return nil, fmt.Errorf("unsupported security handler %q", filter)
+	}
+	v, _ := enc.GetInt("V")
+	r, _ := enc.GetInt("R")
+	d := &decryptor{encryptMetadata: true}
+	if encObj.Type == ObjRef {
+		d.encRef = encObj.Ref.Number
+	}
+	if em, ok := enc["EncryptMetadata"]; ok && em.Type == ObjBool {
+		d.encryptMetadata = em.Bool
+	}
+
+	switch v {
+	case 1, 2:
+		d.stm, d.str = cryptRC4, cryptRC4
+	case 4, 5:
+		cf, _ := enc.GetDict("CF")
+		method := func(key string) (string, error) {
+			name, ok := enc.GetName(key)
+			if !ok || name == "Identity" {
+				return cryptNone, nil
+			}
+			filter := resolveDict(doc, cf[name])
+			if filter == nil {
+				return "", fmt.Errorf("crypt filter %q not defined", name)
+			}
+			switch cfm, _ := filter.GetName("CFM"); cfm {
+			case "None":
+				return cryptNone, nil
+			case cryptRC4, cryptAESV2, cryptAESV3:
+				return cfm, nil
+			default:
+				return "", fmt.Errorf("unsupported crypt filter method %q", cfm)
+			}
+		}
+		var err error
+		if d.stm, err = method("StmF"); err != nil {
+			return nil, err
+		}
+		if d.str, err = method("StrF"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encryption version %d", v)
+	}
+
+	o, u := stringEntry(doc, enc, "O"), stringEntry(doc, enc, "U")
+	switch r {
+	case 2, 3, 4:
+		password = pdfDocEncode(string(password))
+		n := 5
+		if r > 2 {
+			if length, ok := enc.GetInt("Length"); ok && length >= 40 && length <= 128 && length%8 == 0 {
+				n = int(length / 8)
+			} else if v == 4 {
+				n = 16
+			}
+		}
+		if len(o) < 32 || len(u) < 32 {
+			return nil, fmt.Errorf("invalid /O or /U entry")
+		}
+		p, _ := enc.GetInt("P")
+		var id []byte
+		if ids, ok := doc.trailer.GetArray("ID"); ok && len(ids) > 0 {
+			if first, _ := doc.Resolve(ids[0]); first != nil && first.Type == ObjString {
+				id = first.Str
+			}
+		}
+		key := func(password []byte) []byte {
+			return legacyFileKey(password, o, uint32(p), id, int(r), n, d.encryptMetadata)
+		}
+		if k := key(password); legacyUserMatches(k, u, id, int(r)) {
+			d.key = k
+		} else if k := key(legacyOwnerToUser(password, o, int(r), n)); legacyUserMatches(k, u, id, int(r)) {
+			d.key = k
+		} else {
+			return nil, ErrIncorrectPassword
+		}
+	case 5, 6:
+		if len(o) < 48 || len(u) < 48 {
+			return nil, fmt.Errorf("invalid /O or /U entry")
+		}
+		password = truncatePassword([]byte(saslPrep(string(password))))
+		hash := func(password, salt, udata []byte) []byte {
+			if r == 5 {
+				sum := sha256.Sum256(append(append(append([]byte(nil), password...), salt...), udata...))
+				return sum[:]
+			}
+			return hash2B(password, salt, udata)
+		}
+		var ke, wrapped []byte
+		switch {
+		case bytes.Equal(hash(password, o[32:40], u[:48]), o[:32]):
+			ke, wrapped = hash(password, o[40:48], u[:48]), stringEntry(doc, enc, "OE")
+		case bytes.Equal(hash(password, u[32:40], nil), u[:32]):
+			ke, wrapped = hash(password, u[40:48], nil), stringEntry(doc, enc, "UE")
+		default:
+			return nil, ErrIncorrectPassword
+		}
+		if len(wrapped) != 32 {
+			return nil, fmt.Errorf("invalid /OE or /UE entry")
+		}
+		block, _ := aes.NewCipher(ke)
+		d.key = make([]byte, 32)
+		cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(d.key, wrapped)
+	default:
+		return nil, fmt.Errorf("unsupported security handler revision %d", r)
+	}
+	return d, nil
+}
+
+// stringEntry returns the string value of key in d, or nil.
+func stringEntry(doc *Document, d Dict, key string) []byte {
+	s, _ := doc.Resolve(d[key])
+	if s == nil || s.Type != ObjString {
+		return nil
+	}
+	return s.Str
+}
+
+// pdfDocEncode encodes a password for revisions 2 to 4, which take it
+// in PDFDocEncoding. Characters it lacks become "?".
+func pdfDocEncode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r < 0x80 {
+			b = append(b, byte(r))
+			continue
+		}
+		c := byte('?')
+		for i, u := range pdfDocEncodingUpper128 {
+			if u == r {
+				c = byte(0x80 + i)
+				break
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// saslPrep prepares a UTF-8 password for revisions 5 and 6 with the
+// SASLprep profile of stringprep (RFC 4013), as ISO 32000-2 requires:
+// non-ASCII spaces become spaces, characters commonly mapped to nothing
+// are removed, and the result is normalized to NFKC. Passwords holding
+// characters the profile prohibits are returned unchanged.
+func saslPrep(s string) string {
+	mapped := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == 0x00AD, r == 0x034F, r == 0x1806, r >= 0x180B && r <= 0x180D,
+			r >= 0x200B && r <= 0x200D, r == 0x2060, r >= 0xFE00 && r <= 0xFE0F, r == 0xFEFF:
+			// RFC 3454, table B.1
+		case r != ' ' && unicode.Is(unicode.Zs, r):
+			mapped = append(mapped, ' ')
+		default:
+			mapped = append(mapped, r)
+		}
+	}
+	prepped := norm.NFKC.String(string(mapped))
+	for _, r := range prepped {
+		if unicode.IsControl(r) || unicode.Is(unicode.Co, r) || unicode.Is(unicode.Cs, r) ||
+			(r >= 0xFDD0 && r <= 0xFDEF) || r&0xFFFE == 0xFFFE {
+			return s
+		}
+	}
+	return prepped
+}
+
+// padPassword returns password truncated or padded to 32 bytes.
+func padPassword(password []byte) []byte {
+	p := make([]byte, 32)
+	n := copy(p, password)
+	copy(p[n:], passwordPadding)
+	return p
+}
+
+// legacyFileKey computes the file encryption key for revisions 2 to 4
+// (ISO 32000-1, algorithm 2).
+func legacyFileKey(password, o []byte, p uint32, id []byte, r, n int, encryptMetadata bool) []byte {
+	h := md5.New()
+	h.Write(padPassword(password))
+	h.Write(o[:32])
+	binary.Write(h, binary.LittleEndian, p)
+	h.Write(id)
+	if r >= 4 && !encryptMetadata {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key := h.Sum(nil)
+	if r >= 3 {
+		for range 50 {
+			sum := md5.Sum(key[:n])
+			key = sum[:]
+		}
+	}
+	return key[:n]
+}
+
+// legacyUserMatches reports whether key produces the /U entry u
+// (ISO 32000-1, algorithms 4 and 5).
+func legacyUserMatches(key, u, id []byte, r int) bool {
+	if r == 2 {
+		return bytes.Equal(rc4Crypt(key, passwordPadding), u[:32])
+	}
+	h := md5.New()
+	h.Write(passwordPadding)
+	h.Write(id)
+	out := rc4Crypt(key, h.Sum(nil))
+	for i := 1; i <= 19; i++ {
+		out = rc4Crypt(xorKey(key, byte(i)), out)
+	}
+	return bytes.Equal(out, u[:16])
+}
+
+// legacyOwnerToUser recovers the user password from the /O entry o,
+// assuming password is the owner password (ISO 32000-1, algorithm 7).
+func legacyOwnerToUser(password, o []byte, r, n int) []byte {
+	sum := md5.Sum(padPassword(password))
+	key := sum[:]
+	if r >= 3 {
+		for range 50 {
+			sum = md5.Sum(key)
+			key = sum[:]
+		}
+	}
+	key = key[:n]
+	user := o[:32]
+	if r == 2 {
+		return rc4Crypt(key, user)
+	}
+	for i := 19; i >= 0; i-- {
+		user = rc4Crypt(xorKey(key, byte(i)), user)
+	}
+	return user
+}
+
+func xorKey(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i, k := range key {
+		out[i] = k ^ b
+	}
+	return out
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// decryptObject decrypts, in place, the strings and stream data of obj,
+// the object numbered num with generation gen, as parsed from the file.
+func (d *decryptor) decryptObject(obj *Object, num, gen int) {
+	if num == d.encRef && d.encRef != 0 {
+		return
+	}
+	if obj.Type == ObjStream {
+		// Cross-reference streams are never encrypted, and XMP metadata
+		// may be left in the clear for indexers.
+		t, _ := obj.Dict.GetName("Type")
+		if t != "XRef" && !(t == "Metadata" && !d.encryptMetadata) && !hasCryptFilter(obj.Dict) {
+			obj.Stream = d.decrypt(d.stm, obj.Stream, num, gen)
+		}
+	}
+	d.decryptStrings(obj, num, gen)
+}
+
+func (d *decryptor) decryptStrings(obj *Object, num, gen int) {
+	switch obj.Type {
+	case ObjString:
+		obj.Str = d.decrypt(d.str, obj.Str, num, gen)
+	case ObjArray:
+		for _, item := range obj.Array {
+			d.decryptStrings(item, num, gen)
+		}
+	case ObjDict, ObjStream:
+		for _, v := range obj.Dict {
+			d.decryptStrings(v, num, gen)
+		}
+	}
+}
+
+// hasCryptFilter reports whether a stream names its own crypt filter,
+// which this reader does not apply.
+func hasCryptFilter(d Dict) bool {
+	if name, ok := d.GetName("Filter"); ok {
+		return name == "Crypt"
+	}
+	filters, _ := d.GetArray("Filter")
+	for _, f := range filters {
+		if f.Type == ObjName && f.Name == "Crypt" {
+			return true
+		}
+	}
+	return false
+}
+
+// objectKey returns the key for the strings and streams of object num
+// with generation gen (ISO 32000-1, algorithm 1). AES-256 uses the file
+// key itself.
+func (d *decryptor) objectKey(method string, num, gen int) []byte {
+	if method == cryptAESV3 {
+		return d.key
+	}
+	h := md5.New()
+	h.Write(d.key)
+	h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), byte(gen), byte(gen >> 8)})
+	if method == cryptAESV2 {
+		h.Write([]byte("sAlT"))
+	}
+	return h.Sum(nil)[:min(len(d.key)+5, 16)]
+}
+
+// decrypt decrypts data with the object key for num and gen. Data that
+// fails to decrypt is returned as is.
+func (d *decryptor) decrypt(method string, data []byte, num, gen int) []byte {
+	if method == cryptNone {
+		return data
+	}
+	key := d.objectKey(method, num, gen)
+	if method == cryptRC4 {
+		return rc4Crypt(key, data)
+	}
+
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return data
+	}
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	if pad := int(out[len(out)-1]); pad >= 1 && pad <= aes.BlockSize {
+		out = out[:len(out)-pad]
+	}
+	return out
+}
@@ -0,0 +1,264 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// buildLegacyEncrypted returns a one-page document with an Info title,
+// encrypted with the standard security handler revision r: 40-bit RC4
+// for revision 2, 128-bit RC4 for revision 3, and AES-128 for revision
+// 4, as producers of older PDFs write them.
+func buildLegacyEncrypted(t *testing.T, r int, owner, user string) []byte {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Quarterly invoice) Tj ET")}))
+	u, _ := newPDFUpdate(doc)
+	u.trailer["Info"] = refObj(u.add(dictObj(Dict{"Title": textStringObj("Invoice (Q3)")})))
+	doc = mustLoad(t, u.bytes())
+
+	n, method := 16, cryptRC4
+	switch r {
+	case 2:
+		n = 5
+	case 4:
+		method = cryptAESV2
+	}
+	id := []byte("0123456789abcdef")
+	p := int32(-3904) // print, copy and accessibility only
+
+	// Algorithm 3: the /O entry.
+	sum := md5.Sum(padPassword(pdfDocEncode(owner)))
+	ownerKey := sum[:]
+	if r >= 3 {
+		for range 50 {
+			sum = md5.Sum(ownerKey)
+			ownerKey = sum[:]
+		}
+	}
+	o := rc4Crypt(ownerKey[:n], padPassword(pdfDocEncode(user)))
+	if r >= 3 {
+		for i := 1; i <= 19; i++ {
+			o = rc4Crypt(xorKey(ownerKey[:n], byte(i)), o)
+		}
+	}
+
+	// Algorithms 4 and 5: the /U entry.
+	key := legacyFileKey(pdfDocEncode(user), o, uint32(p), id, r, n, true)
+	var uEntry []byte
+	if r == 2 {
+		uEntry = rc4Crypt(key, passwordPadding)
+	} else {
+		h := md5.Sum(append(append([]byte(nil), passwordPadding...), id...))
+		uEntry = rc4Crypt(key, h[:])
+		for i := 1; i <= 19; i++ {
+			uEntry = rc4Crypt(xorKey(key, byte(i)), uEntry)
+		}
+		uEntry = append(uEntry, make([]byte, 16)...)
+	}
+
+	d := &decryptor{key: key}
+	encrypt := func(data []byte, num int) []byte {
+		k := d.objectKey(method, num, 0)
+		if method == cryptRC4 {
+			return rc4Crypt(k, data)
+		}
+		pad := aes.BlockSize - len(data)%aes.BlockSize
+		out := append(make([]byte, aes.BlockSize), data...)
+		out = append(out, bytes.Repeat([]byte{byte(pad)}, pad)...)
+		copy(out, "initialization v")
+		block, _ := aes.NewCipher(k)
+		cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], out[aes.BlockSize:])
+		return out
+	}
+	var encryptObj func(obj *Object, num int) *Object
+	encryptObj = func(obj *Object, num int) *Object {
+		switch obj.Type {
+		case ObjString:
+			return &Object{Type: ObjString, Str: encrypt(obj.Str, num)}
+		case ObjArray:
+			items := make([]*Object, len(obj.Array))
+			for i, item := range obj.Array {
+				items[i] = encryptObj(item, num)
+			}
+			return arrayObj(items...)
+		case ObjDict, ObjStream:
+			dict := make(Dict, len(obj.Dict))
+			for k, v := range obj.Dict {
+				dict[k] = encryptObj(v, num)
+			}
+			if obj.Type == ObjDict {
+				return dictObj(dict)
+			}
+			return &Object{Type: ObjStream, Dict: dict, Stream: encrypt(obj.Stream, num)}
+		}
+		return obj
+	}
+
+	enc := Dict{
+		"Filter": nameObj("Standard"),
+		"V":      intObj(map[int]int{2: 1, 3: 2, 4: 4}[r]),
+		"R":      intObj(r),
+		"Length": intObj(n * 8),
+		"O":      &Object{Type: ObjString, Str: o},
+		"U":      &Object{Type: ObjString, Str: uEntry},
+		"P":      intObj(int(p)),
+	}
+	if r == 4 {
+		enc["CF"] = dictObj(Dict{"StdCF": dictObj(Dict{"CFM": nameObj(cryptAESV2), "Length": intObj(16)})})
+		enc["StmF"], enc["StrF"] = nameObj("StdCF"), nameObj("StdCF")
+	}
+
+	var ids []int
+	for num, e := range doc.xref {
+		if e.InUse && num > 0 {
+			ids = append(ids, num)
+		}
+	}
+	sort.Ints(ids)
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.6\n")
+	offsets := make(map[int]int)
+	for _, num := range ids {
+		obj, _ := doc.ResolveRef(Reference{Number: num})
+		offsets[num] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", num)
+		writeObject(&buf, encryptObj(obj, num))
+		buf.WriteString("\nendobj\n")
+	}
+	encNum := ids[len(ids)-1] + 1
+	offsets[encNum] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n", encNum)
+	writeObject(&buf, dictObj(enc))
+	buf.WriteString("\nendobj\n")
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", encNum+1)
+	for num := 1; num <= encNum; num++ {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offsets[num])
+	}
+	buf.WriteString("trailer\n")
+	fileID := &Object{Type: ObjString, Str: id}
+	writeDict(&buf, Dict{
+		"Size":    intObj(encNum + 1),
+		"Root":    doc.trailer["Root"],
+		"Info":    doc.trailer["Info"],
+		"Encrypt": refObj(Reference{Number: encNum}),
+		"ID":      arrayObj(fileID, fileID),
+	})
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+func TestLoadWithPassword(t *testing.T) {
+	for _, r := range []int{2, 3, 4} {
+		t.Run(fmt.Sprintf("R%d", r), func(t *testing.T) {
+			pdf := buildLegacyEncrypted(t, r, "owner", "user")
+			if bytes.Contains(pdf, []byte("Quarterly invoice")) {
+				t.Fatal("test document is not encrypted")
+			}
+			for _, password := range []string{"user", "owner"} {
+				doc, err := LoadWithPassword(pdf, password)
+				if err != nil {
+					t.Fatalf("LoadWithPassword(%q): %v", password, err)
+				}
+				texts, err := NewExtractor(doc).ExtractAll()
+				if err != nil || len(texts) != 1 || !strings.Contains(texts[0], "Quarterly invoice") {
+					t.Errorf("password %q: text = %q, %v", password, texts, err)
+				}
+				if title := resolveDict(doc, doc.trailer["Info"])["Title"]; title == nil || string(title.Str) != "Invoice (Q3)" {
+					t.Errorf("password %q: Title = %v", password, title)
+				}
+			}
+			if _, err := LoadWithPassword(pdf, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("LoadWithPassword with a wrong password = %v, want ErrIncorrectPassword", err)
+			}
+			if _, err := Load(pdf); !errors.Is(err, ErrIncorrectPassword) {
+				t.Errorf("Load = %v, want ErrIncorrectPassword", err)
+			}
+		})
+	}
+}
+
+func TestLoad_EmptyUserPassword(t *testing.T) {
+	doc, err := Load(buildLegacyEncrypted(t, 4, "owner", ""))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if texts, _ := NewExtractor(doc).ExtractAll(); len(texts) != 1 || !strings.Contains(texts[0], "Quarterly invoice") {
+		t.Errorf("text = %q", texts)
+	}
+}
+
+func TestLoadWithPassword_AES256(t *testing.T) {
+	content := []byte("BT /F1 12 Tf (Payslip) Tj ET")
+	out, err := encryptPDF(buildTestPDF([][]byte{content}), "owner", "user", PermitPrint)
+	if err != nil {
+		t.Fatalf("encryptPDF: %v", err)
+	}
+	for _, password := range []string{"user", "owner"} {
+		doc, err := LoadWithPassword(out, password)
+		if err != nil {
+			t.Fatalf("LoadWithPassword(%q): %v", password, err)
+		}
+		pages, _ := doc.Pages()
+		if cs, _ := doc.ContentStreams(pages[0]); !bytes.Equal(bytes.TrimSpace(cs), content) {
+			t.Errorf("password %q: content = %q, want %q", password, cs, content)
+		}
+	}
+	if _, err := LoadWithPassword(out, ""); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("LoadWithPassword without a password = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+func TestLoadWithPassword_NonASCII(t *testing.T) {
+	legacy := buildLegacyEncrypted(t, 3, "propriétaire", "café")
+	if _, err := LoadWithPassword(legacy, "café"); err != nil {
+		t.Errorf("RC4 document: LoadWithPassword: %v", err)
+	}
+	if _, err := LoadWithPassword(legacy, "propriétaire"); err != nil {
+		t.Errorf("RC4 document with the owner password: LoadWithPassword: %v", err)
+	}
+
+	// Revision 6 passwords are UTF-8 prepared with SASLprep, so the
+	// decomposed and precomposed forms of a password are the same.
+	out, err := encryptPDF(buildTestPDF([][]byte{[]byte("BT ET")}), "", "A\u030angstro\u0308m", PermitAll)
+	if err != nil {
+		t.Fatalf("encryptPDF: %v", err)
+	}
+	if _, err := LoadWithPassword(out, "Ångström"); err != nil {
+		t.Errorf("AES-256 document: LoadWithPassword: %v", err)
+	}
+	if _, err := LoadWithPassword(out, "Angstrom"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("AES-256 document with another password = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+func TestSASLPrep(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"user", "user"},
+		{"I\u00adX", "IX"},              // soft hyphen mapped to nothing
+		{"user\u00a0name", "user name"}, // non-ASCII space
+		{"\u2168", "IX"},                // NFKC: roman numeral nine
+		{"\u00aa", "a"},                 // NFKC: feminine ordinal
+		{"e\u0301", "\u00e9"},           // NFKC composes
+		{"a\u0007", "a\u0007"},          // prohibited control character: unchanged
+	}
+	for _, tt := range tests {
+		if got := saslPrep(tt.in); got != tt.want {
+			t.Errorf("saslPrep(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPDFDocEncode(t *testing.T) {
+	if got, want := pdfDocEncode("café — ☃"), []byte("caf\xe9 \x89 ?"); !bytes.Equal(got, want) {
+		t.Errorf("pdfDocEncode = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// diagnosticsTimeout bounds capturing a [DiagnosticBundle], so that a
+// page stuck in a script cannot hold up the failed conversion for long.
+const diagnosticsTimeout = 5 * time.Second
+
+// DiagnosticBundle is the state of a page whose conversion failed,
+// captured when [ConvertOptions].CaptureDiagnostics is set, for finding
+// out after the fact why, say, a PDF came out blank. Use [Diagnostics] to
+// get it from the returned error.
+//
+// Parts that could not be captured, e.g. because the tab crashed, are
+// left empty and the reason is recorded in Errors.
+type DiagnosticBundle struct {
+	URL            string           // the page being converted
+	Screenshot     []byte           // PNG of the viewport at the time of the failure
+	HTML           string           // serialized DOM at the time of the failure
+	ConsoleLogs    []ConsoleMessage // console messages logged by the page
+	PageErrors     []PageError      // uncaught JavaScript exceptions
+	FailedRequests []RequestFailure // requests that failed or returned 4xx/5xx
+
+	// Errors holds the error that failed the conversion, as reported by
+	// Chrome or chromedp, followed by any errors capturing the bundle.
+	Errors []string
+}
+
+// WriteDir writes the bundle to the directory dir, creating it if
+// needed: screenshot.png, page.html, and report.txt listing the URL,
+// errors, failed requests, page errors and console messages.
+func (b *DiagnosticBundle) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if b.Screenshot != nil {
+		if err := os.WriteFile(filepath.Join(dir, "screenshot.png"), b.Screenshot, 0o644); err != nil {
+			return err
+		}
+	}
+	if b.HTML != "" {
+		if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(b.HTML), 0o644); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(dir, "report.txt"), []byte(b.report()), 0o644)
+}
+
+// report formats the bundle's text parts for WriteDir.
+func (b *DiagnosticBundle) report() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "URL: %s\n", b.URL)
+	section := func(title string, n int, line func(i int) string) {
+		if n == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s:\n", title)
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&sb, "  %s\n", line(i))
+		}
+	}
+	section("Errors", len(b.Errors), func(i int) string { return b.Errors[i] })
+	section("Failed requests", len(b.FailedRequests), func(i int) string {
+		f := b.FailedRequests[i]
+		return fmt.Sprintf("%s: %s", f.URL, f.Error)
+	})
+	section("Page errors", len(b.PageErrors), func(i int) string {
+		e := b.PageErrors[i]
+		return fmt.Sprintf("%s:%d:%d: %s", e.URL, e.Line, e.Column, e.Message)
+	})
+	section("Console", len(b.ConsoleLogs), func(i int) string {
+		m := b.ConsoleLogs[i]
+		return fmt.Sprintf("[%s] %s", m.Type, m.Text)
+	})
+	return sb.String()
+}
+
+// DiagnosticError is returned when a conversion with
+// [ConvertOptions].CaptureDiagnostics set fails. It wraps the conversion
+// error, so [errors.Is] and [errors.As] see through it.
+type DiagnosticError struct {
+	Err         error
+	Diagnostics *DiagnosticBundle
+}
+
+func (e *DiagnosticError) Error() string { return e.Err.Error() }
+func (e *DiagnosticError) Unwrap() error { return e.Err }
+
+// Diagnostics returns the [DiagnosticBundle] captured for the failed
+// conversion that returned err, or nil if none was captured.
+func Diagnostics(err error) *DiagnosticBundle {
+	var diagErr *DiagnosticError
+	if errors.As(err, &diagErr) {
+		return diagErr.Diagnostics
+	}
+	return nil
+}
+
+// outerHTMLScript serializes the document, whatever state it is in.
+const outerHTMLScript = `document.documentElement ? document.documentElement.outerHTML : ""`
+
+// captureDiagnostics captures the state of the tab of tabCtx after its
+// conversion of url failed with cause.
+func captureDiagnostics(tabCtx context.Context, url string, cause error, console *pageConsole, failures *requestFailures) *DiagnosticBundle {
+	b := &DiagnosticBundle{
+		URL:            url,
+		FailedRequests: failures.list(),
+		Errors:         []string{cause.Error()},
+	}
+	ctx, cancel := context.WithTimeout(tabCtx, diagnosticsTimeout)
+	defer cancel()
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		b.Screenshot, err = page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormatPng).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		b.Errors = append(b.Errors, fmt.Sprintf("capturing screenshot: %v", err))
+	}
+	if err := chromedp.Run(ctx, chromedp.Evaluate(outerHTMLScript, &b.HTML)); err != nil {
+		b.Errors = append(b.Errors, fmt.Sprintf("capturing HTML: %v", err))
+	}
+	// The console is read last, to include anything logged meanwhile.
+	b.ConsoleLogs, b.PageErrors = console.snapshot()
+	return b
+}
@@ -0,0 +1,75 @@
+package htmlpdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	bundle := &DiagnosticBundle{URL: "https://example.com/"}
+	err := fmt.Errorf("converting: %w", &DiagnosticError{Err: ErrOutputTooLarge, Diagnostics: bundle})
+	if got := Diagnostics(err); got != bundle {
+		t.Errorf("Diagnostics = %v, want the bundle", got)
+	}
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Error("DiagnosticError does not unwrap to the conversion error")
+	}
+	if !strings.Contains(err.Error(), ErrOutputTooLarge.Error()) {
+		t.Errorf("Error() = %q, want the conversion error's message", err)
+	}
+	if got := Diagnostics(ErrOutputTooLarge); got != nil {
+		t.Errorf("Diagnostics of a plain error = %v, want nil", got)
+	}
+}
+
+func TestDiagnosticBundle_WriteDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "failure")
+	b := &DiagnosticBundle{
+		URL:            "https://example.com/report",
+		Screenshot:     []byte("\x89PNG\r\n\x1a\n"),
+		HTML:           "<html><body></body></html>",
+		ConsoleLogs:    []ConsoleMessage{{Type: "error", Text: "chart failed"}},
+		PageErrors:     []PageError{{Message: "TypeError: x is undefined", URL: "app.js", Line: 3, Column: 7}},
+		FailedRequests: []RequestFailure{{URL: "https://example.com/data.json", Error: "HTTP 500"}},
+		Errors:         []string{"context deadline exceeded"},
+	}
+	if err := b.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "screenshot.png")); string(got) != string(b.Screenshot) {
+		t.Errorf("screenshot.png = %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "page.html")); string(got) != b.HTML {
+		t.Errorf("page.html = %q", got)
+	}
+	report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	for _, want := range []string{
+		"URL: https://example.com/report",
+		"context deadline exceeded",
+		"https://example.com/data.json: HTTP 500",
+		"app.js:3:7: TypeError: x is undefined",
+		"[error] chart failed",
+	} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestDiagnosticBundle_WriteDirEmpty(t *testing.T) {
+	dir := t.TempDir()
+	if err := (&DiagnosticBundle{URL: "about:blank"}).WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "report.txt" {
+		t.Errorf("dir holds %v, want only report.txt", entries)
+	}
+}
@@ -20,6 +20,7 @@
 //	res, err := c.ConvertHTML(ctx, "<h1>Hello</h1>", nil)
 //	res, err  = c.ConvertURL(ctx, "https://example.com", nil)
 //	res, err  = c.ConvertFile(ctx, "report.html", nil)
+//	res, err  = c.ConvertReader(ctx, r, nil)
 //
 // Use [PageConfig] to control paper size, orientation, margins, and scale:
 //
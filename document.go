@@ -3,7 +3,10 @@ package htmlpdf
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -21,40 +24,222 @@ type XRefEntry struct {
 
 // Document represents a loaded PDF file.
 type Document struct {
-	data    []byte
-	xref    map[int]XRefEntry
-	trailer Dict
-	cache   map[int]*Object // resolved indirect objects
+	data      []byte      // the whole file, or nil when read through src
+	src       io.ReaderAt // the file, for documents from OpenReaderAt
+	size      int64
+	xref      map[int]XRefEntry
+	trailer   Dict
+	cache     map[int]*Object // resolved indirect objects
+	startXRef int64           // offset of the newest xref section
+	crypt     *decryptor      // nil unless the document is encrypted
+	spool     *os.File        // temporary file of a large LoadReader document
+	cleanup   runtime.Cleanup // removes spool if Close is not called
+	pages     []Dict          // leaf Page dicts, once Pages has walked the tree
+	unmap     func() error    // unmaps data, for documents from OpenMmap
 }
 
-// Open reads a PDF file from disk.
+// Open reads a PDF file from disk. Encrypted files are opened with the
+// empty user password; see [OpenWithPassword].
 func Open(path string) (*Document, error) {
+	return OpenWithPassword(path, "")
+}
+
+// OpenWithPassword reads a PDF file from disk, decrypting it with
+// password, which may be the user or the owner password.
+func OpenWithPassword(path, password string) (*Document, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading file: %w", err)
 	}
-	return Load(data)
+	return LoadWithPassword(data, password)
 }
 
-// Load parses a PDF from raw bytes.
+// Load parses a PDF from raw bytes. Encrypted documents are decrypted
+// with the empty user password, which most documents that only restrict
+// permissions use; others fail with [ErrIncorrectPassword].
 func Load(data []byte) (*Document, error) {
-	doc := &Document{
-		data:  data,
-		xref:  make(map[int]XRefEntry),
-		cache: make(map[int]*Object),
+	return LoadWithPassword(data, "")
+}
+
+// LoadWithPassword parses a PDF from raw bytes, decrypting its strings
+// and streams as they are read if it is encrypted with the standard
+// security handler (RC4, AES-128 or AES-256). password may be the user
+// or the owner password; it is ignored for unencrypted documents.
+func LoadWithPassword(data []byte, password string) (*Document, error) {
+	return newDocument(&Document{data: data, size: int64(len(data))}, password)
+}
+
+// OpenReaderAt opens a PDF of size bytes read from r, such as an
+// *os.File, as needed rather than all at once: objects are read from
+// their offsets when resolved, so that large files, such as scans, are
+// not held in memory whole. r must stay readable for as long as the
+// Document is used. Editing the document (see [EditDocument] and
+// [Document.Watermark]) reads it whole.
+func OpenReaderAt(r io.ReaderAt, size int64) (*Document, error) {
+	return OpenReaderAtWithPassword(r, size, "")
+}
+
+// OpenReaderAtWithPassword is [OpenReaderAt] for encrypted documents,
+// decrypting them with password as [LoadWithPassword] does.
+func OpenReaderAtWithPassword(r io.ReaderAt, size int64, password string) (*Document, error) {
+	return newDocument(&Document{src: r, size: size}, password)
+}
+
+// spoolThreshold is the size above which LoadReader copies a document
+// to a temporary file instead of holding it in memory.
+var spoolThreshold int64 = 32 << 20
+
+// LoadReader parses a PDF read from r, such as an HTTP response body or
+// an object streamed from S3. Documents up to 32 MB are held in memory,
+// as [Load] does; larger ones are copied to a temporary file and read
+// from it as needed, as [OpenReaderAt] does. Call [Document.Close] to
+// remove the file when done with the document.
+func LoadReader(r io.Reader) (*Document, error) {
+	return LoadReaderWithPassword(r, "")
+}
+
+// LoadReaderWithPassword is [LoadReader] for encrypted documents,
+// decrypting them with password as [LoadWithPassword] does.
+func LoadReaderWithPassword(r io.Reader, password string) (*Document, error) {
+	var buf bytes.Buffer
+	_, err := io.CopyN(&buf, r, spoolThreshold+1)
+	if err == io.EOF {
+		return LoadWithPassword(buf.Bytes(), password)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "htmlpdf-*.pdf")
+	if err != nil {
+		return nil, fmt.Errorf("creating spool file: %w", err)
+	}
+	size, err := io.Copy(f, io.MultiReader(&buf, r))
+	if err != nil {
+		removeSpool(f)
+		return nil, fmt.Errorf("reading: %w", err)
 	}
+	doc, err := OpenReaderAtWithPassword(f, size, password)
+	if err != nil {
+		removeSpool(f)
+		return nil, err
+	}
+	doc.spool = f
+	// Remove the file should the document be dropped without Close.
+	doc.cleanup = runtime.AddCleanup(doc, removeSpool, f)
+	return doc, nil
+}
+
+func removeSpool(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// Close releases the file behind a document from [OpenMmap], which is
+// unmapped, or from [LoadReader], whose temporary file is removed if the
+// document was too large to hold in memory. The document must not be
+// used after that. Close does nothing for other documents, and is safe
+// to call more than once.
+func (doc *Document) Close() error {
+	if doc.unmap != nil {
+		unmap := doc.unmap
+		doc.unmap, doc.data, doc.size = nil, nil, 0
+		doc.cache, doc.pages = map[int]*Object{}, nil
+		return unmap()
+	}
+	if doc.spool == nil {
+		return nil
+	}
+	doc.cleanup.Stop()
+	f := doc.spool
+	doc.spool = nil
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Remove(f.Name())
+}
+
+func newDocument(doc *Document, password string) (*Document, error) {
+	doc.xref = make(map[int]XRefEntry)
+	doc.cache = make(map[int]*Object)
 	if err := doc.validateHeader(); err != nil {
 		return nil, err
 	}
 	if err := doc.loadXRef(); err != nil {
 		return nil, fmt.Errorf("loading xref: %w", err)
 	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		crypt, err := newDecryptor(doc, []byte(password))
+		if err != nil {
+			return nil, fmt.Errorf("decrypting: %w", err)
+		}
+		doc.crypt = crypt
+	}
 	return doc, nil
 }
 
+// initialWindow is the number of bytes first read at an offset of a
+// document opened with OpenReaderAt. Objects larger than that are read
+// again in a larger window.
+const initialWindow = 4 << 10
+
+// window returns up to n bytes of the file from offset; the rest of the
+// file for documents held in memory.
+func (doc *Document) window(offset int64, n int) ([]byte, error) {
+	if offset < 0 || offset > doc.size {
+		return nil, fmt.Errorf("offset %d out of bounds", offset)
+	}
+	if doc.src == nil || doc.data != nil {
+		return doc.data[offset:], nil
+	}
+	buf := make([]byte, min(int64(n), doc.size-offset))
+	read, err := doc.src.ReadAt(buf, offset)
+	if read == len(buf) {
+		return buf, nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, fmt.Errorf("reading at offset %d: %w", offset, err)
+}
+
+// parseAt calls parse with a parser positioned at offset. For documents
+// read through an io.ReaderAt, the parser sees a window of the file, and
+// parse is called again with a larger one while it fails and the window
+// does not reach the end of the file; see Parser.truncated.
+func (doc *Document) parseAt(offset int64, parse func(p *Parser) error) error {
+	for n := initialWindow; ; n *= 4 {
+		data, err := doc.window(offset, n)
+		if err != nil {
+			return err
+		}
+		p := NewParser(data, 0)
+		p.partial = offset+int64(len(data)) < doc.size
+		if err := parse(p); err == nil || !p.partial {
+			return err
+		}
+	}
+}
+
+// readAll returns the whole file, reading it into memory if it was
+// opened with OpenReaderAt.
+func (doc *Document) readAll() ([]byte, error) {
+	if doc.data != nil {
+		return doc.data, nil
+	}
+	data, err := doc.window(0, int(doc.size))
+	if err != nil {
+		return nil, err
+	}
+	doc.data = data
+	return data, nil
+}
+
 // validateHeader checks the %PDF-n.n header.
 func (doc *Document) validateHeader() error {
-	if !bytes.HasPrefix(doc.data, []byte("%PDF-")) {
+	header, err := doc.window(0, 8)
+	if err != nil || !bytes.HasPrefix(header, []byte("%PDF-")) {
 		return fmt.Errorf("not a PDF file")
 	}
 	return nil
@@ -62,14 +247,15 @@ func (doc *Document) validateHeader() error {
 
 // Version returns the PDF version string (e.g. "1.7").
 func (doc *Document) Version() string {
-	if len(doc.data) < 8 {
+	header, _ := doc.window(0, 20)
+	if len(header) < 8 {
 		return "?"
 	}
-	end := bytes.IndexByte(doc.data[5:20], '\n')
+	end := bytes.IndexByte(header[5:min(20, len(header))], '\n')
 	if end < 0 {
 		end = 5
 	}
-	v := string(doc.data[5 : 5+end])
+	v := string(header[5 : 5+end])
 	v = strings.TrimRight(v, "\r\n ")
 	return v
 }
@@ -80,63 +266,86 @@ func (doc *Document) loadXRef() error {
 	if err != nil {
 		return err
 	}
+	doc.startXRef = offset
 	return doc.loadXRefAt(offset)
 }
 
 // findStartXRef scans backward to locate "startxref" and reads the offset.
 func (doc *Document) findStartXRef() (int64, error) {
-	searchFrom := len(doc.data) - 1024
-	if searchFrom < 0 {
-		searchFrom = 0
+	tail, err := doc.window(max(doc.size-1024, 0), 1024)
+	if err != nil {
+		return 0, err
 	}
-	idx := bytes.LastIndex(doc.data[searchFrom:], []byte("startxref"))
+	idx := bytes.LastIndex(tail, []byte("startxref"))
 	if idx < 0 {
 		return 0, fmt.Errorf("startxref not found")
 	}
-	pos := searchFrom + idx + len("startxref")
-	for pos < len(doc.data) && isWhitespace(doc.data[pos]) {
+	pos := idx + len("startxref")
+	for pos < len(tail) && isWhitespace(tail[pos]) {
 		pos++
 	}
 	end := pos
-	for end < len(doc.data) && doc.data[end] >= '0' && doc.data[end] <= '9' {
+	for end < len(tail) && tail[end] >= '0' && tail[end] <= '9' {
 		end++
 	}
 	if end == pos {
 		return 0, fmt.Errorf("invalid startxref value")
 	}
-	offset, err := strconv.ParseInt(string(doc.data[pos:end]), 10, 64)
+	offset, err := strconv.ParseInt(string(tail[pos:end]), 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("parsing startxref: %w", err)
 	}
 	return offset, nil
 }
 
-// loadXRefAt loads the xref section (table or stream) at the given file offset.
+// loadXRefAt loads the xref section (table or stream) at the given file
+// offset, followed by the older sections its trailer links to.
 func (doc *Document) loadXRefAt(offset int64) error {
-	if offset < 0 || int(offset) >= len(doc.data) {
-		return fmt.Errorf("xref offset out of bounds: %d", offset)
-	}
-
-	p := NewParser(doc.data, int(offset))
-	p.skipWhitespace()
+	visited := make(map[int64]bool)
+	for {
+		if offset < 0 || offset >= doc.size {
+			return fmt.Errorf("xref offset out of bounds: %d", offset)
+		}
+		visited[offset] = true
+		var trailer Dict
+		err := doc.parseAt(offset, func(p *Parser) error {
+			var err error
+			p.skipWhitespace()
+			// Traditional xref table starts with "xref"
+			if p.match("xref") {
+				trailer, err = doc.parseXRefTable(p)
+			} else {
+				// Otherwise it's a cross-reference stream (PDF 1.5+)
+				trailer, err = doc.parseXRefStream(p)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if doc.trailer == nil {
+			doc.trailer = trailer
+		}
 
-	// Traditional xref table starts with "xref"
-	if p.match("xref") {
-		return doc.parseXRefTable(p)
+		// Follow this section's /Prev, not the newest trailer's, so that
+		// files with several incremental updates are read completely.
+		prev, ok := trailer.GetInt("Prev")
+		if !ok || prev <= 0 || visited[prev] {
+			return nil
+		}
+		offset = prev
 	}
-
-	// Otherwise it's a cross-reference stream (PDF 1.5+)
-	return doc.parseXRefStream(p)
 }
 
-// parseXRefTable parses the classic "xref" keyword + subsections + trailer.
-func (doc *Document) parseXRefTable(p *Parser) error {
+// parseXRefTable parses the classic "xref" keyword + subsections +
+// trailer, and returns the trailer.
+func (doc *Document) parseXRefTable(p *Parser) (Dict, error) {
 	for {
 		p.skipWhitespace()
-		if p.pos >= len(doc.data) {
+		if p.pos >= len(p.data) {
 			break
 		}
-		if bytes.HasPrefix(doc.data[p.pos:], []byte("trailer")) {
+		if bytes.HasPrefix(p.data[p.pos:], []byte("trailer")) {
 			p.SetPos(p.Pos() + len("trailer"))
 			break
 		}
@@ -152,10 +361,13 @@ func (doc *Document) parseXRefTable(p *Parser) error {
 		// Each entry is exactly 20 bytes: "nnnnnnnnnn ggggg n/f\r\n"
 		for i := 0; i < count; i++ {
 			id := first + i
-			if p.Pos()+20 > len(doc.data) {
+			if p.Pos()+20 > len(p.data) {
+				if p.partial {
+					return nil, errTruncated
+				}
 				break
 			}
-			entry := string(doc.data[p.Pos() : p.Pos()+20])
+			entry := string(p.data[p.Pos() : p.Pos()+20])
 			p.SetPos(p.Pos() + 20)
 			if len(entry) < 18 {
 				continue
@@ -177,20 +389,20 @@ func (doc *Document) parseXRefTable(p *Parser) error {
 	p.skipWhitespace()
 	trailerObj, err := p.ParseObject()
 	if err != nil {
-		return fmt.Errorf("parsing trailer: %w", err)
+		return nil, fmt.Errorf("parsing trailer: %w", err)
 	}
-	if doc.trailer == nil && trailerObj.Type == ObjDict {
-		doc.trailer = trailerObj.Dict
+	if p.truncated(trailerObj) {
+		return nil, errTruncated
 	}
-
-	if prev, ok := doc.trailer.GetInt("Prev"); ok && prev > 0 {
-		return doc.loadXRefAt(prev)
+	if trailerObj.Type != ObjDict {
+		return nil, nil
 	}
-	return nil
+	return trailerObj.Dict, nil
 }
 
-// parseXRefStream handles a cross-reference stream object (PDF 1.5+).
-func (doc *Document) parseXRefStream(p *Parser) error {
+// parseXRefStream handles a cross-reference stream object (PDF 1.5+),
+// and returns its dictionary, which serves as the trailer.
+func (doc *Document) parseXRefStream(p *Parser) (Dict, error) {
 	p.readToken() // object number
 	p.skipWhitespace()
 	p.readToken() // generation
@@ -200,31 +412,30 @@ func (doc *Document) parseXRefStream(p *Parser) error {
 
 	obj, err := p.ParseObject()
 	if err != nil {
-		return fmt.Errorf("parsing xref stream object: %w", err)
+		return nil, fmt.Errorf("parsing xref stream object: %w", err)
 	}
-	if obj.Type != ObjStream {
-		return fmt.Errorf("xref at offset is not a stream")
+	if p.truncated(obj) {
+		return nil, errTruncated
 	}
-
-	if doc.trailer == nil {
-		doc.trailer = obj.Dict
+	if obj.Type != ObjStream {
+		return nil, fmt.Errorf("xref at offset is not a stream")
 	}
 
 	streamData, err := DecompressStream(obj.Dict, obj.Stream)
 	if err != nil {
-		return fmt.Errorf("decompressing xref stream: %w", err)
+		return nil, fmt.Errorf("decompressing xref stream: %w", err)
 	}
 
 	w, _ := obj.Dict.GetArray("W")
 	if len(w) < 3 {
-		return fmt.Errorf("xref stream missing /W")
+		return nil, fmt.Errorf("xref stream missing /W")
 	}
 	w1 := int(w[0].Int)
 	w2 := int(w[1].Int)
 	w3 := int(w[2].Int)
 	entrySize := w1 + w2 + w3
 	if entrySize == 0 {
-		return fmt.Errorf("xref stream zero entry size")
+		return nil, fmt.Errorf("xref stream zero entry size")
 	}
 
 	size, _ := obj.Dict.GetInt("Size")
@@ -272,10 +483,7 @@ func (doc *Document) parseXRefStream(p *Parser) error {
 		}
 	}
 
-	if prev, ok := obj.Dict.GetInt("Prev"); ok && prev > 0 {
-		return doc.loadXRefAt(prev)
-	}
-	return nil
+	return obj.Dict, nil
 }
 
 // readBigEndian reads n bytes as a big-endian integer.
@@ -300,51 +508,66 @@ func (doc *Document) ResolveRef(ref Reference) (*Object, error) {
 	var obj *Object
 	var err error
 	if entry.Compressed {
-		obj, err = doc.resolveCompressed(entry)
+		obj, err = doc.resolveCompressed(ref.Number, entry)
 	} else {
 		obj, err = doc.resolveAtOffset(entry.Offset)
 	}
 	if err != nil {
 		return &Object{Type: ObjNull}, nil
 	}
+	// Objects inside object streams were decrypted with their stream.
+	if doc.crypt != nil && !entry.Compressed {
+		doc.crypt.decryptObject(obj, ref.Number, entry.Generation)
+	}
+	// Streams of documents read on demand, such as the images of a scan,
+	// are read again when needed rather than kept, except object streams.
+	if doc.data == nil && obj.Type == ObjStream {
+		if t, _ := obj.Dict.GetName("Type"); t != "ObjStm" {
+			return obj, nil
+		}
+	}
 	doc.cache[ref.Number] = obj
 	return obj, nil
 }
 
 // resolveAtOffset parses "N G obj ... endobj" at the given byte offset.
 func (doc *Document) resolveAtOffset(offset int64) (*Object, error) {
-	if offset < 0 || int(offset) >= len(doc.data) {
+	if offset < 0 || offset >= doc.size {
 		return nil, fmt.Errorf("object offset %d out of bounds", offset)
 	}
-	p := NewParser(doc.data, int(offset))
-	p.readToken() // object number
-	p.skipWhitespace()
-	p.readToken() // generation
-	p.skipWhitespace()
-	if !p.match("obj") {
-		return nil, fmt.Errorf("expected 'obj' at offset %d", offset)
-	}
+	var obj *Object
+	err := doc.parseAt(offset, func(p *Parser) error {
+		p.readToken() // object number
+		p.skipWhitespace()
+		p.readToken() // generation
+		p.skipWhitespace()
+		if !p.match("obj") {
+			return fmt.Errorf("expected 'obj' at offset %d", offset)
+		}
 
-	obj, err := p.ParseObject()
+		var err error
+		obj, err = p.ParseObject()
+		if err != nil {
+			return err
+		}
+		if p.truncated(obj) {
+			return errTruncated
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// If stream /Length is an indirect ref, resolve and re-parse
+	// If stream /Length is an indirect ref, resolve it; the data was
+	// found by searching for "endstream".
 	if obj.Type == ObjStream {
 		if lenRef, ok := obj.Dict["Length"]; ok && lenRef.Type == ObjRef {
 			lenObj, _ := doc.ResolveRef(lenRef.Ref)
 			if lenObj != nil && lenObj.Type == ObjInt {
 				obj.Dict["Length"] = lenObj
-				p2 := NewParser(doc.data, int(offset))
-				p2.readToken()
-				p2.skipWhitespace()
-				p2.readToken()
-				p2.skipWhitespace()
-				p2.match("obj")
-				obj, err = p2.ParseObject()
-				if err != nil {
-					return nil, err
+				if n := int(lenObj.Int); n >= 0 && n < len(obj.Stream) {
+					obj.Stream = obj.Stream[:n]
 				}
 			}
 		}
@@ -352,8 +575,8 @@ func (doc *Document) resolveAtOffset(offset int64) (*Object, error) {
 	return obj, nil
 }
 
-// resolveCompressed reads an object stored inside an object stream (PDF 1.5+).
-func (doc *Document) resolveCompressed(entry XRefEntry) (*Object, error) {
+// resolveCompressed reads object id stored inside an object stream (PDF 1.5+).
+func (doc *Document) resolveCompressed(id int, entry XRefEntry) (*Object, error) {
 	strmObj, err := doc.ResolveRef(Reference{Number: entry.StreamObjID})
 	if err != nil {
 		return nil, err
@@ -370,25 +593,34 @@ func (doc *Document) resolveCompressed(entry XRefEntry) (*Object, error) {
 	n, _ := strmObj.Dict.GetInt("N")
 	first, _ := strmObj.Dict.GetInt("First")
 
+	// The stream starts with N pairs of object number and offset; the
+	// xref entry gives the object's index among them.
 	p := NewParser(data, 0)
-	offsets := make(map[int]int)
+	offsets := make([][2]int, 0, n)
 	for i := 0; i < int(n); i++ {
 		p.skipWhitespace()
 		idStr := p.readToken()
 		p.skipWhitespace()
 		offStr := p.readToken()
-		id, _ := strconv.Atoi(idStr)
+		objID, _ := strconv.Atoi(idStr)
 		off, _ := strconv.Atoi(offStr)
-		offsets[id] = off
+		offsets = append(offsets, [2]int{objID, off})
 	}
 
-	off, ok := offsets[entry.StreamObjID]
-	if !ok {
-		off = entry.IndexInStrm
+	off := -1
+	if i := entry.IndexInStrm; i >= 0 && i < len(offsets) && offsets[i][0] == id {
+		off = offsets[i][1]
+	} else {
+		for _, pair := range offsets {
+			if pair[0] == id {
+				off = pair[1]
+				break
+			}
+		}
 	}
 	objPos := int(first) + off
-	if objPos > len(data) {
-		objPos = int(first) + entry.IndexInStrm
+	if off < 0 || objPos > len(data) {
+		return nil, fmt.Errorf("object %d not found in object stream %d", id, entry.StreamObjID)
 	}
 	p2 := NewParser(data, objPos)
 	return p2.ParseObject()
@@ -418,8 +650,111 @@ func (doc *Document) Catalog() (Dict, error) {
 	return root.Dict, nil
 }
 
-// Pages returns all page dictionaries in order.
+// Pages returns all page dictionaries in order. The page tree is walked
+// once; later calls return the pages found then.
 func (doc *Document) Pages() ([]Dict, error) {
+	if doc.pages != nil {
+		return slices.Clone(doc.pages), nil
+	}
+	root, err := doc.pageTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	pages := []Dict{}
+	doc.collectPages(root, &pages)
+	doc.pages = pages
+	return slices.Clone(pages), nil
+}
+
+// PageCount returns the number of pages, as recorded by the /Count of
+// the page tree root, or by walking the tree if the root has none.
+func (doc *Document) PageCount() (int, error) {
+	if doc.pages != nil {
+		return len(doc.pages), nil
+	}
+	root, err := doc.pageTreeRoot()
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := root.GetInt("Count"); ok && n >= 0 {
+		return int(n), nil
+	}
+	pages, err := doc.Pages()
+	return len(pages), err
+}
+
+// Page returns the dictionary of page i (0-indexed). Unlike [Document.Pages],
+// it resolves only the page tree nodes on the way to the page, using the
+// /Count of each intermediate node to skip the subtrees before it.
+func (doc *Document) Page(i int) (Dict, error) {
+	page, ok, err := doc.page(i)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("page %d out of range", i)
+	}
+	return page, nil
+}
+
+// page is Page, reporting a page out of range with ok false.
+func (doc *Document) page(i int) (page Dict, ok bool, err error) {
+	if i < 0 {
+		return nil, false, nil
+	}
+	if doc.pages == nil {
+		root, err := doc.pageTreeRoot()
+		if err != nil {
+			return nil, false, err
+		}
+		if page, ok := doc.findPage(root, i, 0); ok {
+			return page, true, nil
+		}
+		// A /Count does not match the tree below it: walk all of it.
+		if _, err := doc.Pages(); err != nil {
+			return nil, false, err
+		}
+	}
+	if i >= len(doc.pages) {
+		return nil, false, nil
+	}
+	return doc.pages[i], true, nil
+}
+
+// findPage returns leaf i below the page tree node, or false if the
+// node's counts do not lead to one.
+func (doc *Document) findPage(node Dict, i, depth int) (Dict, bool) {
+	if depth > maxNesting {
+		return nil, false
+	}
+	if typ, _ := node.GetName("Type"); typ == "Page" {
+		return node, i == 0
+	}
+	kids, err := doc.Resolve(node["Kids"])
+	if err != nil || kids == nil || kids.Type != ObjArray {
+		return nil, false
+	}
+	for _, kidRef := range kids.Array {
+		kid, err := doc.Resolve(kidRef)
+		if err != nil || kid == nil || (kid.Type != ObjDict && kid.Type != ObjStream) {
+			continue
+		}
+		n := int64(1)
+		if typ, _ := kid.Dict.GetName("Type"); typ != "Page" {
+			if n, _ = kid.Dict.GetInt("Count"); n < 0 {
+				return nil, false
+			}
+		}
+		if int64(i) < n {
+			return doc.findPage(kid.Dict, i, depth+1)
+		}
+		i -= int(n)
+	}
+	return nil, false
+}
+
+// pageTreeRoot returns the root node of the page tree.
+func (doc *Document) pageTreeRoot() (Dict, error) {
 	cat, err := doc.Catalog()
 	if err != nil {
 		return nil, err
@@ -432,9 +767,51 @@ func (doc *Document) Pages() ([]Dict, error) {
 	if err != nil {
 		return nil, err
 	}
-	var pages []Dict
-	doc.collectPages(pagesObj.Dict, &pages)
-	return pages, nil
+	if pagesObj == nil || (pagesObj.Type != ObjDict && pagesObj.Type != ObjStream) {
+		return nil, fmt.Errorf("/Pages is not a dict")
+	}
+	return pagesObj.Dict, nil
+}
+
+// pageRefs returns the indirect references of all leaf Page objects in
+// order, for use as link and outline destinations.
+func (doc *Document) pageRefs() ([]Reference, error) {
+	cat, err := doc.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	pagesRef, ok := cat["Pages"]
+	if !ok || pagesRef.Type != ObjRef {
+		return nil, fmt.Errorf("no /Pages reference in catalog")
+	}
+	var refs []Reference
+	doc.collectPageRefs(pagesRef.Ref, &refs, 0)
+	return refs, nil
+}
+
+// collectPageRefs recursively collects the references of leaf Page
+// objects below the Pages tree node ref.
+func (doc *Document) collectPageRefs(ref Reference, refs *[]Reference, depth int) {
+	if depth > maxNesting {
+		return
+	}
+	node, err := doc.ResolveRef(ref)
+	if err != nil || (node.Type != ObjDict && node.Type != ObjStream) {
+		return
+	}
+	if typ, _ := node.Dict.GetName("Type"); typ == "Page" {
+		*refs = append(*refs, ref)
+		return
+	}
+	kids, err := doc.Resolve(node.Dict["Kids"])
+	if err != nil || kids == nil || kids.Type != ObjArray {
+		return
+	}
+	for _, kid := range kids.Array {
+		if kid.Type == ObjRef {
+			doc.collectPageRefs(kid.Ref, refs, depth+1)
+		}
+	}
 }
 
 // collectPages recursively collects all leaf Page dicts from a Pages tree.
@@ -497,10 +874,11 @@ func (doc *Document) ContentStreams(page Dict) ([]byte, error) {
 	return result, nil
 }
 
-// PageFonts returns the font resource objects for a page.
+// PageFonts returns the font resource objects for a page, whose
+// resources may be inherited from the page tree.
 func (doc *Document) PageFonts(page Dict) (map[string]*Object, error) {
-	resourcesObj, ok := page["Resources"]
-	if !ok {
+	resourcesObj := pageAttr(doc, page, "Resources")
+	if resourcesObj == nil {
 		return nil, nil
 	}
 	resources, err := doc.Resolve(resourcesObj)
@@ -544,28 +922,18 @@ type PageInfo struct {
 	Rotation int
 }
 
-// GetPageInfo extracts dimensions and rotation for a page.
+// GetPageInfo extracts dimensions and rotation for a page. The
+// dimensions are those of the visible area, the crop box clipped to the
+// media box, and like the rotation may be inherited from the page tree.
 func (doc *Document) GetPageInfo(page Dict) PageInfo {
 	info := PageInfo{}
-
-	// /MediaBox gives the page dimensions
-	if mbObj, ok := page["MediaBox"]; ok {
-		mb, err := doc.Resolve(mbObj)
-		if err == nil && mb.Type == ObjArray && len(mb.Array) >= 4 {
-			x0 := floatFromObj(mb.Array[0])
-			y0 := floatFromObj(mb.Array[1])
-			x1 := floatFromObj(mb.Array[2])
-			y1 := floatFromObj(mb.Array[3])
-			info.Width = x1 - x0
-			info.Height = y1 - y0
-		}
+	if box, ok := visibleBox(doc, page); ok {
+		info.Width = box[2] - box[0]
+		info.Height = box[3] - box[1]
 	}
-
-	if rotObj, ok := page["Rotate"]; ok {
-		rot, err := doc.Resolve(rotObj)
-		if err == nil && rot.Type == ObjInt {
-			info.Rotation = int(rot.Int)
-		}
+	rot, err := doc.Resolve(pageAttr(doc, page, "Rotate"))
+	if err == nil && rot != nil && rot.Type == ObjInt {
+		info.Rotation = int(rot.Int)
 	}
 	return info
 }
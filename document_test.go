@@ -0,0 +1,340 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/iotest"
+)
+
+// countingReaderAt counts the bytes read from a file.
+type countingReaderAt struct {
+	r    io.ReaderAt
+	read int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += int64(n)
+	return n, err
+}
+
+func openReaderAt(t *testing.T, data []byte) (*Document, *countingReaderAt) {
+	t.Helper()
+	r := &countingReaderAt{r: bytes.NewReader(data)}
+	doc, err := OpenReaderAt(r, int64(len(data)))
+	if err != nil {
+		t.Fatalf("OpenReaderAt: %v", err)
+	}
+	return doc, r
+}
+
+// buildScanPDF returns a two-page document whose first page carries a
+// large image, like a scan, and whose last update has an xref table
+// larger than the initial read window.
+func buildScanPDF(t *testing.T) (pdf []byte, image Reference, pixels []byte) {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf (Scanned page) Tj ET"),
+		[]byte("BT /F1 12 Tf (Second page) Tj ET"),
+	}))
+	u, _ := newPDFUpdate(doc)
+	pixels = make([]byte, 2<<20)
+	rand.Read(pixels)
+	image = u.add(&Object{Type: ObjStream, Dict: Dict{
+		"Type":             nameObj("XObject"),
+		"Subtype":          nameObj("Image"),
+		"Width":            intObj(1024),
+		"Height":           intObj(2048),
+		"ColorSpace":       nameObj("DeviceGray"),
+		"BitsPerComponent": intObj(8),
+	}, Stream: pixels})
+	for range 5000 {
+		u.add(dictObj(Dict{"Filler": intObj(1)}))
+	}
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	cp := copyDict(page.Dict)
+	cp["Resources"] = dictObj(Dict{"XObject": dictObj(Dict{"Im0": refObj(image)})})
+	u.set(Reference{Number: 3}, dictObj(cp))
+	return u.bytes(), image, pixels
+}
+
+func TestOpenReaderAt(t *testing.T) {
+	pdf, image, pixels := buildScanPDF(t)
+	doc, r := openReaderAt(t, pdf)
+
+	texts, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatalf("ExtractAll: %v", err)
+	}
+	if want := []string{"Scanned page", "Second page"}; len(texts) != 2 || texts[0] != want[0] || texts[1] != want[1] {
+		t.Errorf("text = %q, want %q", texts, want)
+	}
+	if r.read > int64(len(pdf))/8 {
+		t.Errorf("read %d of %d bytes to extract the text", r.read, len(pdf))
+	}
+	if mem := mustLoad(t, pdf); !reflect.DeepEqual(doc.xref, mem.xref) {
+		t.Error("xref differs from the document loaded in memory")
+	}
+
+	img, err := doc.ResolveRef(image)
+	if err != nil || img.Type != ObjStream || !bytes.Equal(img.Stream, pixels) {
+		t.Fatalf("image stream not read whole: %v", err)
+	}
+	if _, cached := doc.cache[image.Number]; cached {
+		t.Error("image stream kept in the object cache")
+	}
+}
+
+func TestOpenReaderAt_XRefStream(t *testing.T) {
+	pdf := buildXRefStreamPDF()
+	doc, _ := openReaderAt(t, pdf)
+	mem := mustLoad(t, pdf)
+	got, _ := NewExtractor(doc).ExtractAll()
+	want, _ := NewExtractor(mem).ExtractAll()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("text = %q, want %q as loaded in memory", got, want)
+	}
+}
+
+func TestOpenReaderAt_Edit(t *testing.T) {
+	pdf, _, _ := buildScanPDF(t)
+	doc, _ := openReaderAt(t, pdf)
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		t.Fatalf("newPDFUpdate: %v", err)
+	}
+	catRef, cat, _ := u.catalog()
+	cat["Lang"] = textStringObj("en")
+	u.set(catRef, dictObj(cat))
+	out := u.bytes()
+	if !bytes.HasPrefix(out, pdf) {
+		t.Fatal("update does not extend the original file")
+	}
+	if cat, _ := mustLoad(t, out).Catalog(); cat["Lang"] == nil {
+		t.Error("update lost")
+	}
+}
+
+func TestOpenReaderAtWithPassword(t *testing.T) {
+	pdf := buildLegacyEncrypted(t, 4, "owner", "user")
+	doc, err := OpenReaderAtWithPassword(bytes.NewReader(pdf), int64(len(pdf)), "user")
+	if err != nil {
+		t.Fatalf("OpenReaderAtWithPassword: %v", err)
+	}
+	if texts, _ := NewExtractor(doc).ExtractAll(); len(texts) != 1 || texts[0] != "Quarterly invoice" {
+		t.Errorf("text = %q", texts)
+	}
+	if _, err := OpenReaderAt(bytes.NewReader(pdf), int64(len(pdf))); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("OpenReaderAt = %v, want ErrIncorrectPassword", err)
+	}
+}
+
+func TestOpenReaderAt_Truncated(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	// The reader is shorter than the size given.
+	if _, err := OpenReaderAt(bytes.NewReader(pdf[:len(pdf)-10]), int64(len(pdf))); err == nil {
+		t.Error("expected error for a short reader")
+	}
+	if _, err := OpenReaderAt(bytes.NewReader([]byte("not a pdf")), 9); err == nil {
+		t.Error("expected error for a file that is not a PDF")
+	}
+}
+
+func TestLoadReader(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Streamed) Tj ET")})
+	doc, err := LoadReader(io.MultiReader(bytes.NewReader(pdf[:100]), bytes.NewReader(pdf[100:])))
+	if err != nil {
+		t.Fatalf("LoadReader: %v", err)
+	}
+	if doc.spool != nil || !bytes.Equal(doc.data, pdf) {
+		t.Error("small document not held in memory")
+	}
+	if texts, _ := NewExtractor(doc).ExtractAll(); len(texts) != 1 || texts[0] != "Streamed" {
+		t.Errorf("text = %q", texts)
+	}
+	if err := doc.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestLoadReader_Spooled(t *testing.T) {
+	defer func(n int64) { spoolThreshold = n }(spoolThreshold)
+	spoolThreshold = 64 << 10
+	t.Setenv("TMPDIR", t.TempDir())
+
+	pdf, image, pixels := buildScanPDF(t)
+	doc, err := LoadReader(io.MultiReader(bytes.NewReader(pdf)))
+	if err != nil {
+		t.Fatalf("LoadReader: %v", err)
+	}
+	if doc.spool == nil || doc.data != nil {
+		t.Fatal("large document not spooled to a file")
+	}
+	if texts, _ := NewExtractor(doc).ExtractAll(); len(texts) != 2 || texts[0] != "Scanned page" {
+		t.Errorf("text = %q", texts)
+	}
+	if img, err := doc.ResolveRef(image); err != nil || !bytes.Equal(img.Stream, pixels) {
+		t.Errorf("image stream not read whole: %v", err)
+	}
+
+	name := doc.spool.Name()
+	if err := doc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("spool file %s not removed: %v", name, err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestLoadReader_Error(t *testing.T) {
+	if _, err := LoadReader(iotest.ErrReader(io.ErrClosedPipe)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("LoadReader = %v, want the read error", err)
+	}
+}
+
+// buildPageTreePDF returns a document of n pages, each showing its
+// number, whose page tree root has intermediate nodes of per pages each.
+func buildPageTreePDF(t *testing.T, n, per int) []byte {
+	t.Helper()
+	content := make([][]byte, n)
+	for i := range content {
+		content[i] = []byte("BT /F1 12 Tf (Page " + strconv.Itoa(i) + ") Tj ET")
+	}
+	doc := mustLoad(t, buildTestPDF(content))
+	u, _ := newPDFUpdate(doc)
+	var nodes []*Object
+	for first := 0; first < n; first += per {
+		var kids []*Object
+		for i := first; i < min(first+per, n); i++ {
+			kids = append(kids, refObj(Reference{Number: 3 + 2*i}))
+		}
+		nodes = append(nodes, refObj(u.add(dictObj(Dict{
+			"Type":   nameObj("Pages"),
+			"Parent": refObj(Reference{Number: 2}),
+			"Kids":   arrayObj(kids...),
+			"Count":  intObj(len(kids)),
+		}))))
+	}
+	u.set(Reference{Number: 2}, dictObj(Dict{
+		"Type":  nameObj("Pages"),
+		"Kids":  arrayObj(nodes...),
+		"Count": intObj(n),
+	}))
+	return u.bytes()
+}
+
+func TestDocument_Page(t *testing.T) {
+	doc, _ := openReaderAt(t, buildPageTreePDF(t, 600, 100))
+	text, err := NewExtractor(doc).ExtractPage(500)
+	if err != nil || text != "Page 500" {
+		t.Fatalf("ExtractPage(500) = %q, %v", text, err)
+	}
+	for i := range 500 {
+		if _, cached := doc.cache[3+2*i]; cached {
+			t.Fatalf("page %d resolved to find page 500", i)
+		}
+	}
+	if doc.pages != nil {
+		t.Error("page tree walked whole to find page 500")
+	}
+	if n, err := doc.PageCount(); err != nil || n != 600 {
+		t.Errorf("PageCount = %d, %v, want 600", n, err)
+	}
+	if _, err := doc.Page(600); err == nil {
+		t.Error("expected error for a page out of range")
+	}
+	if text, err := NewExtractor(doc).ExtractPage(-1); text != "" || err != nil {
+		t.Errorf("ExtractPage(-1) = %q, %v", text, err)
+	}
+}
+
+func TestDocument_PageWrongCount(t *testing.T) {
+	pdf := buildPageTreePDF(t, 5, 2)
+	want, _ := mustLoad(t, pdf).Pages()
+	// The first intermediate node claims three pages instead of two, so
+	// looking for its third page runs out of kids. Later pages are found
+	// from the wrong count, as in other readers.
+	for i := range 3 {
+		doc := mustLoad(t, pdf)
+		root, _ := doc.pageTreeRoot()
+		resolveDict(doc, root["Kids"].Array[0])["Count"] = intObj(3)
+
+		page, err := doc.Page(i)
+		if err != nil || !reflect.DeepEqual(page, want[i]) {
+			t.Errorf("Page(%d) differs from Pages()[%d]: %v", i, i, err)
+		}
+	}
+}
+
+func TestDocument_PagesWalkedOnce(t *testing.T) {
+	doc, r := openReaderAt(t, buildPageTreePDF(t, 50, 10))
+	pages, err := doc.Pages()
+	if err != nil || len(pages) != 50 {
+		t.Fatalf("Pages = %d pages, %v", len(pages), err)
+	}
+	pages[0] = nil
+	read := r.read
+	again, _ := doc.Pages()
+	if r.read != read {
+		t.Error("page tree read again")
+	}
+	if again[0] == nil {
+		t.Error("Pages returned the slice modified by its caller")
+	}
+}
+
+func TestLoad_Empty(t *testing.T) {
+	for _, data := range [][]byte{nil, {}} {
+		if _, err := Load(data); err == nil {
+			t.Errorf("Load(%#v): expected error", data)
+		}
+	}
+}
+
+func TestDocument_InheritedPageAttributes(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf 72 700 Td (Inherited) Tj ET")}))
+	u, _ := newPDFUpdate(doc)
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	leaf := copyDict(page.Dict)
+	// The resources, media box and rotation move to the Pages node; the
+	// leaf keeps a crop box reaching past the media box.
+	res := leaf["Resources"]
+	delete(leaf, "Resources")
+	delete(leaf, "MediaBox")
+	leaf["CropBox"] = arrayObj(intObj(500), intObj(900), intObj(10), intObj(10))
+	u.set(Reference{Number: 3}, dictObj(leaf))
+	root, _ := doc.pageTreeRoot()
+	node := copyDict(root)
+	node["Resources"] = res
+	node["MediaBox"] = arrayObj(intObj(0), intObj(0), intObj(595), intObj(842))
+	node["Rotate"] = intObj(90)
+	u.set(Reference{Number: 2}, dictObj(node))
+	doc = mustLoad(t, u.bytes())
+
+	pages, _ := doc.Pages()
+	if info := doc.GetPageInfo(pages[0]); info != (PageInfo{Width: 490, Height: 832, Rotation: 90}) {
+		t.Errorf("GetPageInfo = %+v, want the crop box clipped to the inherited media box, rotated", info)
+	}
+	if fonts, _ := doc.PageFonts(pages[0]); fonts["F1"] == nil {
+		t.Errorf("PageFonts = %v, want the inherited F1", fonts)
+	}
+	if text, _ := NewExtractor(doc).ExtractPage(0); text != "Inherited" {
+		t.Errorf("text = %q", text)
+	}
+	di, err := readDocumentInfo(u.bytes())
+	if err != nil || len(di.Pages) != 1 {
+		t.Fatalf("readDocumentInfo: %v", err)
+	}
+	if got, want := di.Pages[0], (PageSize{Width: Points(832), Height: Points(490)}); got != want {
+		t.Errorf("page size = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package htmlpdf
+
+import (
+	"fmt"
+)
+
+// EditDocument collects changes to the pages of a loaded PDF and writes
+// them as an incremental update: the original bytes are kept unchanged and
+// the modified objects appended, so signatures over the original revision
+// stay verifiable.
+type EditDocument struct {
+	doc   *Document
+	u     *pdfUpdate
+	pages []Reference
+}
+
+// NewEditDocument starts editing doc. The document itself is not
+// modified; call Bytes for the edited PDF. It fails for encrypted
+// documents.
+func NewEditDocument(doc *Document) (*EditDocument, error) {
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	return &EditDocument{doc: doc, u: u, pages: refs}, nil
+}
+
+// RotatePages turns the selected pages clockwise by degrees, a multiple
+// of 90, on top of any rotation they already have. pages holds
+// comma-separated page numbers and ranges counted from 1, such as
+// "1-3, 5, 8-"; empty selects every page. Use -90 to turn a page scanned
+// sideways back counter-clockwise.
+func (e *EditDocument) RotatePages(pages string, degrees int) error {
+	if degrees%90 != 0 {
+		return fmt.Errorf("rotation %d is not a multiple of 90 degrees", degrees)
+	}
+	selected, err := parsePageRanges(pages, len(e.pages))
+	if err != nil {
+		return err
+	}
+	for i, ref := range e.pages {
+		if !selected[i] {
+			continue
+		}
+		page, err := e.page(ref)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		rotate := ((pageRotation(e.doc, page)+degrees)%360 + 360) % 360
+		if rotate == 0 && inheritedAttr(e.doc, page, "Rotate") == nil {
+			delete(page, "Rotate")
+		} else {
+			page["Rotate"] = intObj(rotate)
+		}
+	}
+	return nil
+}
+
+// Bytes returns the PDF with the edits made so far appended.
+func (e *EditDocument) Bytes() []byte {
+	return e.u.bytes()
+}
+
+// page returns the dictionary of the page ref refers to, as last edited,
+// for modification in place.
+func (e *EditDocument) page(ref Reference) (Dict, error) {
+	if obj, ok := e.u.objects[ref.Number]; ok {
+		return obj.Dict, nil
+	}
+	obj, err := e.doc.ResolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Type != ObjDict {
+		return nil, fmt.Errorf("page object %d is not a dictionary", ref.Number)
+	}
+	d := copyDict(obj.Dict)
+	e.u.set(ref, dictObj(d))
+	return d, nil
+}
@@ -0,0 +1,75 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEditDocument_RotatePages(t *testing.T) {
+	src := buildTestPDF([][]byte{[]byte("BT ET"), []byte("BT ET"), []byte("BT ET")})
+	e, err := NewEditDocument(mustLoad(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RotatePages("1-2", 90); err != nil {
+		t.Fatalf("RotatePages: %v", err)
+	}
+	if err := e.RotatePages("2", 180); err != nil {
+		t.Fatalf("RotatePages: %v", err)
+	}
+	if err := e.RotatePages("1", -90); err != nil {
+		t.Fatalf("RotatePages: %v", err)
+	}
+	out := e.Bytes()
+	if !bytes.HasPrefix(out, src) {
+		t.Error("edit is not an incremental update")
+	}
+
+	doc := mustLoad(t, out)
+	pages, err := doc.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{0, 270, 0} {
+		if got := doc.GetPageInfo(pages[i]).Rotation; got != want {
+			t.Errorf("page %d rotation = %d, want %d", i+1, got, want)
+		}
+	}
+	if _, ok := pages[0]["Rotate"]; ok {
+		t.Error("page 1 keeps a /Rotate 0 entry it does not need")
+	}
+}
+
+func TestEditDocument_RotatePagesInherited(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	u, _ := newPDFUpdate(doc)
+	pages, _ := doc.ResolveRef(Reference{Number: 2})
+	pages.Dict["Rotate"] = intObj(90)
+	u.set(Reference{Number: 2}, pages)
+
+	e, err := NewEditDocument(mustLoad(t, u.bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RotatePages("", 270); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := mustLoad(t, e.Bytes()).ResolveRef(Reference{Number: 3})
+	// The inherited 90 is overridden rather than dropped.
+	if rot, ok := page.Dict.GetInt("Rotate"); !ok || rot != 0 {
+		t.Errorf("/Rotate = %d, %v; want an explicit 0", rot, ok)
+	}
+}
+
+func TestEditDocument_RotatePagesErrors(t *testing.T) {
+	e, err := NewEditDocument(mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RotatePages("", 45); err == nil {
+		t.Error("expected error for 45 degrees")
+	}
+	if err := e.RotatePages("2", 90); err == nil {
+		t.Error("expected error for a page beyond the document")
+	}
+}
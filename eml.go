@@ -0,0 +1,257 @@
+package htmlpdf
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// emlCSS styles the header block ConvertEML places above the message.
+const emlCSS = `
+.htmlpdf-eml-headers { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 10pt; color: #1f2328; border-bottom: 1px solid #d1d9e0; margin: 0 0 1.5em; padding: 0 0 0.75em; }
+.htmlpdf-eml-headers h1 { font-size: 14pt; margin: 0 0 0.5em; }
+.htmlpdf-eml-headers table { border-collapse: collapse; }
+.htmlpdf-eml-headers th { text-align: left; vertical-align: top; padding: 0.1em 1em 0.1em 0; color: #59636e; font-weight: 600; white-space: nowrap; }
+.htmlpdf-eml-headers td { padding: 0.1em 0; }
+.htmlpdf-eml-text { font-family: ui-monospace, "SFMono-Regular", Menlo, Consolas, monospace; font-size: 10pt; white-space: pre-wrap; overflow-wrap: anywhere; }
+`
+
+// emlHeaders are the message headers shown above the body, in order.
+var emlHeaders = []string{"From", "To", "Cc", "Date"}
+
+// email is a parsed RFC 5322 message.
+type email struct {
+	header      mail.Header
+	html        string
+	text        string
+	inline      map[string][]byte // Content-ID (without brackets) to data
+	attachments []string          // file names
+}
+
+// ConvertEML converts an RFC 5322 email message, such as a .eml file, to a
+// PDF document. The subject and the From, To, Cc and Date headers are
+// shown above the message body. The HTML body is used when there is one,
+// otherwise the plain-text body; images it references by cid: URL are
+// served from the message's inline parts. The names of other attachments
+// are listed with the headers. If page is nil, [DefaultPageConfig] values
+// are used.
+func (c *Converter) ConvertEML(ctx context.Context, r io.Reader, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	msg, err := parseEmail(r)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: parsing email: %w", err)
+	}
+	doc, assets := msg.render()
+	return c.ConvertHTMLWithAssets(ctx, doc, assets, pg, opts...)
+}
+
+// ConvertEML converts an email message to PDF using a temporary
+// [Converter].
+func ConvertEML(ctx context.Context, r io.Reader, pg *PageConfig, opts ...Option) (*Result, error) {
+	c, err := NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.ConvertEML(ctx, r, pg)
+}
+
+// parseEmail reads a message and collects its bodies and attachments.
+func parseEmail(r io.Reader) (*email, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	msg := &email{header: m.Header, inline: make(map[string][]byte)}
+	if err := msg.addPart(m.Header, m.Body, 0); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// addPart adds one MIME part, descending into multipart containers.
+func (msg *email) addPart(header map[string][]string, body io.Reader, depth int) error {
+	if depth > maxNesting {
+		return fmt.Errorf("MIME parts nested too deeply")
+	}
+	get := func(key string) string {
+		if v := header[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	mediaType, params, err := mime.ParseMediaType(get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{"charset": "us-ascii"}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			// NextRawPart leaves the transfer encoding to decodeBody,
+			// which also handles base64.
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := msg.addPart(p.Header, p, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := decodeBody(body, get("Content-Transfer-Encoding"))
+	if err != nil {
+		return err
+	}
+	disposition, dparams, _ := mime.ParseMediaType(get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if filename != "" {
+		filename, _ = (&mime.WordDecoder{}).DecodeHeader(filename)
+	}
+	cid := strings.Trim(get("Content-Id"), "<> ")
+
+	switch {
+	case disposition != "attachment" && mediaType == "text/html" && msg.html == "":
+		msg.html = toUTF8(data, params["charset"])
+	case disposition != "attachment" && mediaType == "text/plain" && msg.text == "" && filename == "":
+		msg.text = toUTF8(data, params["charset"])
+	case cid != "":
+		msg.inline[cid] = data
+	case filename != "":
+		msg.attachments = append(msg.attachments, filename)
+	}
+	return nil
+}
+
+// decodeBody undoes a Content-Transfer-Encoding.
+func decodeBody(r io.Reader, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	return io.ReadAll(r)
+}
+
+// newlineStripper removes the line breaks base64 bodies are wrapped with.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s *newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+// toUTF8 converts text in charset to UTF-8. Latin-1 is converted; other
+// charsets are assumed to be UTF-8 compatible.
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		if !utf8.Valid(data) {
+			runes := make([]rune, len(data))
+			for i, b := range data {
+				runes[i] = rune(b)
+			}
+			return string(runes)
+		}
+	}
+	return string(data)
+}
+
+// cidURL matches a cid: URL in an HTML attribute or CSS url().
+var cidURL = regexp.MustCompile(`(?i)cid:[^"'\s)>]+`)
+
+// bodyOpenTag matches the opening <body> tag of an HTML document.
+var bodyOpenTag = regexp.MustCompile(`(?i)<body[^>]*>`)
+
+// render lays the message out as an HTML document, returning it with the
+// inline images it references as assets.
+func (msg *email) render() (string, map[string][]byte) {
+	var headers strings.Builder
+	headers.WriteString("<style>" + emlCSS + "</style>\n<div class=\"htmlpdf-eml-headers\">\n")
+	dec := &mime.WordDecoder{}
+	subject, err := dec.DecodeHeader(msg.header.Get("Subject"))
+	if err != nil {
+		subject = msg.header.Get("Subject")
+	}
+	if subject != "" {
+		fmt.Fprintf(&headers, "<h1>%s</h1>\n", html.EscapeString(subject))
+	}
+	headers.WriteString("<table>\n")
+	row := func(name, value string) {
+		fmt.Fprintf(&headers, "<tr><th>%s</th><td>%s</td></tr>\n", name, html.EscapeString(value))
+	}
+	for _, key := range emlHeaders {
+		value := msg.header.Get(key)
+		if value == "" {
+			continue
+		}
+		if decoded, err := dec.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		row(key, value)
+	}
+	if len(msg.attachments) > 0 {
+		row("Attachments", strings.Join(msg.attachments, ", "))
+	}
+	headers.WriteString("</table>\n</div>\n")
+
+	assets := make(map[string][]byte, len(msg.inline))
+	if msg.html == "" {
+		body := "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n</head>\n<body>\n" +
+			headers.String() +
+			"<div class=\"htmlpdf-eml-text\">" + html.EscapeString(msg.text) + "</div>\n</body>\n</html>\n"
+		return body, assets
+	}
+
+	// Point cid: URLs at assets named by index, as Content-IDs may hold
+	// characters that are not valid in a path.
+	names := make(map[string]string, len(msg.inline))
+	body := cidURL.ReplaceAllStringFunc(msg.html, func(u string) string {
+		cid := u[len("cid:"):]
+		data, ok := msg.inline[cid]
+		if !ok {
+			return u
+		}
+		name, ok := names[cid]
+		if !ok {
+			name = "cid/" + strconv.Itoa(len(names))
+			names[cid] = name
+			assets[name] = data
+		}
+		return name
+	})
+
+	if loc := bodyOpenTag.FindStringIndex(body); loc != nil {
+		return body[:loc[1]] + "\n" + headers.String() + body[loc[1]:], assets
+	}
+	return headers.String() + body, assets
+}
@@ -0,0 +1,119 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testEML = "From: =?UTF-8?Q?Ana_P=C3=A9rez?= <ana@example.com>\r\n" +
+	"To: legal@example.com\r\n" +
+	"Date: Mon, 2 Mar 2026 10:00:00 +0000\r\n" +
+	"Subject: =?UTF-8?B?UXVhcnRlcmx5IHJlcG9ydCDinJM=?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/related; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: multipart/alternative; boundary=alt\r\n" +
+	"\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Plain version\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<html><body class=3D\"mail\"><p>See chart:</p><img src=3D\"cid:chart@x\"><img src=3D\"cid:chart@x1\"></body></html>\r\n" +
+	"--alt--\r\n" +
+	"--inner\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-ID: <chart@x>\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"iVBO\r\nRw0KGgo=\r\n" +
+	"--inner\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-ID: <chart@x1>\r\n" +
+	"\r\n" +
+	"second\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"report.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"report.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0=\r\n" +
+	"--outer--\r\n"
+
+func TestParseEmail(t *testing.T) {
+	msg, err := parseEmail(strings.NewReader(testEML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.html, `<body class="mail">`) {
+		t.Errorf("html = %q, want the decoded HTML body", msg.html)
+	}
+	if msg.text != "Plain version" {
+		t.Errorf("text = %q", msg.text)
+	}
+	if got := msg.inline["chart@x"]; !bytes.Equal(got, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Errorf("inline image = %q, want the decoded PNG signature", got)
+	}
+	if len(msg.attachments) != 1 || msg.attachments[0] != "report.pdf" {
+		t.Errorf("attachments = %v, want [report.pdf]", msg.attachments)
+	}
+}
+
+func TestEmailRender(t *testing.T) {
+	msg, err := parseEmail(strings.NewReader(testEML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, assets := msg.render()
+
+	for _, want := range []string{
+		"<h1>Quarterly report ✓</h1>",
+		"<td>Ana Pérez &lt;ana@example.com&gt;</td>",
+		"<th>Attachments</th><td>report.pdf</td>",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document is missing %q", want)
+		}
+	}
+	if strings.Index(doc, "htmlpdf-eml-headers") < strings.Index(doc, `<body class="mail">`) {
+		t.Error("headers are not placed inside the message body")
+	}
+	if strings.Contains(doc, "cid:") {
+		t.Errorf("document still references cid: URLs: %s", doc)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("got %d assets, want 2", len(assets))
+	}
+	for name, data := range assets {
+		if !strings.Contains(doc, `src="`+name+`"`) {
+			t.Errorf("asset %s is not referenced", name)
+		}
+		if string(data) != "second" && !bytes.HasPrefix(data, []byte("\x89PNG")) {
+			t.Errorf("asset %s = %q", name, data)
+		}
+	}
+}
+
+func TestEmailRender_PlainText(t *testing.T) {
+	eml := "From: a@example.com\r\nSubject: Hi\r\n" +
+		"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n\r\n" +
+		"Caf=E9 <b>not bold</b>\r\n"
+	msg, err := parseEmail(strings.NewReader(eml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := msg.render()
+	if !strings.Contains(doc, "Café &lt;b&gt;not bold&lt;/b&gt;") {
+		t.Errorf("document = %q, want the escaped, Latin-1 decoded text", doc)
+	}
+}
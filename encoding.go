@@ -16,6 +16,12 @@ type FontEncoding struct {
 	// cmapChars holds individual ToUnicode CMap bf-char entries
 	cmapChars map[uint32]string
 	isSimple  bool
+	// widths measures shown text; nil if the font gives no widths
+	widths *glyphWidths
+	// ref is the font object, if indirect
+	ref Reference
+	// baseFont is the font's /BaseFont, e.g. ABCDEF+Helvetica
+	baseFont string
 }
 
 type cmapRange struct {
@@ -81,6 +87,17 @@ func NewFontEncoding(fontObj *Object) *FontEncoding {
 	return enc
 }
 
+// newFontEncoding is NewFontEncoding, also loading the glyph widths of
+// the font, whose entries may be indirect objects of doc.
+func newFontEncoding(doc *Document, fontObj *Object) *FontEncoding {
+	enc := NewFontEncoding(fontObj)
+	if fontObj != nil && (fontObj.Type == ObjDict || fontObj.Type == ObjStream) {
+		enc.widths = loadGlyphWidths(doc, fontObj.Dict)
+		enc.baseFont, _ = fontObj.Dict.GetName("BaseFont")
+	}
+	return enc
+}
+
 // applyNamedEncoding loads a standard PDF encoding table.
 func (e *FontEncoding) applyNamedEncoding(name string) {
 	var table [128]rune
@@ -261,6 +278,29 @@ func (e *FontEncoding) Decode(data []byte) string {
 	return buf.String()
 }
 
+// splitCodes splits a shown string into the codes of its glyphs: single
+// bytes for simple fonts, two bytes for Identity-encoded composite fonts,
+// and otherwise two bytes where the ToUnicode CMap maps them, as Decode
+// does. A nil FontEncoding splits single bytes.
+func (e *FontEncoding) splitCodes(data []byte) [][]byte {
+	var codes [][]byte
+	for i := 0; i < len(data); {
+		n := 1
+		switch {
+		case e == nil || e.isSimple || i+1 >= len(data):
+		case e.widths != nil && e.widths.twoByte:
+			n = 2
+		default:
+			if _, ok := e.cmapChars[uint32(data[i])<<8|uint32(data[i+1])]; ok {
+				n = 2
+			}
+		}
+		codes = append(codes, data[i:i+n])
+		i += n
+	}
+	return codes
+}
+
 // parseCMapTokens splits a CMap line into hex tokens and other tokens.
 func parseCMapTokens(line string) []string {
 	var tokens []string
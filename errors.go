@@ -1,9 +1,145 @@
 package htmlpdf
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // Sentinel errors returned by the library.
 var (
 	// ErrClosed is returned when attempting to use a closed [Converter].
 	ErrClosed = errors.New("htmlpdf: converter is closed")
+
+	// ErrURLNotAllowed is returned when the URL to convert is refused by
+	// the policy set with [WithURLPolicy].
+	ErrURLNotAllowed = errors.New("htmlpdf: URL not allowed by policy")
+
+	// ErrOutputTooLarge is returned when the generated document exceeds
+	// the size set with [WithMaxOutputSize].
+	ErrOutputTooLarge = errors.New("htmlpdf: output exceeds the maximum size")
+
+	// ErrBrowserNotFound is returned by [NewConverter] and [FindBrowser]
+	// when no browser executable is found and [WithAutoDownload] is not
+	// set.
+	ErrBrowserNotFound = errors.New("htmlpdf: no browser executable found")
+
+	// ErrMemoryLimit is returned when the browser was stopped during the
+	// conversion for exceeding the limit set with
+	// [WithBrowserMemoryLimit].
+	ErrMemoryLimit = errors.New("htmlpdf: browser exceeded its memory limit")
+
+	// ErrIncorrectPassword is returned by [Load], [Open] and their
+	// WithPassword variants when the document is encrypted and the
+	// password given is neither its user nor its owner password.
+	ErrIncorrectPassword = errors.New("htmlpdf: incorrect password for encrypted document")
 )
+
+// HTTPError is returned when the page being converted responds with a
+// non-2xx status, instead of printing the server's error page, unless
+// [ConvertOptions.IgnoreHTTPErrors] is set.
+type HTTPError struct {
+	URL        string // final URL of the document, after redirects
+	StatusCode int    // e.g. 404
+	Status     string // status text, e.g. "Not Found"; may be empty
+}
+
+func (e *HTTPError) Error() string {
+	status := e.Status
+	if status == "" {
+		status = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("htmlpdf: %s responded with HTTP %d %s", e.URL, e.StatusCode, status)
+}
+
+// RequestFailureError is returned when requests made by the page fail and
+// [ConvertOptions.FailOnRequestFailure] is set.
+type RequestFailureError struct {
+	Failures []RequestFailure
+}
+
+func (e *RequestFailureError) Error() string {
+	first := e.Failures[0]
+	msg := fmt.Sprintf("htmlpdf: request for %s failed: %s", first.URL, first.Error)
+	if n := len(e.Failures) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+// ContentError is returned by [Result.AssertContains] when expected text
+// is missing from the PDF.
+type ContentError struct {
+	Missing []string
+}
+
+func (e *ContentError) Error() string {
+	msg := fmt.Sprintf("htmlpdf: PDF does not contain %q", e.Missing[0])
+	if n := len(e.Missing) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+// PDFAError is returned when [PageConfig.PDFA] is set and the generated
+// document contains constructs PDF/A-2b forbids that cannot be removed
+// without changing its content, such as JavaScript or fonts that are not
+// embedded.
+type PDFAError struct {
+	Violations []string
+}
+
+func (e *PDFAError) Error() string {
+	return "htmlpdf: document cannot be made PDF/A-2b conformant: " + strings.Join(e.Violations, "; ")
+}
+
+// BrowserVersionError is returned by [NewConverter] when the browser is
+// older than the oldest Chrome version supported, and by conversions
+// using a [PageConfig] option, such as GenerateTaggedPDF, that the
+// browser is too old to apply. Chrome would otherwise ignore the option
+// without an error.
+type BrowserVersionError struct {
+	Product string // as reported by the browser, e.g. "HeadlessChrome/118.0.5993.70"
+	Minimum int    // the oldest Chrome major version that would do
+	Option  string // the PageConfig field requiring it, or "" for the converter itself
+}
+
+func (e *BrowserVersionError) Error() string {
+	if e.Option != "" {
+		return fmt.Sprintf("htmlpdf: %s requires Chrome %d or later, browser is %s", e.Option, e.Minimum, e.Product)
+	}
+	return fmt.Sprintf("htmlpdf: browser %s is older than Chrome %d, the oldest supported", e.Product, e.Minimum)
+}
+
+// BatchError is returned by [Converter.ConvertMany] when some of the
+// inputs fail to convert. Errors is indexed like the inputs, with nil for
+// inputs that converted successfully.
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	failed, first := 0, -1
+	for i, err := range e.Errors {
+		if err != nil {
+			failed++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	return fmt.Sprintf("htmlpdf: %d of %d conversions failed; input %d: %v", failed, len(e.Errors), first, e.Errors[first])
+}
+
+// Unwrap returns the individual errors, so that [errors.Is] and
+// [errors.As] match any of them.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -1,31 +1,44 @@
 package htmlpdf
 
 import (
+	"bytes"
 	"math"
+	"slices"
 	"strings"
+	"sync"
 	"unicode"
 )
 
 // Extractor extracts plain text from PDF pages.
 type Extractor struct {
-	doc *Document
+	doc     *Document
+	reflow  bool
+	altText bool
+
+	structureOrder bool
+	structure      func() map[int][][]int // page index -> MCIDs of each block
 }
 
+// ExtractorOption configures an [Extractor].
+type ExtractorOption func(*Extractor)
+
 // NewExtractor creates a text extractor for the given document.
-func NewExtractor(doc *Document) *Extractor {
-	return &Extractor{doc: doc}
+func NewExtractor(doc *Document, opts ...ExtractorOption) *Extractor {
+	e := &Extractor{doc: doc}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.structure = sync.OnceValue(func() map[int][][]int { return readStructure(doc) })
+	return e
 }
 
 // ExtractPage returns the plain text for a single page (0-indexed).
 func (e *Extractor) ExtractPage(pageIndex int) (string, error) {
-	pages, err := e.doc.Pages()
-	if err != nil {
+	page, ok, err := e.doc.page(pageIndex)
+	if err != nil || !ok {
 		return "", err
 	}
-	if pageIndex < 0 || pageIndex >= len(pages) {
-		return "", nil
-	}
-	return e.ExtractPageDict(pages[pageIndex])
+	return e.pageText(page, pageIndex)
 }
 
 // ExtractAll returns the plain text for all pages, one page per element.
@@ -36,7 +49,7 @@ func (e *Extractor) ExtractAll() ([]string, error) {
 	}
 	results := make([]string, len(pages))
 	for i, page := range pages {
-		text, err := e.ExtractPageDict(page)
+		text, err := e.pageText(page, i)
 		if err != nil {
 			continue
 		}
@@ -45,30 +58,150 @@ func (e *Extractor) ExtractAll() ([]string, error) {
 	return results, nil
 }
 
-// ExtractPageDict extracts text from a page dictionary.
+// ExtractPageDict extracts text from a page dictionary, including the
+// text of the form XObjects its content draws, such as letterheads and
+// stamps. The text is in geometric order even with
+// [WithStructureOrder], as the page's place in the document is not known.
 func (e *Extractor) ExtractPageDict(page Dict) (string, error) {
-	// Get fonts for this page
-	fontObjs, err := e.doc.PageFonts(page)
-	if err != nil {
-		fontObjs = nil
+	return e.pageText(page, -1)
+}
+
+// pageText returns the plain text of page, whose index is pageIndex or
+// -1 if not known.
+func (e *Extractor) pageText(page Dict, pageIndex int) (string, error) {
+	var out textOutput
+	if err := e.extractPage(page, &out); err != nil {
+		return "", err
 	}
+	if e.structureOrder && pageIndex >= 0 {
+		if blocks, ok := e.structure()[pageIndex]; ok {
+			return structuredText(out.spans, blocks, e.reflow), nil
+		}
+	}
+	if e.reflow {
+		return reflowText(groupLines(out.spans)), nil
+	}
+	return spansToText(out.spans), nil
+}
 
-	// Build font encoding map: resource name -> FontEncoding
-	fonts := make(map[string]*FontEncoding)
-	for name, obj := range fontObjs {
-		fonts[name] = NewFontEncoding(obj)
+// Glyph is one glyph shown on a page, as returned by
+// [Extractor.ExtractGlyphs].
+type Glyph struct {
+	// Text is the Unicode text the glyph stands for: several characters
+	// for a ligature, or none if the font does not map it.
+	Text string
+	// X and Y are the origin of the glyph on the page, in points.
+	X, Y float64
+	// Advance is the distance along the baseline to the origin of the
+	// next glyph, in points, including character and word spacing. For
+	// fonts that do not give the widths of their glyphs, it is estimated
+	// as half the font size.
+	Advance float64
+	// FontSize is the size of the glyph as drawn, in points.
+	FontSize float64
+	// Font is the name of the font in the resources of the page or form
+	// XObject showing the glyph, e.g. "F1", and FontRef the font object;
+	// FontRef is zero for a font written directly in the resources.
+	Font    string
+	FontRef Reference
+}
+
+// ExtractGlyphs returns the glyphs shown on a single page (0-indexed), in
+// the order they are drawn, for layout analysis such as detecting
+// tables or comparing documents.
+func (e *Extractor) ExtractGlyphs(pageIndex int) ([]Glyph, error) {
+	page, ok, err := e.doc.page(pageIndex)
+	if err != nil || !ok {
+		return nil, err
 	}
+	return e.ExtractGlyphsDict(page)
+}
+
+// ExtractGlyphsDict returns the glyphs shown by a page dictionary,
+// including those of the form XObjects its content draws.
+func (e *Extractor) ExtractGlyphsDict(page Dict) ([]Glyph, error) {
+	out := textOutput{perGlyph: true}
+	if err := e.extractPage(page, &out); err != nil {
+		return nil, err
+	}
+	return out.glyphs, nil
+}
 
+// textOutput collects what content streams show: spans of text, each
+// glyph if perGlyph is set, and the horizontal and vertical lines drawn
+// if withRulings is set. The /Alt text of marked content replaces it if
+// altText is set.
+type textOutput struct {
+	spans       []textSpan
+	glyphs      []Glyph
+	perGlyph    bool
+	rulings     []ruling
+	withRulings bool
+	altText     bool
+}
+
+func (e *Extractor) extractPage(page Dict, out *textOutput) error {
 	// Get and parse content streams
 	content, err := e.doc.ContentStreams(page)
-	if err != nil {
-		return "", err
+	if err != nil || len(content) == 0 {
+		return err
 	}
-	if len(content) == 0 {
-		return "", nil
+	res := pageAttr(e.doc, page, "Resources")
+	out.altText = e.altText
+	e.extractText(content, resolveDict(e.doc, res), identity, out, nil)
+	return nil
+}
+
+// extractText adds to out the text drawn by a content stream with the
+// resources res and the transformation ctm, following the form XObjects
+// it draws into their own content and resources. forms holds the forms
+// being drawn, so that a form drawing itself is not followed.
+func (e *Extractor) extractText(content []byte, res Dict, ctm matrix, out *textOutput, forms []Reference) {
+	if len(forms) > maxNesting {
+		return
+	}
+	// Build font encoding map: resource name -> FontEncoding
+	fonts := make(map[string]*FontEncoding)
+	for name, ref := range resolveDict(e.doc, res["Font"]) {
+		if obj, err := e.doc.Resolve(ref); err == nil && obj != nil {
+			fonts[name] = newFontEncoding(e.doc, obj)
+			if ref.Type == ObjRef {
+				fonts[name].ref = ref.Ref
+			}
+		}
+	}
+	xobjects := resolveDict(e.doc, res["XObject"])
+	properties := make(map[string]Dict)
+	for name, ref := range resolveDict(e.doc, res["Properties"]) {
+		if d := resolveDict(e.doc, ref); d != nil {
+			properties[name] = d
+		}
 	}
 
-	return parseContentStream(content, fonts), nil
+	do := func(name string, ctm matrix) {
+		ref := xobjects[name]
+		if ref == nil || ref.Type != ObjRef || slices.Contains(forms, ref.Ref) {
+			return
+		}
+		obj, err := e.doc.ResolveRef(ref.Ref)
+		if err != nil || obj.Type != ObjStream {
+			return
+		}
+		if sub, _ := obj.Dict.GetName("Subtype"); sub != "Form" {
+			return
+		}
+		// A form without resources uses those of the stream drawing it.
+		formRes := resolveDict(e.doc, obj.Dict["Resources"])
+		if formRes == nil {
+			formRes = res
+		}
+		data, err := DecompressStream(obj.Dict, obj.Stream)
+		if err != nil {
+			return
+		}
+		e.extractText(data, formRes, formMatrix(obj.Dict).mul(ctm), out, append(forms, ref.Ref))
+	}
+	parseContentStream(content, fonts, properties, ctm, out, do)
 }
 
 // ---- Content stream parser ----
@@ -78,38 +211,155 @@ type textSpan struct {
 	x, y     float64
 	text     string
 	fontSize float64
+	width    float64 // advance width on the page
+	font     string  // the font's /BaseFont
+	mcid     int     // of the marked-content sequence shown in, or -1
+	artifact bool    // shown in an /Artifact marked-content sequence
 }
 
-// textState holds the current PDF text state during content stream parsing.
-type textState struct {
+// graphicsState holds the parameters of the graphics state that place
+// text, which q saves and Q restores.
+type graphicsState struct {
+	ctm         matrix // current transformation matrix
 	fontName    string
 	fontSize    float64
 	charSpacing float64
 	wordSpacing float64
-	// Text matrix components (simplified: we only track tx, ty)
-	tx, ty float64
-	// Line matrix
-	lx, ly  float64
-	leading float64
-	// Current transformation matrix (simplified)
-	ctmA, ctmB, ctmC, ctmD, ctmE, ctmF float64
+	scale       float64 // horizontal scaling (Tz), as a fraction
+	rise        float64
+	leading     float64
 }
 
-func newTextState() textState {
+// textState holds the current PDF text state during content stream parsing.
+type textState struct {
+	graphicsState
+	saved []graphicsState // pushed by q
+	// Text matrix and text line matrix
+	tm, tlm matrix
+	path    pathState       // the path being constructed, if out collects rulings
+	marked  []markedContent // marked-content sequences entered, innermost last
+
+	properties map[string]Dict // named property lists for BDC
+}
+
+func newTextState(ctm matrix) textState {
 	return textState{
-		ctmA:     1,
-		ctmD:     1,
-		fontSize: 12,
+		graphicsState: graphicsState{
+			ctm:      ctm,
+			fontSize: 12,
+			scale:    1,
+		},
+		tm:  identity,
+		tlm: identity,
+	}
+}
+
+// moveLine starts a new line offset by (tx, ty) from the start of the
+// current one, as Td does.
+func (ts *textState) moveLine(tx, ty float64) {
+	ts.tlm = matrix{1, 0, 0, 1, tx, ty}.mul(ts.tlm)
+	ts.tm = ts.tlm
+}
+
+// show adds a span of text shown at the current text position to out,
+// placed on the page through the text matrix and the transformation
+// matrix, and moves the text position past it. adv is the displacement
+// of the text in unscaled text space units, if known from the font's
+// widths.
+func (ts *textState) show(out *textOutput, fonts map[string]*FontEncoding, text string, adv float64, known bool) {
+	if text != "" {
+		// The text rendering matrix, without the font size: the glyphs'
+		// origin, size and direction on the page.
+		m := ts.renderMatrix(0)
+		sp := textSpan{
+			x:        m[4],
+			y:        m[5],
+			text:     text,
+			fontSize: ts.fontSize * math.Hypot(m[2], m[3]),
+		}
+		sp.mcid, sp.artifact = ts.markedContent()
+		if enc := fonts[ts.fontName]; enc != nil {
+			sp.font = enc.baseFont
+		}
+		if known {
+			// The horizontal extent of the text, however it is rotated.
+			sp.width = adv * m[0]
+		} else {
+			sp.width = estimateWidth(sp)
+		}
+		if !ts.replaceSpan(sp) {
+			out.spans = append(out.spans, sp)
+		}
+	}
+	if known {
+		ts.tm = matrix{1, 0, 0, 1, adv * ts.scale, 0}.mul(ts.tm)
 	}
 }
 
-// parseContentStream parses a PDF content stream and extracts text.
-func parseContentStream(data []byte, fonts map[string]*FontEncoding) string {
+// renderMatrix returns the text rendering matrix, without the font size,
+// for text shown offset unscaled text space units from the current text
+// position.
+func (ts *textState) renderMatrix(offset float64) matrix {
+	return matrix{ts.scale, 0, 0, 1, offset * ts.scale, ts.rise}.mul(ts.tm).mul(ts.ctm)
+}
+
+// showGlyphs adds to out each glyph of the string obj, shown offset
+// unscaled text space units from the current text position, if out
+// collects glyphs.
+func (ts *textState) showGlyphs(out *textOutput, obj *Object, fonts map[string]*FontEncoding, offset float64) {
+	if !out.perGlyph || obj.Type != ObjString {
+		return
+	}
+	enc := fonts[ts.fontName]
+	for _, code := range enc.splitCodes(obj.Str) {
+		adv := ts.fontSize / 2
+		spaces := 0
+		if enc != nil && enc.widths != nil {
+			var w float64
+			w, _, spaces = enc.widths.measure(code)
+			adv = w / 1000 * ts.fontSize
+		}
+		adv += ts.charSpacing + float64(spaces)*ts.wordSpacing
+
+		m := ts.renderMatrix(offset)
+		g := Glyph{
+			Text:     decodeTextObj(&Object{Type: ObjString, Str: code}, ts.fontName, fonts),
+			X:        m[4],
+			Y:        m[5],
+			Advance:  adv * math.Hypot(m[0], m[1]),
+			FontSize: ts.fontSize * math.Hypot(m[2], m[3]),
+			Font:     ts.fontName,
+		}
+		if enc != nil {
+			g.FontRef = enc.ref
+		}
+		out.glyphs = append(out.glyphs, g)
+		offset += adv
+	}
+}
+
+// advance returns the horizontal displacement in text space of showing
+// the string obj in the current font, and false if the font does not
+// give the widths of its glyphs.
+func (ts *textState) advance(obj *Object, fonts map[string]*FontEncoding) (float64, bool) {
+	enc := fonts[ts.fontName]
+	if obj.Type != ObjString || enc == nil || enc.widths == nil {
+		return 0, false
+	}
+	w, glyphs, spaces := enc.widths.measure(obj.Str)
+	return w/1000*ts.fontSize + float64(glyphs)*ts.charSpacing + float64(spaces)*ts.wordSpacing, true
+}
+
+// parseContentStream parses a PDF content stream drawn with the
+// transformation ctm, adding the text it shows to out. properties holds
+// the property lists of its /Properties resource. do is called with the
+// name and transformation of each XObject drawn.
+func parseContentStream(data []byte, fonts map[string]*FontEncoding, properties map[string]Dict, ctm matrix, out *textOutput, do func(name string, ctm matrix)) {
 	p := NewParser(data, 0)
-	ts := newTextState()
+	ts := newTextState(ctm)
+	ts.properties = properties
 	inText := false
 
-	var spans []textSpan
 	var operandStack []*Object
 
 	for p.pos < len(data) {
@@ -134,14 +384,21 @@ func parseContentStream(data []byte, fonts map[string]*FontEncoding) string {
 		// Operator: alphabetic or special
 		if isOperatorStart(c) {
 			op := p.readOperator()
-			processOperator(op, &operandStack, &ts, &inText, &spans, fonts)
+			if op == "BI" {
+				p.skipInlineImage()
+				operandStack = operandStack[:0]
+				continue
+			}
+			processOperator(op, &operandStack, &ts, &inText, out, fonts, do)
 			continue
 		}
 
 		p.pos++
 	}
-
-	return spansToText(spans)
+	// Sequences left open at the end of the stream end with it.
+	for len(ts.marked) > 0 {
+		ts.endMarkedContent(out, inText)
+	}
 }
 
 func isOperatorStart(c byte) bool {
@@ -164,37 +421,172 @@ func (p *Parser) readOperator() string {
 	return string(p.data[start:p.pos])
 }
 
+// skipInlineImage moves past an inline image, from after its BI
+// operator to after its EI operator. The image data between ID and EI is
+// binary, so it is skipped by its length where the image dictionary
+// gives it or it can be computed, and the EI searched for after that.
+func (p *Parser) skipInlineImage() {
+	params := Dict{}
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			break
+		}
+		key, _ := p.parseName()
+		val, err := p.ParseObject()
+		if err != nil {
+			return
+		}
+		params[key.Name] = val
+	}
+	if !p.match("ID") {
+		return
+	}
+	// A single white-space character separates ID from the data.
+	if p.pos < len(p.data) && isWhitespace(p.data[p.pos]) {
+		p.pos++
+	}
+	start := p.pos
+	if n, ok := inlineImageLength(params); ok && n <= len(p.data)-p.pos {
+		p.pos += n
+	} else if filter := inlineImageFilter(params); filter == "AHx" || filter == "ASCIIHexDecode" {
+		if end := bytes.IndexByte(p.data[p.pos:], '>'); end >= 0 {
+			p.pos += end + 1
+		}
+	} else if filter == "A85" || filter == "ASCII85Decode" {
+		if end := bytes.Index(p.data[p.pos:], []byte("~>")); end >= 0 {
+			p.pos += end + 2
+		}
+	}
+	for i := p.pos; i+2 <= len(p.data); i++ {
+		if p.data[i] == 'E' && p.data[i+1] == 'I' &&
+			(i == start || isWhitespace(p.data[i-1])) &&
+			(i+2 == len(p.data) || isWhitespace(p.data[i+2]) || isDelim(p.data[i+2])) {
+			p.pos = i + 2
+			return
+		}
+	}
+	p.pos = len(p.data)
+}
+
+// inlineParam returns the inline image parameter key, which may be
+// given by its abbreviation short.
+func inlineParam(params Dict, short, key string) *Object {
+	if v, ok := params[short]; ok {
+		return v
+	}
+	return params[key]
+}
+
+// inlineImageFilter returns the name of the first filter of an inline
+// image, or "" if its data is not encoded.
+func inlineImageFilter(params Dict) string {
+	f := inlineParam(params, "F", "Filter")
+	if f != nil && f.Type == ObjArray && len(f.Array) > 0 {
+		f = f.Array[0]
+	}
+	if f == nil || f.Type != ObjName {
+		return ""
+	}
+	return f.Name
+}
+
+// inlineImageLength returns the length of the data of an inline image:
+// its /L entry (PDF 2.0), or, for data that is not encoded, the size of
+// its samples.
+func inlineImageLength(params Dict) (int, bool) {
+	if l := inlineParam(params, "L", "Length"); l != nil && l.Type == ObjInt && l.Int >= 0 {
+		return int(l.Int), true
+	}
+	if inlineParam(params, "F", "Filter") != nil {
+		return 0, false
+	}
+	w, h := inlineParam(params, "W", "Width"), inlineParam(params, "H", "Height")
+	if w == nil || h == nil || w.Type != ObjInt || h.Type != ObjInt || w.Int <= 0 || h.Int <= 0 {
+		return 0, false
+	}
+	comps, bpc := 0, 0
+	if b := inlineParam(params, "BPC", "BitsPerComponent"); b != nil && b.Type == ObjInt {
+		bpc = int(b.Int)
+	}
+	if im := inlineParam(params, "IM", "ImageMask"); im != nil && im.Type == ObjBool && im.Bool {
+		comps, bpc = 1, 1
+	} else {
+		cs := inlineParam(params, "CS", "ColorSpace")
+		if cs != nil && cs.Type == ObjArray && len(cs.Array) > 0 {
+			cs = cs.Array[0]
+		}
+		if cs != nil && cs.Type == ObjName {
+			switch cs.Name {
+			case "G", "DeviceGray", "CalGray", "I", "Indexed":
+				comps = 1
+			case "RGB", "DeviceRGB", "CalRGB", "Lab":
+				comps = 3
+			case "CMYK", "DeviceCMYK":
+				comps = 4
+			}
+		}
+	}
+	if comps == 0 || bpc <= 0 || bpc > 16 || w.Int > 1<<20 || h.Int > 1<<20 {
+		return 0, false
+	}
+	return int(h.Int) * ((int(w.Int)*comps*bpc + 7) / 8), true
+}
+
 // processOperator handles one content stream operator and its operands.
 func processOperator(
 	op string,
 	stack *[]*Object,
 	ts *textState,
 	inText *bool,
-	spans *[]textSpan,
+	out *textOutput,
 	fonts map[string]*FontEncoding,
+	do func(name string, ctm matrix),
 ) {
 	args := *stack
 	*stack = (*stack)[:0]
 
 	switch op {
 	// ---- Graphics state ----
-	case "q": // push graphics state (ignored for text)
-	case "Q": // pop graphics state (ignored for text)
+	case "q": // push graphics state
+		ts.saved = append(ts.saved, ts.graphicsState)
+	case "Q": // pop graphics state
+		if n := len(ts.saved); n > 0 {
+			ts.graphicsState, ts.saved = ts.saved[n-1], ts.saved[:n-1]
+		}
 	case "cm": // concat matrix
 		if len(args) >= 6 {
-			ts.ctmA = floatArg(args[0])
-			ts.ctmB = floatArg(args[1])
-			ts.ctmC = floatArg(args[2])
-			ts.ctmD = floatArg(args[3])
-			ts.ctmE = floatArg(args[4])
-			ts.ctmF = floatArg(args[5])
+			var m matrix
+			for i, o := range args[len(args)-6:] {
+				m[i] = floatArg(o)
+			}
+			ts.ctm = m.mul(ts.ctm)
+		}
+
+	// ---- XObjects ----
+	case "Do": // Draw XObject
+		if len(args) >= 1 && args[len(args)-1].Type == ObjName && do != nil {
+			n := len(out.spans)
+			do(args[len(args)-1].Name, ts.ctm)
+			// A form's content belongs to the marked-content sequence
+			// drawing it.
+			mcid, artifact := ts.markedContent()
+			for i := n; i < len(out.spans); i++ {
+				out.spans[i].mcid = mcid
+				out.spans[i].artifact = out.spans[i].artifact || artifact
+			}
+			if ts.replacing() >= 0 {
+				for _, sp := range out.spans[n:] {
+					ts.replaceSpan(sp)
+				}
+				out.spans = out.spans[:n]
+			}
 		}
 
 	// ---- Text object ----
 	case "BT": // Begin text
 		*inText = true
-		ts.tx, ts.ty = 0, 0
-		ts.lx, ts.ly = 0, 0
+		ts.tm, ts.tlm = identity, identity
 	case "ET": // End text
 		*inText = false
 
@@ -218,120 +610,101 @@ func processOperator(
 		if len(args) >= 1 {
 			ts.leading = floatArg(args[0])
 		}
+	case "Tz": // Horizontal scaling, in percent
+		if len(args) >= 1 {
+			ts.scale = floatArg(args[0]) / 100
+		}
+	case "Ts": // Text rise
+		if len(args) >= 1 {
+			ts.rise = floatArg(args[0])
+		}
 
 	// ---- Text positioning ----
 	case "Td": // Move text position
 		if len(args) >= 2 {
-			tx := floatArg(args[0])
-			ty := floatArg(args[1])
-			ts.lx += tx
-			ts.ly += ty
-			ts.tx = ts.lx
-			ts.ty = ts.ly
+			ts.moveLine(floatArg(args[0]), floatArg(args[1]))
 		}
 	case "TD": // Move text position and set leading
 		if len(args) >= 2 {
-			tx := floatArg(args[0])
-			ty := floatArg(args[1])
-			ts.leading = -ty
-			ts.lx += tx
-			ts.ly += ty
-			ts.tx = ts.lx
-			ts.ty = ts.ly
+			ts.leading = -floatArg(args[1])
+			ts.moveLine(floatArg(args[0]), floatArg(args[1]))
 		}
 	case "Tm": // Set text matrix
 		if len(args) >= 6 {
-			ts.tx = floatArg(args[4])
-			ts.ty = floatArg(args[5])
-			ts.lx = ts.tx
-			ts.ly = ts.ty
+			for i, o := range args[len(args)-6:] {
+				ts.tm[i] = floatArg(o)
+			}
+			ts.tlm = ts.tm
 		}
 	case "T*": // Move to next line
-		ts.lx = 0
-		ts.ly -= ts.leading
-		ts.tx = ts.lx
-		ts.ty = ts.ly
+		ts.moveLine(0, -ts.leading)
 
 	// ---- Text showing ----
 	case "Tj": // Show text string
 		if *inText && len(args) >= 1 {
-			text := decodeTextObj(args[0], ts.fontName, fonts)
-			if text != "" {
-				*spans = append(*spans, textSpan{
-					x:        ts.tx,
-					y:        ts.ty,
-					text:     text,
-					fontSize: ts.fontSize,
-				})
-			}
+			adv, known := ts.advance(args[0], fonts)
+			ts.showGlyphs(out, args[0], fonts, 0)
+			ts.show(out, fonts, decodeTextObj(args[0], ts.fontName, fonts), adv, known)
 		}
 	case "TJ": // Show text array with kerning
 		if *inText && len(args) >= 1 && args[0].Type == ObjArray {
 			var sb strings.Builder
+			var adv float64
+			known := true
 			for _, elem := range args[0].Array {
 				switch elem.Type {
 				case ObjString:
 					sb.WriteString(decodeTextObj(elem, ts.fontName, fonts))
+					ts.showGlyphs(out, elem, fonts, adv)
+					w, ok := ts.advance(elem, fonts)
+					adv += w
+					known = known && ok
 				case ObjInt, ObjFloat:
 					// Negative kerning values indicate word spaces
 					kern := floatArg(elem)
 					if kern < -100 {
 						sb.WriteRune(' ')
 					}
+					adv -= kern / 1000 * ts.fontSize
 				}
 			}
-			text := sb.String()
-			if text != "" {
-				*spans = append(*spans, textSpan{
-					x:        ts.tx,
-					y:        ts.ty,
-					text:     text,
-					fontSize: ts.fontSize,
-				})
-			}
+			ts.show(out, fonts, sb.String(), adv, known)
 		}
 	case "'": // Move to next line and show text
-		ts.lx = 0
-		ts.ly -= ts.leading
-		ts.tx = ts.lx
-		ts.ty = ts.ly
+		ts.moveLine(0, -ts.leading)
 		if *inText && len(args) >= 1 {
-			text := decodeTextObj(args[0], ts.fontName, fonts)
-			if text != "" {
-				*spans = append(*spans, textSpan{
-					x:        ts.tx,
-					y:        ts.ty,
-					text:     text,
-					fontSize: ts.fontSize,
-				})
-			}
+			adv, known := ts.advance(args[0], fonts)
+			ts.showGlyphs(out, args[0], fonts, 0)
+			ts.show(out, fonts, decodeTextObj(args[0], ts.fontName, fonts), adv, known)
 		}
 	case `"`: // Set spacing, move to next line, and show text
 		if len(args) >= 3 {
 			ts.wordSpacing = floatArg(args[0])
 			ts.charSpacing = floatArg(args[1])
 		}
-		ts.lx = 0
-		ts.ly -= ts.leading
-		ts.tx = ts.lx
-		ts.ty = ts.ly
+		ts.moveLine(0, -ts.leading)
 		if *inText && len(args) >= 3 {
-			text := decodeTextObj(args[2], ts.fontName, fonts)
-			if text != "" {
-				*spans = append(*spans, textSpan{
-					x:        ts.tx,
-					y:        ts.ty,
-					text:     text,
-					fontSize: ts.fontSize,
-				})
-			}
+			adv, known := ts.advance(args[2], fonts)
+			ts.showGlyphs(out, args[2], fonts, 0)
+			ts.show(out, fonts, decodeTextObj(args[2], ts.fontName, fonts), adv, known)
+		}
+
+	// ---- Path construction and painting ----
+	case "m", "l", "c", "v", "y", "h", "re",
+		"S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+		if out.withRulings {
+			ts.pathOperator(op, args, out)
 		}
 
-	// ---- Marked content (ignored for basic extraction) ----
-	case "BMC", "BDC", "EMC", "MP", "DP":
-		// Ignore marked content operators
+	// ---- Marked content ----
+	case "BMC", "BDC":
+		ts.beginMarkedContent(args, out.altText)
+	case "EMC":
+		ts.endMarkedContent(out, *inText)
+	case "MP", "DP":
+		// Marked-content points enclose no content
 
-	// All other operators (path, image, color, etc.) are ignored
+	// All other operators (image, color, etc.) are ignored
 	}
 }
 
@@ -373,17 +746,30 @@ func floatArg(obj *Object) float64 {
 // spansToText converts positioned text spans into a readable string,
 // inserting spaces and newlines based on position differences.
 func spansToText(spans []textSpan) string {
-	if len(spans) == 0 {
-		return ""
+	var sb strings.Builder
+	for li, l := range groupLines(spans) {
+		if li > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(l.text())
 	}
+	return strings.TrimSpace(sb.String())
+}
 
-	// Group spans into lines by Y coordinate (within tolerance)
-	type line struct {
-		y     float64
-		spans []textSpan
+// textLine is a line of spans sharing a baseline, left to right.
+type textLine struct {
+	y     float64
+	spans []textSpan
+}
+
+// groupLines groups spans into lines by Y coordinate (within tolerance),
+// top to bottom.
+func groupLines(spans []textSpan) []textLine {
+	if len(spans) == 0 {
+		return nil
 	}
 
-	var lines []line
+	var lines []textLine
 	lineTol := averageFontSize(spans) * 0.5
 	if lineTol < 2 {
 		lineTol = 2
@@ -399,7 +785,7 @@ func spansToText(spans []textSpan) string {
 			}
 		}
 		if !found {
-			lines = append(lines, line{y: sp.y, spans: []textSpan{sp}})
+			lines = append(lines, textLine{y: sp.y, spans: []textSpan{sp}})
 		}
 	}
 
@@ -416,30 +802,28 @@ func spansToText(spans []textSpan) string {
 	for i := range lines {
 		sortSpansByX(lines[i].spans)
 	}
+	return lines
+}
 
+// text returns the text of the line, with a space between spans where
+// there is a gap.
+func (l textLine) text() string {
 	var sb strings.Builder
-	for li, l := range lines {
-		if li > 0 {
-			sb.WriteByte('\n')
-		}
-		// Emit spans with space between them if there's a gap
-		for si, sp := range l.spans {
-			if si > 0 {
-				prev := l.spans[si-1]
-				gap := sp.x - (prev.x + estimateWidth(prev))
-				avgFS := (sp.fontSize + prev.fontSize) / 2
-				if avgFS < 1 {
-					avgFS = 12
-				}
-				if gap > avgFS*0.3 {
-					sb.WriteByte(' ')
-				}
+	for si, sp := range l.spans {
+		if si > 0 {
+			prev := l.spans[si-1]
+			gap := sp.x - (prev.x + prev.width)
+			avgFS := (sp.fontSize + prev.fontSize) / 2
+			if avgFS < 1 {
+				avgFS = 12
+			}
+			if gap > avgFS*0.3 {
+				sb.WriteByte(' ')
 			}
-			sb.WriteString(cleanText(sp.text))
 		}
+		sb.WriteString(cleanText(sp.text))
 	}
-
-	return strings.TrimSpace(sb.String())
+	return sb.String()
 }
 
 func averageFontSize(spans []textSpan) float64 {
@@ -453,7 +837,8 @@ func averageFontSize(spans []textSpan) float64 {
 	return sum / float64(len(spans))
 }
 
-// estimateWidth gives a rough character-width estimate for a span.
+// estimateWidth gives a rough character-width estimate for a span whose
+// font does not give the widths of its glyphs.
 func estimateWidth(sp textSpan) float64 {
 	return float64(len([]rune(sp.text))) * sp.fontSize * 0.5
 }
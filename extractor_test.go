@@ -1,6 +1,7 @@
 package htmlpdf
 
 import (
+	"math"
 	"strings"
 	"testing"
 )
@@ -18,7 +19,7 @@ func buildTestPDF(contentStreams [][]byte) []byte {
 		return n
 	}
 
-	cat("%PDF-1.4\n")
+	cat("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
 
 	objOffsets := map[int]int{}
 
@@ -149,6 +150,193 @@ func TestExtractTJOperator(t *testing.T) {
 	}
 }
 
+func TestExtractFormXObjects(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte(
+		"BT /F1 12 Tf 72 700 Td (Invoice 42) Tj ET " +
+			"q 1 0 0 1 72 750 cm /Fx0 Do Q " +
+			"BT /F1 12 Tf 72 100 Td (Total) Tj ET"),
+	}))
+	u, _ := newPDFUpdate(doc)
+	form := func(matrix []*Object, res Dict, content string) *Object {
+		d := Dict{"Type": nameObj("XObject"), "Subtype": nameObj("Form"), "Resources": dictObj(res)}
+		if matrix != nil {
+			d["Matrix"] = arrayObj(matrix...)
+		}
+		return &Object{Type: ObjStream, Dict: d, Stream: []byte(content)}
+	}
+
+	// The stamp is drawn 300 points right of the letterhead, and draws
+	// itself, which must not be followed.
+	stamp := u.reserve()
+	u.set(stamp, form(
+		[]*Object{intObj(1), intObj(0), intObj(0), intObj(1), intObj(300), intObj(0)},
+		Dict{
+			"Font":    dictObj(Dict{"F1": refObj(Reference{Number: 5})}),
+			"XObject": dictObj(Dict{"Self": refObj(stamp)}),
+		},
+		"BT /F1 10 Tf 0 0 Td (PAID) Tj ET /Self Do",
+	))
+	// The letterhead's font re-encodes its codes: (ABCD) shows "ACME".
+	letterhead := u.add(form(nil, Dict{
+		"Font": dictObj(Dict{"FS": dictObj(Dict{
+			"Type":     nameObj("Font"),
+			"Subtype":  nameObj("Type1"),
+			"BaseFont": nameObj("Helvetica"),
+			"Encoding": dictObj(Dict{"Differences": arrayObj(
+				intObj(65), nameObj("A"), nameObj("C"), nameObj("M"), nameObj("E"),
+			)}),
+		})}),
+		"XObject": dictObj(Dict{"Stamp": refObj(stamp)}),
+	}, "BT /FS 10 Tf 0 0 Td (ABCD) Tj ET /Stamp Do"))
+
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	cp := copyDict(page.Dict)
+	cp["Resources"] = dictObj(Dict{
+		"Font":    dictObj(Dict{"F1": refObj(Reference{Number: 5})}),
+		"XObject": dictObj(Dict{"Fx0": refObj(letterhead)}),
+	})
+	u.set(Reference{Number: 3}, dictObj(cp))
+
+	text, err := NewExtractor(mustLoad(t, u.bytes())).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "ACME PAID\nInvoice 42\nTotal"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractTransformedText(t *testing.T) {
+	// Chrome draws pages with the y axis flipped; q/Q scope a translation.
+	cs := []byte("1 0 0 -1 0 792 cm " +
+		"BT /F1 12 Tf 1 0 0 -1 72 50 Tm (First) Tj ET " +
+		"q 1 0 0 1 0 -15 cm BT /F1 12 Tf 1 0 0 -1 72 80 Tm (Above) Tj ET Q " +
+		"BT /F1 12 Tf 1 0 0 -1 72 80 Tm (Second) Tj ET")
+	text, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "First\nAbove\nSecond"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractInlineImage(t *testing.T) {
+	// The 4×4 grey samples read like operators; skipped by their size.
+	cs := []byte("BT /F1 12 Tf 72 700 Td (Before) Tj ET " +
+		"q 4 0 0 4 72 650 cm BI /W 4 /H 4 /CS /G /BPC 8 ID BT(Fake text)Tj EI Q " +
+		"BT /F1 12 Tf 72 600 Td (After) Tj ET")
+	text, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "Before\nAfter"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestSkipInlineImage(t *testing.T) {
+	tests := []struct {
+		name, content string
+	}{
+		{"samples containing EI", "/W 2 /H 2 /BPC 8 /CS /G ID \x00 EI  EI"},
+		{"image mask", "/IM true /W 16 /H 2 ID  EI  EI"},
+		{"length", "/F /DCT /L 6 ID \xff EI \xd9 EI"},
+		{"hex", "/F /AHx /W 2 /H 1 /CS /G /BPC 8 ID 0 EI 1> EI"},
+		{"ascii85", "/F [/A85 /Fl] /W 9 /H 9 /CS /RGB /BPC 8 ID ab\nEI ~> EI"},
+		{"encoded", "/F /Fl /W 9 /H 9 /CS /RGB /BPC 8 ID x\x9c\x01EI\x02 EI"},
+		{"resource color space", "/W 2 /H 1 /CS /CS0 /BPC 8 ID \x01\x02 EI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := tt.content + " Q"
+			p := NewParser([]byte(content), 0)
+			p.skipInlineImage()
+			if rest := content[p.pos:]; rest != " Q" {
+				t.Errorf("stopped before %q, want before \" Q\"", rest)
+			}
+		})
+	}
+}
+
+func TestExtractTextMatrix(t *testing.T) {
+	cs := []byte(
+		// A unit font scaled by the text matrix: the leading is scaled too.
+		"BT /F1 1 Tf 12 0 0 12 72 700 Tm 1.2 TL (First) Tj T* (Second) Tj ET " +
+			// Rotated table headers: Td moves along the rotated axes, so
+			// Price is drawn right of Qty rather than below it.
+			"BT /F1 10 Tf 0 1 -1 0 100 600 Tm (Qty) Tj 0 -30 Td (Price) Tj ET " +
+			"BT /F1 10 Tf 95 580 Td (3) Tj ET BT /F1 10 Tf 125 580 Td (9.99) Tj ET " +
+			// Doubled horizontal scaling doubles the width of WWW.
+			"BT /F1 10 Tf 200 Tz 72 500 Td (WWW) Tj ET BT /F1 10 Tf 130 500 Td (ORD) Tj ET " +
+			// Raised text stays on its line.
+			"BT /F1 10 Tf 100 Tz 72 480 Td (x) Tj 4 Ts (2) Tj ET")
+	text, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "First\nSecond\nQty Price\n3 9.99\nWWWORD\nx2"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractGraphicsStateStack(t *testing.T) {
+	cs := []byte(
+		// A footer drawn in a translated group first: Q restores the CTM.
+		"q 1 0 0 1 0 -200 cm BT /F1 10 Tf 72 700 Td (Footer) Tj ET Q " +
+			"BT /F1 10 Tf 72 690 Td (Body) Tj ET " +
+			// Q also restores the horizontal scaling, so WWW is not
+			// measured twice as wide.
+			"q 200 Tz Q BT /F1 10 Tf 72 680 Td (WWW) Tj ET BT /F1 10 Tf 130 680 Td (ORD) Tj ET " +
+			// Unbalanced Q operators are ignored.
+			"Q Q BT /F1 10 Tf 72 660 Td (End) Tj ET")
+	text, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "Body\nWWW ORD\nEnd\nFooter"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExtractGlyphs(t *testing.T) {
+	// Helvetica's A and V are 667 thousandths wide and its W 944, each
+	// advanced 2 points more by the character spacing; the TJ kerning
+	// moves the last A 5 points right.
+	cs := []byte("BT /F1 10 Tf 2 Tc 72 700 Td (AW) Tj [(V) -500 (A)] TJ ET " +
+		// Rotated text advances up the page.
+		"BT /F1 10 Tf 0 Tc 0 1 -1 0 300 600 Tm (A) Tj ET")
+	glyphs, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractGlyphs(0)
+	if err != nil {
+		t.Fatalf("ExtractGlyphs: %v", err)
+	}
+	want := []Glyph{
+		{Text: "A", X: 72, Y: 700, Advance: 8.67},
+		{Text: "W", X: 80.67, Y: 700, Advance: 11.44},
+		{Text: "V", X: 92.11, Y: 700, Advance: 8.67},
+		{Text: "A", X: 105.78, Y: 700, Advance: 8.67},
+		{Text: "A", X: 300, Y: 600, Advance: 6.67},
+	}
+	if len(glyphs) != len(want) {
+		t.Fatalf("got %d glyphs, want %d: %+v", len(glyphs), len(want), glyphs)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for i, g := range glyphs {
+		w := want[i]
+		if g.Text != w.Text || !near(g.X, w.X) || !near(g.Y, w.Y) || !near(g.Advance, w.Advance) {
+			t.Errorf("glyph %d = %q at (%v, %v) advancing %v, want %q at (%v, %v) advancing %v",
+				i, g.Text, g.X, g.Y, g.Advance, w.Text, w.X, w.Y, w.Advance)
+		}
+		if g.FontSize != 10 || g.Font != "F1" || g.FontRef != (Reference{Number: 5}) {
+			t.Errorf("glyph %d font = %s %v at %v, want F1 5 0 R at 10", i, g.Font, g.FontRef, g.FontSize)
+		}
+	}
+
+	if glyphs, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractGlyphs(1); glyphs != nil || err != nil {
+		t.Errorf("ExtractGlyphs(1) = %v, %v; want nil, nil", glyphs, err)
+	}
+}
+
 func TestMultiplePages(t *testing.T) {
 	pages := [][]byte{
 		[]byte("BT /F1 12 Tf 100 700 Td (Page one) Tj ET"),
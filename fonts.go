@@ -0,0 +1,67 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// systemFontConfig is the fontconfig configuration Chrome reads when
+// FONTCONFIG_FILE is not set.
+const systemFontConfig = "/etc/fonts/fonts.conf"
+
+// writeFontConfig writes a fontconfig configuration that adds dirs to
+// the system's fonts into a new directory under tempDir (or the system
+// temporary directory if empty), and returns the directory. The caller
+// removes it once the browser has exited.
+func writeFontConfig(tempDir string, dirs []string) (string, error) {
+	abs := make([]string, len(dirs))
+	for i, dir := range dirs {
+		a, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("htmlpdf: font directory: %w", err)
+		}
+		if fi, err := os.Stat(a); err != nil {
+			return "", fmt.Errorf("htmlpdf: font directory: %w", err)
+		} else if !fi.IsDir() {
+			return "", fmt.Errorf("htmlpdf: font directory %s is not a directory", a)
+		}
+		abs[i] = a
+	}
+	confDir, err := os.MkdirTemp(tempDir, "htmlpdf-fonts-*")
+	if err != nil {
+		return "", fmt.Errorf("htmlpdf: creating font configuration: %w", err)
+	}
+	base := os.Getenv("FONTCONFIG_FILE")
+	if base == "" {
+		base = systemFontConfig
+	}
+	conf := fontConfig(base, abs, filepath.Join(confDir, "cache"))
+	if err := os.WriteFile(filepath.Join(confDir, "fonts.conf"), conf, 0o644); err != nil {
+		os.RemoveAll(confDir)
+		return "", fmt.Errorf("htmlpdf: creating font configuration: %w", err)
+	}
+	return confDir, nil
+}
+
+// fontConfig returns a fontconfig configuration that includes the
+// configuration file base and adds the font directories dirs, caching
+// their index in cacheDir.
+func fontConfig(base string, dirs []string, cacheDir string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version=\"1.0\"?>\n<!DOCTYPE fontconfig SYSTEM \"fonts.dtd\">\n<fontconfig>\n")
+	element := func(open, name, value string) {
+		fmt.Fprintf(&buf, "  <%s>", open)
+		xml.EscapeText(&buf, []byte(value))
+		fmt.Fprintf(&buf, "</%s>\n", name)
+	}
+	element(`include ignore_missing="yes"`, "include", base)
+	for _, dir := range dirs {
+		element("dir", "dir", dir)
+	}
+	element("cachedir", "cachedir", cacheDir)
+	buf.WriteString("</fontconfig>\n")
+	return buf.Bytes()
+}
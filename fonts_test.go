@@ -0,0 +1,58 @@
+package htmlpdf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFontConfig(t *testing.T) {
+	got := string(fontConfig("/etc/fonts/fonts.conf", []string{"/opt/fonts", "/srv/R&D fonts"}, "/tmp/x/cache"))
+	for _, want := range []string{
+		`<include ignore_missing="yes">/etc/fonts/fonts.conf</include>`,
+		"<dir>/opt/fonts</dir>",
+		"<dir>/srv/R&amp;D fonts</dir>",
+		"<cachedir>/tmp/x/cache</cachedir>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("configuration lacks %s:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteFontConfig(t *testing.T) {
+	fonts := t.TempDir()
+	t.Setenv("FONTCONFIG_FILE", "/custom/fonts.conf")
+	tmp := t.TempDir()
+	dir, err := writeFontConfig(tmp, []string{fonts})
+	if err != nil {
+		t.Fatalf("writeFontConfig: %v", err)
+	}
+	if filepath.Dir(dir) != tmp {
+		t.Errorf("configuration in %s, want under %s", dir, tmp)
+	}
+	conf, err := os.ReadFile(filepath.Join(dir, "fonts.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(conf), "<dir>"+fonts+"</dir>") || !strings.Contains(string(conf), "/custom/fonts.conf") {
+		t.Errorf("fonts.conf = %s", conf)
+	}
+}
+
+func TestWriteFontConfig_MissingDir(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "font.ttf")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{filepath.Join(tmp, "missing"), file} {
+		if _, err := writeFontConfig(tmp, []string{dir}); err == nil {
+			t.Errorf("writeFontConfig(%s) succeeded", dir)
+		}
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 1 {
+		t.Errorf("temporary directory holds %d entries, want none left behind", len(entries))
+	}
+}
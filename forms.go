@@ -0,0 +1,596 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formFieldURL prefixes the links formFieldsScript places over each form
+// control. Chrome prints them as link annotations at the control's
+// rectangle on the right page, which addFormFields replaces with widgets.
+const formFieldURL = "https://htmlpdf.invalid/field/"
+
+// formField is an HTML form control as found in the rendered page.
+type formField struct {
+	Kind      string        `json:"kind"` // text, textarea, checkbox, radio or select
+	Name      string        `json:"name"`
+	Value     string        `json:"value"`
+	Checked   bool          `json:"checked"`
+	Options   []fieldOption `json:"options"`
+	Multiple  bool          `json:"multiple"`
+	ListBox   bool          `json:"listBox"`
+	Password  bool          `json:"password"`
+	ReadOnly  bool          `json:"readOnly"`
+	Required  bool          `json:"required"`
+	MaxLength int           `json:"maxLength"`
+	FontSize  float64       `json:"fontSize"` // CSS pixels
+	Height    float64       `json:"height"`   // CSS pixels, for scaling FontSize
+}
+
+// fieldOption is an <option> of a <select>.
+type fieldOption struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Selected bool   `json:"selected"`
+}
+
+// formFieldsScript lists the rendered <input>, <select> and <textarea>
+// elements that have a PDF counterpart, in document order, and prepares
+// them for printing: each is wrapped in an element holding a link that
+// covers it, and its own value is hidden so that only the field's
+// appearance is drawn.
+const formFieldsScript = `(() => {
+	const textTypes = ["text", "email", "tel", "url", "number", "search", "password",
+		"date", "datetime-local", "month", "time", "week"];
+	const fields = [];
+	for (const el of document.querySelectorAll("input, select, textarea")) {
+		let kind = el.tagName.toLowerCase();
+		if (kind === "input") {
+			if (el.type === "checkbox" || el.type === "radio") kind = el.type;
+			else if (textTypes.includes(el.type)) kind = "text";
+			else continue;
+		}
+		if (el.getClientRects().length === 0) continue;
+		const style = getComputedStyle(el);
+		const f = {
+			kind: kind,
+			name: el.name || el.id,
+			readOnly: el.disabled || el.readOnly === true,
+			required: el.required,
+			fontSize: parseFloat(style.fontSize) || 0,
+		};
+		switch (kind) {
+		case "text":
+		case "textarea":
+			f.password = el.type === "password";
+			f.value = f.password ? "" : el.value;
+			f.maxLength = Math.max(0, el.maxLength);
+			el.removeAttribute("placeholder");
+			el.style.setProperty("color", "transparent", "important");
+			break;
+		case "checkbox":
+		case "radio":
+			f.value = el.value;
+			f.checked = el.checked;
+			el.checked = false;
+			break;
+		case "select":
+			f.options = Array.from(el.options, o => ({value: o.value, label: o.text, selected: o.selected}));
+			f.multiple = el.multiple;
+			f.listBox = el.multiple || el.size > 1;
+			el.style.setProperty("color", "transparent", "important");
+			break;
+		}
+		const wrap = document.createElement("span");
+		wrap.style.cssText = "position: relative; display: " + (style.display === "block" ? "block" : "inline-block") +
+			"; vertical-align: " + style.verticalAlign + "; margin: " + style.margin;
+		el.style.setProperty("margin", "0", "important");
+		el.before(wrap);
+		wrap.append(el);
+		const link = document.createElement("a");
+		link.href = "` + formFieldURL + `" + fields.length;
+		link.style.cssText = "position: absolute; inset: 0; display: block";
+		wrap.append(link);
+		f.height = el.getBoundingClientRect().height;
+		fields.push(f);
+	}
+	return fields;
+})()`
+
+// Field flags (ISO 32000-1, 12.7.3.1 and 12.7.4).
+const (
+	fieldReadOnly      = 1 << 0
+	fieldRequired      = 1 << 1
+	fieldMultiline     = 1 << 12
+	fieldPassword      = 1 << 13
+	fieldNoToggleToOff = 1 << 14
+	fieldRadio         = 1 << 15
+	fieldCombo         = 1 << 17
+	fieldMultiSelect   = 1 << 21
+)
+
+// fieldWidget is where a form control was printed.
+type fieldWidget struct {
+	page Reference
+	rect [4]float64
+}
+
+// formPage is a page whose annotations addFormFields changes.
+type formPage struct {
+	dict    Dict
+	annots  []*Object
+	changed bool
+}
+
+// radioGroup is the parent field of the radio buttons sharing a name.
+type radioGroup struct {
+	ref     Reference
+	field   Dict
+	kids    []*Object
+	exports map[string]bool
+}
+
+// addFormFields returns pdf with an interactive form (AcroForm) appended
+// as an incremental update: the links formFieldsScript placed over each
+// control are replaced with a field of the matching type, holding the
+// control's value. Controls Chrome printed no link for are left out.
+func addFormFields(pdf []byte, fields []formField) ([]byte, error) {
+	if len(fields) == 0 {
+		return pdf, nil
+	}
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	placed := make([]*fieldWidget, len(fields))
+	pages := make(map[Reference]*formPage, len(refs))
+	for _, ref := range refs {
+		page, err := doc.ResolveRef(ref)
+		if err != nil || page == nil || page.Type != ObjDict {
+			continue
+		}
+		p := &formPage{dict: page.Dict}
+		pages[ref] = p
+		annots, _ := doc.Resolve(page.Dict["Annots"])
+		if annots == nil || annots.Type != ObjArray {
+			continue
+		}
+		for _, a := range annots.Array {
+			annot := resolveDict(doc, a)
+			i, ok := formFieldIndex(doc, annot, len(fields))
+			if !ok {
+				p.annots = append(p.annots, a)
+				continue
+			}
+			p.changed = true
+			// A control split across pages keeps its first part.
+			if placed[i] == nil {
+				placed[i] = &fieldWidget{page: ref, rect: annotRect(doc, annot)}
+			}
+		}
+	}
+
+	helv := u.add(standardFontDict("Helvetica"))
+	zadb := u.add(dictObj(Dict{
+		"Type":     nameObj("Font"),
+		"Subtype":  nameObj("Type1"),
+		"BaseFont": nameObj("ZapfDingbats"),
+	}))
+	fonts := dictObj(Dict{"Helv": refObj(helv), "ZaDb": refObj(zadb)})
+
+	var top []*Object
+	used := make(map[string]bool)
+	uniqueName := func(name string) string {
+		// Periods separate the parts of qualified field names.
+		name = strings.ReplaceAll(name, ".", "_")
+		if name == "" {
+			name = "field"
+		}
+		n := name
+		for i := 2; used[n]; i++ {
+			n = name + "_" + strconv.Itoa(i)
+		}
+		used[n] = true
+		return n
+	}
+	groups := make(map[string]*radioGroup)
+	var groupOrder []*radioGroup
+
+	for i, f := range fields {
+		w := placed[i]
+		if w == nil {
+			continue
+		}
+		width, height := w.rect[2]-w.rect[0], w.rect[3]-w.rect[1]
+		fs := fieldFontSize(f, height)
+		flags := 0
+		if f.ReadOnly {
+			flags |= fieldReadOnly
+		}
+		if f.Required {
+			flags |= fieldRequired
+		}
+		d := Dict{
+			"Type":    nameObj("Annot"),
+			"Subtype": nameObj("Widget"),
+			"Rect":    arrayObj(realObj(w.rect[0]), realObj(w.rect[1]), realObj(w.rect[2]), realObj(w.rect[3])),
+			"P":       refObj(w.page),
+			"F":       intObj(annotPrint),
+		}
+		da := textStringObj("/Helv " + formatNumber(fs) + " Tf 0 g")
+
+		switch f.Kind {
+		case "checkbox", "radio":
+			var g *radioGroup
+			if f.Kind == "radio" {
+				if f.Name != "" {
+					g = groups[f.Name]
+				}
+				if g == nil {
+					g = &radioGroup{
+						ref: u.reserve(),
+						field: Dict{
+							"FT": nameObj("Btn"),
+							"Ff": intObj(flags | fieldRadio | fieldNoToggleToOff),
+							"T":  textStringObj(uniqueName(f.Name)),
+							"V":  nameObj("Off"),
+						},
+						exports: make(map[string]bool),
+					}
+					if f.Name != "" {
+						groups[f.Name] = g
+					}
+					groupOrder = append(groupOrder, g)
+				}
+			}
+			export := f.Value
+			if export == "" || export == "on" || export == "Off" {
+				export = "Yes"
+			}
+			if g != nil {
+				e := export
+				for n := 2; g.exports[e]; n++ {
+					e = export + "_" + strconv.Itoa(n)
+				}
+				export = e
+				g.exports[export] = true
+			}
+			state := nameObj("Off")
+			if f.Checked {
+				state = nameObj(export)
+			}
+			var on []byte
+			if f.Kind == "radio" {
+				on = radioAppearance(width, height)
+				d["MK"] = dictObj(Dict{"CA": textStringObj("l")})
+			} else {
+				on = checkAppearance(width, height)
+				d["MK"] = dictObj(Dict{"CA": textStringObj("4")})
+			}
+			d["AS"] = state
+			d["AP"] = dictObj(Dict{"N": dictObj(Dict{
+				export: refObj(u.add(appearanceStream(width, height, fonts, on))),
+				"Off":  refObj(u.add(appearanceStream(width, height, fonts, nil))),
+			})})
+			d["DA"] = textStringObj("/ZaDb 0 Tf 0 g")
+			if g != nil {
+				d["Parent"] = refObj(g.ref)
+				if f.Checked {
+					g.field["V"] = state
+				}
+				ref := u.add(dictObj(d))
+				g.kids = append(g.kids, refObj(ref))
+				pages[w.page].annots = append(pages[w.page].annots, refObj(ref))
+				pages[w.page].changed = true
+				continue
+			}
+			d["FT"] = nameObj("Btn")
+			d["V"] = state
+
+		case "select":
+			if !f.ListBox {
+				flags |= fieldCombo
+			}
+			if f.Multiple {
+				flags |= fieldMultiSelect
+			}
+			opts := make([]*Object, len(f.Options))
+			var values, indices []*Object
+			var lines []string
+			selected := make([]bool, len(f.Options))
+			for j, o := range f.Options {
+				if o.Value == o.Label {
+					opts[j] = textStringObj(o.Label)
+				} else {
+					opts[j] = arrayObj(textStringObj(o.Value), textStringObj(o.Label))
+				}
+				if o.Selected {
+					values = append(values, textStringObj(o.Value))
+					indices = append(indices, intObj(j))
+					selected[j] = true
+					if len(lines) == 0 {
+						lines = append(lines, o.Label)
+					}
+				}
+			}
+			d["FT"] = nameObj("Ch")
+			d["Opt"] = arrayObj(opts...)
+			switch {
+			case len(values) == 1:
+				d["V"] = values[0]
+			case len(values) > 1:
+				d["V"] = arrayObj(values...)
+			}
+			var content []byte
+			if f.ListBox {
+				if len(indices) > 0 {
+					d["I"] = arrayObj(indices...)
+				}
+				labels := make([]string, len(f.Options))
+				for j, o := range f.Options {
+					labels[j] = o.Label
+				}
+				content = listAppearance(labels, selected, width, height, fs)
+			} else {
+				content = textAppearance(lines, width, height, fs, false)
+			}
+			d["DA"] = da
+			d["AP"] = dictObj(Dict{"N": refObj(u.add(appearanceStream(width, height, fonts, content)))})
+
+		default: // text, textarea
+			if f.Kind == "textarea" {
+				flags |= fieldMultiline
+			}
+			if f.Password {
+				flags |= fieldPassword
+			}
+			d["FT"] = nameObj("Tx")
+			if f.Value != "" {
+				d["V"] = textStringObj(f.Value)
+			}
+			if f.MaxLength > 0 {
+				d["MaxLen"] = intObj(f.MaxLength)
+			}
+			var lines []string
+			if f.Value != "" {
+				lines = strings.Split(strings.ReplaceAll(f.Value, "\r\n", "\n"), "\n")
+			}
+			d["DA"] = da
+			d["AP"] = dictObj(Dict{"N": refObj(u.add(appearanceStream(width, height, fonts,
+				textAppearance(lines, width, height, fs, f.Kind == "textarea"))))})
+		}
+
+		d["T"] = textStringObj(uniqueName(f.Name))
+		if flags != 0 {
+			d["Ff"] = intObj(flags)
+		}
+		ref := u.add(dictObj(d))
+		top = append(top, refObj(ref))
+		pages[w.page].annots = append(pages[w.page].annots, refObj(ref))
+		pages[w.page].changed = true
+	}
+	for _, g := range groupOrder {
+		g.field["Kids"] = arrayObj(g.kids...)
+		u.set(g.ref, dictObj(g.field))
+		top = append(top, refObj(g.ref))
+	}
+	if len(top) == 0 {
+		return pdf, nil
+	}
+
+	for _, ref := range refs {
+		p := pages[ref]
+		if p == nil || !p.changed {
+			continue
+		}
+		cp := copyDict(p.dict)
+		if len(p.annots) > 0 {
+			cp["Annots"] = arrayObj(p.annots...)
+		} else {
+			delete(cp, "Annots")
+		}
+		u.set(ref, dictObj(cp))
+	}
+
+	catRef, cat, err := u.catalog()
+	if err != nil {
+		return nil, err
+	}
+	if acro := resolveDict(doc, cat["AcroForm"]); acro != nil {
+		existing, _ := doc.Resolve(acro["Fields"])
+		if existing != nil && existing.Type == ObjArray {
+			top = append(append([]*Object{}, existing.Array...), top...)
+		}
+	}
+	cat["AcroForm"] = dictObj(Dict{
+		"Fields": arrayObj(top...),
+		"DA":     textStringObj("/Helv 0 Tf 0 g"),
+		"DR":     dictObj(Dict{"Font": fonts}),
+	})
+	u.set(catRef, dictObj(cat))
+	return u.bytes(), nil
+}
+
+// formFieldIndex returns the index of the control a link annotation
+// placed by formFieldsScript covers.
+func formFieldIndex(doc *Document, annot Dict, n int) (int, bool) {
+	if st, _ := annot.GetName("Subtype"); st != "Link" {
+		return 0, false
+	}
+	uri, err := doc.Resolve(resolveDict(doc, annot["A"])["URI"])
+	if err != nil || uri == nil || uri.Type != ObjString {
+		return 0, false
+	}
+	s, ok := strings.CutPrefix(string(uri.Str), formFieldURL)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 || i >= n {
+		return 0, false
+	}
+	return i, true
+}
+
+// annotRect returns the normalized rectangle of annot.
+func annotRect(doc *Document, annot Dict) [4]float64 {
+	arr, err := doc.Resolve(annot["Rect"])
+	if err != nil || arr == nil || arr.Type != ObjArray || len(arr.Array) != 4 {
+		return [4]float64{}
+	}
+	var r [4]float64
+	for i, v := range arr.Array {
+		r[i] = floatFromObj(v)
+	}
+	return [4]float64{min(r[0], r[2]), min(r[1], r[3]), max(r[0], r[2]), max(r[1], r[3])}
+}
+
+// fieldFontSize returns the size in points to draw f's text at in a
+// widget height points high, scaling its CSS font size as the page was.
+func fieldFontSize(f formField, height float64) float64 {
+	fs := f.FontSize * 0.75
+	if f.Height > 0 && height > 0 {
+		fs = f.FontSize * height / f.Height
+	}
+	if fs <= 0 {
+		fs = 10
+	}
+	return math.Round(fs*100) / 100
+}
+
+// formatNumber formats f as a PDF number.
+func formatNumber(f float64) string {
+	var buf bytes.Buffer
+	writeReal(&buf, f)
+	return buf.String()
+}
+
+// appearanceStream returns a form XObject drawing content in a widget of
+// the given size.
+func appearanceStream(width, height float64, fonts *Object, content []byte) *Object {
+	return &Object{Type: ObjStream, Dict: Dict{
+		"Type":      nameObj("XObject"),
+		"Subtype":   nameObj("Form"),
+		"BBox":      arrayObj(realObj(0), realObj(0), realObj(width), realObj(height)),
+		"Resources": dictObj(Dict{"Font": fonts}),
+	}, Stream: content}
+}
+
+// fieldPadding is the space left between a widget's edge and its text.
+const fieldPadding = 2
+
+// textAppearance draws lines in Helvetica at size fs: vertically centred
+// for a single-line field, from the top for a multiline one.
+func textAppearance(lines []string, width, height, fs float64, multiline bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/Tx BMC\nq\n")
+	writeReals(&buf, 1, 1, width-2, height-2)
+	buf.WriteString(" re W n\n")
+	if len(lines) > 0 {
+		y := (height - fs*0.718) / 2
+		if multiline {
+			y = height - fieldPadding - fs*0.8
+		}
+		buf.WriteString("BT\n/Helv ")
+		writeReal(&buf, fs)
+		buf.WriteString(" Tf 0 g\n")
+		writeReals(&buf, fs*1.15)
+		buf.WriteString(" TL\n")
+		writeReals(&buf, fieldPadding, y)
+		buf.WriteString(" Td\n")
+		for i, line := range lines {
+			if i > 0 && !multiline {
+				break
+			}
+			if i > 0 {
+				buf.WriteString("T*\n")
+			}
+			writeString(&buf, winAnsiEncode(line))
+			buf.WriteString(" Tj\n")
+		}
+		buf.WriteString("ET\n")
+	}
+	buf.WriteString("Q\nEMC\n")
+	return buf.Bytes()
+}
+
+// listAppearance draws the options of a list box one per line from the
+// top, highlighting the selected ones.
+func listAppearance(labels []string, selected []bool, width, height, fs float64) []byte {
+	var buf bytes.Buffer
+	lead := fs * 1.15
+	buf.WriteString("/Tx BMC\nq\n")
+	writeReals(&buf, 1, 1, width-2, height-2)
+	buf.WriteString(" re W n\n")
+	for i, sel := range selected {
+		if sel {
+			buf.WriteString("0.6 0.75 0.9 rg ")
+			writeReals(&buf, 1, height-1-float64(i+1)*lead, width-2, lead)
+			buf.WriteString(" re f\n")
+		}
+	}
+	if len(labels) > 0 {
+		buf.WriteString("BT\n/Helv ")
+		writeReal(&buf, fs)
+		buf.WriteString(" Tf 0 g\n")
+		writeReals(&buf, lead)
+		buf.WriteString(" TL\n")
+		writeReals(&buf, fieldPadding, height-1-lead+(lead-fs*0.718)/2)
+		buf.WriteString(" Td\n")
+		for i, label := range labels {
+			if i > 0 {
+				buf.WriteString("T*\n")
+			}
+			writeString(&buf, winAnsiEncode(label))
+			buf.WriteString(" Tj\n")
+		}
+		buf.WriteString("ET\n")
+	}
+	buf.WriteString("Q\nEMC\n")
+	return buf.Bytes()
+}
+
+// checkAppearance draws a ZapfDingbats check mark centred in a widget.
+func checkAppearance(width, height float64) []byte {
+	fs := min(width, height) * 0.8
+	var buf bytes.Buffer
+	buf.WriteString("q BT 0 g /ZaDb ")
+	writeReal(&buf, fs)
+	buf.WriteString(" Tf ")
+	writeReals(&buf, (width-fs*0.846)/2, (height-fs*0.7)/2)
+	buf.WriteString(" Td (4) Tj ET Q\n")
+	return buf.Bytes()
+}
+
+// radioAppearance draws a filled circle centred in a widget.
+func radioAppearance(width, height float64) []byte {
+	cx, cy := width/2, height/2
+	r := min(width, height) / 4
+	k := r * 0.5523 // control point offset approximating a quarter circle
+	var buf bytes.Buffer
+	buf.WriteString("q 0 g\n")
+	writeReals(&buf, cx+r, cy)
+	buf.WriteString(" m\n")
+	for _, c := range [][6]float64{
+		{cx + r, cy + k, cx + k, cy + r, cx, cy + r},
+		{cx - k, cy + r, cx - r, cy + k, cx - r, cy},
+		{cx - r, cy - k, cx - k, cy - r, cx, cy - r},
+		{cx + k, cy - r, cx + r, cy - k, cx + r, cy},
+	} {
+		writeReals(&buf, c[:]...)
+		buf.WriteString(" c\n")
+	}
+	buf.WriteString("f Q\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,168 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+// buildFormInput returns a one-page PDF with a link annotation over each
+// of n form controls, as Chrome prints formFieldsScript's links, and an
+// ordinary link.
+func buildFormInput(t *testing.T, n int) []byte {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Form) Tj ET")}))
+	u, _ := newPDFUpdate(doc)
+	link := func(uri string, y float64) *Object {
+		return refObj(u.add(dictObj(Dict{
+			"Type":    nameObj("Annot"),
+			"Subtype": nameObj("Link"),
+			"Rect":    arrayObj(realObj(72), realObj(y), realObj(272), realObj(y+20)),
+			"A":       dictObj(Dict{"S": nameObj("URI"), "URI": textStringObj(uri)}),
+		})))
+	}
+	annots := []*Object{link("https://example.com", 700)}
+	for i := range n {
+		annots = append(annots, link(formFieldURL+strconv.Itoa(i), 650-float64(i)*30))
+	}
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	page.Dict["Annots"] = arrayObj(annots...)
+	u.set(Reference{Number: 3}, page)
+	return u.bytes()
+}
+
+func TestAddFormFields(t *testing.T) {
+	fields := []formField{
+		{Kind: "text", Name: "name", Value: "Ada", MaxLength: 40, Required: true, FontSize: 16, Height: 21.33},
+		{Kind: "textarea", Name: "notes", Value: "one\ntwo"},
+		{Kind: "checkbox", Name: "agree", Value: "on", Checked: true},
+		{Kind: "radio", Name: "size", Value: "s"},
+		{Kind: "radio", Name: "size", Value: "m", Checked: true},
+		{Kind: "select", Name: "color", Options: []fieldOption{
+			{Value: "r", Label: "Red"}, {Value: "g", Label: "Green", Selected: true},
+		}},
+		{Kind: "text", Name: "name", ReadOnly: true},
+		{Kind: "text", Name: "unplaced"},
+	}
+	out, err := addFormFields(buildFormInput(t, len(fields)-1), fields)
+	if err != nil {
+		t.Fatalf("addFormFields: %v", err)
+	}
+	doc := mustLoad(t, out)
+	cat, _ := doc.Catalog()
+	acro := resolveDict(doc, cat["AcroForm"])
+	if acro == nil {
+		t.Fatal("no /AcroForm in catalog")
+	}
+	if font := resolveDict(doc, resolveDict(doc, acro["DR"])["Font"]); font["Helv"] == nil || font["ZaDb"] == nil {
+		t.Errorf("/DR fonts = %v, want Helv and ZaDb", font)
+	}
+	list, _ := acro.GetArray("Fields")
+	byName := make(map[string]Dict)
+	for _, f := range list {
+		d := resolveDict(doc, f)
+		name, _ := doc.Resolve(d["T"])
+		byName[string(name.Str)] = d
+	}
+	if len(byName) != 6 {
+		t.Fatalf("got fields %v, want 6 (radios grouped, unplaced left out)", fieldNames(byName))
+	}
+
+	name := byName["name"]
+	if ft, _ := name.GetName("FT"); ft != "Tx" {
+		t.Errorf("name /FT = %s, want Tx", ft)
+	}
+	if v, _ := doc.Resolve(name["V"]); v == nil || string(v.Str) != "Ada" {
+		t.Errorf("name /V = %v, want Ada", v)
+	}
+	if ff, _ := name.GetInt("Ff"); ff != fieldRequired {
+		t.Errorf("name /Ff = %d, want required", ff)
+	}
+	if da, _ := doc.Resolve(name["DA"]); da == nil || string(da.Str) != "/Helv 15 Tf 0 g" {
+		t.Errorf("name /DA = %v, want the font size scaled to the widget", da)
+	}
+	if ap := resolveDict(doc, resolveDict(doc, name["AP"])["N"]); ap == nil {
+		t.Error("name has no normal appearance")
+	}
+	if ff, _ := byName["name_2"].GetInt("Ff"); ff != fieldReadOnly {
+		t.Errorf("name_2 /Ff = %d, want read-only", ff)
+	}
+	if ff, _ := byName["notes"].GetInt("Ff"); ff != fieldMultiline {
+		t.Errorf("notes /Ff = %d, want multiline", ff)
+	}
+	if v, _ := byName["agree"].GetName("V"); v != "Yes" {
+		t.Errorf("agree /V = %s, want Yes", v)
+	}
+
+	size := byName["size"]
+	if ff, _ := size.GetInt("Ff"); ff != fieldRadio|fieldNoToggleToOff {
+		t.Errorf("size /Ff = %d, want radio", ff)
+	}
+	if v, _ := size.GetName("V"); v != "m" {
+		t.Errorf("size /V = %s, want m", v)
+	}
+	if kids, _ := size.GetArray("Kids"); len(kids) != 2 {
+		t.Errorf("size has %d kids, want 2", len(kids))
+	} else if as, _ := resolveDict(doc, kids[0]).GetName("AS"); as != "Off" {
+		t.Errorf("first radio /AS = %s, want Off", as)
+	}
+
+	color := byName["color"]
+	if ff, _ := color.GetInt("Ff"); ff != fieldCombo {
+		t.Errorf("color /Ff = %d, want combo", ff)
+	}
+	if v, _ := doc.Resolve(color["V"]); v == nil || string(v.Str) != "g" {
+		t.Errorf("color /V = %v, want g", v)
+	}
+
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	annots, _ := page.Dict.GetArray("Annots")
+	links, widgets := 0, 0
+	for _, a := range annots {
+		switch st, _ := resolveDict(doc, a).GetName("Subtype"); st {
+		case "Link":
+			links++
+		case "Widget":
+			widgets++
+		}
+	}
+	if links != 1 || widgets != 7 {
+		t.Errorf("page has %d links and %d widgets, want the ordinary link and 7 widgets", links, widgets)
+	}
+}
+
+func fieldNames(m map[string]Dict) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+func TestAddFormFields_NoneFound(t *testing.T) {
+	pdf := buildFormInput(t, 0)
+	out, err := addFormFields(pdf, []formField{{Kind: "text", Name: "q"}})
+	if err != nil {
+		t.Fatalf("addFormFields: %v", err)
+	}
+	if !bytes.Equal(out, pdf) {
+		t.Error("document changed with no control printed")
+	}
+}
+
+func TestFieldFontSize(t *testing.T) {
+	tests := []struct {
+		f      formField
+		height float64
+		want   float64
+	}{
+		{formField{FontSize: 16, Height: 20}, 15, 12},
+		{formField{FontSize: 16}, 15, 12},
+		{formField{}, 15, 10},
+	}
+	for _, tt := range tests {
+		if got := fieldFontSize(tt.f, tt.height); got != tt.want {
+			t.Errorf("fieldFontSize(%+v, %v) = %v, want %v", tt.f, tt.height, got, tt.want)
+		}
+	}
+}
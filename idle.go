@@ -0,0 +1,31 @@
+package htmlpdf
+
+import "time"
+
+// armIdleTimer (re)starts the countdown to stopping the browser. The
+// caller must hold c.mu.
+func (c *Converter) armIdleTimer() {
+	if c.closed {
+		return
+	}
+	if c.idleTimer == nil {
+		c.idleTimer = time.AfterFunc(c.cfg.idleTimeout, c.stopIdleBrowser)
+		return
+	}
+	c.idleTimer.Reset(c.cfg.idleTimeout)
+}
+
+// stopIdleBrowser stops the browser if no conversion has started since
+// the timer fired. The next conversion relaunches it (see browser).
+func (c *Converter) stopIdleBrowser() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.busy > 0 || c.stopped {
+		return
+	}
+	c.cfg.logger.Info("htmlpdf: stopping idle browser", "idle", c.cfg.idleTimeout)
+	c.browserCancel()
+	c.allocCancel()
+	c.stopped = true
+	c.launchable.Store(true)
+}
@@ -0,0 +1,104 @@
+package htmlpdf
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// idleTestConverter returns a Converter with a stand-in browser that
+// stops after d of inactivity.
+func idleTestConverter(d time.Duration) *Converter {
+	cfg := defaultConfig()
+	cfg.idleTimeout = d
+	c := &Converter{cfg: cfg}
+	c.allocCtx, c.allocCancel = context.WithCancel(context.Background())
+	c.browserCtx, c.browserCancel = context.WithCancel(c.allocCtx)
+	c.browserDone.Store(c.browserCtx.Done())
+	return c
+}
+
+func TestIdleTimeout_StopsBrowser(t *testing.T) {
+	c := idleTestConverter(10 * time.Millisecond)
+	c.mu.Lock()
+	c.armIdleTimer()
+	c.mu.Unlock()
+
+	select {
+	case <-c.browserCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("browser was not stopped after the idle timeout")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stopped {
+		t.Error("stopped = false, want the browser marked as stopped for idleness")
+	}
+}
+
+func TestIdleTimeout_WaitsForConversions(t *testing.T) {
+	c := idleTestConverter(10 * time.Millisecond)
+	c.mu.Lock()
+	c.armIdleTimer()
+	c.mu.Unlock()
+
+	leave := c.enterBusy()
+	time.Sleep(50 * time.Millisecond)
+	if c.browserCtx.Err() != nil {
+		t.Fatal("browser was stopped during a conversion")
+	}
+	leave()
+	select {
+	case <-c.browserCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("browser was not stopped once the conversion ended")
+	}
+}
+
+func TestIdleTimeout_Close(t *testing.T) {
+	c := idleTestConverter(10 * time.Millisecond)
+	c.mu.Lock()
+	c.armIdleTimer()
+	c.mu.Unlock()
+	c.Close()
+
+	time.Sleep(50 * time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		t.Error("idle timer fired after Close")
+	}
+}
+
+func TestIdleTimeout_Disabled(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	c.enterBusy()()
+	if c.idleTimer != nil || c.busy != 0 {
+		t.Error("enterBusy armed an idle timer without WithIdleTimeout")
+	}
+}
+
+func TestBrowser_WaitsForRelaunch(t *testing.T) {
+	c := idleTestConverter(time.Hour)
+	done := make(chan struct{})
+	c.mu.Lock()
+	c.relaunch = done
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.browser(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("browser during a relaunch = %v, want the caller's deadline", err)
+	}
+
+	go func() {
+		c.mu.Lock()
+		c.relaunch = nil
+		close(done)
+		c.mu.Unlock()
+	}()
+	got, err := c.browser(context.Background())
+	if err != nil || got != c.browserCtx {
+		t.Errorf("browser after the relaunch = %v, %v, want the running browser", got, err)
+	}
+}
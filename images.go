@@ -0,0 +1,440 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+)
+
+// ImageCompression configures the re-encoding of the images embedded in
+// a generated PDF (see [PageConfig].Images). Chrome embeds images at their
+// full resolution, so a large screenshot scaled down on the page keeps
+// every one of its pixels.
+//
+// Only 8-bit grayscale and RGB images are re-encoded; others, such as
+// CMYK JPEGs, are kept as they are. An image whose re-encoding would be
+// larger than the original is also kept.
+type ImageCompression struct {
+	// MaxDPI, if positive, downsamples images whose resolution on the
+	// page exceeds it, e.g. 150 for reading on screen or 300 for print.
+	// An image drawn several times is measured where it is drawn
+	// largest.
+	MaxDPI float64
+
+	// JPEGQuality, if positive, re-encodes images as JPEG at this quality
+	// (1–100), e.g. 80. Transparency is kept, losslessly. Otherwise
+	// downsampled images are compressed losslessly.
+	JPEGQuality int
+}
+
+// isZero reports whether c leaves images unchanged.
+func (c ImageCompression) isZero() bool {
+	return c.MaxDPI <= 0 && c.JPEGQuality <= 0
+}
+
+// compressImages returns pdf rewritten with its images re-encoded as c
+// specifies. If no image changes, pdf is returned as is.
+func compressImages(pdf []byte, c ImageCompression) ([]byte, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("cannot recompress images in an encrypted document")
+	}
+	drawn, err := imageSizes(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := make(map[int]*Object)
+	for ref, size := range drawn {
+		obj, err := doc.ResolveRef(ref)
+		if err != nil || obj.Type != ObjStream {
+			continue
+		}
+		img, ok := decodeImage(doc, obj.Dict, obj.Stream)
+		if !ok {
+			continue
+		}
+		w, h := img.w, img.h
+		if c.MaxDPI > 0 {
+			w = min(w, int(math.Ceil(size.w/72*c.MaxDPI)))
+			h = min(h, int(math.Ceil(size.h/72*c.MaxDPI)))
+		}
+		if w == img.w && h == img.h && c.JPEGQuality <= 0 {
+			continue
+		}
+		if w != img.w || h != img.h {
+			img = img.resample(w, h)
+		}
+		enc, err := img.encode(obj.Dict, c.JPEGQuality)
+		if err != nil || len(enc.Stream) >= len(obj.Stream) {
+			continue
+		}
+
+		// A soft mask is scaled to match, but always kept lossless.
+		if sm := obj.Dict["SMask"]; sm != nil && sm.Type == ObjRef {
+			if mask := resampleMask(doc, sm.Ref, w, h); mask != nil {
+				replaced[sm.Ref.Number] = mask
+			}
+		}
+		replaced[ref.Number] = enc
+	}
+	if len(replaced) == 0 {
+		return pdf, nil
+	}
+	return rewritePDF(doc, replaced)
+}
+
+// resampleMask returns the soft mask ref refers to scaled to w×h pixels,
+// or nil if it is already that size or cannot be decoded.
+func resampleMask(doc *Document, ref Reference, w, h int) *Object {
+	obj, err := doc.ResolveRef(ref)
+	if err != nil || obj.Type != ObjStream {
+		return nil
+	}
+	mask, ok := decodeImage(doc, obj.Dict, obj.Stream)
+	if !ok || (mask.w == w && mask.h == h) {
+		return nil
+	}
+	enc, err := mask.resample(w, h).encode(obj.Dict, 0)
+	if err != nil {
+		return nil
+	}
+	return enc
+}
+
+// drawnSize is the size at which an image is drawn, in points.
+type drawnSize struct{ w, h float64 }
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n: m applied first, then n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// invert returns the inverse of m, which must not be singular.
+func (m matrix) invert() matrix {
+	det := m[0]*m[3] - m[1]*m[2]
+	return matrix{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det, (m[1]*m[4] - m[0]*m[5]) / det,
+	}
+}
+
+// formMatrix returns the /Matrix of a form XObject, mapping form space
+// to the space of the stream drawing it.
+func formMatrix(form Dict) matrix {
+	arr, ok := form.GetArray("Matrix")
+	if !ok || len(arr) != 6 {
+		return identity
+	}
+	var m matrix
+	for i, o := range arr {
+		m[i] = floatArg(o)
+	}
+	return m
+}
+
+// imageSizes returns the largest size each image XObject in doc is drawn
+// at, by following the transformation matrix through the page content
+// streams and the form XObjects they draw. Images that are not drawn
+// are not included.
+func imageSizes(doc *Document) (map[Reference]drawnSize, error) {
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[Reference]drawnSize)
+	for _, ref := range refs {
+		page, err := doc.ResolveRef(ref)
+		if err != nil || page.Type != ObjDict {
+			continue
+		}
+		res := page.Dict["Resources"]
+		if res == nil {
+			res = inheritedAttr(doc, page.Dict, "Resources")
+		}
+		content, err := doc.ContentStreams(page.Dict)
+		if err != nil {
+			continue
+		}
+		walkImages(doc, content, resolveDict(doc, res), identity, sizes, 0)
+	}
+	return sizes, nil
+}
+
+// walkImages interprets the graphics state operators of a content stream
+// drawn with the transformation ctm, recording the images it draws in
+// sizes.
+func walkImages(doc *Document, content []byte, res Dict, ctm matrix, sizes map[Reference]drawnSize, depth int) {
+	if depth > maxNesting {
+		return
+	}
+	xobjects := resolveDict(doc, res["XObject"])
+	var stack []matrix
+	var operands []*Object
+
+	p := NewParser(content, 0)
+	for p.pos < len(content) {
+		p.skipWhitespace()
+		if p.pos >= len(content) {
+			break
+		}
+		c := content[p.pos]
+		if c == '(' || c == '<' || c == '/' || c == '[' ||
+			c == '+' || c == '-' || c == '.' ||
+			(c >= '0' && c <= '9') {
+			obj, err := p.ParseObject()
+			if err != nil {
+				p.pos++
+				continue
+			}
+			operands = append(operands, obj)
+			continue
+		}
+		if !isOperatorStart(c) {
+			p.pos++
+			continue
+		}
+
+		switch p.readOperator() {
+		case "BI":
+			p.skipInlineImage()
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if n := len(stack); n > 0 {
+				ctm, stack = stack[n-1], stack[:n-1]
+			}
+		case "cm":
+			if len(operands) >= 6 {
+				var m matrix
+				for i, o := range operands[len(operands)-6:] {
+					m[i] = floatArg(o)
+				}
+				ctm = m.mul(ctm)
+			}
+		case "Do":
+			if len(operands) == 0 || operands[len(operands)-1].Type != ObjName {
+				break
+			}
+			ref := xobjects[operands[len(operands)-1].Name]
+			if ref == nil || ref.Type != ObjRef {
+				break
+			}
+			obj, err := doc.ResolveRef(ref.Ref)
+			if err != nil || obj.Type != ObjStream {
+				break
+			}
+			switch sub, _ := obj.Dict.GetName("Subtype"); sub {
+			case "Image":
+				size := drawnSize{math.Hypot(ctm[0], ctm[1]), math.Hypot(ctm[2], ctm[3])}
+				prev := sizes[ref.Ref]
+				sizes[ref.Ref] = drawnSize{max(prev.w, size.w), max(prev.h, size.h)}
+			case "Form":
+				form := formMatrix(obj.Dict).mul(ctm)
+				formRes := resolveDict(doc, obj.Dict["Resources"])
+				if formRes == nil {
+					formRes = res
+				}
+				data, err := DecompressStream(obj.Dict, obj.Stream)
+				if err == nil {
+					walkImages(doc, data, formRes, form, sizes, depth+1)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// rasterImage holds the decoded samples of an image, n components per
+// pixel, 8 bits each.
+type rasterImage struct {
+	w, h, n int
+	pix     []byte
+}
+
+// decodeImage decodes an image XObject, reporting false for images it
+// does not re-encode: masks, images with a Decode array, colour spaces
+// other than grayscale and RGB, and depths other than 8 bits.
+func decodeImage(doc *Document, dict Dict, data []byte) (*rasterImage, bool) {
+	if m := dict["ImageMask"]; m != nil && m.Bool {
+		return nil, false
+	}
+	if _, ok := dict["Decode"]; ok {
+		return nil, false
+	}
+	if bpc, _ := dict.GetInt("BitsPerComponent"); bpc != 8 {
+		return nil, false
+	}
+	w, _ := dict.GetInt("Width")
+	h, _ := dict.GetInt("Height")
+	n := colorComponents(doc, dict["ColorSpace"])
+	if w <= 0 || h <= 0 || n == 0 {
+		return nil, false
+	}
+	img := &rasterImage{w: int(w), h: int(h), n: n}
+
+	switch filter := dict["Filter"]; {
+	case filter == nil || filter.Type == ObjName && filter.Name == "FlateDecode":
+		raw, err := DecompressStream(dict, data)
+		if err != nil || len(raw) < img.w*img.h*n {
+			return nil, false
+		}
+		img.pix = raw[:img.w*img.h*n]
+	case filter.Type == ObjName && filter.Name == "DCTDecode":
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil || decoded.Bounds().Dx() != img.w || decoded.Bounds().Dy() != img.h {
+			return nil, false
+		}
+		if img.pix = samples(decoded, n); img.pix == nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	return img, true
+}
+
+// colorComponents returns the number of components of a grayscale or RGB
+// colour space, or 0 for other colour spaces.
+func colorComponents(doc *Document, cs *Object) int {
+	cs, err := doc.Resolve(cs)
+	if err != nil || cs == nil {
+		return 0
+	}
+	switch cs.Type {
+	case ObjName:
+		switch cs.Name {
+		case "DeviceGray":
+			return 1
+		case "DeviceRGB":
+			return 3
+		}
+	case ObjArray:
+		if len(cs.Array) == 2 && cs.Array[0].Type == ObjName && cs.Array[0].Name == "ICCBased" {
+			profile, err := doc.Resolve(cs.Array[1])
+			if err != nil || profile == nil || profile.Type != ObjStream {
+				return 0
+			}
+			if n, _ := profile.Dict.GetInt("N"); n == 1 || n == 3 {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
+
+// samples returns the pixels of img as n-component samples, or nil if
+// img is not grayscale or colour to match.
+func samples(img image.Image, n int) []byte {
+	b := img.Bounds()
+	pix := make([]byte, 0, b.Dx()*b.Dy()*n)
+	switch img := img.(type) {
+	case *image.Gray:
+		if n != 1 {
+			return nil
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := img.PixOffset(b.Min.X, y)
+			pix = append(pix, img.Pix[i:i+b.Dx()]...)
+		}
+	case *image.YCbCr:
+		if n != 3 {
+			return nil
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				pix = append(pix, byte(r>>8), byte(g>>8), byte(bl>>8))
+			}
+		}
+	default:
+		return nil
+	}
+	return pix
+}
+
+// resample returns img scaled down to w×h pixels, averaging the source
+// pixels each one covers.
+func (img *rasterImage) resample(w, h int) *rasterImage {
+	out := &rasterImage{w: w, h: h, n: img.n, pix: make([]byte, w*h*img.n)}
+	for y := 0; y < h; y++ {
+		y0, y1 := y*img.h/h, max((y+1)*img.h/h, y*img.h/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*img.w/w, max((x+1)*img.w/w, x*img.w/w+1)
+			count := (y1 - y0) * (x1 - x0)
+			for k := 0; k < img.n; k++ {
+				sum := 0
+				for sy := y0; sy < y1; sy++ {
+					row := sy * img.w * img.n
+					for sx := x0; sx < x1; sx++ {
+						sum += int(img.pix[row+sx*img.n+k])
+					}
+				}
+				out.pix[(y*w+x)*img.n+k] = byte((sum + count/2) / count)
+			}
+		}
+	}
+	return out
+}
+
+// encode returns an image XObject holding img, with the entries of dict
+// that do not describe the encoding kept. Images are encoded as JPEG at
+// the given quality if it is positive, and losslessly otherwise.
+func (img *rasterImage) encode(dict Dict, quality int) (*Object, error) {
+	d := make(Dict, len(dict))
+	for k, v := range dict {
+		switch k {
+		case "Filter", "DecodeParms", "Length":
+		default:
+			d[k] = v
+		}
+	}
+	d["Width"], d["Height"] = intObj(img.w), intObj(img.h)
+
+	var buf bytes.Buffer
+	if quality > 0 {
+		var src image.Image
+		rect := image.Rect(0, 0, img.w, img.h)
+		if img.n == 1 {
+			src = &image.Gray{Pix: img.pix, Stride: img.w, Rect: rect}
+		} else {
+			rgba := image.NewRGBA(rect)
+			for i, j := 0, 0; i < len(img.pix); i, j = i+3, j+4 {
+				copy(rgba.Pix[j:j+3], img.pix[i:i+3])
+				rgba.Pix[j+3] = 0xff
+			}
+			src = rgba
+		}
+		if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: min(quality, 100)}); err != nil {
+			return nil, err
+		}
+		d["Filter"] = nameObj("DCTDecode")
+	} else {
+		zw, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+		zw.Write(img.pix)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		d["Filter"] = nameObj("FlateDecode")
+	}
+	return &Object{Type: ObjStream, Dict: d, Stream: buf.Bytes()}, nil
+}
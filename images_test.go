@@ -0,0 +1,180 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image/jpeg"
+	"math"
+	"testing"
+)
+
+// noise returns n pseudo-random bytes, which compress poorly.
+func noise(n int) []byte {
+	b := make([]byte, n)
+	x := uint32(1)
+	for i := range b {
+		x = x*1664525 + 1013904223
+		b[i] = byte(x >> 24)
+	}
+	return b
+}
+
+func flate(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// buildImagePDF returns a one-page PDF drawing a w×h RGB image with a
+// soft mask through content, which refers to it as /Im1.
+func buildImagePDF(t *testing.T, w, h int, content string) []byte {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	u, _ := newPDFUpdate(doc)
+	mask := u.add(&Object{Type: ObjStream, Dict: Dict{
+		"Type": nameObj("XObject"), "Subtype": nameObj("Image"),
+		"Width": intObj(w), "Height": intObj(h),
+		"ColorSpace": nameObj("DeviceGray"), "BitsPerComponent": intObj(8),
+		"Filter": nameObj("FlateDecode"),
+	}, Stream: flate(bytes.Repeat([]byte{0xff}, w*h))})
+	img := u.add(&Object{Type: ObjStream, Dict: Dict{
+		"Type": nameObj("XObject"), "Subtype": nameObj("Image"),
+		"Width": intObj(w), "Height": intObj(h),
+		"ColorSpace": nameObj("DeviceRGB"), "BitsPerComponent": intObj(8),
+		"Filter": nameObj("FlateDecode"), "SMask": refObj(mask),
+	}, Stream: flate(noise(w * h * 3))})
+	contents := u.add(&Object{Type: ObjStream, Dict: Dict{}, Stream: []byte(content)})
+
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	page.Dict["Contents"] = refObj(contents)
+	page.Dict["Resources"] = dictObj(Dict{"XObject": dictObj(Dict{"Im1": refObj(img)})})
+	u.set(Reference{Number: 3}, page)
+	return u.bytes()
+}
+
+// pageImage returns the image and soft mask the first page of pdf
+// draws as /Im1.
+func pageImage(t *testing.T, pdf []byte) (img, mask *Object) {
+	t.Helper()
+	doc := mustLoad(t, pdf)
+	pages, err := doc.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	xobjects := resolveDict(doc, resolveDict(doc, pages[0]["Resources"])["XObject"])
+	img, err = doc.Resolve(xobjects["Im1"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask, err = doc.Resolve(img.Dict["SMask"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img, mask
+}
+
+func TestCompressImages_Downsample(t *testing.T) {
+	// 400×200 pixels drawn at 100×50 points is 288 DPI.
+	pdf := buildImagePDF(t, 400, 200, "q 2 0 0 2 0 0 cm q 50 0 0 25 10 10 cm /Im1 Do Q Q")
+	out, err := compressImages(pdf, ImageCompression{MaxDPI: 144})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) >= len(pdf) {
+		t.Errorf("output is %d bytes, want fewer than the input's %d", len(out), len(pdf))
+	}
+
+	img, mask := pageImage(t, out)
+	for _, o := range []*Object{img, mask} {
+		w, _ := o.Dict.GetInt("Width")
+		h, _ := o.Dict.GetInt("Height")
+		if w != 200 || h != 100 {
+			t.Errorf("image is %d×%d, want 200×100", w, h)
+		}
+		if f, _ := o.Dict.GetName("Filter"); f != "FlateDecode" {
+			t.Errorf("Filter = %q, want FlateDecode", f)
+		}
+	}
+	raw, err := DecompressStream(img.Dict, img.Stream)
+	if err != nil || len(raw) != 200*100*3 {
+		t.Errorf("decoded image is %d bytes (%v), want %d", len(raw), err, 200*100*3)
+	}
+}
+
+func TestCompressImages_JPEG(t *testing.T) {
+	pdf := buildImagePDF(t, 64, 32, "q 64 0 0 32 0 0 cm /Im1 Do Q")
+	out, err := compressImages(pdf, ImageCompression{JPEGQuality: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, mask := pageImage(t, out)
+	if f, _ := img.Dict.GetName("Filter"); f != "DCTDecode" {
+		t.Fatalf("Filter = %q, want DCTDecode", f)
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(img.Stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 64 || b.Dy() != 32 {
+		t.Errorf("JPEG is %v, want 64×32", b)
+	}
+	if f, _ := mask.Dict.GetName("Filter"); f != "FlateDecode" {
+		t.Errorf("soft mask Filter = %q, want it kept lossless", f)
+	}
+}
+
+func TestCompressImages_Unchanged(t *testing.T) {
+	// 100 pixels drawn across 100 points is 72 DPI.
+	pdf := buildImagePDF(t, 100, 50, "q 100 0 0 50 0 0 cm /Im1 Do Q")
+	out, err := compressImages(pdf, ImageCompression{MaxDPI: 150})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, pdf) {
+		t.Error("document rewritten though no image exceeds MaxDPI")
+	}
+}
+
+func TestImageSizes(t *testing.T) {
+	doc := mustLoad(t, buildImagePDF(t, 10, 10,
+		"q 2 0 0 2 0 0 cm q 0 30 -40 0 0 0 cm /Im1 Do Q Q q 10 0 0 10 0 0 cm /Im1 Do Q"))
+	sizes, err := imageSizes(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 1 {
+		t.Fatalf("got %d images, want 1", len(sizes))
+	}
+	for _, s := range sizes {
+		// The rotated drawing is the largest: 60 wide and 80 high.
+		if math.Abs(s.w-60) > 1e-9 || math.Abs(s.h-80) > 1e-9 {
+			t.Errorf("size = %v, want 60×80", s)
+		}
+	}
+}
+
+func TestRasterImage_Resample(t *testing.T) {
+	img := &rasterImage{w: 4, h: 2, n: 1, pix: []byte{
+		0, 10, 20, 30,
+		40, 50, 60, 70,
+	}}
+	got := img.resample(2, 1)
+	want := []byte{25, 45}
+	if !bytes.Equal(got.pix, want) {
+		t.Errorf("resample = %v, want %v", got.pix, want)
+	}
+}
+
+func TestMatrixMul(t *testing.T) {
+	scale := matrix{2, 0, 0, 3, 0, 0}
+	translate := matrix{1, 0, 0, 1, 5, 7}
+	// Translating then scaling moves the origin to (10, 21).
+	if got, want := translate.mul(scale), (matrix{2, 0, 0, 3, 10, 21}); got != want {
+		t.Errorf("mul = %v, want %v", got, want)
+	}
+	if got := scale.mul(identity); got != scale {
+		t.Errorf("mul by identity = %v, want %v", got, scale)
+	}
+}
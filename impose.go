@@ -0,0 +1,190 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+)
+
+// NUpOptions configures [Impose].
+type NUpOptions struct {
+	// Cols and Rows give the grid of pages on each sheet: 2×1 places two
+	// pages side by side, 2×2 four pages. Both must be at least 1.
+	Cols, Rows int
+
+	// Gap is the space between the pages and around the edge of the
+	// sheet, in centimeters.
+	Gap float64
+
+	// Sheet is the size of the output pages. Zero sizes the sheets to
+	// hold the grid of pages at their original size.
+	Sheet PageSize
+}
+
+// Impose lays the pages of doc out n-up onto sheets, such as two slides
+// per page for handouts. Pages fill each sheet left to right, then top to
+// bottom, scaled uniformly and centred to fit their cell of the grid, and
+// upright as they are displayed.
+//
+// The result is a new document whose sheets draw the original pages as
+// form XObjects. Annotations such as links, the outline and other
+// document-level structures are not carried over.
+func Impose(doc *Document, opts NUpOptions) ([]byte, error) {
+	if opts.Cols < 1 || opts.Rows < 1 {
+		return nil, fmt.Errorf("n-up grid %d×%d needs at least one column and row", opts.Cols, opts.Rows)
+	}
+	if opts.Gap < 0 || opts.Sheet.Width < 0 || opts.Sheet.Height < 0 {
+		return nil, errors.New("n-up gap and sheet size must not be negative")
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, errors.New("cannot impose an encrypted document")
+	}
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, errors.New("document has no pages")
+	}
+
+	m := &merger{version: "1.4"}
+	if v := doc.Version(); v > m.version && len(v) == 3 {
+		m.version = v
+	}
+	catalogRef := m.reserve()
+	pagesRef := m.reserve()
+	c := &objectCopier{doc: doc, m: m, refs: make(map[Reference]Reference), pagesRef: pagesRef}
+
+	// Turn each page into a form XObject, with the matrix that sets it
+	// upright with its lower left corner at the origin.
+	type placed struct {
+		form    Reference
+		upright matrix
+		w, h    float64 // displayed size, in points
+	}
+	pages := make([]placed, len(refs))
+	var maxW, maxH float64
+	for i, ref := range refs {
+		page, err := doc.ResolveRef(ref)
+		if err != nil || page.Type != ObjDict {
+			return nil, fmt.Errorf("page %d: not a dictionary", i+1)
+		}
+		form, err := pageForm(c, page.Dict)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		p := placed{form: m.reserve()}
+		m.set(p.form, form)
+		p.upright, p.w, p.h = uprightMatrix(pageBox(doc, page.Dict), pageRotation(doc, page.Dict))
+		maxW, maxH = max(maxW, p.w), max(maxH, p.h)
+		pages[i] = p
+	}
+
+	gap := cmToInches(opts.Gap) * 72
+	sheetW, sheetH := cmToInches(opts.Sheet.Width)*72, cmToInches(opts.Sheet.Height)*72
+	if sheetW == 0 || sheetH == 0 {
+		sheetW = float64(opts.Cols)*maxW + float64(opts.Cols+1)*gap
+		sheetH = float64(opts.Rows)*maxH + float64(opts.Rows+1)*gap
+	}
+	cellW := (sheetW - float64(opts.Cols+1)*gap) / float64(opts.Cols)
+	cellH := (sheetH - float64(opts.Rows+1)*gap) / float64(opts.Rows)
+	if cellW <= 0 || cellH <= 0 {
+		return nil, errors.New("n-up gap leaves no room for the pages")
+	}
+
+	perSheet := opts.Cols * opts.Rows
+	var kids []*Object
+	for first := 0; first < len(pages); first += perSheet {
+		var content bytes.Buffer
+		xobjects := Dict{}
+		for k, p := range pages[first:min(first+perSheet, len(pages))] {
+			col, row := k%opts.Cols, k/opts.Cols
+			scale := min(cellW/p.w, cellH/p.h)
+			x := gap + float64(col)*(cellW+gap) + (cellW-p.w*scale)/2
+			y := sheetH - float64(row+1)*(cellH+gap) + (cellH-p.h*scale)/2
+			place := p.upright.mul(matrix{scale, 0, 0, scale, x, y})
+
+			name := fmt.Sprintf("P%d", k+1)
+			xobjects[name] = refObj(p.form)
+			content.WriteString("q ")
+			writeReals(&content, place[:]...)
+			content.WriteString(" cm ")
+			writeName(&content, name)
+			content.WriteString(" Do Q\n")
+		}
+		contentRef := m.reserve()
+		m.set(contentRef, &Object{Type: ObjStream, Dict: Dict{}, Stream: content.Bytes()})
+		sheet := m.reserve()
+		m.set(sheet, dictObj(Dict{
+			"Type":      nameObj("Page"),
+			"Parent":    refObj(pagesRef),
+			"MediaBox":  arrayObj(intObj(0), intObj(0), realObj(sheetW), realObj(sheetH)),
+			"Resources": dictObj(Dict{"XObject": dictObj(xobjects)}),
+			"Contents":  refObj(contentRef),
+		}))
+		kids = append(kids, refObj(sheet))
+	}
+
+	m.set(pagesRef, dictObj(Dict{
+		"Type":  nameObj("Pages"),
+		"Kids":  arrayObj(kids...),
+		"Count": intObj(len(kids)),
+	}))
+	m.set(catalogRef, dictObj(Dict{
+		"Type":  nameObj("Catalog"),
+		"Pages": refObj(pagesRef),
+	}))
+	return m.bytes(catalogRef), nil
+}
+
+// pageForm returns a form XObject drawing page, with its resources copied
+// by c.
+func pageForm(c *objectCopier, page Dict) (*Object, error) {
+	content, err := c.doc.ContentStreams(page)
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(content)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	box := pageBox(c.doc, page)
+	form := Dict{
+		"Type":    nameObj("XObject"),
+		"Subtype": nameObj("Form"),
+		"BBox":    arrayObj(realObj(box[0]), realObj(box[1]), realObj(box[2]), realObj(box[3])),
+		"Filter":  nameObj("FlateDecode"),
+	}
+	if res := c.copy(pageAttr(c.doc, page, "Resources"), 0); res != nil {
+		form["Resources"] = res
+	}
+	return &Object{Type: ObjStream, Dict: form, Stream: compressed.Bytes()}, nil
+}
+
+// pageRotation returns the page's /Rotate as 0, 90, 180 or 270.
+func pageRotation(doc *Document, page Dict) int {
+	rot, err := doc.Resolve(pageAttr(doc, page, "Rotate"))
+	if err != nil || rot == nil || rot.Type != ObjInt {
+		return 0
+	}
+	return (int(rot.Int)%360 + 360) % 360
+}
+
+// uprightMatrix returns the matrix that maps the page region box, shown
+// turned clockwise by rotate degrees, to an upright w×h rectangle at the
+// origin.
+func uprightMatrix(box [4]float64, rotate int) (m matrix, w, h float64) {
+	x0, y0, x1, y1 := box[0], box[1], box[2], box[3]
+	switch rotate {
+	case 90:
+		return matrix{0, -1, 1, 0, -y0, x1}, y1 - y0, x1 - x0
+	case 180:
+		return matrix{-1, 0, 0, -1, x1, y1}, x1 - x0, y1 - y0
+	case 270:
+		return matrix{0, 1, -1, 0, y1, -x0}, y1 - y0, x1 - x0
+	}
+	return matrix{1, 0, 0, 1, -x0, -y0}, x1 - x0, y1 - y0
+}
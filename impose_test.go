@@ -0,0 +1,115 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestImpose(t *testing.T) {
+	src := mustLoad(t, buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 72 720 Td (First) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Second) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Third) Tj ET"),
+	}))
+	out, err := Impose(src, NUpOptions{Cols: 2, Rows: 1})
+	if err != nil {
+		t.Fatalf("Impose: %v", err)
+	}
+	doc := mustLoad(t, out)
+	sheets, err := doc.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 2 {
+		t.Fatalf("got %d sheets, want 2", len(sheets))
+	}
+	if info := doc.GetPageInfo(sheets[0]); info.Width != 1224 || info.Height != 792 {
+		t.Errorf("sheet is %g×%g, want two pages side by side: 1224×792", info.Width, info.Height)
+	}
+
+	content, err := doc.ContentStreams(sheets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"q 1 0 0 1 0 0 cm /P1 Do Q", "q 1 0 0 1 612 0 cm /P2 Do Q"} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("sheet content %q lacks %q", content, want)
+		}
+	}
+	if content, _ := doc.ContentStreams(sheets[1]); bytes.Contains(content, []byte("/P2")) {
+		t.Errorf("last sheet content %q places more than the one remaining page", content)
+	}
+
+	// The forms hold the pages' content and resources.
+	xobjects := resolveDict(doc, resolveDict(doc, sheets[0]["Resources"])["XObject"])
+	form, err := doc.Resolve(xobjects["P2"])
+	if err != nil || form.Type != ObjStream {
+		t.Fatalf("P2 = %v, %v", form, err)
+	}
+	data, err := DecompressStream(form.Dict, form.Stream)
+	if err != nil || !bytes.Contains(data, []byte("(Second) Tj")) {
+		t.Errorf("form content = %q, %v", data, err)
+	}
+	if _, ok := resolveDict(doc, resolveDict(doc, form.Dict["Resources"])["Font"])["F1"]; !ok {
+		t.Error("form lost the page's font")
+	}
+}
+
+func TestImpose_Sheet(t *testing.T) {
+	src := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	// Four Letter pages on an A4 sheet with a 1 cm gap.
+	out, err := Impose(src, NUpOptions{Cols: 2, Rows: 2, Gap: 1, Sheet: A4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := mustLoad(t, out)
+	sheets, _ := doc.Pages()
+	if info := doc.GetPageInfo(sheets[0]); int(info.Width) != 595 || int(info.Height) != 841 {
+		t.Errorf("sheet is %g×%g, want A4", info.Width, info.Height)
+	}
+	// The cell is (595.28 - 3×28.35)/2 = 255.12 points wide, so the page
+	// is scaled by 255.12/612 = 0.4169 and centred in the top left cell.
+	content, _ := doc.ContentStreams(sheets[0])
+	if !bytes.Contains(content, []byte("q 0.4169 0 0 0.4169 28.3465 459.2543 cm /P1 Do Q")) {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestUprightMatrix(t *testing.T) {
+	box := [4]float64{0, 0, 600, 800}
+	for _, tt := range []struct {
+		rotate int
+		w, h   float64
+	}{{0, 600, 800}, {90, 800, 600}, {180, 600, 800}, {270, 800, 600}} {
+		m, w, h := uprightMatrix(box, tt.rotate)
+		if w != tt.w || h != tt.h {
+			t.Errorf("rotate %d: size %g×%g, want %g×%g", tt.rotate, w, h, tt.w, tt.h)
+		}
+		// The box's corners map onto the upright rectangle.
+		for _, p := range [][2]float64{{0, 0}, {600, 0}, {0, 800}, {600, 800}} {
+			x := m[0]*p[0] + m[2]*p[1] + m[4]
+			y := m[1]*p[0] + m[3]*p[1] + m[5]
+			if x < 0 || x > w || y < 0 || y > h {
+				t.Errorf("rotate %d: corner %v maps to (%g, %g), outside %g×%g", tt.rotate, p, x, y, w, h)
+			}
+		}
+	}
+	// Rotated 90° clockwise, the top left corner is shown top right.
+	m, w, h := uprightMatrix(box, 90)
+	if x, y := m[2]*800+m[4], m[3]*800+m[5]; x != w || y != h {
+		t.Errorf("top left maps to (%g, %g), want (%g, %g)", x, y, w, h)
+	}
+}
+
+func TestImpose_Errors(t *testing.T) {
+	src := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	for _, opts := range []NUpOptions{
+		{Cols: 0, Rows: 1},
+		{Cols: 2, Rows: 2, Gap: -1},
+		{Cols: 2, Rows: 1, Gap: 20, Sheet: A4},
+	} {
+		if _, err := Impose(src, opts); err == nil {
+			t.Errorf("Impose(%+v): expected error", opts)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package htmlpdf
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// assetOrigin is the synthetic origin under which in-memory documents and
+// their assets are served through request interception. The .invalid TLD
+// is reserved (RFC 2606), so it can never shadow a real host.
+const assetOrigin = "http://htmlpdf.invalid/"
+
+// source describes what a conversion navigates to.
+type source struct {
+	// url is the navigation target. It is ignored when document is set.
+	url string
+
+	// document, if non-nil, is served as the page at assetOrigin.
+	document []byte
+
+	// assets are served relative to assetOrigin, keyed by cleaned
+	// absolute path (see assetPath).
+	assets map[string][]byte
+
+	// sink, if non-nil, receives the PDF as it is printed instead of
+	// the Result (see ConvertHTMLTo).
+	sink io.Writer
+
+	// shot, if non-nil, captures a screenshot instead of printing a PDF
+	// (see Converter.Screenshot).
+	shot *ScreenshotOptions
+
+	// blockFiles fails file: subresource requests, for documents that
+	// are loaded from a file: URL but are not trusted to read the disk
+	// (see ConvertReader).
+	blockFiles bool
+
+	// routes, if set, are further URLs of a single-page app shown after
+	// url, its first element, and printed into the same PDF (see
+	// ConvertRoutes).
+	routes []string
+}
+
+// target returns the URL the tab navigates to.
+func (s source) target() string {
+	if s.document != nil {
+		return assetOrigin
+	}
+	return s.url
+}
+
+// intercepts reports whether the source needs request interception.
+func (s source) intercepts() bool {
+	return s.document != nil || len(s.assets) > 0
+}
+
+// isFile reports whether the source is loaded from a file: URL. Only such
+// pages can load other files; Chrome refuses file: subresources to pages
+// from other schemes, including in-memory documents.
+func (s source) isFile() bool {
+	return s.document == nil && strings.HasPrefix(s.url, "file:")
+}
+
+// assetPath normalises an asset key or request path so that "logo.png",
+// "./logo.png" and "/logo.png" all refer to the same asset.
+func assetPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// newAssetMap returns assets re-keyed by assetPath.
+func newAssetMap(assets map[string][]byte) map[string][]byte {
+	m := make(map[string][]byte, len(assets))
+	for k, v := range assets {
+		m[assetPath(k)] = v
+	}
+	return m
+}
+
+// interceptor answers paused Fetch requests for a single tab.
+type interceptor struct {
+	src          source
+	auth         *Credentials
+	authOrigin   string                        // origin credentials are offered to
+	blockedTypes map[network.ResourceType]bool // keys lower-cased
+	blockedHosts []string
+	blockFiles   bool // fail file: requests other than the document
+	policy       *urlPolicy
+
+	mu         sync.Mutex
+	challenged map[fetch.RequestID]bool // requests already answered with auth
+}
+
+// newInterceptor returns the interceptor a conversion needs, or nil if
+// no request has to be intercepted.
+func newInterceptor(cfg *converterConfig, src source, o ConvertOptions) *interceptor {
+	blockFiles := (src.blockFiles || cfg.blockLocalFiles) && src.isFile()
+	if !src.intercepts() && o.Credentials == nil && !blockFiles && cfg.policy == nil &&
+		len(cfg.blockedTypes) == 0 && len(cfg.blockedHosts) == 0 {
+		return nil
+	}
+	ic := &interceptor{
+		src:          src,
+		auth:         o.Credentials,
+		authOrigin:   urlOrigin(src.url),
+		blockedHosts: cfg.blockedHosts,
+		blockFiles:   blockFiles,
+		policy:       cfg.policy,
+		challenged:   make(map[fetch.RequestID]bool),
+	}
+	if len(cfg.blockedTypes) > 0 {
+		ic.blockedTypes = make(map[network.ResourceType]bool, len(cfg.blockedTypes))
+		for _, t := range cfg.blockedTypes {
+			ic.blockedTypes[network.ResourceType(strings.ToLower(t))] = true
+		}
+	}
+	return ic
+}
+
+// enable returns the action that turns on request interception for the
+// requests the interceptor needs to see.
+func (ic *interceptor) enable() chromedp.Action {
+	pattern := assetOrigin + "*"
+	if ic.auth != nil || ic.blockFiles || ic.policy != nil || len(ic.blockedTypes) > 0 || len(ic.blockedHosts) > 0 {
+		// Blocking needs to see every request, and auth challenges are
+		// only reported for intercepted ones.
+		pattern = "*"
+	}
+	return fetch.Enable().
+		WithPatterns([]*fetch.RequestPattern{
+			{URLPattern: pattern, RequestStage: fetch.RequestStageRequest},
+		}).
+		WithHandleAuthRequests(ic.auth != nil)
+}
+
+// listen returns a chromedp target listener that answers paused requests
+// on tabCtx. Responses are decided and sent from a new goroutine because
+// listeners must not block, and the URL policy may resolve host names.
+func (ic *interceptor) listen(tabCtx context.Context) func(ev any) {
+	return func(ev any) {
+		switch ev.(type) {
+		case *fetch.EventRequestPaused, *fetch.EventAuthRequired:
+		default:
+			return
+		}
+		go func() {
+			var action chromedp.Action
+			switch e := ev.(type) {
+			case *fetch.EventRequestPaused:
+				action = ic.respond(tabCtx, e)
+			case *fetch.EventAuthRequired:
+				action = ic.authenticate(e)
+			}
+			c := chromedp.FromContext(tabCtx)
+			// Errors mean the tab is already closing; nothing to do.
+			_ = action.Do(cdp.WithExecutor(tabCtx, c.Target))
+		}()
+	}
+}
+
+// authenticate answers an authentication challenge. Server challenges
+// from the origin of the converted URL are answered with the configured
+// credentials once per request; a repeated challenge means they were
+// rejected, and is cancelled so that the 401 response is rendered rather
+// than retried forever. Challenges from other origins, such as those of
+// third-party resources or redirect targets, are cancelled. Proxy
+// challenges are left to the browser.
+func (ic *interceptor) authenticate(e *fetch.EventAuthRequired) chromedp.Action {
+	resp := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+	if ic.auth != nil && e.AuthChallenge != nil && e.AuthChallenge.Source != fetch.AuthChallengeSourceProxy {
+		ic.mu.Lock()
+		retry := ic.challenged[e.RequestID]
+		ic.challenged[e.RequestID] = true
+		ic.mu.Unlock()
+
+		if retry || ic.authOrigin == "" || urlOrigin(e.AuthChallenge.Origin) != ic.authOrigin {
+			resp.Response = fetch.AuthChallengeResponseResponseCancelAuth
+		} else {
+			resp.Response = fetch.AuthChallengeResponseResponseProvideCredentials
+			resp.Username = ic.auth.Username
+			resp.Password = ic.auth.Password
+		}
+	}
+	return fetch.ContinueWithAuth(e.RequestID, resp)
+}
+
+// urlOrigin returns the origin of an http or https URL, such as
+// "https://example.com:8443", with the default port left out, or "" for
+// other URLs.
+func urlOrigin(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	scheme, host, port := strings.ToLower(u.Scheme), strings.ToLower(u.Hostname()), u.Port()
+	switch {
+	case scheme == "http" && port == "80", scheme == "https" && port == "443":
+		port = ""
+	case scheme != "http" && scheme != "https":
+		return ""
+	}
+	if port != "" {
+		return scheme + "://" + net.JoinHostPort(host, port)
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return scheme + "://" + host
+}
+
+// respond decides how to answer a paused request.
+func (ic *interceptor) respond(ctx context.Context, e *fetch.EventRequestPaused) chromedp.Action {
+	u, err := url.Parse(e.Request.URL)
+	if err != nil {
+		return fetch.ContinueRequest(e.RequestID)
+	}
+	if u.Scheme == "file" && ic.blockFiles && !ic.isDocument(u) {
+		return fetch.FailRequest(e.RequestID, network.ErrorReasonAccessDenied)
+	}
+	if u.Scheme+"://"+u.Host+"/" != assetOrigin {
+		if ic.blocked(u, e.ResourceType) || !ic.policy.allows(ctx, u) {
+			return fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient)
+		}
+		return fetch.ContinueRequest(e.RequestID)
+	}
+
+	p := assetPath(u.Path)
+	if p == "/" && ic.src.document != nil {
+		return fulfill(e.RequestID, "text/html; charset=utf-8", ic.src.document)
+	}
+	if data, ok := ic.src.assets[p]; ok {
+		return fulfill(e.RequestID, assetContentType(p, data), data)
+	}
+	return fetch.FulfillRequest(e.RequestID, http.StatusNotFound)
+}
+
+// isDocument reports whether the file: URL u is the document being
+// converted.
+func (ic *interceptor) isDocument(u *url.URL) bool {
+	doc, err := url.Parse(ic.src.url)
+	return err == nil && doc.Path == u.Path
+}
+
+// blocked reports whether a request for u of resource type rt is blocked
+// by the converter's blocking policy.
+func (ic *interceptor) blocked(u *url.URL, rt network.ResourceType) bool {
+	if ic.blockedTypes[network.ResourceType(strings.ToLower(string(rt)))] {
+		return true
+	}
+	host := u.Hostname()
+	for _, p := range ic.blockedHosts {
+		if ok, _ := path.Match(p, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fulfill answers a paused request with a 200 response carrying data.
+func fulfill(id fetch.RequestID, contentType string, data []byte) chromedp.Action {
+	return fetch.FulfillRequest(id, http.StatusOK).
+		WithResponseHeaders([]*fetch.HeaderEntry{
+			{Name: "Content-Type", Value: contentType},
+		}).
+		WithBody(base64.StdEncoding.EncodeToString(data))
+}
+
+// assetContentType guesses the MIME type of an asset from its extension,
+// falling back to content sniffing (which recognises common font formats).
+func assetContentType(p string, data []byte) string {
+	if ct := mime.TypeByExtension(path.Ext(p)); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,229 @@
+package htmlpdf
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+func pausedRequest(rawURL string) *fetch.EventRequestPaused {
+	return &fetch.EventRequestPaused{
+		RequestID: "req-1",
+		Request:   &network.Request{URL: rawURL},
+	}
+}
+
+func TestAssetPath(t *testing.T) {
+	for _, p := range []string{"img/logo.png", "./img/logo.png", "/img/logo.png", "img/../img/logo.png"} {
+		if got := assetPath(p); got != "/img/logo.png" {
+			t.Errorf("assetPath(%q) = %q, want /img/logo.png", p, got)
+		}
+	}
+}
+
+func TestInterceptor_ServesDocumentAndAssets(t *testing.T) {
+	ic := &interceptor{src: source{
+		document: []byte("<h1>doc</h1>"),
+		assets:   newAssetMap(map[string][]byte{"css/site.css": []byte("h1{}")}),
+	}}
+
+	doc, ok := ic.respond(context.Background(), pausedRequest(assetOrigin)).(*fetch.FulfillRequestParams)
+	if !ok {
+		t.Fatal("document request was not fulfilled")
+	}
+	if got, _ := base64.StdEncoding.DecodeString(doc.Body); string(got) != "<h1>doc</h1>" {
+		t.Errorf("document body = %q", got)
+	}
+
+	css, ok := ic.respond(context.Background(), pausedRequest(assetOrigin+"css/site.css?v=2")).(*fetch.FulfillRequestParams)
+	if !ok {
+		t.Fatal("asset request was not fulfilled")
+	}
+	if css.ResponseCode != http.StatusOK {
+		t.Errorf("asset status = %d, want 200", css.ResponseCode)
+	}
+	if ct := css.ResponseHeaders[0].Value; !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("asset Content-Type = %q, want text/css", ct)
+	}
+}
+
+func TestInterceptor_MissingAssetIs404(t *testing.T) {
+	ic := &interceptor{src: source{document: []byte("x")}}
+
+	res, ok := ic.respond(context.Background(), pausedRequest(assetOrigin+"missing.png")).(*fetch.FulfillRequestParams)
+	if !ok {
+		t.Fatal("missing asset was not fulfilled")
+	}
+	if res.ResponseCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", res.ResponseCode)
+	}
+}
+
+func TestInterceptor_ContinuesOtherOrigins(t *testing.T) {
+	ic := &interceptor{src: source{document: []byte("x")}}
+
+	if _, ok := ic.respond(context.Background(), pausedRequest("https://example.com/a.png")).(*fetch.ContinueRequestParams); !ok {
+		t.Error("request to another origin was not continued")
+	}
+}
+
+func TestAssetContentType_SniffsFonts(t *testing.T) {
+	woff2 := []byte("wOF2\x00\x01\x00\x00")
+	if got := assetContentType("/fonts/brand.woff2", woff2); got != "font/woff2" {
+		t.Errorf("assetContentType = %q, want font/woff2", got)
+	}
+}
+
+func TestInterceptor_Authenticate(t *testing.T) {
+	ic := newInterceptor(&converterConfig{}, source{url: "https://example.com"}, ConvertOptions{
+		Credentials: &Credentials{Username: "alice", Password: "s3cret"},
+	})
+	challenge := &fetch.EventAuthRequired{
+		RequestID:     "req-1",
+		AuthChallenge: &fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "https://example.com", Scheme: "basic"},
+	}
+
+	first := ic.authenticate(challenge).(*fetch.ContinueWithAuthParams)
+	if r := first.AuthChallengeResponse; r.Response != fetch.AuthChallengeResponseResponseProvideCredentials ||
+		r.Username != "alice" || r.Password != "s3cret" {
+		t.Errorf("first challenge response = %+v, want credentials", r)
+	}
+
+	// Rejected credentials are not offered again.
+	retry := ic.authenticate(challenge).(*fetch.ContinueWithAuthParams)
+	if r := retry.AuthChallengeResponse.Response; r != fetch.AuthChallengeResponseResponseCancelAuth {
+		t.Errorf("repeated challenge response = %s, want CancelAuth", r)
+	}
+
+	proxy := ic.authenticate(&fetch.EventAuthRequired{
+		RequestID:     "req-2",
+		AuthChallenge: &fetch.AuthChallenge{Source: fetch.AuthChallengeSourceProxy},
+	}).(*fetch.ContinueWithAuthParams)
+	if r := proxy.AuthChallengeResponse.Response; r != fetch.AuthChallengeResponseResponseDefault {
+		t.Errorf("proxy challenge response = %s, want Default", r)
+	}
+}
+
+func TestInterceptor_AuthenticateCrossOrigin(t *testing.T) {
+	ic := newInterceptor(&converterConfig{}, source{url: "https://Example.com:443/report"}, ConvertOptions{
+		Credentials: &Credentials{Username: "alice", Password: "s3cret"},
+	})
+	for i, origin := range []string{"https://cdn.example.net", "http://example.com", "https://example.com:8443"} {
+		// A third-party subresource, or the page redirected elsewhere.
+		r := ic.authenticate(&fetch.EventAuthRequired{
+			RequestID:     fetch.RequestID(fmt.Sprintf("req-%d", i)),
+			AuthChallenge: &fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: origin, Scheme: "basic"},
+		}).(*fetch.ContinueWithAuthParams).AuthChallengeResponse
+		if r.Response != fetch.AuthChallengeResponseResponseCancelAuth || r.Password != "" {
+			t.Errorf("challenge from %s: response = %+v, want CancelAuth", origin, r)
+		}
+	}
+	r := ic.authenticate(&fetch.EventAuthRequired{
+		RequestID:     "req-same",
+		AuthChallenge: &fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "https://example.com", Scheme: "basic"},
+	}).(*fetch.ContinueWithAuthParams).AuthChallengeResponse
+	if r.Response != fetch.AuthChallengeResponseResponseProvideCredentials {
+		t.Errorf("same-origin challenge response = %s, want ProvideCredentials", r.Response)
+	}
+
+	// A document converted from memory has no origin to trust.
+	ic = newInterceptor(&converterConfig{}, source{document: []byte("x")}, ConvertOptions{
+		Credentials: &Credentials{Username: "alice", Password: "s3cret"},
+	})
+	r = ic.authenticate(&fetch.EventAuthRequired{
+		RequestID:     "req-asset",
+		AuthChallenge: &fetch.AuthChallenge{Source: fetch.AuthChallengeSourceServer, Origin: "https://example.com", Scheme: "basic"},
+	}).(*fetch.ContinueWithAuthParams).AuthChallengeResponse
+	if r.Response != fetch.AuthChallengeResponseResponseCancelAuth {
+		t.Errorf("challenge to an in-memory document's resource = %s, want CancelAuth", r.Response)
+	}
+}
+
+func TestNewInterceptor_NotNeeded(t *testing.T) {
+	if ic := newInterceptor(&converterConfig{}, source{url: "https://example.com"}, ConvertOptions{}); ic != nil {
+		t.Error("newInterceptor returned an interceptor for a plain URL conversion")
+	}
+}
+
+func TestInterceptor_Blocking(t *testing.T) {
+	cfg := &converterConfig{
+		blockedTypes: []string{"image", "Media"},
+		blockedHosts: []string{"*.doubleclick.net", "tracker.example.com"},
+	}
+	ic := newInterceptor(cfg, source{url: "https://example.com"}, ConvertOptions{})
+
+	tests := []struct {
+		url     string
+		rt      network.ResourceType
+		blocked bool
+	}{
+		{"https://example.com/", network.ResourceTypeDocument, false},
+		{"https://example.com/logo.png", network.ResourceTypeImage, true},
+		{"https://example.com/clip.mp4", network.ResourceTypeMedia, true},
+		{"https://example.com/site.css", network.ResourceTypeStylesheet, false},
+		{"https://ad.doubleclick.net/pixel.js", network.ResourceTypeScript, true},
+		{"https://tracker.example.com:8443/t", network.ResourceTypeFetch, true},
+		{"https://doubleclick.net/x.js", network.ResourceTypeScript, false},
+	}
+	for _, tt := range tests {
+		e := pausedRequest(tt.url)
+		e.ResourceType = tt.rt
+		_, failed := ic.respond(context.Background(), e).(*fetch.FailRequestParams)
+		if failed != tt.blocked {
+			t.Errorf("%s (%s): blocked = %v, want %v", tt.url, tt.rt, failed, tt.blocked)
+		}
+	}
+}
+
+func TestInterceptor_BlockingSparesInMemoryDocument(t *testing.T) {
+	cfg := &converterConfig{blockedHosts: []string{"*"}}
+	ic := newInterceptor(cfg, source{document: []byte("x")}, ConvertOptions{})
+
+	if _, ok := ic.respond(context.Background(), pausedRequest(assetOrigin)).(*fetch.FulfillRequestParams); !ok {
+		t.Error("in-memory document was not fulfilled")
+	}
+}
+
+func TestInterceptor_BlockFiles(t *testing.T) {
+	src := source{url: "file:///tmp/htmlpdf-123.html", blockFiles: true}
+	ic := newInterceptor(&converterConfig{}, src, ConvertOptions{})
+	if ic == nil {
+		t.Fatal("newInterceptor returned nil for a source that blocks files")
+	}
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"file:///tmp/htmlpdf-123.html", false},
+		{"file:///etc/passwd", true},
+		{"file:///tmp/logo.png", true},
+		{"https://example.com/logo.png", false},
+	}
+	for _, tt := range tests {
+		_, failed := ic.respond(context.Background(), pausedRequest(tt.url)).(*fetch.FailRequestParams)
+		if failed != tt.blocked {
+			t.Errorf("%s: blocked = %v, want %v", tt.url, failed, tt.blocked)
+		}
+	}
+}
+
+func TestNewInterceptor_BlockLocalFiles(t *testing.T) {
+	cfg := &converterConfig{blockLocalFiles: true}
+	if ic := newInterceptor(cfg, source{url: "file:///srv/report.html"}, ConvertOptions{}); ic == nil || !ic.blockFiles {
+		t.Error("WithBlockLocalFiles did not block files for a file: source")
+	}
+	// Pages from other schemes cannot load files, so need no interception.
+	if ic := newInterceptor(cfg, source{url: "https://example.com"}, ConvertOptions{}); ic != nil {
+		t.Error("newInterceptor returned an interceptor for a plain URL conversion")
+	}
+	if ic := newInterceptor(cfg, source{document: []byte("x")}, ConvertOptions{}); ic.blockFiles {
+		t.Error("in-memory document blocks files")
+	}
+}
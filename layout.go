@@ -0,0 +1,276 @@
+package htmlpdf
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+// PageLayout is the text of a page structured into blocks, lines and
+// spans, as returned by [Extractor.ExtractLayout], for consumers that
+// need more than the plain text, such as ingestion pipelines. It
+// marshals to JSON.
+//
+// Coordinates are in points in the page's user space, with the origin
+// at the lower-left corner and y growing upwards. Bounding boxes are
+// [x0, y0, x1, y1] and reach from the baseline up by the font size.
+type PageLayout struct {
+	Page     int         `json:"page"` // 0-indexed
+	Width    float64     `json:"width"`
+	Height   float64     `json:"height"`
+	Rotation int         `json:"rotation"`
+	Blocks   []TextBlock `json:"blocks"`
+
+	// origin is the lower-left corner of the page's visible area, for
+	// renderings measured from its top-left corner.
+	origin [2]float64
+}
+
+// TextBlock is a run of lines set close together, such as a paragraph.
+type TextBlock struct {
+	BBox  [4]float64 `json:"bbox"`
+	Lines []TextLine `json:"lines"`
+}
+
+// TextLine is a line of spans sharing a baseline, left to right. Text is
+// the text of its spans, with a space where they are set apart.
+type TextLine struct {
+	BBox  [4]float64 `json:"bbox"`
+	Text  string     `json:"text"`
+	Spans []TextSpan `json:"spans"`
+}
+
+// TextSpan is text shown by one text operator. X and Y are its origin,
+// Font the /BaseFont of its font and Size the font size as drawn.
+type TextSpan struct {
+	BBox [4]float64 `json:"bbox"`
+	Text string     `json:"text"`
+	X    float64    `json:"x"`
+	Y    float64    `json:"y"`
+	Font string     `json:"font"`
+	Size float64    `json:"size"`
+}
+
+// ExtractLayout returns the structured text of a single page
+// (0-indexed), or nil if there is no such page.
+func (e *Extractor) ExtractLayout(pageIndex int) (*PageLayout, error) {
+	page, ok, err := e.doc.page(pageIndex)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return e.extractLayout(page, pageIndex)
+}
+
+// ExtractLayoutAll returns the structured text of all pages, one page
+// per element.
+func (e *Extractor) ExtractLayoutAll() ([]PageLayout, error) {
+	pages, err := e.doc.Pages()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]PageLayout, len(pages))
+	for i, page := range pages {
+		layout, err := e.extractLayout(page, i)
+		if err != nil {
+			results[i] = PageLayout{Page: i}
+			continue
+		}
+		results[i] = *layout
+	}
+	return results, nil
+}
+
+func (e *Extractor) extractLayout(page Dict, index int) (*PageLayout, error) {
+	var out textOutput
+	if err := e.extractPage(page, &out); err != nil {
+		return nil, err
+	}
+	info := e.doc.GetPageInfo(page)
+	layout := &PageLayout{
+		Page:     index,
+		Width:    info.Width,
+		Height:   info.Height,
+		Rotation: info.Rotation,
+		Blocks:   []TextBlock{},
+	}
+	if box, ok := visibleBox(e.doc, page); ok {
+		layout.origin = [2]float64{box[0], box[1]}
+	}
+
+	var prev *TextLine
+	for _, l := range groupLines(out.spans) {
+		line := layoutLine(l)
+		if len(line.Spans) == 0 {
+			continue
+		}
+		// A line starts a new block where the gap above it is wider than
+		// one and a half lines of its text.
+		size := line.BBox[3] - line.BBox[1]
+		if prev == nil || prev.BBox[1]-line.BBox[1] > 1.5*size {
+			layout.Blocks = append(layout.Blocks, TextBlock{BBox: line.BBox})
+		}
+		b := &layout.Blocks[len(layout.Blocks)-1]
+		b.BBox = unionBox(b.BBox, line.BBox)
+		b.Lines = append(b.Lines, line)
+		prev = &b.Lines[len(b.Lines)-1]
+	}
+	return layout, nil
+}
+
+// layoutLine converts a line of spans, leaving out those that show no
+// text.
+func layoutLine(l textLine) TextLine {
+	line := TextLine{Text: l.text()}
+	for _, sp := range l.spans {
+		text := cleanText(sp.text)
+		if text == "" {
+			continue
+		}
+		span := TextSpan{
+			BBox: [4]float64{sp.x, sp.y, sp.x + sp.width, sp.y + sp.fontSize},
+			Text: text,
+			X:    sp.x,
+			Y:    sp.y,
+			Font: sp.font,
+			Size: sp.fontSize,
+		}
+		if len(line.Spans) == 0 {
+			line.BBox = span.BBox
+		}
+		line.BBox = unionBox(line.BBox, span.BBox)
+		line.Spans = append(line.Spans, span)
+	}
+	return line
+}
+
+// top returns the distance of a box's top edge from the top of the
+// page's visible area, for renderings with y growing downwards.
+func (p *PageLayout) top(box [4]float64) float64 {
+	return p.Height - (box[3] - p.origin[1])
+}
+
+// unionBox returns the smallest box enclosing the boxes a and b.
+func unionBox(a, b [4]float64) [4]float64 {
+	return [4]float64{
+		math.Min(a[0], b[0]), math.Min(a[1], b[1]),
+		math.Max(a[2], b[2]), math.Max(a[3], b[3]),
+	}
+}
+
+// styledRun is text of a block set in one style, for the HTML and
+// Markdown renderings of a layout.
+type styledRun struct {
+	text         string
+	bold, italic bool
+}
+
+// fontStyle reports whether a font's /BaseFont names a bold or an italic
+// face, such as "Helvetica-BoldOblique" or "ABCDEF+Arial,Bold" for a
+// subset.
+func fontStyle(font string) (bold, italic bool) {
+	name := strings.ToLower(font)
+	bold = strings.Contains(name, "bold") || strings.Contains(name, "black") || strings.Contains(name, "heavy")
+	italic = strings.Contains(name, "italic") || strings.Contains(name, "oblique")
+	return bold, italic
+}
+
+// blockRuns returns the text of a block as runs of one style. Its lines
+// are joined into one paragraph; a line ending in a hyphen is joined to
+// the next without a space, keeping the hyphen.
+func blockRuns(b TextBlock) []styledRun {
+	var runs []styledRun
+	add := func(text string, bold, italic bool) {
+		if n := len(runs); n > 0 && runs[n-1].bold == bold && runs[n-1].italic == italic {
+			runs[n-1].text += text
+			return
+		}
+		runs = append(runs, styledRun{text: text, bold: bold, italic: italic})
+	}
+	for li, l := range b.Lines {
+		for si, sp := range l.Spans {
+			bold, italic := fontStyle(sp.Font)
+			text := sp.Text
+			switch {
+			case si > 0:
+				// Spans set apart by more than a third of their size are
+				// separate words, as in TextLine.Text.
+				prev := l.Spans[si-1]
+				if sp.BBox[0]-prev.BBox[2] > (sp.Size+prev.Size)/2*0.3 {
+					text = " " + text
+				}
+			case li > 0 && !strings.HasSuffix(b.Lines[li-1].Text, "-"):
+				text = " " + text
+			}
+			add(text, bold, italic)
+		}
+	}
+	return runs
+}
+
+// headingSizes infers the heading levels of a document's text from its
+// font sizes. The body size is the size of most of the text; larger
+// sizes are headings, the largest level 1, down to level 6.
+func headingSizes(pages []PageLayout) (body float64, levels map[float64]int) {
+	chars := make(map[float64]int)
+	for _, p := range pages {
+		for _, b := range p.Blocks {
+			for _, l := range b.Lines {
+				for _, sp := range l.Spans {
+					chars[roundSize(sp.Size)] += len([]rune(sp.Text))
+				}
+			}
+		}
+	}
+	for size, n := range chars {
+		if n > chars[body] || n == chars[body] && size < body {
+			body = size
+		}
+	}
+	var sizes []float64
+	for size := range chars {
+		if size >= body*1.15 {
+			sizes = append(sizes, size)
+		}
+	}
+	slices.Sort(sizes)
+	slices.Reverse(sizes)
+	levels = make(map[float64]int, len(sizes))
+	for i, size := range sizes {
+		levels[size] = min(i+1, 6)
+	}
+	return body, levels
+}
+
+// headingLevel returns the heading level of a block, or 0 for body text.
+// A block is a heading where all its text is set at a heading size, or,
+// failing that, where it is a single line set entirely in bold, which is
+// taken to be a heading one level below the smallest heading size.
+func headingLevel(b TextBlock, levels map[float64]int) int {
+	level, allBold := 0, true
+	for _, l := range b.Lines {
+		for _, sp := range l.Spans {
+			n, ok := levels[roundSize(sp.Size)]
+			if !ok {
+				level = -1
+			} else if level >= 0 {
+				level = max(level, n)
+			}
+			if bold, _ := fontStyle(sp.Font); !bold {
+				allBold = false
+			}
+		}
+	}
+	if level > 0 {
+		return level
+	}
+	if allBold && len(b.Lines) == 1 {
+		return min(len(levels)+1, 6)
+	}
+	return 0
+}
+
+// roundSize rounds a font size to a tenth of a point, so that sizes
+// differing only by rounding in the text matrix compare equal.
+func roundSize(size float64) float64 {
+	return math.Round(size*10) / 10
+}
@@ -0,0 +1,84 @@
+package htmlpdf
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractLayout(t *testing.T) {
+	cs := []byte(
+		"BT /F1 18 Tf 72 700 Td (Title) Tj ET " +
+			// A paragraph set at 1.2 lines, two spans on its first line.
+			"BT /F1 10 Tf 72 660 Td (First) Tj ET BT /F1 10 Tf 120 660 Td (line) Tj ET " +
+			"BT /F1 10 Tf 72 648 Td (Second line) Tj ET " +
+			// A blank line starts another block; empty strings show nothing.
+			"BT /F1 10 Tf 72 624 Td () Tj ET BT /F1 10 Tf 72 612 Td (Next) Tj ET")
+	ext := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs})))
+	layout, err := ext.ExtractLayout(0)
+	if err != nil {
+		t.Fatalf("ExtractLayout: %v", err)
+	}
+	if layout.Page != 0 || layout.Width != 612 || layout.Height != 792 {
+		t.Errorf("page %d is %vx%v, want page 0 at 612x792", layout.Page, layout.Width, layout.Height)
+	}
+
+	var blocks [][]string
+	for _, b := range layout.Blocks {
+		var lines []string
+		for _, l := range b.Lines {
+			lines = append(lines, l.Text)
+		}
+		blocks = append(blocks, lines)
+	}
+	if got, want := blocks, [][]string{{"Title"}, {"First line", "Second line"}, {"Next"}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Fatalf("blocks = %q, want %q", got, want)
+	}
+
+	// Helvetica's Title is 1889 thousandths wide.
+	title := layout.Blocks[0].Lines[0].Spans[0]
+	if want := [4]float64{72, 700, 72 + 1889*18/1000.0, 718}; math.Abs(title.BBox[2]-want[2]) > 1e-9 || title.BBox != [4]float64{72, 700, title.BBox[2], 718} {
+		t.Errorf("title bbox = %v, want %v", title.BBox, want)
+	}
+	if title.Font != "Helvetica" || title.Size != 18 || title.X != 72 || title.Y != 700 {
+		t.Errorf("title span = %+v", title)
+	}
+	para := layout.Blocks[1]
+	if len(para.Lines[0].Spans) != 2 {
+		t.Errorf("first line has %d spans, want 2", len(para.Lines[0].Spans))
+	}
+	if para.BBox[1] != 648 || para.BBox[3] != 670 || para.BBox[0] != 72 {
+		t.Errorf("paragraph bbox = %v", para.BBox)
+	}
+
+	data, err := json.Marshal(layout)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, want := range []string{`"page":0`, `"blocks":[{"bbox":[`, `"text":"First line","spans":[`, `"font":"Helvetica","size":10`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s does not contain %s", data, want)
+		}
+	}
+
+	all, err := ext.ExtractLayoutAll()
+	if err != nil || len(all) != 1 || len(all[0].Blocks) != 3 {
+		t.Errorf("ExtractLayoutAll = %+v, %v", all, err)
+	}
+	if layout, err := ext.ExtractLayout(1); layout != nil || err != nil {
+		t.Errorf("ExtractLayout(1) = %v, %v; want nil, nil", layout, err)
+	}
+}
+
+func TestExtractLayout_Empty(t *testing.T) {
+	layout, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))).ExtractLayout(0)
+	if err != nil {
+		t.Fatalf("ExtractLayout: %v", err)
+	}
+	// An empty page has no blocks, rather than null ones.
+	if data, _ := json.Marshal(layout); !strings.Contains(string(data), `"blocks":[]`) {
+		t.Errorf("JSON = %s, want empty blocks", data)
+	}
+}
@@ -0,0 +1,32 @@
+package htmlpdf
+
+import (
+	"context"
+	"fmt"
+)
+
+// acquireTab waits for a conversion slot when [WithMaxConcurrent] is set,
+// returning a func that frees it. Waiting stops when ctx is done. The
+// wait is traced as an htmlpdf.queue span.
+func (c *Converter) acquireTab(ctx context.Context) (release func(), err error) {
+	if c.tabs == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.tabs <- struct{}{}:
+		// Free slot; no need for a span.
+	default:
+		err = traced(ctx, c.cfg.tracer, spanQueue, func() error {
+			select {
+			case c.tabs <- struct{}{}:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("htmlpdf: waiting for a free tab: %w", ctx.Err())
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func() { <-c.tabs }, nil
+}
@@ -0,0 +1,60 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireTab(t *testing.T) {
+	cfg := defaultConfig()
+	c := &Converter{cfg: cfg, tabs: make(chan struct{}, 1)}
+
+	release, err := c.acquireTab(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		r, err := c.acquireTab(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- r
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second conversion got a tab while the first held it")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("queued conversion did not get the freed tab")
+	}
+}
+
+func TestAcquireTab_ContextDone(t *testing.T) {
+	c := &Converter{cfg: defaultConfig(), tabs: make(chan struct{}, 1)}
+	c.tabs <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.acquireTab(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestAcquireTab_Unlimited(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	for range 100 {
+		if _, err := c.acquireTab(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
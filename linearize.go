@@ -0,0 +1,530 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// linearizePDF returns pdf rewritten as a linearized ("fast web view")
+// file (ISO 32000-1, Annex F): the objects needed to display the first
+// page come first, behind a cross-reference section of their own, and a
+// hint stream tells viewers where every other page's objects are, so a
+// viewer reading over HTTP range requests can show the first page before
+// the rest of the file has arrived.
+//
+// Objects are laid out as Annex F describes: the linearization parameter
+// dictionary and first-page cross-reference section, the catalog, the
+// hint stream, the first page with everything it uses, the other pages
+// with the objects used by them alone, the objects shared between those
+// pages, and finally everything else, such as the page tree and outline.
+// Objects not reachable from the catalog or the Info dictionary are
+// dropped.
+func linearizePDF(pdf []byte) ([]byte, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("cannot linearize an encrypted document")
+	}
+	rootObj := doc.trailer["Root"]
+	if rootObj == nil || rootObj.Type != ObjRef {
+		return nil, fmt.Errorf("no /Root reference in trailer")
+	}
+	pageRefs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(pageRefs) == 0 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+
+	l := &linearizer{doc: doc, renum: make(map[Reference]int)}
+	l.partition(rootObj.Ref, pageRefs)
+	l.number()
+	return l.write(pdf, rootObj.Ref)
+}
+
+// linearizer lays out the objects of a linearized file.
+type linearizer struct {
+	doc *Document
+
+	catalog   Reference
+	firstPage []Reference   // part 6: the first page and all it uses
+	pages     [][]Reference // part 7: each later page, then its own objects
+	shared    []Reference   // part 8: objects used by several later pages
+	other     []Reference   // part 9: everything else
+	pageUses  [][]Reference // objects each page uses, for the hint tables
+
+	renum      map[Reference]int // original reference to new number
+	firstIndex int               // number of the linearization dictionary
+}
+
+// reach returns the objects reachable from start in a depth-first,
+// deterministic order, start first. Within a page, other pages and page
+// tree nodes are not followed, so that a link to another page does not
+// pull that page in, nor are stream lengths, which are rewritten.
+func (l *linearizer) reach(start Reference, withinPage bool) []Reference {
+	var out []Reference
+	seen := map[Reference]bool{start: true}
+	var visit func(obj *Object, depth int)
+	visitRef := func(ref Reference, depth int) {
+		if seen[ref] {
+			return
+		}
+		obj, err := l.doc.ResolveRef(ref)
+		if err != nil {
+			return
+		}
+		if withinPage && (obj.Type == ObjDict || obj.Type == ObjStream) {
+			if t, _ := obj.Dict.GetName("Type"); t == "Page" || t == "Pages" {
+				return
+			}
+		}
+		seen[ref] = true
+		out = append(out, ref)
+		visit(obj, depth+1)
+	}
+	visit = func(obj *Object, depth int) {
+		if obj == nil || depth > maxNesting {
+			return
+		}
+		switch obj.Type {
+		case ObjRef:
+			visitRef(obj.Ref, depth)
+		case ObjArray:
+			for _, item := range obj.Array {
+				visit(item, depth+1)
+			}
+		case ObjDict, ObjStream:
+			keys := make([]string, 0, len(obj.Dict))
+			for k := range obj.Dict {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if withinPage && k == "Parent" || obj.Type == ObjStream && k == "Length" {
+					continue
+				}
+				visit(obj.Dict[k], depth+1)
+			}
+		}
+	}
+
+	out = append(out, start)
+	if obj, err := l.doc.ResolveRef(start); err == nil {
+		visit(obj, 0)
+	}
+	return out
+}
+
+// partition assigns every reachable object to its part of the file.
+func (l *linearizer) partition(catalog Reference, pageRefs []Reference) {
+	l.catalog = catalog
+	assigned := map[Reference]bool{catalog: true}
+
+	l.pageUses = make([][]Reference, len(pageRefs))
+	users := make(map[Reference]int)
+	for i, ref := range pageRefs {
+		l.pageUses[i] = l.reach(ref, true)
+		if i == 0 {
+			continue
+		}
+		for _, r := range l.pageUses[i] {
+			users[r]++
+		}
+	}
+
+	l.firstPage = l.pageUses[0]
+	for _, r := range l.firstPage {
+		assigned[r] = true
+	}
+	l.pages = make([][]Reference, len(pageRefs)-1)
+	for i, uses := range l.pageUses[1:] {
+		for _, r := range uses {
+			if !assigned[r] && users[r] == 1 {
+				assigned[r] = true
+				l.pages[i] = append(l.pages[i], r)
+			}
+		}
+	}
+	for _, uses := range l.pageUses[1:] {
+		for _, r := range uses {
+			if !assigned[r] {
+				assigned[r] = true
+				l.shared = append(l.shared, r)
+			}
+		}
+	}
+
+	roots := l.reach(catalog, false)
+	if info := l.doc.trailer["Info"]; info != nil && info.Type == ObjRef {
+		roots = append(roots, l.reach(info.Ref, false)...)
+	}
+	for _, r := range roots {
+		if !assigned[r] {
+			assigned[r] = true
+			l.other = append(l.other, r)
+		}
+	}
+}
+
+// number gives the objects of the later parts the numbers from 1, in file
+// order, and the first-page section the numbers after them: the
+// linearization dictionary, the catalog, the hint stream and then the
+// first page's objects.
+func (l *linearizer) number() {
+	n := 1
+	add := func(refs []Reference) {
+		for _, r := range refs {
+			l.renum[r] = n
+			n++
+		}
+	}
+	for _, refs := range l.pages {
+		add(refs)
+	}
+	add(l.shared)
+	add(l.other)
+
+	l.firstIndex = n
+	l.renum[l.catalog] = n + 1
+	n += 3
+	add(l.firstPage)
+}
+
+// renumbered returns a copy of obj with its references renumbered.
+// References to dropped objects become null.
+func (l *linearizer) renumbered(obj *Object, depth int) *Object {
+	if obj == nil || depth > maxNesting {
+		return nil
+	}
+	switch obj.Type {
+	case ObjRef:
+		n, ok := l.renum[obj.Ref]
+		if !ok {
+			return &Object{Type: ObjNull}
+		}
+		return refObj(Reference{Number: n})
+	case ObjArray:
+		items := make([]*Object, len(obj.Array))
+		for i, item := range obj.Array {
+			items[i] = l.renumbered(item, depth+1)
+		}
+		return arrayObj(items...)
+	case ObjDict, ObjStream:
+		d := make(Dict, len(obj.Dict))
+		for k, v := range obj.Dict {
+			d[k] = l.renumbered(v, depth+1)
+		}
+		cp := *obj
+		cp.Dict = d
+		return &cp
+	default:
+		return obj
+	}
+}
+
+// serialize returns the renumbered indirect object for ref.
+func (l *linearizer) serialize(ref Reference) ([]byte, error) {
+	obj, err := l.doc.ResolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d 0 obj\n", l.renum[ref])
+	writeObject(&buf, l.renumbered(obj, 0))
+	buf.WriteString("\nendobj\n")
+	return buf.Bytes(), nil
+}
+
+// linearizationDict formats the linearization parameter dictionary. Its
+// offsets are padded to a fixed width so that the file's layout can be
+// computed before they are known.
+func linearizationDict(num, fileLen, hintOffset, hintLen, firstPage, endFirstPage, pages, mainXRef int) []byte {
+	return fmt.Appendf(nil, "%d 0 obj\n<< /Linearized 1 /L %10d /H [%10d %10d] /O %d /E %10d /N %d /T %10d >>\nendobj\n",
+		num, fileLen, hintOffset, hintLen, firstPage, endFirstPage, pages, mainXRef)
+}
+
+// write lays the parts out and returns the linearized file. Offsets in
+// the hint tables are given as if the hint stream were absent (Annex F,
+// F.4), which lets the tables be built before the stream's length is
+// known.
+func (l *linearizer) write(pdf []byte, catalog Reference) ([]byte, error) {
+	body := make(map[Reference][]byte, len(l.renum))
+	for ref := range l.renum {
+		b, err := l.serialize(ref)
+		if err != nil {
+			return nil, err
+		}
+		body[ref] = b
+	}
+
+	version := l.doc.Version()
+	if len(version) != 3 {
+		version = "1.4"
+	}
+	header := fmt.Sprintf("%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+	size := l.firstIndex + 3 + len(l.firstPage)
+	firstPageNum := l.renum[l.firstPage[0]]
+
+	// The first-page trailer; only /Prev changes once offsets are known.
+	var trailer bytes.Buffer
+	fmt.Fprintf(&trailer, "trailer\n<< /Size %d /Root %d 0 R", size, l.renum[catalog])
+	if info := l.doc.trailer["Info"]; info != nil && info.Type == ObjRef {
+		if n, ok := l.renum[info.Ref]; ok {
+			fmt.Fprintf(&trailer, " /Info %d 0 R", n)
+		}
+	}
+	id := l.doc.trailer["ID"]
+	if id == nil {
+		sum := md5.Sum(pdf)
+		fileID := &Object{Type: ObjString, Str: sum[:]}
+		id = arrayObj(fileID, fileID)
+	}
+	trailer.WriteString(" /ID ")
+	writeObject(&trailer, id)
+	firstXRef := func(offsets []int, mainXRef int) []byte {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "xref\n%d %d\n", l.firstIndex, len(offsets))
+		for _, off := range offsets {
+			fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+		}
+		buf.Write(trailer.Bytes())
+		fmt.Fprintf(&buf, " /Prev %10d >>\nstartxref\n0\n%%%%EOF\n", mainXRef)
+		return buf.Bytes()
+	}
+
+	// Lay the file out without the hint stream.
+	firstOffsets := make([]int, 3+len(l.firstPage))
+	pos := len(header) + len(linearizationDict(l.firstIndex, 0, 0, 0, firstPageNum, 0, 0, 0))
+	pos += len(firstXRef(firstOffsets, 0))
+	firstOffsets[1] = pos
+	pos += len(body[catalog])
+	hintOffset := pos
+
+	offsets := make(map[Reference]int, len(l.renum))
+	place := func(refs []Reference) {
+		for _, r := range refs {
+			offsets[r] = pos
+			pos += len(body[r])
+		}
+	}
+	place(l.firstPage)
+	endFirstPage := pos
+	for _, refs := range l.pages {
+		place(refs)
+	}
+	place(l.shared)
+	place(l.other)
+	mainXRef := pos
+
+	hint := l.hintStream(offsets, body)
+	hintLen := len(hint)
+	for r := range offsets {
+		offsets[r] += hintLen
+	}
+	endFirstPage += hintLen
+	mainXRef += hintLen
+
+	firstOffsets[0] = len(header)
+	firstOffsets[2] = hintOffset
+	for i, r := range l.firstPage {
+		firstOffsets[3+i] = offsets[r]
+	}
+
+	var main bytes.Buffer
+	fmt.Fprintf(&main, "xref\n0 %d\n", l.firstIndex)
+	main.WriteString("0000000000 65535 f \n")
+	all := make([]Reference, 0, l.firstIndex)
+	for _, refs := range l.pages {
+		all = append(all, refs...)
+	}
+	all = append(append(all, l.shared...), l.other...)
+	for _, r := range all {
+		fmt.Fprintf(&main, "%010d 00000 n \n", offsets[r])
+	}
+	fmt.Fprintf(&main, "trailer\n<< /Size %d >>\n", l.firstIndex)
+	mainEntries := len(fmt.Sprintf("xref\n0 %d\n", l.firstIndex))
+
+	firstXRefOffset := len(header) + len(linearizationDict(l.firstIndex, 0, 0, 0, firstPageNum, 0, 0, 0))
+	tail := fmt.Sprintf("startxref\n%d\n%%%%EOF\n", firstXRefOffset)
+	fileLen := mainXRef + main.Len() + len(tail)
+
+	var out bytes.Buffer
+	out.Grow(fileLen)
+	out.WriteString(header)
+	out.Write(linearizationDict(l.firstIndex, fileLen, hintOffset, hintLen, firstPageNum,
+		endFirstPage, len(l.pageUses), mainXRef+mainEntries-1))
+	out.Write(firstXRef(firstOffsets, mainXRef))
+	out.Write(body[catalog])
+	out.Write(hint)
+	for _, r := range l.firstPage {
+		out.Write(body[r])
+	}
+	for _, r := range all {
+		out.Write(body[r])
+	}
+	out.Write(main.Bytes())
+	out.WriteString(tail)
+	if out.Len() != fileLen {
+		return nil, fmt.Errorf("linearized file is %d bytes, laid out as %d", out.Len(), fileLen)
+	}
+	return out.Bytes(), nil
+}
+
+// hintStream returns the primary hint stream object, holding the page
+// offset hint table (Annex F, F.4.1) followed by the shared object hint
+// table (F.4.2). Every shared object forms a group of its own. Content
+// stream offsets and lengths, and the fractional positions of shared
+// object references, are not recorded.
+func (l *linearizer) hintStream(offsets map[Reference]int, body map[Reference][]byte) []byte {
+	// Shared object identifiers: the first page's objects, then part 8.
+	sharedID := make(map[Reference]int, len(l.firstPage)+len(l.shared))
+	groups := append(append([]Reference{}, l.firstPage...), l.shared...)
+	for i, r := range groups {
+		sharedID[r] = i
+	}
+
+	n := len(l.pageUses)
+	nobjects := make([]int, n)
+	lengths := make([]int, n)
+	refs := make([][]int, n)
+	for _, r := range l.firstPage {
+		lengths[0] += len(body[r])
+	}
+	nobjects[0] = len(l.firstPage)
+	for i := 1; i < n; i++ {
+		nobjects[i] = len(l.pages[i-1])
+		for _, r := range l.pages[i-1] {
+			lengths[i] += len(body[r])
+		}
+		for _, r := range l.pageUses[i] {
+			if id, ok := sharedID[r]; ok {
+				refs[i] = append(refs[i], id)
+			}
+		}
+	}
+	minObjects, maxObjects := minMax(nobjects)
+	minLength, maxLength := minMax(lengths)
+	maxRefs, maxID := 0, 0
+	for _, ids := range refs {
+		maxRefs = max(maxRefs, len(ids))
+		for _, id := range ids {
+			maxID = max(maxID, id)
+		}
+	}
+
+	var w bitWriter
+	w.write(minObjects, 32)
+	w.write(offsets[l.firstPage[0]], 32)
+	objBits := bitLen(maxObjects - minObjects)
+	w.write(objBits, 16)
+	w.write(minLength, 32)
+	lenBits := bitLen(maxLength - minLength)
+	w.write(lenBits, 16)
+	w.write(0, 32) // least content stream offset
+	w.write(0, 16)
+	w.write(0, 32) // least content stream length
+	w.write(0, 16)
+	refBits, idBits := bitLen(maxRefs), bitLen(maxID)
+	w.write(refBits, 16)
+	w.write(idBits, 16)
+	w.write(0, 16) // numerator bits
+	w.write(1, 16) // denominator
+	for _, v := range nobjects {
+		w.write(v-minObjects, objBits)
+	}
+	w.flush()
+	for _, v := range lengths {
+		w.write(v-minLength, lenBits)
+	}
+	w.flush()
+	for _, ids := range refs {
+		w.write(len(ids), refBits)
+	}
+	w.flush()
+	for _, ids := range refs {
+		for _, id := range ids {
+			w.write(id, idBits)
+		}
+	}
+	w.flush()
+	// Numerators, content stream offsets and lengths take no bits.
+
+	sharedTable := len(w.buf)
+	groupLengths := make([]int, len(groups))
+	for i, r := range groups {
+		groupLengths[i] = len(body[r])
+	}
+	minGroup, maxGroup := minMax(groupLengths)
+	firstShared, firstSharedOffset := 0, 0
+	if len(l.shared) > 0 {
+		firstShared, firstSharedOffset = l.renum[l.shared[0]], offsets[l.shared[0]]
+	}
+	w.write(firstShared, 32)
+	w.write(firstSharedOffset, 32)
+	w.write(len(l.firstPage), 32)
+	w.write(len(groups), 32)
+	w.write(0, 16) // groups hold one object
+	w.write(minGroup, 32)
+	groupBits := bitLen(maxGroup - minGroup)
+	w.write(groupBits, 16)
+	for _, v := range groupLengths {
+		w.write(v-minGroup, groupBits)
+	}
+	w.flush()
+	for range groups {
+		w.write(0, 1) // no MD5 signature
+	}
+	w.flush()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d 0 obj\n", l.firstIndex+2)
+	writeObject(&buf, &Object{Type: ObjStream, Dict: Dict{"S": intObj(sharedTable)}, Stream: w.buf})
+	buf.WriteString("\nendobj\n")
+	return buf.Bytes()
+}
+
+// minMax returns the least and greatest of vs, which must not be empty.
+func minMax(vs []int) (lo, hi int) {
+	lo, hi = vs[0], vs[0]
+	for _, v := range vs[1:] {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	return lo, hi
+}
+
+// bitLen returns the number of bits needed to represent v.
+func bitLen(v int) int {
+	return bits.Len(uint(v))
+}
+
+// bitWriter packs values most significant bit first, as the hint tables
+// require.
+type bitWriter struct {
+	buf   []byte
+	cur   byte
+	nbits int // bits used in cur
+}
+
+// write appends the low n bits of v.
+func (w *bitWriter) write(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>i&1)
+		if w.nbits++; w.nbits == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.nbits = 0, 0
+		}
+	}
+}
+
+// flush pads the last byte with zero bits.
+func (w *bitWriter) flush() {
+	if w.nbits > 0 {
+		w.write(0, 8-w.nbits)
+	}
+}
@@ -0,0 +1,122 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLinearizePDF(t *testing.T) {
+	src := buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 72 720 Td (First) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Second) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Third) Tj ET"),
+	})
+	src, err := setMetadata(src, Metadata{Title: "Linear"}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := linearizePDF(src)
+	if err != nil {
+		t.Fatalf("linearizePDF: %v", err)
+	}
+	doc := mustLoad(t, out)
+
+	// The linearization dictionary is the first object in the file.
+	lin, err := doc.resolveAtOffset(int64(len("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")))
+	if err != nil {
+		t.Fatalf("no object after the header: %v", err)
+	}
+	if _, ok := lin.Dict["Linearized"]; !ok {
+		t.Fatalf("first object is not a linearization dictionary: %v", lin.Dict)
+	}
+	get := func(key string) int {
+		v, _ := lin.Dict.GetInt(key)
+		return int(v)
+	}
+
+	if get("L") != len(out) {
+		t.Errorf("/L = %d, want the file length %d", get("L"), len(out))
+	}
+	if get("N") != 3 {
+		t.Errorf("/N = %d, want 3", get("N"))
+	}
+	refs, err := doc.pageRefs()
+	if err != nil || len(refs) != 3 {
+		t.Fatalf("pageRefs = %v, %v", refs, err)
+	}
+	if get("O") != refs[0].Number {
+		t.Errorf("/O = %d, want the first page %d", get("O"), refs[0].Number)
+	}
+
+	// Everything the first page needs lies before /E, and nothing of the
+	// other pages does.
+	first, _ := doc.ResolveRef(refs[0])
+	for _, ref := range []Reference{refs[0], first.Dict["Contents"].Ref, resolveDict(doc, first.Dict["Resources"])["Font"].Dict["F1"].Ref} {
+		if off := doc.xref[ref.Number].Offset; int(off) >= get("E") {
+			t.Errorf("object %d at %d, after the end of the first page %d", ref.Number, off, get("E"))
+		}
+	}
+	for _, ref := range refs[1:] {
+		if off := doc.xref[ref.Number].Offset; int(off) < get("E") {
+			t.Errorf("page object %d at %d, within the first page section", ref.Number, off)
+		}
+	}
+
+	// /T points just before the main xref table's first entry.
+	if T := get("T"); !bytes.HasPrefix(out[T:], []byte("\n0000000000 65535 f \n")) {
+		t.Errorf("/T = %d does not precede the main xref table: %.30q", T, out[T:])
+	}
+
+	// The hint stream gives the first page's offset as if it were absent.
+	h, _ := lin.Dict.GetArray("H")
+	hintOffset, hintLen := int(h[0].Int), int(h[1].Int)
+	hintObj, err := doc.resolveAtOffset(int64(hintOffset))
+	if err != nil || hintObj.Type != ObjStream {
+		t.Fatalf("no hint stream at /H offset %d: %v", hintOffset, err)
+	}
+	if s, ok := hintObj.Dict.GetInt("S"); !ok || int(s) >= len(hintObj.Stream) {
+		t.Errorf("/S = %d, want an offset within the %d-byte stream", s, len(hintObj.Stream))
+	}
+	firstPage := int(binary.BigEndian.Uint32(hintObj.Stream[4:8]))
+	if firstPage+hintLen != int(doc.xref[refs[0].Number].Offset) {
+		t.Errorf("hinted first page offset %d (+%d) does not match %d", firstPage, hintLen, doc.xref[refs[0].Number].Offset)
+	}
+
+	texts, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"First", "Second", "Third"}; !slices.Equal(texts, want) {
+		t.Errorf("pages = %q, want %q", texts, want)
+	}
+	if info := readDocInfo(doc); info.title != "Linear" {
+		t.Errorf("title = %q, want the Info dictionary kept", info.title)
+	}
+}
+
+func TestLinearizePDF_RejectsEncrypted(t *testing.T) {
+	enc, err := encryptPDF(buildTestPDF([][]byte{[]byte("BT ET")}), "owner", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := linearizePDF(enc); err == nil {
+		t.Error("expected error for encrypted document")
+	}
+}
+
+func TestBitWriter(t *testing.T) {
+	var w bitWriter
+	w.write(0b101, 3)
+	w.write(0b1, 1)
+	w.flush()
+	w.write(0x1234, 16)
+	w.write(1, 2)
+	w.flush()
+	if want := []byte{0b10110000, 0x12, 0x34, 0b01000000}; !bytes.Equal(w.buf, want) {
+		t.Errorf("buf = %08b, want %08b", w.buf, want)
+	}
+}
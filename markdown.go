@@ -0,0 +1,87 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+// defaultMarkdownCSS is the stylesheet applied to documents rendered by
+// ConvertMarkdown unless replaced with [WithMarkdownCSS].
+const defaultMarkdownCSS = `
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 11pt; line-height: 1.5; color: #1f2328; }
+h1, h2, h3, h4, h5, h6 { margin: 1.2em 0 0.5em; line-height: 1.25; font-weight: 600; page-break-after: avoid; }
+h1 { font-size: 2em; border-bottom: 1px solid #d1d9e0; padding-bottom: 0.3em; }
+h2 { font-size: 1.5em; border-bottom: 1px solid #d1d9e0; padding-bottom: 0.3em; }
+h3 { font-size: 1.25em; }
+p, ul, ol, blockquote, table, pre { margin: 0 0 1em; }
+a { color: #0969da; text-decoration: none; }
+code { font-family: ui-monospace, "SFMono-Regular", Menlo, Consolas, monospace; font-size: 0.9em; background: #f6f8fa; padding: 0.2em 0.4em; border-radius: 4px; }
+pre { background: #f6f8fa; padding: 1em; border-radius: 6px; overflow: hidden; white-space: pre-wrap; page-break-inside: avoid; }
+pre code { background: none; padding: 0; }
+blockquote { color: #59636e; border-left: 0.25em solid #d1d9e0; padding: 0 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d1d9e0; padding: 6px 13px; }
+th { background: #f6f8fa; }
+tr { page-break-inside: avoid; }
+img { max-width: 100%; }
+hr { border: 0; border-top: 1px solid #d1d9e0; }
+`
+
+// markdown renders CommonMark with the GitHub Flavored Markdown extensions
+// (tables, strikethrough, autolinks, task lists). Raw HTML in the source
+// is omitted.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// ConvertMarkdown renders Markdown to HTML and converts it to a PDF
+// document. The HTML is styled with a built-in stylesheet, which can be
+// replaced with [WithMarkdownCSS]. If page is nil, [DefaultPageConfig]
+// values are used.
+func (c *Converter) ConvertMarkdown(ctx context.Context, md string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	html, err := renderMarkdown(md, c.cfg.markdownCSS)
+	if err != nil {
+		return nil, err
+	}
+	return c.ConvertHTML(ctx, html, pg, opts...)
+}
+
+// renderMarkdown converts md to a standalone HTML document styled with css,
+// or with defaultMarkdownCSS when css is empty.
+func renderMarkdown(md, css string) (string, error) {
+	var body bytes.Buffer
+	if err := markdown.Convert([]byte(md), &body); err != nil {
+		return "", fmt.Errorf("htmlpdf: rendering markdown: %w", err)
+	}
+	if css == "" {
+		css = defaultMarkdownCSS
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<style>")
+	sb.WriteString(css)
+	sb.WriteString("</style>\n</head>\n<body>\n")
+	sb.Write(body.Bytes())
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String(), nil
+}
+
+// ConvertMarkdown converts Markdown to PDF using a temporary [Converter].
+func ConvertMarkdown(ctx context.Context, md string, pg *PageConfig, opts ...Option) (*Result, error) {
+	conv, err := NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer conv.Close()
+	return conv.ConvertMarkdown(ctx, md, pg)
+}
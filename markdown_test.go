@@ -0,0 +1,47 @@
+package htmlpdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	html, err := renderMarkdown("# Title\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n~~old~~", "")
+	if err != nil {
+		t.Fatalf("renderMarkdown: %v", err)
+	}
+	for _, want := range []string{
+		`<h1 id="title">Title</h1>`,
+		"<table>",
+		"<del>old</del>",
+		defaultMarkdownCSS,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+}
+
+func TestRenderMarkdown_CustomCSS(t *testing.T) {
+	css := "body { color: red; }"
+	html, err := renderMarkdown("text", css)
+	if err != nil {
+		t.Fatalf("renderMarkdown: %v", err)
+	}
+	if !strings.Contains(html, css) {
+		t.Error("custom CSS not included")
+	}
+	if strings.Contains(html, defaultMarkdownCSS) {
+		t.Error("default CSS included alongside custom CSS")
+	}
+}
+
+func TestRenderMarkdown_OmitsRawHTML(t *testing.T) {
+	html, err := renderMarkdown("<script>alert(1)</script>", "")
+	if err != nil {
+		t.Fatalf("renderMarkdown: %v", err)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("raw HTML was passed through")
+	}
+}
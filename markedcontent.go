@@ -0,0 +1,132 @@
+package htmlpdf
+
+import "math"
+
+// WithAltText makes the extractor substitute the /Alt alternate
+// description of a marked-content sequence for its content, as it does
+// /ActualText, such as the description of a figure or of a formula drawn
+// as paths. It applies to plain text, layouts and the renderings built on
+// them, not to [Extractor.ExtractGlyphs].
+func WithAltText() ExtractorOption {
+	return func(e *Extractor) {
+		e.altText = true
+	}
+}
+
+// markedContent is a marked-content sequence entered with BMC or BDC.
+type markedContent struct {
+	mcid     int // -1 if the sequence has no /MCID
+	artifact bool
+
+	// The /ActualText (or /Alt) shown instead of the content, if replace
+	// is set, and the span standing in for it once the content has shown
+	// text.
+	replace     bool
+	replacement string
+	span        *textSpan
+}
+
+// beginMarkedContent enters the marked-content sequence opened by BMC or
+// BDC with the operands args: a tag, and for BDC a property list written
+// inline or named in the /Properties resource.
+func (ts *textState) beginMarkedContent(args []*Object, altText bool) {
+	mc := markedContent{mcid: -1}
+	if len(args) >= 1 && args[0].Type == ObjName {
+		mc.artifact = args[0].Name == "Artifact"
+	}
+	var props Dict
+	if len(args) >= 2 {
+		switch args[1].Type {
+		case ObjDict:
+			props = args[1].Dict
+		case ObjName:
+			props = ts.properties[args[1].Name]
+		}
+	}
+	if mcid, ok := props.GetInt("MCID"); ok && mcid >= 0 {
+		mc.mcid = int(mcid)
+	}
+	if s, ok := props["ActualText"]; ok && s.Type == ObjString {
+		mc.replace, mc.replacement = true, decodeTextString(s.Str)
+	} else if s, ok := props["Alt"]; ok && s.Type == ObjString && altText {
+		mc.replace, mc.replacement = true, decodeTextString(s.Str)
+	}
+	ts.marked = append(ts.marked, mc)
+}
+
+// endMarkedContent leaves the innermost marked-content sequence. If its
+// content is replaced, the replacement is added to out where the content
+// showed its text. Content that showed none, such as a figure, is
+// replaced at the current text position inside a text object, and
+// otherwise at the origin of the transformation, where an image is
+// drawn. An empty replacement removes the content, leaving no space in
+// its place.
+func (ts *textState) endMarkedContent(out *textOutput, inText bool) {
+	n := len(ts.marked)
+	if n == 0 {
+		return
+	}
+	mc := ts.marked[n-1]
+	if mc.replace && ts.replacing() == n-1 {
+		switch {
+		case mc.span != nil:
+			out.spans = append(out.spans, *mc.span)
+		case mc.replacement != "":
+			sp := textSpan{text: mc.replacement, fontSize: ts.fontSize}
+			if inText {
+				m := ts.renderMatrix(0)
+				sp.x, sp.y, sp.fontSize = m[4], m[5], ts.fontSize*math.Hypot(m[2], m[3])
+			} else {
+				p := ts.ctm.apply(0, 0)
+				sp.x, sp.y = p[0], p[1]
+			}
+			sp.width = estimateWidth(sp)
+			sp.mcid, sp.artifact = ts.markedContent()
+			out.spans = append(out.spans, sp)
+		}
+	}
+	ts.marked = ts.marked[:n-1]
+}
+
+// replacing returns the index of the outermost marked-content sequence
+// whose content is replaced, or -1.
+func (ts *textState) replacing() int {
+	for i, mc := range ts.marked {
+		if mc.replace {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceSpan folds a span shown inside a replaced marked-content
+// sequence into the span standing in for the sequence, reporting false
+// if no sequence is replaced.
+func (ts *textState) replaceSpan(sp textSpan) bool {
+	i := ts.replacing()
+	if i < 0 {
+		return false
+	}
+	mc := &ts.marked[i]
+	if mc.span == nil {
+		r := sp
+		r.text = mc.replacement
+		mc.span = &r
+		return true
+	}
+	mc.span.width = math.Max(mc.span.width, sp.x+sp.width-mc.span.x)
+	return true
+}
+
+// markedContent returns the identifier of the innermost marked-content
+// sequence with one, or -1, and whether text shown now is an artifact.
+func (ts *textState) markedContent() (mcid int, artifact bool) {
+	mcid = -1
+	for _, mc := range ts.marked {
+		if mc.mcid >= 0 {
+			mcid = mc.mcid
+		}
+		artifact = artifact || mc.artifact
+	}
+	return mcid, artifact
+}
@@ -0,0 +1,43 @@
+package htmlpdf
+
+import "testing"
+
+func TestExtractPage_ActualText(t *testing.T) {
+	cs := []byte(
+		// A drop cap shown apart from the rest of its word.
+		"/Span <</ActualText (The)>> BDC BT /F1 30 Tf 72 700 Td (T) Tj ET BT /F1 10 Tf 92 700 Td (he) Tj ET EMC " +
+			"BT /F1 10 Tf 110 700 Td (quick fox) Tj ET " +
+			// A ligature glyph, and a hyphen that is not part of the text.
+			"BT /F1 10 Tf 72 680 Td (re) Tj /Span <</ActualText <FEFF0066006C>>> BDC (X) Tj EMC (ect on hy) Tj " +
+			"/Span <</ActualText ()>> BDC (-) Tj EMC (phens) Tj ET " +
+			// A property list named in the resources.
+			"/Span /MC0 BDC BT /F1 10 Tf 72 660 Td (n4m3d) Tj ET EMC " +
+			// A figure described by its alternate text.
+			"/Figure <</Alt (A bar chart)>> BDC 72 600 100 40 re f EMC")
+	doc := mustLoad(t, buildTestPDF([][]byte{cs}))
+	u, _ := newPDFUpdate(doc)
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	res := resolveDict(doc, page.Dict["Resources"])
+	res["Properties"] = dictObj(Dict{"MC0": dictObj(Dict{"ActualText": textStringObj("named")})})
+	u.set(Reference{Number: 3}, page)
+	doc = mustLoad(t, u.bytes())
+
+	got, err := NewExtractor(doc).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "The quick fox\nreflect on hyphens\nnamed"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+
+	got, _ = NewExtractor(doc, WithAltText()).ExtractPage(0)
+	if want := "The quick fox\nreflect on hyphens\nnamed\nA bar chart"; got != want {
+		t.Errorf("text with alternate text = %q, want %q", got, want)
+	}
+
+	// Glyphs are those drawn.
+	glyphs, _ := NewExtractor(doc).ExtractGlyphs(0)
+	if len(glyphs) < 3 || glyphs[0].Text != "T" || glyphs[1].Text != "h" {
+		t.Errorf("glyphs = %+v", glyphs)
+	}
+}
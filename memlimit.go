@@ -0,0 +1,130 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// memoryCheckInterval is how often [WithBrowserMemoryLimit] samples the
+// browser's memory use.
+const memoryCheckInterval = 500 * time.Millisecond
+
+// startMemoryWatch starts watchMemory for the local browser of
+// browserCtx. Memory is read from /proc, so it does nothing on systems
+// other than Linux.
+func (c *Converter) startMemoryWatch(browserCtx context.Context) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+	if p := chromedp.FromContext(browserCtx).Browser.Process(); p != nil {
+		go c.watchMemory(browserCtx, p.Pid)
+	}
+}
+
+// watchMemory stops the browser of browserCtx, whose main process is pid,
+// once it and its child processes together use more than the limit set
+// with WithBrowserMemoryLimit. It returns when browserCtx is done.
+func (c *Converter) watchMemory(browserCtx context.Context, pid int) {
+	limit := int64(c.cfg.memoryLimit) << 20
+	procfs := os.DirFS("/proc")
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-browserCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		rss, err := treeRSS(procfs, pid, os.Getpagesize())
+		if err != nil || rss <= limit {
+			continue
+		}
+		c.mu.Lock()
+		if c.browserCtx == browserCtx {
+			c.cfg.logger.Warn("htmlpdf: browser exceeded memory limit, stopping it",
+				"rss_mb", rss>>20, "limit_mb", c.cfg.memoryLimit)
+			c.overMemory = browserCtx
+			c.browserCancel()
+			c.allocCancel()
+		}
+		c.mu.Unlock()
+		return
+	}
+}
+
+// exceededMemory reports whether the browser of browserCtx was stopped
+// by watchMemory.
+func (c *Converter) exceededMemory(browserCtx context.Context) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.overMemory == browserCtx
+}
+
+// treeRSS returns the resident memory, in bytes, of process root and all
+// its descendants, read from fsys, a Linux proc filesystem.
+func treeRSS(fsys fs.FS, root, pageSize int) (int64, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, err
+	}
+	children := make(map[int][]int)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if ppid, ok := parentPID(fsys, pid); ok {
+			children[ppid] = append(children[ppid], pid)
+		}
+	}
+
+	var rss int64
+	queue := []int{root}
+	seen := map[int]bool{root: true}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		// A process may exit while it is being read; it then uses no
+		// memory.
+		if b, err := fs.ReadFile(fsys, strconv.Itoa(pid)+"/statm"); err == nil {
+			if f := bytes.Fields(b); len(f) > 1 {
+				pages, _ := strconv.ParseInt(string(f[1]), 10, 64)
+				rss += pages * int64(pageSize)
+			}
+		}
+		for _, child := range children[pid] {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return rss, nil
+}
+
+// parentPID returns the parent of process pid from its stat file in
+// fsys. The stat line is "pid (comm) state ppid ...", where comm may
+// itself contain spaces and parentheses.
+func parentPID(fsys fs.FS, pid int) (int, bool) {
+	b, err := fs.ReadFile(fsys, strconv.Itoa(pid)+"/stat")
+	if err != nil {
+		return 0, false
+	}
+	i := bytes.LastIndexByte(b, ')')
+	if i < 0 {
+		return 0, false
+	}
+	f := bytes.Fields(b[i+1:])
+	if len(f) < 2 {
+		return 0, false
+	}
+	ppid, err := strconv.Atoi(string(f[1]))
+	return ppid, err == nil
+}
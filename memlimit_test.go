@@ -0,0 +1,51 @@
+package htmlpdf
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func procFile(s string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(s)}
+}
+
+func TestTreeRSS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"10/stat":  procFile("10 (chrome) S 1 10 10 0 -1"),
+		"10/statm": procFile("5000 100 50 0 0 0 0"),
+		// A renderer whose name contains spaces and parentheses.
+		"11/stat":  procFile("11 (chrome (renderer) x) S 10 10 10 0 -1"),
+		"11/statm": procFile("9000 200 60 0 0 0 0"),
+		"12/stat":  procFile("12 (chrome) S 11 10 10 0 -1"),
+		"12/statm": procFile("3000 300 20 0 0 0 0"),
+		// An unrelated process.
+		"20/stat":  procFile("20 (bash) S 1 20 20 0 -1"),
+		"20/statm": procFile("1000 1000 10 0 0 0 0"),
+		// A process that exited between listing and reading.
+		"13/stat": procFile("13 (chrome) S 10 10 10 0 -1"),
+		"self":    &fstest.MapFile{},
+	}
+	got, err := treeRSS(fsys, 10, 4096)
+	if err != nil {
+		t.Fatalf("treeRSS: %v", err)
+	}
+	if want := int64(600 * 4096); got != want {
+		t.Errorf("treeRSS = %d, want %d", got, want)
+	}
+}
+
+func TestParentPID(t *testing.T) {
+	fsys := fstest.MapFS{
+		"1/stat": procFile("1 (a) b) S 42 1 1"),
+		"2/stat": procFile("2 (truncated"),
+	}
+	if ppid, ok := parentPID(fsys, 1); !ok || ppid != 42 {
+		t.Errorf("parentPID(1) = %d, %v, want 42, true", ppid, ok)
+	}
+	if _, ok := parentPID(fsys, 2); ok {
+		t.Error("parentPID of a malformed stat line succeeded")
+	}
+	if _, ok := parentPID(fsys, 3); ok {
+		t.Error("parentPID of a missing process succeeded")
+	}
+}
@@ -0,0 +1,235 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// inheritedPageKeys are the page attributes a Page may inherit from its
+// ancestors in the page tree (ISO 32000-1, 7.7.3.4).
+var inheritedPageKeys = []string{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// mergePDFs concatenates the pages of pdfs into a single document. The
+// pages keep their content, resources and annotations; document-level
+// structures such as the outline, named destinations, the structure tree
+// and metadata are not carried over. Instead, each document with a
+// non-empty entry in titles gets a top-level bookmark at its first page.
+func mergePDFs(pdfs [][]byte, titles []string) ([]byte, error) {
+	m := &merger{version: "1.4"}
+	catalogRef := m.reserve()
+	pagesRef := m.reserve()
+
+	var kids []*Object
+	var bookmarks []outlineItem
+	for i, pdf := range pdfs {
+		doc, err := Load(pdf)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if _, ok := doc.trailer["Encrypt"]; ok {
+			return nil, fmt.Errorf("document %d is encrypted", i)
+		}
+		if v := doc.Version(); v > m.version && len(v) == 3 {
+			m.version = v
+		}
+		pages, err := m.addPages(doc, pagesRef)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if i < len(titles) && titles[i] != "" && len(pages) > 0 {
+			bookmarks = append(bookmarks, outlineItem{title: titles[i], level: 1, page: pages[0].Ref})
+		}
+		kids = append(kids, pages...)
+	}
+
+	m.set(pagesRef, dictObj(Dict{
+		"Type":  nameObj("Pages"),
+		"Kids":  arrayObj(kids...),
+		"Count": intObj(len(kids)),
+	}))
+	cat := Dict{
+		"Type":  nameObj("Catalog"),
+		"Pages": refObj(pagesRef),
+	}
+	if len(bookmarks) > 0 {
+		cat["Outlines"] = refObj(addOutline(m, bookmarks))
+		cat["PageMode"] = nameObj("UseOutlines")
+	}
+	m.set(catalogRef, dictObj(cat))
+	return m.bytes(catalogRef), nil
+}
+
+// merger accumulates the objects of a merged document.
+type merger struct {
+	version string
+	objects []*Object // object n is objects[n-1]
+	trailer Dict      // trailer entries besides Size and Root, if any
+}
+
+func (m *merger) reserve() Reference {
+	m.objects = append(m.objects, nil)
+	return Reference{Number: len(m.objects)}
+}
+
+func (m *merger) set(ref Reference, obj *Object) {
+	m.objects[ref.Number-1] = obj
+}
+
+// addPages copies the pages of doc under the page tree node pagesRef and
+// returns references to the copies.
+func (m *merger) addPages(doc *Document, pagesRef Reference) ([]*Object, error) {
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Number the pages up front, so links between them resolve to the
+	// copies, and map the source page tree nodes to the merged one.
+	c := &objectCopier{doc: doc, m: m, refs: make(map[Reference]Reference)}
+	for _, ref := range refs {
+		c.refs[ref] = m.reserve()
+	}
+	c.pagesRef = pagesRef
+
+	kids := make([]*Object, 0, len(refs))
+	for _, ref := range refs {
+		page, err := doc.ResolveRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		d := make(Dict, len(page.Dict))
+		for k, v := range page.Dict {
+			switch k {
+			case "Parent", "StructParents":
+				// The parent is replaced below; the structure tree is
+				// not carried over.
+			default:
+				d[k] = c.copy(v, 0)
+			}
+		}
+		for _, k := range inheritedPageKeys {
+			if _, ok := d[k]; !ok {
+				if v := inheritedAttr(doc, page.Dict, k); v != nil {
+					d[k] = c.copy(v, 0)
+				}
+			}
+		}
+		d["Parent"] = refObj(pagesRef)
+		m.set(c.refs[ref], dictObj(d))
+		kids = append(kids, refObj(c.refs[ref]))
+	}
+	return kids, nil
+}
+
+// inheritedAttr looks key up in the ancestors of page.
+func inheritedAttr(doc *Document, page Dict, key string) *Object {
+	node := page
+	for depth := 0; depth < maxNesting; depth++ {
+		parent, err := doc.Resolve(node["Parent"])
+		if err != nil || parent == nil || parent.Type != ObjDict {
+			return nil
+		}
+		if v, ok := parent.Dict[key]; ok {
+			return v
+		}
+		node = parent.Dict
+	}
+	return nil
+}
+
+// objectSink receives the objects an objectCopier copies: a merged
+// document or an incremental update.
+type objectSink interface {
+	reserve() Reference
+	set(ref Reference, obj *Object)
+}
+
+// objectCopier deep-copies objects from doc into m, renumbering indirect
+// objects as they are first reached.
+type objectCopier struct {
+	doc      *Document
+	m        objectSink
+	refs     map[Reference]Reference // source to merged
+	pagesRef Reference
+}
+
+func (c *objectCopier) copy(obj *Object, depth int) *Object {
+	if obj == nil || depth > maxNesting {
+		return nil
+	}
+	switch obj.Type {
+	case ObjRef:
+		return refObj(c.copyRef(obj.Ref, depth))
+	case ObjArray:
+		items := make([]*Object, len(obj.Array))
+		for i, item := range obj.Array {
+			items[i] = c.copy(item, depth+1)
+		}
+		return arrayObj(items...)
+	case ObjDict, ObjStream:
+		d := make(Dict, len(obj.Dict))
+		for k, v := range obj.Dict {
+			d[k] = c.copy(v, depth+1)
+		}
+		cp := *obj
+		cp.Dict = d
+		return &cp
+	default:
+		return obj
+	}
+}
+
+// copyRef returns the merged reference for the source object ref,
+// copying the object on first use. References to page tree nodes other
+// than pages map to the merged page tree.
+func (c *objectCopier) copyRef(ref Reference, depth int) Reference {
+	if r, ok := c.refs[ref]; ok {
+		return r
+	}
+	obj, err := c.doc.ResolveRef(ref)
+	if err != nil {
+		obj = &Object{Type: ObjNull}
+	}
+	if obj.Type == ObjDict {
+		if t, _ := obj.Dict.GetName("Type"); t == "Pages" {
+			c.refs[ref] = c.pagesRef
+			return c.pagesRef
+		}
+	}
+	// Number the object before copying it, as it may refer to itself.
+	r := c.m.reserve()
+	c.refs[ref] = r
+	c.m.set(r, c.copy(obj, depth+1))
+	return r
+}
+
+// bytes serializes the merged document with a classic xref table.
+func (m *merger) bytes(root Reference) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", m.version)
+	offsets := make([]int, len(m.objects))
+	for i, obj := range m.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		writeObject(&buf, obj)
+		buf.WriteString("\nendobj\n")
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", len(m.objects)+1)
+	buf.WriteString("0000000000 65535 f \n")
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	buf.WriteString("trailer\n")
+	trailer := Dict{
+		"Size": intObj(len(m.objects) + 1),
+		"Root": refObj(root),
+	}
+	for k, v := range m.trailer {
+		trailer[k] = v
+	}
+	writeDict(&buf, trailer)
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes()
+}
@@ -0,0 +1,131 @@
+package htmlpdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergePDFs(t *testing.T) {
+	first := buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 100 700 Td (Cover) Tj ET"),
+		[]byte("BT /F1 12 Tf 100 700 Td (Body) Tj ET"),
+	})
+	second := buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 100 700 Td (Appendix) Tj ET"),
+	})
+	third := buildXRefStreamPDF()
+
+	merged, err := mergePDFs([][]byte{first, second, third}, nil)
+	if err != nil {
+		t.Fatalf("mergePDFs: %v", err)
+	}
+	if !strings.HasPrefix(string(merged), "%PDF-1.5\n") {
+		t.Errorf("header = %q, want the highest input version", merged[:9])
+	}
+
+	doc := mustLoad(t, merged)
+	pages, err := doc.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 4 {
+		t.Fatalf("got %d pages, want 4", len(pages))
+	}
+	ext := NewExtractor(doc)
+	for i, want := range []string{"Cover", "Body", "Appendix"} {
+		if text, _ := ext.ExtractPage(i); !strings.Contains(text, want) {
+			t.Errorf("page %d: text %q, want %q", i, text, want)
+		}
+	}
+
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, page := range pages {
+		if page["Parent"].Ref != cat["Pages"].Ref {
+			t.Errorf("page %d: parent %v, want the merged page tree %v", i, page["Parent"].Ref, cat["Pages"].Ref)
+		}
+	}
+}
+
+func TestMergePDFs_Encrypted(t *testing.T) {
+	enc, err := encryptPDF(buildTestPDF([][]byte{[]byte("BT ET")}), "owner", "", PermitAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mergePDFs([][]byte{enc}, nil); err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Errorf("err = %v, want an encrypted document error", err)
+	}
+}
+
+func TestInheritedAttr(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	box := arrayObj(intObj(0), intObj(0), intObj(100), intObj(100))
+	page := Dict{"Parent": dictObj(Dict{
+		"Parent": dictObj(Dict{"MediaBox": box, "Rotate": intObj(0)}),
+		"Rotate": intObj(90),
+	})}
+
+	if got := inheritedAttr(doc, page, "MediaBox"); got != box {
+		t.Errorf("MediaBox = %v, want the grandparent's", got)
+	}
+	if got := inheritedAttr(doc, page, "Rotate"); got == nil || got.Int != 90 {
+		t.Errorf("Rotate = %v, want the nearest ancestor's 90", got)
+	}
+	if got := inheritedAttr(doc, page, "CropBox"); got != nil {
+		t.Errorf("CropBox = %v, want nil", got)
+	}
+}
+
+func TestMergePDFs_Titles(t *testing.T) {
+	cover := buildTestPDF([][]byte{[]byte("BT ET")})
+	body := buildTestPDF([][]byte{[]byte("BT ET"), []byte("BT ET")})
+	appendix := buildTestPDF([][]byte{[]byte("BT ET")})
+
+	merged, err := mergePDFs([][]byte{cover, body, appendix}, []string{"", "Sales", "Appendix"})
+	if err != nil {
+		t.Fatalf("mergePDFs: %v", err)
+	}
+	doc := mustLoad(t, merged)
+	cat, _ := doc.Catalog()
+	if mode, _ := cat.GetName("PageMode"); mode != "UseOutlines" {
+		t.Errorf("PageMode = %q, want UseOutlines", mode)
+	}
+	root, _ := doc.Resolve(cat["Outlines"])
+	if root == nil || root.Type != ObjDict {
+		t.Fatalf("/Outlines = %v", root)
+	}
+	if n, _ := root.Dict.GetInt("Count"); n != 2 {
+		t.Errorf("outline Count = %d, want 2", n)
+	}
+
+	refs, _ := doc.pageRefs()
+	item, _ := doc.Resolve(root.Dict["First"])
+	for _, want := range []struct {
+		title string
+		page  int
+	}{{"Sales", 1}, {"Appendix", 3}} {
+		if item == nil {
+			t.Fatalf("no bookmark for %q", want.title)
+		}
+		if got := string(item.Dict["Title"].Str); got != want.title {
+			t.Errorf("bookmark = %q, want %q", got, want.title)
+		}
+		if dest := item.Dict["Dest"]; dest.Array[0].Ref != refs[want.page] {
+			t.Errorf("%s points at %v, want page %d (%v)", want.title, dest.Array[0].Ref, want.page+1, refs[want.page])
+		}
+		item, _ = doc.Resolve(item.Dict["Next"])
+	}
+}
+
+func TestMergePDFs_NoTitlesNoOutline(t *testing.T) {
+	merged, err := mergePDFs([][]byte{buildTestPDF([][]byte{[]byte("BT ET")})}, []string{""})
+	if err != nil {
+		t.Fatalf("mergePDFs: %v", err)
+	}
+	cat, _ := mustLoad(t, merged).Catalog()
+	if _, ok := cat["Outlines"]; ok {
+		t.Error("merged document has an outline without titles")
+	}
+}
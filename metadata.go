@@ -0,0 +1,322 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metadata is the document information written into a generated PDF. It
+// is shown in viewers' document properties and indexed by search engines
+// and document management systems. Empty fields leave the values Chrome
+// wrote unchanged.
+type Metadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string // comma-separated
+	Creator  string // application that created the source content
+}
+
+// isZero reports whether m sets no fields.
+func (m Metadata) isZero() bool {
+	return m.Title == "" && m.Author == "" && m.Subject == "" &&
+		m.Keywords == "" && m.Creator == ""
+}
+
+// docInfo is the merged document information written to both the Info
+// dictionary and the XMP packet, which must agree.
+type docInfo struct {
+	title, author, subject, keywords string
+	creator, producer                string
+	created, modified                time.Time
+}
+
+// setMetadata returns pdf with md written to its document information
+// dictionary and to an XMP metadata stream referenced from the catalog.
+// The modification date is set to now.
+func setMetadata(pdf []byte, md Metadata, now time.Time) ([]byte, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeDocInfo(u, mergeDocInfo(doc, md, now), nil); err != nil {
+		return nil, err
+	}
+	return u.bytes(), nil
+}
+
+// mergeDocInfo returns the document's information with the fields set in
+// md overriding it and the modification date set to now.
+func mergeDocInfo(doc *Document, md Metadata, now time.Time) docInfo {
+	info := readDocInfo(doc)
+	info.modified = now
+	if info.created.IsZero() {
+		info.created = now
+	}
+	if md.Title != "" {
+		info.title = md.Title
+	}
+	if md.Author != "" {
+		info.author = md.Author
+	}
+	if md.Subject != "" {
+		info.subject = md.Subject
+	}
+	if md.Keywords != "" {
+		info.keywords = md.Keywords
+	}
+	if md.Creator != "" {
+		info.creator = md.Creator
+	}
+	return info
+}
+
+// readDocInfo returns the entries of the document's Info dictionary.
+func readDocInfo(doc *Document) docInfo {
+	var info docInfo
+	obj, err := doc.Resolve(doc.trailer["Info"])
+	if err != nil || obj == nil || obj.Type != ObjDict {
+		return info
+	}
+	text := func(key string) string {
+		v, err := doc.Resolve(obj.Dict[key])
+		if err != nil || v == nil || v.Type != ObjString {
+			return ""
+		}
+		return decodeTextString(v.Str)
+	}
+	info.title = text("Title")
+	info.author = text("Author")
+	info.subject = text("Subject")
+	info.keywords = text("Keywords")
+	info.creator = text("Creator")
+	info.producer = text("Producer")
+	info.created, _ = parsePDFDate(text("CreationDate"))
+	info.modified, _ = parsePDFDate(text("ModDate"))
+	return info
+}
+
+// DocumentInfo describes a generated PDF, as read back from the file by
+// [Result.Metadata].
+type DocumentInfo struct {
+	Title, Author, Subject, Keywords string
+	Creator, Producer                string
+	Created, Modified                time.Time // zero if not recorded
+
+	// Version is the PDF version from the file header, such as "1.4".
+	Version string
+
+	// Pages holds the size of each page in centimeters, as displayed:
+	// the crop box, and pages rotated by 90 or 270 degrees have their
+	// sides swapped.
+	Pages []PageSize
+
+	// Encrypted reports whether the document is encrypted. The text
+	// fields and dates of an encrypted document are left empty, as its
+	// strings cannot be read without the key.
+	Encrypted bool
+}
+
+// readDocumentInfo parses pdf and describes it.
+func readDocumentInfo(pdf []byte) (*DocumentInfo, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	di := &DocumentInfo{Version: doc.Version()}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		di.Encrypted = true
+	} else {
+		info := readDocInfo(doc)
+		di.Title, di.Author, di.Subject, di.Keywords = info.title, info.author, info.subject, info.keywords
+		di.Creator, di.Producer = info.creator, info.producer
+		di.Created, di.Modified = info.created, info.modified
+	}
+
+	pages, err := doc.Pages()
+	if err != nil {
+		return nil, err
+	}
+	di.Pages = make([]PageSize, len(pages))
+	for i, page := range pages {
+		pi := doc.GetPageInfo(page)
+		size := PageSize{Width: Points(pi.Width), Height: Points(pi.Height)}
+		if r := (pi.Rotation%360 + 360) % 360; r == 90 || r == 270 {
+			size.Width, size.Height = size.Height, size.Width
+		}
+		di.Pages[i] = size
+	}
+	return di, nil
+}
+
+// writeDocInfo adds a new Info dictionary and XMP metadata stream for info
+// to u. extraXMP is inserted verbatim into the XMP rdf:RDF element, for
+// additional schemas such as PDF/A identification.
+func writeDocInfo(u *pdfUpdate, info docInfo, extraXMP []byte) error {
+	d := Dict{}
+	set := func(key, value string) {
+		if value != "" {
+			d[key] = textStringObj(value)
+		}
+	}
+	set("Title", info.title)
+	set("Author", info.author)
+	set("Subject", info.subject)
+	set("Keywords", info.keywords)
+	set("Creator", info.creator)
+	set("Producer", info.producer)
+	set("CreationDate", formatPDFDate(info.created))
+	set("ModDate", formatPDFDate(info.modified))
+	u.trailer["Info"] = refObj(u.add(dictObj(d)))
+
+	catRef, cat, err := u.catalog()
+	if err != nil {
+		return fmt.Errorf("writing metadata: %w", err)
+	}
+	xmp := &Object{
+		Type: ObjStream,
+		Dict: Dict{"Type": nameObj("Metadata"), "Subtype": nameObj("XML")},
+		// Uncompressed, so that tools that are not PDF-aware can find it.
+		Stream: xmpPacket(info, extraXMP),
+	}
+	cat["Metadata"] = refObj(u.add(xmp))
+	u.set(catRef, dictObj(cat))
+	return nil
+}
+
+// xmpPacket renders info as an XMP packet using the Dublin Core, PDF and
+// XMP basic schemas.
+func xmpPacket(info docInfo, extra []byte) []byte {
+	var b bytes.Buffer
+	esc := func(s string) string {
+		var e bytes.Buffer
+		xml.EscapeText(&e, []byte(s))
+		return e.String()
+	}
+	prop := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "   <%s>%s</%s>\n", name, esc(value), name)
+		}
+	}
+	alt := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "   <%s><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></%s>\n", name, esc(value), name)
+		}
+	}
+
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\"\n")
+	b.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\">\n")
+	prop("dc:format", "application/pdf")
+	alt("dc:title", info.title)
+	if info.author != "" {
+		fmt.Fprintf(&b, "   <dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", esc(info.author))
+	}
+	alt("dc:description", info.subject)
+	if info.keywords != "" {
+		b.WriteString("   <dc:subject><rdf:Bag>")
+		for _, k := range strings.Split(info.keywords, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				fmt.Fprintf(&b, "<rdf:li>%s</rdf:li>", esc(k))
+			}
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+	}
+	prop("pdf:Keywords", info.keywords)
+	prop("pdf:Producer", info.producer)
+	prop("xmp:CreatorTool", info.creator)
+	prop("xmp:CreateDate", formatXMPDate(info.created))
+	prop("xmp:ModifyDate", formatXMPDate(info.modified))
+	prop("xmp:MetadataDate", formatXMPDate(info.modified))
+	b.WriteString("  </rdf:Description>\n")
+	b.Write(extra)
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return b.Bytes()
+}
+
+// decodeTextString decodes a PDF text string: UTF-16BE if it starts with
+// a byte order mark, otherwise PDFDocEncoding, approximated as Latin-1.
+func decodeTextString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		units := make([]uint16, 0, (len(b)-2)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return utf16ToString(units)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// parsePDFDate parses a PDF date string (D:YYYYMMDDHHmmSSOHH'mm'), in
+// which every component after the year is optional.
+func parsePDFDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(s, "D:")
+	// Pad the date and time to full length with their defaults.
+	const defaults = "00000101000000"
+	n := 0
+	for n < len(s) && n < len(defaults) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	if n < 4 {
+		return time.Time{}, false
+	}
+	stamp := s[:n] + defaults[n:]
+	loc := time.UTC
+	if tz := s[n:]; len(tz) >= 3 && (tz[0] == '+' || tz[0] == '-') {
+		var h, m int
+		fmt.Sscanf(strings.ReplaceAll(tz[1:], "'", " "), "%d %d", &h, &m)
+		offset := h*3600 + m*60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+	t, err := time.ParseInLocation("20060102150405", stamp, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// formatPDFDate formats t as a PDF date string, or "" for the zero time.
+func formatPDFDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	s := t.Format("D:20060102150405")
+	_, offset := t.Zone()
+	if offset == 0 {
+		return s + "Z"
+	}
+	sign := byte('+')
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%s%c%02d'%02d'", s, sign, offset/3600, offset%3600/60)
+}
+
+// formatXMPDate formats t as an XMP (ISO 8601) date, or "" for the zero
+// time.
+func formatXMPDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,187 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSetMetadata(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	out, err := setMetadata(pdf, Metadata{
+		Title:    "Invoice #42 — März",
+		Author:   "Billing <billing@example.com>",
+		Keywords: "invoice, 2026",
+		Creator:  "billing-service",
+	}, now)
+	if err != nil {
+		t.Fatalf("setMetadata: %v", err)
+	}
+
+	doc := mustLoad(t, out)
+	info := readDocInfo(doc)
+	if info.title != "Invoice #42 — März" {
+		t.Errorf("Title = %q", info.title)
+	}
+	if info.author != "Billing <billing@example.com>" {
+		t.Errorf("Author = %q", info.author)
+	}
+	if info.keywords != "invoice, 2026" || info.creator != "billing-service" {
+		t.Errorf("Keywords, Creator = %q, %q", info.keywords, info.creator)
+	}
+	if !info.modified.Equal(now) || !info.created.Equal(now) {
+		t.Errorf("dates = %v, %v, want %v", info.created, info.modified, now)
+	}
+
+	cat, _ := doc.Catalog()
+	xmp, _ := doc.Resolve(cat["Metadata"])
+	if xmp == nil || xmp.Type != ObjStream {
+		t.Fatalf("catalog /Metadata = %v, want stream", xmp)
+	}
+	if st, _ := xmp.Dict.GetName("Subtype"); st != "XML" {
+		t.Errorf("/Subtype = %q, want XML", st)
+	}
+	if err := xml.Unmarshal(xmp.Stream, new(struct{})); err != nil {
+		t.Errorf("XMP is not well-formed: %v", err)
+	}
+	for _, want := range []string{
+		"Invoice #42 — März",
+		"Billing &lt;billing@example.com&gt;",
+		"<rdf:li>invoice</rdf:li><rdf:li>2026</rdf:li>",
+		"<xmp:ModifyDate>2026-03-14T15:09:26Z</xmp:ModifyDate>",
+	} {
+		if !bytes.Contains(xmp.Stream, []byte(want)) {
+			t.Errorf("XMP does not contain %q", want)
+		}
+	}
+}
+
+func TestSetMetadata_KeepsExistingInfo(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	doc := mustLoad(t, pdf)
+	u, _ := newPDFUpdate(doc)
+	u.trailer["Info"] = refObj(u.add(dictObj(Dict{
+		"Producer":     textStringObj("Skia/PDF m126"),
+		"CreationDate": textStringObj("D:20240102030405+01'00'"),
+	})))
+
+	out, err := setMetadata(u.bytes(), Metadata{Title: "T"}, time.Now())
+	if err != nil {
+		t.Fatalf("setMetadata: %v", err)
+	}
+	info := readDocInfo(mustLoad(t, out))
+	if info.producer != "Skia/PDF m126" {
+		t.Errorf("Producer = %q, want Skia/PDF m126", info.producer)
+	}
+	want := time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)
+	if !info.created.Equal(want) {
+		t.Errorf("CreationDate = %v, want %v", info.created, want)
+	}
+}
+
+func TestPDFDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"D:20240102030405Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"D:20240102030405-05'30'", time.Date(2024, 1, 2, 8, 34, 5, 0, time.UTC)},
+		{"D:2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := parsePDFDate(tt.in)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("parsePDFDate(%q) = %v, %v, want %v", tt.in, got, ok, tt.want)
+		}
+		if back, _ := parsePDFDate(formatPDFDate(got)); !back.Equal(got) {
+			t.Errorf("formatPDFDate(%v) does not round-trip: %q", got, formatPDFDate(got))
+		}
+	}
+	if _, ok := parsePDFDate("garbage"); ok {
+		t.Error("parsePDFDate accepted garbage")
+	}
+}
+
+func TestReadDocumentInfo(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET"), []byte("BT ET")})
+	created := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	pdf, err := setMetadata(pdf, Metadata{Title: "Invoice #42", Author: "Billing"}, created)
+	if err != nil {
+		t.Fatalf("setMetadata: %v", err)
+	}
+
+	// Rotate the second page and move the first page's MediaBox to the
+	// page tree, from where it is inherited.
+	doc := mustLoad(t, pdf)
+	refs, _ := doc.pageRefs()
+	u, _ := newPDFUpdate(doc)
+	first, _ := doc.ResolveRef(refs[0])
+	d := Dict{}
+	for k, v := range first.Dict {
+		if k != "MediaBox" {
+			d[k] = v
+		}
+	}
+	u.set(refs[0], dictObj(d))
+	second, _ := doc.ResolveRef(refs[1])
+	second.Dict["Rotate"] = intObj(90)
+	u.set(refs[1], second)
+	pagesRef := first.Dict["Parent"].Ref
+	pages, _ := doc.ResolveRef(pagesRef)
+	pages.Dict["MediaBox"] = arrayObj(intObj(0), intObj(0), intObj(612), intObj(792))
+	u.set(pagesRef, pages)
+
+	info, err := readDocumentInfo(u.bytes())
+	if err != nil {
+		t.Fatalf("readDocumentInfo: %v", err)
+	}
+	if info.Title != "Invoice #42" || info.Author != "Billing" {
+		t.Errorf("Title, Author = %q, %q", info.Title, info.Author)
+	}
+	if !info.Created.Equal(created) || !info.Modified.Equal(created) {
+		t.Errorf("dates = %v, %v, want %v", info.Created, info.Modified, created)
+	}
+	if info.Version != "1.4" {
+		t.Errorf("Version = %q, want 1.4", info.Version)
+	}
+	if info.Encrypted {
+		t.Error("Encrypted = true for an unencrypted document")
+	}
+	want := []PageSize{{Width: 21.59, Height: 27.94}, {Width: 27.94, Height: 21.59}}
+	if len(info.Pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(info.Pages), len(want))
+	}
+	for i, size := range info.Pages {
+		if math.Abs(size.Width-want[i].Width) > 1e-9 || math.Abs(size.Height-want[i].Height) > 1e-9 {
+			t.Errorf("page %d is %v, want %v", i, size, want[i])
+		}
+	}
+}
+
+func TestReadDocumentInfo_Encrypted(t *testing.T) {
+	pdf, err := setMetadata(buildTestPDF([][]byte{[]byte("BT ET")}), Metadata{Title: "Secret"}, time.Now())
+	if err != nil {
+		t.Fatalf("setMetadata: %v", err)
+	}
+	enc, err := encryptPDF(pdf, "", "", PermitAll)
+	if err != nil {
+		t.Fatalf("encryptPDF: %v", err)
+	}
+	info, err := readDocumentInfo(enc)
+	if err != nil {
+		t.Fatalf("readDocumentInfo: %v", err)
+	}
+	if !info.Encrypted {
+		t.Error("Encrypted = false for an encrypted document")
+	}
+	if info.Title != "" {
+		t.Errorf("Title = %q, want it left empty", info.Title)
+	}
+	if len(info.Pages) != 1 {
+		t.Errorf("got %d pages, want 1", len(info.Pages))
+	}
+}
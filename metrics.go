@@ -0,0 +1,214 @@
+package htmlpdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metrics receives instrumentation events from a [Converter], for export
+// to a monitoring system. Implementations must be safe for concurrent use
+// and should return quickly, as they are called on the conversion path.
+// [PrometheusMetrics] is a ready-made implementation.
+type Metrics interface {
+	// ConversionStarted is called when a conversion begins.
+	ConversionStarted()
+
+	// ConversionSucceeded is called when a conversion produces a PDF of
+	// size bytes after d, including any retries.
+	ConversionSucceeded(d time.Duration, size int)
+
+	// ConversionFailed is called when a conversion fails with err
+	// after d, including any retries.
+	ConversionFailed(d time.Duration, err error)
+
+	// QueueDepth is called with the number of conversions in progress on
+	// the Converter whenever it changes. Implementations shared by
+	// several Converters, such as the members of a [ConverterPool],
+	// receive each Converter's count separately.
+	QueueDepth(n int)
+}
+
+// observe runs a conversion, reporting it to the configured Metrics.
+func (c *Converter) observe(convert func() (*Result, error)) (*Result, error) {
+	m := c.cfg.metrics
+	if m == nil {
+		return convert()
+	}
+	m.QueueDepth(int(c.active.Add(1)))
+	m.ConversionStarted()
+	start := time.Now()
+	res, err := convert()
+	d := time.Since(start)
+	m.QueueDepth(int(c.active.Add(-1)))
+	if err != nil {
+		m.ConversionFailed(d, err)
+	} else {
+		m.ConversionSucceeded(d, res.Len())
+	}
+	return res, err
+}
+
+// Histogram bucket upper bounds used by PrometheusMetrics.
+var (
+	durationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	sizeBuckets     = []float64{1e4, 1e5, 1e6, 1e7, 1e8}
+)
+
+// PrometheusMetrics is a [Metrics] implementation that serves the
+// collected metrics in the Prometheus text exposition format, with no
+// dependency on the Prometheus client library:
+//
+//	m := htmlpdf.NewPrometheusMetrics()
+//	c, err := htmlpdf.NewConverter(htmlpdf.WithMetrics(m))
+//	http.Handle("/metrics/htmlpdf", m)
+//
+// It exports htmlpdf_conversions_started_total, htmlpdf_conversions_total
+// and htmlpdf_conversion_duration_seconds (both labelled with result
+// "success" or "failure"), htmlpdf_output_bytes and htmlpdf_queue_depth.
+// One PrometheusMetrics may be shared by several Converters, e.g. by
+// passing WithMetrics to [NewConverterPool]; the queue depth is then the
+// total across them.
+type PrometheusMetrics struct {
+	mu       sync.Mutex
+	started  uint64
+	duration [2]histogram // indexed by resultSuccess, resultFailure
+	size     histogram
+}
+
+const (
+	resultSuccess = iota
+	resultFailure
+)
+
+var resultLabels = [2]string{"success", "failure"}
+
+// NewPrometheusMetrics returns an empty PrometheusMetrics.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	p := &PrometheusMetrics{size: newHistogram(sizeBuckets)}
+	for i := range p.duration {
+		p.duration[i] = newHistogram(durationBuckets)
+	}
+	return p
+}
+
+func (p *PrometheusMetrics) ConversionStarted() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started++
+}
+
+func (p *PrometheusMetrics) ConversionSucceeded(d time.Duration, size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.duration[resultSuccess].observe(d.Seconds())
+	p.size.observe(float64(size))
+}
+
+func (p *PrometheusMetrics) ConversionFailed(d time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.duration[resultFailure].observe(d.Seconds())
+}
+
+// QueueDepth does nothing: the exported queue depth is derived from the
+// conversions started and finished, which stays correct when shared.
+func (p *PrometheusMetrics) QueueDepth(n int) {}
+
+// ServeHTTP serves the metrics for scraping.
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition
+// format, e.g. to append them to an existing /metrics handler. It
+// implements [io.WriterTo].
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+	header := func(name, typ, help string) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	header("htmlpdf_conversions_started_total", "counter", "Conversions started.")
+	fmt.Fprintf(b, "htmlpdf_conversions_started_total %d\n", p.started)
+
+	header("htmlpdf_conversions_total", "counter", "Conversions finished, by result.")
+	for i, label := range resultLabels {
+		fmt.Fprintf(b, "htmlpdf_conversions_total{result=%q} %d\n", label, p.duration[i].count)
+	}
+
+	header("htmlpdf_conversion_duration_seconds", "histogram", "Time taken by conversions, including retries.")
+	for i, label := range resultLabels {
+		p.duration[i].write(b, "htmlpdf_conversion_duration_seconds", fmt.Sprintf("result=%q,", label))
+	}
+
+	header("htmlpdf_output_bytes", "histogram", "Size of the generated PDFs.")
+	p.size.write(b, "htmlpdf_output_bytes", "")
+
+	header("htmlpdf_queue_depth", "gauge", "Conversions in progress.")
+	fmt.Fprintf(b, "htmlpdf_queue_depth %d\n", p.started-p.duration[resultSuccess].count-p.duration[resultFailure].count)
+
+	err := b.Flush()
+	return cw.n, err
+}
+
+// histogram is a cumulative Prometheus-style histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// write writes the histogram's samples; labels is a prefix of
+// "name=value," pairs added to every sample.
+func (h *histogram) write(w io.Writer, name, labels string) {
+	var cum uint64
+	for i, b := range h.bounds {
+		cum += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", name, labels, strconv.FormatFloat(b, 'g', -1, 64), cum)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+	trimmed := labels
+	if trimmed != "" {
+		trimmed = "{" + trimmed[:len(trimmed)-1] + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, trimmed, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, trimmed, h.count)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
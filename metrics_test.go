@@ -0,0 +1,84 @@
+package htmlpdf
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recordingMetrics) record(e string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recordingMetrics) ConversionStarted() { r.record("started") }
+func (r *recordingMetrics) ConversionSucceeded(_ time.Duration, n int) {
+	r.record("succeeded " + itoa(n))
+}
+func (r *recordingMetrics) ConversionFailed(_ time.Duration, err error) {
+	r.record("failed " + err.Error())
+}
+func (r *recordingMetrics) QueueDepth(n int) { r.record("queue " + itoa(n)) }
+
+func TestConverter_Observe(t *testing.T) {
+	m := &recordingMetrics{}
+	c := &Converter{cfg: converterConfig{metrics: m}}
+
+	c.observe(func() (*Result, error) { return &Result{data: []byte("%PDF-")}, nil })
+	c.observe(func() (*Result, error) { return nil, errors.New("boom") })
+
+	want := []string{
+		"queue 1", "started", "queue 0", "succeeded 5",
+		"queue 1", "started", "queue 0", "failed boom",
+	}
+	if strings.Join(m.events, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %q, want %q", m.events, want)
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	p := NewPrometheusMetrics()
+	p.ConversionStarted()
+	p.ConversionStarted()
+	p.ConversionStarted()
+	p.ConversionSucceeded(300*time.Millisecond, 50_000)
+	p.ConversionFailed(2*time.Second, errors.New("boom"))
+	p.QueueDepth(7) // ignored; derived from started and finished
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE htmlpdf_conversions_started_total counter\n",
+		"htmlpdf_conversions_started_total 3\n",
+		`htmlpdf_conversions_total{result="success"} 1` + "\n",
+		`htmlpdf_conversions_total{result="failure"} 1` + "\n",
+		`htmlpdf_conversion_duration_seconds_bucket{result="success",le="0.25"} 0` + "\n",
+		`htmlpdf_conversion_duration_seconds_bucket{result="success",le="0.5"} 1` + "\n",
+		`htmlpdf_conversion_duration_seconds_bucket{result="failure",le="+Inf"} 1` + "\n",
+		`htmlpdf_conversion_duration_seconds_sum{result="failure"} 2` + "\n",
+		`htmlpdf_output_bytes_bucket{le="100000"} 1` + "\n",
+		"htmlpdf_output_bytes_sum 50000\n",
+		"htmlpdf_output_bytes_count 1\n",
+		"htmlpdf_queue_depth 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if n, _ := p.WriteTo(&strings.Builder{}); n != int64(len(body)) {
+		t.Errorf("WriteTo returned %d, want %d", n, len(body))
+	}
+}
@@ -0,0 +1,43 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"os"
+)
+
+// OpenMmap opens a PDF file by mapping it read-only into memory rather
+// than reading it onto the heap: the operating system pages it in as it
+// is parsed and shares the pages with every other mapping of the file.
+// A Document is not safe for concurrent use, but worker goroutines can
+// each call OpenMmap on the same large file without holding a copy of
+// it each. Call [Document.Close] to unmap the file; objects and streams
+// read from the document must not be used after that. On platforms
+// without memory mapping, the file is read into memory as [Open] does.
+func OpenMmap(path string) (*Document, error) {
+	return OpenMmapWithPassword(path, "")
+}
+
+// OpenMmapWithPassword is [OpenMmap] for encrypted documents, decrypting
+// them with password as [LoadWithPassword] does.
+func OpenMmapWithPassword(path, password string) (*Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	data, unmap, err := mmapFile(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("mapping file: %w", err)
+	}
+	doc, err := LoadWithPassword(data, password)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	doc.unmap = unmap
+	return doc, nil
+}
@@ -0,0 +1,17 @@
+//go:build !unix && !windows
+
+package htmlpdf
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads the file into memory where it cannot be mapped.
+func mmapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,56 @@
+package htmlpdf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenMmap(t *testing.T) {
+	pdf, image, pixels := buildScanPDF(t)
+	path := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(path, pdf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := OpenMmap(path)
+	if err != nil {
+		t.Fatalf("OpenMmap: %v", err)
+	}
+	if doc.unmap == nil {
+		t.Error("document not mapped")
+	}
+	if texts, _ := NewExtractor(doc).ExtractAll(); len(texts) != 2 || texts[0] != "Scanned page" {
+		t.Errorf("text = %q", texts)
+	}
+	img, err := doc.ResolveRef(image)
+	if err != nil || string(img.Stream) != string(pixels) {
+		t.Errorf("image stream differs: %v", err)
+	}
+
+	if err := doc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if obj, _ := doc.ResolveRef(image); obj.Type != ObjNull {
+		t.Error("object read from the file after Close")
+	}
+}
+
+func TestOpenMmap_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenMmap(filepath.Join(dir, "missing.pdf")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	empty := filepath.Join(dir, "empty.pdf")
+	os.WriteFile(empty, nil, 0o644)
+	if _, err := OpenMmap(empty); err == nil {
+		t.Error("expected error for an empty file")
+	}
+	notPDF := filepath.Join(dir, "note.txt")
+	os.WriteFile(notPDF, []byte("not a pdf"), 0o644)
+	if _, err := OpenMmap(notPDF); err == nil {
+		t.Error("expected error for a file that is not a PDF")
+	}
+}
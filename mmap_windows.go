@@ -0,0 +1,29 @@
+//go:build windows
+
+package htmlpdf
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func mmapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	if size == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	h, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, syscall.PAGE_READONLY, 0, 0, nil)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	// The view keeps the mapping alive once its handle is closed.
+	defer syscall.CloseHandle(h)
+	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	if err != nil {
+		return nil, nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	// addr is outside the Go heap, so converting it does not hide a
+	// pointer from the garbage collector.
+	data := unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), size)
+	return data, func() error { return syscall.UnmapViewOfFile(addr) }, nil
+}
@@ -0,0 +1,309 @@
+package htmlpdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// NetworkEntry is a request made by the page during a conversion and the
+// response to it, as recorded when [ConvertOptions].RecordNetwork is set.
+// A redirect is recorded as its own entry with the 3xx response.
+type NetworkEntry struct {
+	URL            string
+	Method         string
+	ResourceType   string // Chrome's resource type, e.g. "Document" or "Image"
+	RequestHeaders http.Header
+	Started        time.Time
+	// Duration is the time from sending the request until the response
+	// finished loading, failed or redirected; 0 if none of these happened
+	// before printing.
+	Duration time.Duration
+
+	StatusCode      int // HTTP status, or 0 if no response was received
+	StatusText      string
+	ResponseHeaders http.Header
+	MIMEType        string
+	Protocol        string // e.g. "http/1.1" or "h2"
+	RemoteAddress   string // IP address and port of the server
+	FromCache       bool
+	Size            int64  // bytes received, including headers
+	Error           string // network error, e.g. "net::ERR_CONNECTION_REFUSED"
+}
+
+// networkLog records the requests of a tab and their responses.
+type networkLog struct {
+	mu      sync.Mutex
+	entries []*NetworkEntry
+	pending map[network.RequestID]pendingRequest
+}
+
+type pendingRequest struct {
+	entry *NetworkEntry
+	sent  *cdp.MonotonicTime
+}
+
+func newNetworkLog() *networkLog {
+	return &networkLog{pending: make(map[network.RequestID]pendingRequest)}
+}
+
+// listen is a chromedp target listener. It must not block.
+func (l *networkLog) listen(ev any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// A redirect reuses the request ID and completes the request
+		// before it.
+		if p, ok := l.pending[e.RequestID]; ok && e.RedirectResponse != nil {
+			p.entry.setResponse(e.RedirectResponse)
+			p.entry.Duration = elapsed(p.sent, e.Timestamp)
+		}
+		entry := &NetworkEntry{
+			URL:            e.Request.URL,
+			Method:         e.Request.Method,
+			ResourceType:   string(e.Type),
+			RequestHeaders: httpHeader(e.Request.Headers),
+		}
+		if e.WallTime != nil {
+			entry.Started = e.WallTime.Time()
+		}
+		l.entries = append(l.entries, entry)
+		l.pending[e.RequestID] = pendingRequest{entry: entry, sent: e.Timestamp}
+	case *network.EventResponseReceived:
+		if p, ok := l.pending[e.RequestID]; ok {
+			p.entry.setResponse(e.Response)
+		}
+	case *network.EventLoadingFinished:
+		if p, ok := l.pending[e.RequestID]; ok {
+			p.entry.Size = int64(e.EncodedDataLength)
+			p.entry.Duration = elapsed(p.sent, e.Timestamp)
+			delete(l.pending, e.RequestID)
+		}
+	case *network.EventLoadingFailed:
+		if p, ok := l.pending[e.RequestID]; ok {
+			p.entry.Error = e.ErrorText
+			p.entry.Duration = elapsed(p.sent, e.Timestamp)
+			delete(l.pending, e.RequestID)
+		}
+	}
+}
+
+// setResponse records resp in e.
+func (e *NetworkEntry) setResponse(resp *network.Response) {
+	e.StatusCode = int(resp.Status)
+	e.StatusText = resp.StatusText
+	e.ResponseHeaders = httpHeader(resp.Headers)
+	e.MIMEType = resp.MimeType
+	e.Protocol = resp.Protocol
+	if resp.RemoteIPAddress != "" {
+		e.RemoteAddress = net.JoinHostPort(strings.Trim(resp.RemoteIPAddress, "[]"), strconv.FormatInt(resp.RemotePort, 10))
+	}
+	e.FromCache = resp.FromDiskCache || resp.FromPrefetchCache
+	e.Size = int64(resp.EncodedDataLength)
+}
+
+// list returns a copy of the entries recorded so far, in the order the
+// requests were sent.
+func (l *networkLog) list() []NetworkEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == 0 {
+		return nil
+	}
+	entries := make([]NetworkEntry, len(l.entries))
+	for i, e := range l.entries {
+		entries[i] = *e
+	}
+	return entries
+}
+
+// elapsed returns the time between two DevTools timestamps, or 0 if
+// either is missing.
+func elapsed(from, to *cdp.MonotonicTime) time.Duration {
+	if from == nil || to == nil {
+		return 0
+	}
+	return max(to.Time().Sub(from.Time()), 0)
+}
+
+// httpHeader converts DevTools headers, which join repeated headers with
+// newlines, to an http.Header.
+func httpHeader(h network.Headers) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	header := make(http.Header, len(h))
+	for k, v := range h {
+		for _, line := range strings.Split(fmt.Sprint(v), "\n") {
+			header.Add(k, line)
+		}
+	}
+	return header
+}
+
+// ---- HAR export ----
+
+// harLog and the types below are the subset of HAR 1.2
+// (http://www.softwareishard.com/blog/har-12-spec/) the network log fills.
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	HTTPVersion string    `json:"httpVersion"`
+	Cookies     []harPair `json:"cookies"`
+	Headers     []harPair `json:"headers"`
+	QueryString []harPair `json:"queryString"`
+	HeadersSize int       `json:"headersSize"`
+	BodySize    int       `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int        `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []harPair  `json:"cookies"`
+	Headers     []harPair  `json:"headers"`
+	Content     harContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int64      `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// marshalHAR returns entries as a HAR 1.2 document. Response bodies are
+// not recorded, so the entries' content holds only sizes and types.
+func marshalHAR(entries []NetworkEntry) ([]byte, error) {
+	log := harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "go-html-pdf", Version: "1"},
+		Entries: make([]harEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		ms := float64(e.Duration) / float64(time.Millisecond)
+		entry := harEntry{
+			StartedDateTime: e.Started.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+			Time:            ms,
+			Request: harRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: harHTTPVersion(e.Protocol),
+				Cookies:     []harPair{},
+				Headers:     harHeaders(e.RequestHeaders),
+				QueryString: []harPair{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: harResponse{
+				Status:      e.StatusCode,
+				StatusText:  e.StatusText,
+				HTTPVersion: harHTTPVersion(e.Protocol),
+				Cookies:     []harPair{},
+				Headers:     harHeaders(e.ResponseHeaders),
+				Content:     harContent{Size: -1, MimeType: e.MIMEType},
+				RedirectURL: e.ResponseHeaders.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    e.Size,
+			},
+			Timings:      harTimings{Wait: ms},
+			ResourceType: e.ResourceType,
+			Error:        e.Error,
+		}
+		if e.StatusCode == 0 {
+			entry.Response.BodySize = -1
+		}
+		if host, _, err := net.SplitHostPort(e.RemoteAddress); err == nil {
+			entry.ServerIPAddress = host
+		}
+		if u, err := url.Parse(e.URL); err == nil {
+			for k, vs := range u.Query() {
+				for _, v := range vs {
+					entry.Request.QueryString = append(entry.Request.QueryString, harPair{Name: k, Value: v})
+				}
+			}
+			sortPairs(entry.Request.QueryString)
+		}
+		log.Entries = append(log.Entries, entry)
+	}
+	return json.MarshalIndent(struct {
+		Log harLog `json:"log"`
+	}{log}, "", "  ")
+}
+
+// harHTTPVersion returns the HTTP version HAR expects for a DevTools
+// protocol name.
+func harHTTPVersion(protocol string) string {
+	switch protocol {
+	case "h2":
+		return "HTTP/2"
+	case "h3":
+		return "HTTP/3"
+	case "":
+		return ""
+	}
+	return strings.ToUpper(protocol)
+}
+
+// harHeaders returns h as name-value pairs sorted by name.
+func harHeaders(h http.Header) []harPair {
+	pairs := []harPair{}
+	for k, vs := range h {
+		for _, v := range vs {
+			pairs = append(pairs, harPair{Name: k, Value: v})
+		}
+	}
+	sortPairs(pairs)
+	return pairs
+}
+
+func sortPairs(pairs []harPair) {
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+}
@@ -0,0 +1,152 @@
+package htmlpdf
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+func monotonic(d time.Duration) *cdp.MonotonicTime {
+	t := cdp.MonotonicTime(time.Unix(0, 0).Add(d))
+	return &t
+}
+
+func TestNetworkLog(t *testing.T) {
+	l := newNetworkLog()
+	wall := cdp.TimeSinceEpoch(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	// A redirect, then the page it leads to.
+	l.listen(&network.EventRequestWillBeSent{RequestID: "1", Type: network.ResourceTypeDocument,
+		Request:   &network.Request{URL: "http://example.com/", Method: "GET", Headers: network.Headers{"Accept": "text/html"}},
+		Timestamp: monotonic(0), WallTime: &wall})
+	l.listen(&network.EventRequestWillBeSent{RequestID: "1", Type: network.ResourceTypeDocument,
+		Request: &network.Request{URL: "https://example.com/", Method: "GET"},
+		RedirectResponse: &network.Response{Status: 301, StatusText: "Moved Permanently",
+			Headers: network.Headers{"Location": "https://example.com/"}},
+		Timestamp: monotonic(20 * time.Millisecond)})
+	l.listen(&network.EventResponseReceived{RequestID: "1", Response: &network.Response{
+		Status: 200, StatusText: "OK", MimeType: "text/html", Protocol: "h2",
+		RemoteIPAddress: "93.184.216.34", RemotePort: 443,
+		Headers: network.Headers{"Set-Cookie": "a=1\nb=2"}}})
+	l.listen(&network.EventLoadingFinished{RequestID: "1", EncodedDataLength: 1256,
+		Timestamp: monotonic(70 * time.Millisecond)})
+
+	// A font that fails to load, and an image still loading.
+	l.listen(&network.EventRequestWillBeSent{RequestID: "2", Type: network.ResourceTypeFont,
+		Request: &network.Request{URL: "https://fonts.invalid/a.woff2", Method: "GET"}, Timestamp: monotonic(0)})
+	l.listen(&network.EventLoadingFailed{RequestID: "2", ErrorText: "net::ERR_NAME_NOT_RESOLVED",
+		Timestamp: monotonic(5 * time.Millisecond)})
+	l.listen(&network.EventRequestWillBeSent{RequestID: "3", Type: network.ResourceTypeImage,
+		Request: &network.Request{URL: "https://example.com/a.png", Method: "GET"}})
+
+	// Events for requests sent before recording started are ignored.
+	l.listen(&network.EventLoadingFinished{RequestID: "9"})
+
+	got := l.list()
+	if len(got) != 4 {
+		t.Fatalf("got %d entries, want 4: %+v", len(got), got)
+	}
+	redirect, page, font, image := got[0], got[1], got[2], got[3]
+	if redirect.URL != "http://example.com/" || redirect.StatusCode != 301 ||
+		redirect.ResponseHeaders.Get("Location") != "https://example.com/" ||
+		redirect.Duration != 20*time.Millisecond || !redirect.Started.Equal(time.Time(wall)) ||
+		redirect.RequestHeaders.Get("Accept") != "text/html" {
+		t.Errorf("redirect = %+v", redirect)
+	}
+	if page.URL != "https://example.com/" || page.StatusCode != 200 || page.Protocol != "h2" ||
+		page.RemoteAddress != "93.184.216.34:443" || page.Size != 1256 ||
+		page.Duration != 50*time.Millisecond || page.ResourceType != "Document" {
+		t.Errorf("page = %+v", page)
+	}
+	if cookies := page.ResponseHeaders.Values("Set-Cookie"); len(cookies) != 2 {
+		t.Errorf("Set-Cookie = %q, want the two joined headers split", cookies)
+	}
+	if font.Error != "net::ERR_NAME_NOT_RESOLVED" || font.StatusCode != 0 || font.Duration != 5*time.Millisecond {
+		t.Errorf("font = %+v", font)
+	}
+	if image.StatusCode != 0 || image.Duration != 0 {
+		t.Errorf("image = %+v, want no response", image)
+	}
+}
+
+func TestNetworkLog_NoneIsNil(t *testing.T) {
+	if got := newNetworkLog().list(); got != nil {
+		t.Errorf("list() = %v, want nil", got)
+	}
+}
+
+func TestMarshalHAR(t *testing.T) {
+	entries := []NetworkEntry{{
+		URL:             "https://example.com/search?q=pdf&page=2",
+		Method:          "GET",
+		ResourceType:    "Document",
+		Started:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Duration:        1500 * time.Microsecond,
+		StatusCode:      302,
+		StatusText:      "Found",
+		ResponseHeaders: map[string][]string{"Location": {"/results"}, "Content-Length": {"0"}},
+		Protocol:        "http/1.1",
+		RemoteAddress:   "[2001:db8::1]:443",
+		Size:            180,
+	}, {
+		URL:    "https://fonts.invalid/a.woff2",
+		Method: "GET",
+		Error:  "net::ERR_NAME_NOT_RESOLVED",
+	}}
+	data, err := marshalHAR(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har struct {
+		Log harLog `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("HAR is not valid JSON: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("log = %+v", har.Log)
+	}
+
+	e := har.Log.Entries[0]
+	if e.StartedDateTime != "2024-05-01T12:00:00.000Z" || e.Time != 1.5 || e.Timings.Wait != 1.5 {
+		t.Errorf("timing = %q %v %+v", e.StartedDateTime, e.Time, e.Timings)
+	}
+	if e.Request.HTTPVersion != "HTTP/1.1" || e.ServerIPAddress != "2001:db8::1" {
+		t.Errorf("httpVersion = %q, serverIPAddress = %q", e.Request.HTTPVersion, e.ServerIPAddress)
+	}
+	if q := e.Request.QueryString; len(q) != 2 || q[0] != (harPair{"page", "2"}) || q[1] != (harPair{"q", "pdf"}) {
+		t.Errorf("queryString = %+v", q)
+	}
+	if h := e.Response.Headers; len(h) != 2 || h[0].Name != "Content-Length" || h[1].Name != "Location" {
+		t.Errorf("response headers = %+v, want sorted by name", h)
+	}
+	if e.Response.Status != 302 || e.Response.RedirectURL != "/results" || e.Response.BodySize != 180 {
+		t.Errorf("response = %+v", e.Response)
+	}
+
+	failed := har.Log.Entries[1]
+	if failed.Error != "net::ERR_NAME_NOT_RESOLVED" || failed.Response.Status != 0 || failed.Response.BodySize != -1 {
+		t.Errorf("failed entry = %+v", failed)
+	}
+	if failed.Request.Headers == nil || failed.Response.Cookies == nil {
+		t.Error("empty lists are encoded as null, want []")
+	}
+}
+
+func TestMarshalHAR_Empty(t *testing.T) {
+	data, err := marshalHAR(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har struct {
+		Log struct {
+			Entries []any `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil || har.Log.Entries == nil {
+		t.Errorf("HAR = %s, want an empty entries list", data)
+	}
+}
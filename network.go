@@ -0,0 +1,195 @@
+package htmlpdf
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// networkIdle tracks the in-flight requests of a tab so that printing can
+// be deferred until the page has stopped loading resources.
+type networkIdle struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]struct{}
+	last     time.Time // time of the most recent request start or finish
+}
+
+func newNetworkIdle() *networkIdle {
+	return &networkIdle{
+		inflight: make(map[network.RequestID]struct{}),
+		last:     time.Now(),
+	}
+}
+
+// listen is a chromedp target listener. It must not block.
+func (n *networkIdle) listen(ev any) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// Redirects reuse the request ID, so the map keeps one entry.
+		n.inflight[e.RequestID] = struct{}{}
+	case *network.EventLoadingFinished:
+		delete(n.inflight, e.RequestID)
+	case *network.EventLoadingFailed:
+		delete(n.inflight, e.RequestID)
+	default:
+		return
+	}
+	n.last = time.Now()
+}
+
+// reset restarts the idle period, as after a route change the page may
+// not have started its requests yet.
+func (n *networkIdle) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.last = time.Now()
+}
+
+// idleFor reports whether no request has been in flight for at least d.
+func (n *networkIdle) idleFor(d time.Duration) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.inflight) == 0 && time.Since(n.last) >= d
+}
+
+// wait returns an action that blocks until the page has been idle for d.
+// Pages holding long-lived connections (WebSockets, server-sent events)
+// never become idle; the wait is then bounded by the action's context.
+func (n *networkIdle) wait(d time.Duration) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		tick := d / 4
+		if tick < 10*time.Millisecond {
+			tick = 10 * time.Millisecond
+		}
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+
+		for !n.idleFor(d) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+		return nil
+	})
+}
+
+// RequestFailure describes a request made by the page that did not
+// succeed: either a network error or an HTTP error status.
+type RequestFailure struct {
+	URL          string
+	ResourceType string // Chrome's resource type, e.g. "Image" or "Font"
+	StatusCode   int    // HTTP status, or 0 for network errors
+	Error        string // e.g. "net::ERR_NAME_NOT_RESOLVED" or "Not Found"
+}
+
+// requestFailures records the failed requests of a tab.
+type requestFailures struct {
+	mu     sync.Mutex
+	urls   map[network.RequestID]string
+	failed []RequestFailure
+}
+
+func newRequestFailures() *requestFailures {
+	return &requestFailures{urls: make(map[network.RequestID]string)}
+}
+
+// listen is a chromedp target listener. It must not block.
+func (f *requestFailures) listen(ev any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		f.urls[e.RequestID] = e.Request.URL
+	case *network.EventResponseReceived:
+		if e.Response.Status >= 400 {
+			text := e.Response.StatusText
+			if text == "" {
+				text = http.StatusText(int(e.Response.Status))
+			}
+			f.failed = append(f.failed, RequestFailure{
+				URL:          e.Response.URL,
+				ResourceType: string(e.Type),
+				StatusCode:   int(e.Response.Status),
+				Error:        text,
+			})
+		}
+	case *network.EventLoadingFailed:
+		// Cancelled requests (e.g. superseded navigations) and requests
+		// blocked by the converter's blocking policy are not failures.
+		if e.Canceled || e.ErrorText == blockedByClient {
+			return
+		}
+		f.failed = append(f.failed, RequestFailure{
+			URL:          f.urls[e.RequestID],
+			ResourceType: string(e.Type),
+			Error:        e.ErrorText,
+		})
+	}
+}
+
+// blockedByClient is the error text of requests failed by the interceptor.
+const blockedByClient = "net::ERR_BLOCKED_BY_CLIENT"
+
+// list returns a copy of the failures recorded so far.
+func (f *requestFailures) list() []RequestFailure {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.failed) == 0 {
+		return nil
+	}
+	return append([]RequestFailure(nil), f.failed...)
+}
+
+// cookieParams converts cookies to DevTools cookie parameters. Cookies
+// without a Domain are associated with pageURL, which scopes them to its
+// host as a Set-Cookie header from that page would.
+func cookieParams(cookies []*http.Cookie, pageURL string) []*network.CookieParam {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		p := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if c.Domain == "" {
+			p.URL = pageURL
+		}
+		switch c.SameSite {
+		case http.SameSiteStrictMode:
+			p.SameSite = network.CookieSameSiteStrict
+		case http.SameSiteLaxMode:
+			p.SameSite = network.CookieSameSiteLax
+		case http.SameSiteNoneMode:
+			p.SameSite = network.CookieSameSiteNone
+		}
+		if !c.Expires.IsZero() {
+			expires := cdp.TimeSinceEpoch(c.Expires)
+			p.Expires = &expires
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// headerParams converts extra request headers to their DevTools form.
+func headerParams(headers map[string]string) network.Headers {
+	h := make(network.Headers, len(headers))
+	for k, v := range headers {
+		h[k] = v
+	}
+	return h
+}
@@ -0,0 +1,96 @@
+package htmlpdf
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestNetworkIdle_TracksInflight(t *testing.T) {
+	n := newNetworkIdle()
+
+	n.listen(&network.EventRequestWillBeSent{RequestID: "1"})
+	n.listen(&network.EventRequestWillBeSent{RequestID: "2"})
+	if n.idleFor(0) {
+		t.Fatal("idle with two requests in flight")
+	}
+
+	n.listen(&network.EventLoadingFinished{RequestID: "1"})
+	if n.idleFor(0) {
+		t.Fatal("idle with one request in flight")
+	}
+
+	n.listen(&network.EventLoadingFailed{RequestID: "2"})
+	if !n.idleFor(0) {
+		t.Fatal("not idle after all requests completed")
+	}
+	if n.idleFor(time.Hour) {
+		t.Fatal("idle for an hour immediately after the last request")
+	}
+}
+
+func TestNetworkIdle_IgnoresOtherEvents(t *testing.T) {
+	n := newNetworkIdle()
+	n.last = time.Now().Add(-time.Second)
+
+	n.listen(&network.EventResponseReceived{RequestID: "1"})
+	if !n.idleFor(500 * time.Millisecond) {
+		t.Fatal("unrelated event reset the idle timer")
+	}
+}
+
+func TestRequestFailures(t *testing.T) {
+	f := newRequestFailures()
+
+	f.listen(&network.EventRequestWillBeSent{RequestID: "1", Request: &network.Request{URL: "https://example.com/logo.png"}})
+	f.listen(&network.EventResponseReceived{RequestID: "1", Type: network.ResourceTypeImage,
+		Response: &network.Response{URL: "https://example.com/logo.png", Status: 404}})
+
+	f.listen(&network.EventRequestWillBeSent{RequestID: "2", Request: &network.Request{URL: "https://fonts.invalid/a.woff2"}})
+	f.listen(&network.EventLoadingFailed{RequestID: "2", Type: network.ResourceTypeFont, ErrorText: "net::ERR_NAME_NOT_RESOLVED"})
+
+	f.listen(&network.EventRequestWillBeSent{RequestID: "3", Request: &network.Request{URL: "https://example.com/ok.css"}})
+	f.listen(&network.EventResponseReceived{RequestID: "3", Response: &network.Response{Status: 200}})
+
+	f.listen(&network.EventLoadingFailed{RequestID: "4", ErrorText: "net::ERR_ABORTED", Canceled: true})
+	f.listen(&network.EventLoadingFailed{RequestID: "5", ErrorText: blockedByClient})
+
+	got := f.list()
+	want := []RequestFailure{
+		{URL: "https://example.com/logo.png", ResourceType: "Image", StatusCode: 404, Error: "Not Found"},
+		{URL: "https://fonts.invalid/a.woff2", ResourceType: "Font", Error: "net::ERR_NAME_NOT_RESOLVED"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d failures, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("failure %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRequestFailures_NoneIsNil(t *testing.T) {
+	if got := newRequestFailures().list(); got != nil {
+		t.Errorf("list() = %v, want nil", got)
+	}
+}
+
+func TestCookieParams(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	params := cookieParams([]*http.Cookie{
+		{Name: "session", Value: "abc", HttpOnly: true, SameSite: http.SameSiteLaxMode},
+		{Name: "pref", Value: "dark", Domain: ".example.com", Path: "/app", Secure: true, Expires: expires},
+	}, "https://app.example.com/report")
+
+	if p := params[0]; p.URL != "https://app.example.com/report" || p.Domain != "" ||
+		!p.HTTPOnly || p.SameSite != network.CookieSameSiteLax || p.Expires != nil {
+		t.Errorf("host cookie = %+v", p)
+	}
+	if p := params[1]; p.URL != "" || p.Domain != ".example.com" || p.Path != "/app" ||
+		!p.Secure || p.Expires == nil || !time.Time(*p.Expires).Equal(expires) {
+		t.Errorf("domain cookie = %+v", p)
+	}
+}
@@ -0,0 +1,236 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"slices"
+	"sort"
+)
+
+// Optimize rewrites doc to shrink it, returning the new PDF:
+//
+//   - identical objects, such as the font and image streams Chrome
+//     embeds once per page, or the copies each input of a merged
+//     document brings along, are stored once;
+//   - objects nothing refers to any more, such as those replaced by
+//     incremental updates, are dropped;
+//   - streams are recompressed at the highest Flate compression level,
+//     where that makes them smaller.
+//
+// Objects are renumbered, and object streams unpacked into plain objects.
+// It fails for encrypted documents.
+func Optimize(doc *Document) ([]byte, error) {
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, errors.New("cannot optimize an encrypted document")
+	}
+	root := doc.trailer["Root"]
+	if root == nil || root.Type != ObjRef {
+		return nil, errors.New("no /Root reference in trailer")
+	}
+
+	objects := make(map[int]*Object, len(doc.xref))
+	for id, e := range doc.xref {
+		if !e.InUse || id == 0 {
+			continue
+		}
+		obj, err := doc.ResolveRef(Reference{Number: id})
+		if err != nil {
+			return nil, err
+		}
+		if obj.Type == ObjStream {
+			if t, _ := obj.Dict.GetName("Type"); t == "ObjStm" || t == "XRef" {
+				continue
+			}
+		}
+		objects[id] = obj
+	}
+	trailer := Dict{"Root": root}
+	if info := doc.trailer["Info"]; info != nil && info.Type == ObjRef {
+		trailer["Info"] = info
+	}
+
+	// Merging objects can make the objects referring to them identical in
+	// turn, such as the font dictionaries of merged font files.
+	for {
+		dups := duplicateObjects(objects)
+		if len(dups) == 0 {
+			break
+		}
+		for id := range dups {
+			delete(objects, id)
+		}
+		for id, obj := range objects {
+			objects[id] = renumberRefs(obj, dups, false)
+		}
+	}
+
+	// Keep what the trailer reaches, numbered in order of the original
+	// object numbers.
+	reached := make(map[int]bool)
+	var walk func(obj *Object, depth int)
+	walk = func(obj *Object, depth int) {
+		if obj == nil || depth > maxNesting {
+			return
+		}
+		switch obj.Type {
+		case ObjRef:
+			if target, ok := objects[obj.Ref.Number]; ok && !reached[obj.Ref.Number] {
+				reached[obj.Ref.Number] = true
+				walk(target, 0)
+			}
+		case ObjArray:
+			for _, item := range obj.Array {
+				walk(item, depth+1)
+			}
+		case ObjDict, ObjStream:
+			for _, v := range obj.Dict {
+				walk(v, depth+1)
+			}
+		}
+	}
+	walk(dictObj(trailer), 0)
+	ids := make([]int, 0, len(reached))
+	for id := range reached {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	numbers := make(map[int]int, len(ids))
+	for i, id := range ids {
+		numbers[id] = i + 1
+	}
+
+	m := &merger{version: doc.Version(), trailer: make(Dict)}
+	if len(m.version) != 3 {
+		m.version = "1.4"
+	}
+	for _, id := range ids {
+		obj := renumberRefs(objects[id], numbers, true)
+		if obj.Type == ObjStream {
+			obj = recompress(obj)
+		}
+		m.objects = append(m.objects, obj)
+	}
+	if info := trailer["Info"]; info != nil {
+		m.trailer["Info"] = renumberRefs(info, numbers, true)
+	}
+	if id := doc.trailer["ID"]; id != nil {
+		m.trailer["ID"] = id
+	}
+	return m.bytes(Reference{Number: numbers[root.Ref.Number]}), nil
+}
+
+// duplicateObjects maps the numbers of objects that are identical to an
+// object with a lower number to that number. Objects that must stay
+// distinct, such as pages and annotations, are left out.
+func duplicateObjects(objects map[int]*Object) map[int]int {
+	ids := make([]int, 0, len(objects))
+	for id := range objects {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	first := make(map[string]int)
+	dups := make(map[int]int)
+	var buf bytes.Buffer
+	for _, id := range ids {
+		obj := objects[id]
+		if obj.Type == ObjDict || obj.Type == ObjStream {
+			if _, ok := obj.Dict["Parent"]; ok {
+				continue
+			}
+			switch t, _ := obj.Dict.GetName("Type"); t {
+			case "Catalog", "Pages", "Page", "Annot":
+				continue
+			}
+		}
+		buf.Reset()
+		writeObject(&buf, obj)
+		key := buf.String()
+		if orig, ok := first[key]; ok {
+			dups[id] = orig
+		} else {
+			first[key] = id
+		}
+	}
+	return dups
+}
+
+// renumberRefs returns obj with the references to the objects in numbers
+// replaced by references to the numbers they map to. If dropOthers is
+// set, other references become null, as they refer to objects that are
+// not written. Parts of obj without such references are shared, not
+// copied.
+func renumberRefs(obj *Object, numbers map[int]int, dropOthers bool) *Object {
+	switch obj.Type {
+	case ObjRef:
+		if n, ok := numbers[obj.Ref.Number]; ok {
+			return refObj(Reference{Number: n})
+		}
+		if dropOthers {
+			return &Object{Type: ObjNull}
+		}
+	case ObjArray:
+		var items []*Object
+		for i, item := range obj.Array {
+			r := renumberRefs(item, numbers, dropOthers)
+			if r != item && items == nil {
+				items = slices.Clone(obj.Array)
+			}
+			if items != nil {
+				items[i] = r
+			}
+		}
+		if items != nil {
+			return arrayObj(items...)
+		}
+	case ObjDict, ObjStream:
+		var d Dict
+		for k, v := range obj.Dict {
+			if r := renumberRefs(v, numbers, dropOthers); r != v {
+				if d == nil {
+					d = copyDict(obj.Dict)
+				}
+				d[k] = r
+			}
+		}
+		if d != nil {
+			cp := *obj
+			cp.Dict = d
+			return &cp
+		}
+	}
+	return obj
+}
+
+// recompress returns stream compressed with Flate at the highest level if
+// that is smaller than its current encoding. Streams with other filters,
+// or with predictors, and XMP metadata, which tools expect to find in
+// plain text, are kept as they are.
+func recompress(stream *Object) *Object {
+	if t, _ := stream.Dict.GetName("Type"); t == "Metadata" {
+		return stream
+	}
+	if _, ok := stream.Dict["DecodeParms"]; ok {
+		return stream
+	}
+	raw := stream.Stream
+	if filter := stream.Dict["Filter"]; filter != nil {
+		if filter.Type != ObjName || filter.Name != "FlateDecode" {
+			return stream
+		}
+		var err error
+		if raw, err = DecompressStream(stream.Dict, stream.Stream); err != nil {
+			return stream
+		}
+	}
+	var buf bytes.Buffer
+	zw, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	zw.Write(raw)
+	if err := zw.Close(); err != nil || buf.Len() >= len(stream.Stream) {
+		return stream
+	}
+	d := copyDict(stream.Dict)
+	d["Filter"] = nameObj("FlateDecode")
+	return &Object{Type: ObjStream, Dict: d, Stream: buf.Bytes()}
+}
@@ -0,0 +1,144 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+// buildDuplicatedFontPDF returns a two-page PDF in which each page has
+// its own copy of the same embedded font, plus an object nothing refers
+// to.
+func buildDuplicatedFontPDF(t *testing.T) []byte {
+	t.Helper()
+	src, err := setMetadata(buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 72 720 Td (First) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Second) Tj ET"),
+	}), Metadata{Title: "Optimized"}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := mustLoad(t, src)
+	u, _ := newPDFUpdate(doc)
+	for _, page := range []Reference{{Number: 3}, {Number: 5}} {
+		file := u.add(&Object{Type: ObjStream, Dict: Dict{"Length1": intObj(4096)}, Stream: bytes.Repeat([]byte("glyphs "), 600)})
+		desc := u.add(dictObj(Dict{
+			"Type":     nameObj("FontDescriptor"),
+			"FontName": nameObj("Embedded"),
+			"FontFile": refObj(file),
+		}))
+		font := u.add(dictObj(Dict{
+			"Type":           nameObj("Font"),
+			"Subtype":        nameObj("TrueType"),
+			"BaseFont":       nameObj("Embedded"),
+			"FontDescriptor": refObj(desc),
+		}))
+		obj, _ := doc.ResolveRef(page)
+		d := copyDict(obj.Dict)
+		d["Resources"] = dictObj(Dict{"Font": dictObj(Dict{"F1": refObj(font)})})
+		u.set(page, dictObj(d))
+	}
+	u.add(dictObj(Dict{"Orphan": &Object{Type: ObjBool, Bool: true}}))
+	return u.bytes()
+}
+
+func TestOptimize(t *testing.T) {
+	src := buildDuplicatedFontPDF(t)
+	out, err := Optimize(mustLoad(t, src))
+	if err != nil {
+		t.Fatalf("Optimize: %v", err)
+	}
+	if len(out) >= len(src) {
+		t.Errorf("output is %d bytes, want fewer than the input's %d", len(out), len(src))
+	}
+	if bytes.Contains(out, []byte("Orphan")) {
+		t.Error("unreferenced object kept")
+	}
+
+	doc := mustLoad(t, out)
+	pages, err := doc.Pages()
+	if err != nil || len(pages) != 2 {
+		t.Fatalf("Pages = %d, %v", len(pages), err)
+	}
+	var fonts []Reference
+	for _, page := range pages {
+		fonts = append(fonts, resolveDict(doc, resolveDict(doc, page["Resources"])["Font"])["F1"].Ref)
+	}
+	if fonts[0] != fonts[1] {
+		t.Errorf("pages use fonts %v and %v, want the copies merged", fonts[0], fonts[1])
+	}
+	font, _ := doc.ResolveRef(fonts[0])
+	file, err := doc.Resolve(resolveDict(doc, font.Dict["FontDescriptor"])["FontFile"])
+	if err != nil || file.Type != ObjStream {
+		t.Fatalf("FontFile = %v, %v", file, err)
+	}
+	if f, _ := file.Dict.GetName("Filter"); f != "FlateDecode" {
+		t.Errorf("font file Filter = %q, want it compressed", f)
+	}
+	if data, err := DecompressStream(file.Dict, file.Stream); err != nil || len(data) != 4200 {
+		t.Errorf("font file is %d bytes, %v; want 4200", len(data), err)
+	}
+
+	texts, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"First", "Second"}; !slices.Equal(texts, want) {
+		t.Errorf("pages = %q, want %q", texts, want)
+	}
+	if info := readDocInfo(doc); info.title != "Optimized" {
+		t.Errorf("title = %q, want the Info dictionary kept", info.title)
+	}
+	// Objects are numbered densely from 1.
+	if size, _ := doc.trailer.GetInt("Size"); int(size) != len(doc.xref) {
+		t.Errorf("/Size = %d with %d xref entries", size, len(doc.xref))
+	}
+}
+
+func TestOptimize_Merged(t *testing.T) {
+	part := buildTestPDF([][]byte{[]byte("BT /F1 12 Tf 72 720 Td (Part) Tj ET")})
+	merged, err := mergePDFs([][]byte{part, part, part}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Optimize(mustLoad(t, merged))
+	if err != nil {
+		t.Fatalf("Optimize: %v", err)
+	}
+	doc := mustLoad(t, out)
+	pages, _ := doc.Pages()
+	if len(pages) != 3 {
+		t.Fatalf("got %d pages, want 3", len(pages))
+	}
+	// Each part's font and identical content stream are stored once.
+	first := pages[0]["Contents"].Ref
+	for i, page := range pages[1:] {
+		if page["Contents"].Ref != first {
+			t.Errorf("page %d content %v, want shared %v", i+2, page["Contents"].Ref, first)
+		}
+	}
+}
+
+func TestOptimize_RejectsEncrypted(t *testing.T) {
+	enc, err := encryptPDF(buildTestPDF([][]byte{[]byte("BT ET")}), "owner", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Optimize(mustLoad(t, enc)); err == nil {
+		t.Error("expected error for encrypted document")
+	}
+}
+
+func TestRenumberRefs(t *testing.T) {
+	obj := arrayObj(refObj(Reference{Number: 4}), intObj(1), dictObj(Dict{"A": refObj(Reference{Number: 9})}))
+	got := renumberRefs(obj, map[int]int{4: 1}, true)
+	var buf bytes.Buffer
+	writeObject(&buf, got)
+	if want := "[1 0 R 1 << /A null >>]"; buf.String() != want {
+		t.Errorf("renumbered = %s, want %s", buf.String(), want)
+	}
+	if same := renumberRefs(obj, map[int]int{7: 1}, false); same != obj {
+		t.Error("object without affected references was copied")
+	}
+}
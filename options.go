@@ -1,20 +1,61 @@
 package htmlpdf
 
-import "time"
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/trace"
+)
 
 // converterConfig holds internal configuration for a Converter.
 type converterConfig struct {
-	chromePath   string
-	timeout      time.Duration
-	noSandbox    bool
-	headless     string
-	autoDownload bool
+	chromePath      string
+	timeout         time.Duration
+	noSandbox       bool
+	autoSandbox     bool
+	headless        string
+	autoDownload    bool
+	browserRev      int
+	browserCache    string
+	browserHost     string
+	tempDir         string
+	fontDirs        []string
+	incognito       bool
+	userDataDir     string
+	browserNames    []string
+	memoryLimit     int
+	jsHeapLimit     int
+	rendererLimit   int
+	markdownCSS     string
+	remoteURL       string
+	proxy           string
+	proxyBypass     []string
+	userAgent       string
+	blockedTypes    []string
+	blockedHosts    []string
+	blockLocalFiles bool
+	allowURLs       []string
+	denyURLs        []string
+	policy          *urlPolicy // parsed from allowURLs and denyURLs by NewConverter
+	retries         int
+	retryBackoff    time.Duration
+	metrics         Metrics
+	logger          *slog.Logger
+	tracer          trace.Tracer
+	maxTabs         int
+	warmTabs        int
+	maxOutput       int64
+	idleTimeout     time.Duration
 }
 
 func defaultConfig() converterConfig {
 	return converterConfig{
 		timeout:  30 * time.Second,
 		headless: "new",
+		logger:   slog.New(slog.DiscardHandler),
+		tracer:   defaultTracer(),
 	}
 }
 
@@ -22,13 +63,28 @@ func defaultConfig() converterConfig {
 type Option func(*converterConfig)
 
 // WithChromePath sets the path to the Chrome or Chromium executable.
-// By default the library searches standard locations automatically.
+// By default the library searches standard locations automatically (see
+// [WithBrowserCandidates]).
 func WithChromePath(path string) Option {
 	return func(c *converterConfig) {
 		c.chromePath = path
 	}
 }
 
+// WithBrowserCandidates sets the browser executables looked for, in
+// order, when neither [WithChromePath] nor [WithAutoDownload] is set,
+// replacing [DefaultBrowserCandidates]. Each is a name searched for in
+// PATH, such as "brave-browser" or "thorium-browser", or a path. To try
+// other browsers before or after the usual ones, include
+// DefaultBrowserCandidates():
+//
+//	htmlpdf.WithBrowserCandidates(append([]string{"thorium-browser"}, htmlpdf.DefaultBrowserCandidates()...)...)
+func WithBrowserCandidates(names ...string) Option {
+	return func(c *converterConfig) {
+		c.browserNames = names
+	}
+}
+
 // WithTimeout sets the maximum duration for a single conversion.
 // Defaults to 30 seconds. A zero or negative value disables the timeout.
 func WithTimeout(d time.Duration) Option {
@@ -45,6 +101,17 @@ func WithNoSandbox() Option {
 	}
 }
 
+// WithAutoSandbox disables the Chrome sandbox only where it cannot run:
+// when running as root, when unprivileged user namespaces are disabled,
+// or inside a container, whose runtime typically blocks them. Elsewhere
+// the sandbox stays on. The decision is logged at info level (see
+// [WithLogger]). [WithNoSandbox] takes precedence.
+func WithAutoSandbox() Option {
+	return func(c *converterConfig) {
+		c.autoSandbox = true
+	}
+}
+
 // WithAutoDownload enables automatic download of a compatible Chromium
 // binary when no browser is found in the system PATH. The binary is
 // cached in ~/.cache/rod/browser (Unix) or %APPDATA%\rod\browser (Windows)
@@ -52,9 +119,507 @@ func WithNoSandbox() Option {
 // depending on network speed; subsequent calls add only ~1 ms to check the
 // cache.
 //
-// This option is ignored when [WithChromePath] is also set.
+// This option is ignored when [WithChromePath] is also set. See
+// [WithBrowserRevision] and [WithBrowserCacheDir] to pin the version and
+// move the cache, and [WithBrowserDownloadHost] to download from a
+// mirror.
 func WithAutoDownload() Option {
 	return func(c *converterConfig) {
 		c.autoDownload = true
 	}
 }
+
+// WithBrowserRevision pins the Chromium snapshot revision downloaded by
+// [WithAutoDownload], such as 1321438, so every deployment runs the same
+// browser build. By default the revision is the one go-rod was released
+// with, which changes when the dependency is upgraded.
+func WithBrowserRevision(rev int) Option {
+	return func(c *converterConfig) {
+		c.browserRev = rev
+	}
+}
+
+// WithBrowserCacheDir sets the directory [WithAutoDownload] downloads
+// Chromium to and reuses it from, in place of the default under the home
+// directory. Point it at a writable volume in containers with a read-only
+// root filesystem. Revisions are kept in separate subdirectories.
+func WithBrowserCacheDir(path string) Option {
+	return func(c *converterConfig) {
+		c.browserCache = path
+	}
+}
+
+// WithBrowserDownloadHost makes [WithAutoDownload] fetch Chromium from a
+// mirror of the Chromium snapshot storage instead of Google's and its
+// public mirrors, e.g. an internal artifact repository. baseURL replaces
+// https://storage.googleapis.com/chromium-browser-snapshots, so the
+// mirror must keep its layout: <baseURL>/Linux_x64/<revision>/chrome-linux.zip.
+func WithBrowserDownloadHost(baseURL string) Option {
+	return func(c *converterConfig) {
+		c.browserHost = baseURL
+	}
+}
+
+// WithTempDir places the Converter's temporary files in dir instead of
+// the system default: the files [Converter.ConvertReader] writes its
+// input to, and the profile (user data) directory and TMPDIR of a local
+// browser. The profile directory is removed when the browser exits. dir
+// must exist.
+func WithTempDir(dir string) Option {
+	return func(c *converterConfig) {
+		c.tempDir = dir
+	}
+}
+
+// WithIncognito gives every conversion a fresh browser context, like an
+// incognito window: it starts with no cookies, storage or cache and is
+// discarded afterwards, so nothing one conversion loads is visible to the
+// next, e.g. when converting pages for different tenants. By default
+// conversions share the browser's default context, and with it the HTTP
+// cache, cookies and storage, unless they set cookies or a proxy.
+// Conversions do not use the tabs kept by [WithWarmTabs].
+func WithIncognito() Option {
+	return func(c *converterConfig) {
+		c.incognito = true
+	}
+}
+
+// WithUserDataDir runs a local browser with its profile in dir, which is
+// kept across browser launches and Converters so that its HTTP cache,
+// cookies and storage persist, e.g. to avoid downloading the same
+// stylesheets and fonts for every conversion. By default the profile is a
+// temporary directory removed when the browser exits. Chrome locks the
+// profile, so dir must not be used by two browsers at once, such as the
+// members of a [ConverterPool]. It is ignored with [WithRemoteBrowser].
+func WithUserDataDir(dir string) Option {
+	return func(c *converterConfig) {
+		c.userDataDir = dir
+	}
+}
+
+// WithBrowserMemoryLimit stops a local browser once the resident memory
+// of its processes together exceeds mb megabytes, so that one
+// pathological page cannot exhaust the memory of the host. The
+// conversions in progress fail with [ErrMemoryLimit] and are not retried;
+// the next conversion relaunches the browser. Memory is sampled twice a
+// second, so use comes shortly before the stop may exceed the limit. It
+// is only enforced on Linux, and not with [WithRemoteBrowser].
+func WithBrowserMemoryLimit(mb int) Option {
+	return func(c *converterConfig) {
+		c.memoryLimit = mb
+	}
+}
+
+// WithJSHeapLimit caps the JavaScript heap of each page at mb megabytes.
+// A page whose scripts allocate more crashes, failing its conversion,
+// instead of growing until the host runs out of memory. Chrome's default
+// depends on the system's memory. It is ignored with [WithRemoteBrowser].
+func WithJSHeapLimit(mb int) Option {
+	return func(c *converterConfig) {
+		c.jsHeapLimit = mb
+	}
+}
+
+// WithRendererProcessLimit caps the number of renderer processes a local
+// browser starts at n. Beyond it, pages share processes, saving memory
+// and CPU at the cost of isolation: a page that crashes or hangs its
+// renderer then affects the other pages in it. It is ignored with
+// [WithRemoteBrowser].
+func WithRendererProcessLimit(n int) Option {
+	return func(c *converterConfig) {
+		c.rendererLimit = n
+	}
+}
+
+// WithFontDir makes the font files in dirs, such as corporate fonts,
+// available to pages in addition to the fonts installed on the system,
+// so they need not be installed on every worker image. Pages use them by
+// family name in CSS, as with installed fonts.
+//
+// The fonts are added through a fontconfig configuration passed to a
+// local browser, which is how Chrome finds fonts on Linux. They are not
+// available to a browser on other platforms or to a remote browser (see
+// [WithRemoteBrowser]); load fonts there with @font-face instead. The
+// directories must exist when the browser is launched.
+func WithFontDir(dirs ...string) Option {
+	return func(c *converterConfig) {
+		c.fontDirs = append(c.fontDirs, dirs...)
+	}
+}
+
+// WithIdleTimeout stops the browser once no conversion has run for d,
+// and relaunches it for the next conversion, which then takes as long as
+// NewConverter to start. This frees Chrome's memory in services that
+// convert documents only occasionally. With [WithRemoteBrowser], the
+// connection is closed instead. By default the browser runs until
+// [Converter.Close].
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *converterConfig) {
+		c.idleTimeout = d
+	}
+}
+
+// WithRemoteBrowser attaches the Converter to an already running Chrome
+// instance, such as one in a separate container or a browserless service,
+// instead of launching a local browser process.
+//
+// wsURL is either the browser's HTTP debugging endpoint
+// (http://chrome:9222), from which the WebSocket URL is discovered, or a
+// WebSocket URL (ws://chrome:9222/devtools/browser/<id>, or a
+// service-specific endpoint such as ws://browserless:3000?token=...).
+//
+// Options that configure a local browser process ([WithChromePath],
+// [WithNoSandbox], [WithAutoDownload]) are ignored. [Converter.Close]
+// closes the Converter's tabs and connection but leaves the remote browser
+// running.
+func WithRemoteBrowser(wsURL string) Option {
+	return func(c *converterConfig) {
+		c.remoteURL = wsURL
+	}
+}
+
+// WithProxy routes all browser traffic through the proxy server at
+// proxyURL, e.g. "http://proxy.internal:3128" or "socks5://10.0.0.1:1080".
+// Hosts matching a bypass rule ("*.internal", "10.0.0.0/8", "<local>") are
+// connected to directly. A per-conversion proxy can be set with
+// [ConvertOptions.Proxy].
+//
+// For a local browser this sets Chrome's --proxy-server and
+// --proxy-bypass-list flags. With [WithRemoteBrowser], each conversion
+// runs in its own browser context configured with the proxy instead.
+func WithProxy(proxyURL string, bypass ...string) Option {
+	return func(c *converterConfig) {
+		c.proxy = proxyURL
+		c.proxyBypass = bypass
+	}
+}
+
+// WithUserAgent sets the User-Agent sent by every conversion, replacing
+// the browser's default, which identifies itself as HeadlessChrome and is
+// blocked by some servers. [ConvertOptions.UserAgent] overrides it for a
+// single conversion.
+func WithUserAgent(ua string) Option {
+	return func(c *converterConfig) {
+		c.userAgent = ua
+	}
+}
+
+// WithBlockedResourceTypes fails every request for a resource of the given
+// types, such as "image", "media", "font", "stylesheet" or "script"
+// (Chrome's network resource types, matched case-insensitively). Blocking
+// heavy or irrelevant resources speeds up conversions.
+func WithBlockedResourceTypes(types ...string) Option {
+	return func(c *converterConfig) {
+		c.blockedTypes = append(c.blockedTypes, types...)
+	}
+}
+
+// WithBlockedHosts fails every request to a host matching one of the glob
+// patterns, e.g. "*.doubleclick.net" or "analytics.example.com", keeping
+// trackers and ads out of generated PDFs. Patterns use [path.Match]
+// syntax; "*.example.com" does not match example.com itself. Blocking the
+// host of the page being converted makes the conversion fail.
+func WithBlockedHosts(patterns ...string) Option {
+	return func(c *converterConfig) {
+		c.blockedHosts = append(c.blockedHosts, patterns...)
+	}
+}
+
+// WithBlockLocalFiles fails every request a page makes for a file: URL
+// other than the document being converted, so that markup such as
+// <img src="file:///etc/passwd"> cannot copy files from the host into
+// the PDF. It affects [Converter.ConvertFile] and [Converter.ConvertURL]
+// with a file: URL, whose relative references to images and stylesheets
+// on disk then fail too; serve those with
+// [Converter.ConvertHTMLWithAssets] instead. [Converter.ConvertReader]
+// blocks file: requests regardless, and documents converted from memory
+// cannot load files at all.
+func WithBlockLocalFiles() Option {
+	return func(c *converterConfig) {
+		c.blockLocalFiles = true
+	}
+}
+
+// WithURLPolicy restricts the URLs conversions may load, both the page
+// itself and every resource it requests, so that rendering user-supplied
+// URLs cannot reach internal services such as cloud metadata endpoints.
+// A URL is refused if it matches a deny rule, or if allow is not empty
+// and it matches no allow rule.
+//
+// A rule is either a host name glob in [path.Match] syntax, like those of
+// [WithBlockedHosts] ("*.example.com", "localhost"), or an address range
+// in CIDR notation ("10.0.0.0/8", "169.254.169.254/32", "::1/128"), which
+// matches IP literals and host names resolving to an address in the
+// range. A host name that cannot be resolved is refused when an address
+// rule has to be checked. Only http and https URLs are checked;
+// WebSocket connections opened by the page are not. A refused page fails
+// the conversion with [ErrURLNotAllowed]; a refused resource fails as a
+// blocked request.
+//
+// Host names are resolved by Go when the request is checked, and again
+// by the browser when it is sent, so a name whose address changes in
+// between (DNS rebinding) is not covered by address rules. Deny the host
+// names of untrusted pages, or route the browser through a filtering
+// proxy with [WithProxy], where that matters.
+func WithURLPolicy(allow, deny []string) Option {
+	return func(c *converterConfig) {
+		c.allowURLs = append(c.allowURLs, allow...)
+		c.denyURLs = append(c.denyURLs, deny...)
+	}
+}
+
+// WithRetry retries a conversion that fails for a transient reason: the
+// tab crashing or being closed, or the timeout expiring while the page is
+// still loading. attempts is the total number of attempts, so 3 retries
+// twice; values below 2 disable retrying. Successive attempts wait
+// backoff, then twice as long each time. Retries stop as soon as the
+// caller's context is done, and each attempt gets the full timeout.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *converterConfig) {
+		c.retries = attempts - 1
+		c.retryBackoff = backoff
+	}
+}
+
+// WithMaxConcurrent limits the Converter to n simultaneous conversions,
+// each of which uses a browser tab. Further conversions wait for a tab to
+// free up, or for their context to be done, instead of overloading the
+// browser under a burst of requests. With a [ConverterPool], the limit
+// applies to each member. By default there is no limit.
+func WithMaxConcurrent(n int) Option {
+	return func(c *converterConfig) {
+		c.maxTabs = n
+	}
+}
+
+// WithWarmTabs keeps n blank tabs open and ready, so a conversion skips
+// the cost of creating and attaching to a tab. Each warm tab serves one
+// conversion and is replaced in the background. Conversions that set a
+// proxy or cookies need a fresh browser context and do not use warm tabs.
+func WithWarmTabs(n int) Option {
+	return func(c *converterConfig) {
+		c.warmTabs = n
+	}
+}
+
+// WithMaxOutputSize fails conversions whose PDF, or screenshot, is larger
+// than n bytes with [ErrOutputTooLarge], protecting the process from
+// documents crafted to exhaust its memory. The PDF is read from the
+// browser in chunks and abandoned as soon as it passes the limit, so at
+// most about n bytes are held. With [Converter.ConvertHTMLTo], the bytes
+// up to the limit have already been written. By default there is no
+// limit.
+func WithMaxOutputSize(n int64) Option {
+	return func(c *converterConfig) {
+		c.maxOutput = n
+	}
+}
+
+// WithMetrics reports every conversion to m: starts, successes and
+// failures with their duration, output sizes and the number of
+// conversions in progress. See [PrometheusMetrics] for a ready-made
+// implementation.
+func WithMetrics(m Metrics) Option {
+	return func(c *converterConfig) {
+		c.metrics = m
+	}
+}
+
+// WithLogger sends diagnostic events to l: browser launches and
+// relaunches, navigation, printing, temporary files and retries. Routine
+// steps are logged at debug level, retries and relaunches at info and
+// warn. By default nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *converterConfig) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// WithTracerProvider traces the Converter with OpenTelemetry spans from
+// tp: one for NewConverter, and one per conversion with child spans for
+// the navigation, wait and print phases, parented to the span in the
+// conversion's context. By default no spans are recorded.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *converterConfig) {
+		if tp != nil {
+			c.tracer = tp.Tracer(tracerName)
+		}
+	}
+}
+
+// WithMarkdownCSS replaces the built-in stylesheet used by
+// [Converter.ConvertMarkdown] with css.
+func WithMarkdownCSS(css string) Option {
+	return func(c *converterConfig) {
+		c.markdownCSS = css
+	}
+}
+
+// ConvertOptions controls request-scoped behaviour of a single conversion,
+// as opposed to [PageConfig], which describes the printed output.
+//
+// A nil ConvertOptions is the same as the zero value, which
+// [DefaultConvertOptions] returns. The zero value of every field is the
+// default, so a ConvertOptions literal that sets some fields keeps the
+// defaults of the others.
+type ConvertOptions struct {
+	// Timeout, if positive, overrides the Converter's timeout (see
+	// [WithTimeout]) for this conversion, e.g. to allow a slow report
+	// more time than the service's default.
+	Timeout time.Duration
+
+	// Headers are extra HTTP headers sent with every request the page
+	// makes, including subresources on other origins, e.g. an
+	// Authorization or tenant header.
+	Headers map[string]string
+
+	// Cookies are set before the page is loaded, e.g. a session cookie
+	// for a page behind a login. A cookie without a Domain applies to the
+	// host of the converted URL. The page is loaded in a separate browser
+	// context, so the cookies are not visible to other conversions.
+	Cookies []*http.Cookie
+
+	// WaitForSelector delays printing until an element matching this CSS
+	// selector is present in the page, e.g. a chart container that is only
+	// inserted once rendering has finished.
+	WaitForSelector string
+
+	// WaitForExpression delays printing until this JavaScript expression
+	// evaluates to a truthy value, e.g. "window.chartsReady === true".
+	// The expression is re-evaluated on every animation frame.
+	WaitForExpression string
+
+	// WaitForReadySignal delays printing until the page calls
+	// window.__htmlpdfReady(), which is defined before any of the page's
+	// own scripts run. This lets the page say exactly when it is ready to
+	// print, e.g. once its charts have rendered.
+	WaitForReadySignal bool
+
+	// WaitForPromise delays printing until the promise this JavaScript
+	// expression evaluates to resolves, e.g. "window.pdfReady". The
+	// expression is re-evaluated on every animation frame until it is
+	// neither undefined nor null, so the page may create the promise
+	// late. A rejected promise fails the conversion.
+	WaitForPromise string
+
+	// WaitNetworkIdle, if positive, delays printing until no network
+	// request has been in flight for this long. This lets pages that
+	// lazy-load data finish rendering; 500 ms is a reasonable value.
+	WaitNetworkIdle time.Duration
+
+	// Proxy, if set, overrides the Converter's proxy (see [WithProxy]) for
+	// this conversion. The page is loaded in a separate browser context
+	// that shares no cookies or cache with other conversions.
+	Proxy string
+
+	// ProxyBypass lists hosts that are connected to directly when Proxy is
+	// set, using the same rules as [WithProxy].
+	ProxyBypass []string
+
+	// Credentials, if set, answer HTTP Basic and Digest authentication
+	// challenges from the pages and resources loaded by this conversion,
+	// so protected URLs can be converted without embedding the password
+	// in the URL. They are offered only to the origin of the converted
+	// URL, not to third-party resources or hosts reached by redirects,
+	// and never for documents converted from memory.
+	Credentials *Credentials
+
+	// UserAgent, if set, overrides the Converter's User-Agent (see
+	// [WithUserAgent]) for this conversion.
+	UserAgent string
+
+	// ExtraCSS is a stylesheet appended to the page once the document has
+	// loaded, e.g. "@page { margin: 0 } .no-print { display: none }". It
+	// takes precedence over the page's own styles of equal specificity.
+	ExtraCSS string
+
+	// Scripts are JavaScript snippets evaluated in order once the page is
+	// ready (after any of the waits above) and before printing, e.g. to
+	// dismiss cookie banners or expand collapsed sections. A script that
+	// returns a promise is awaited. A script that throws fails the
+	// conversion.
+	Scripts []string
+
+	// Actions are chromedp actions run in order on the conversion's tab
+	// after the Scripts, for what Scripts cannot do, such as emulating a
+	// timezone, pressing keys, or any other DevTools Protocol command.
+	// The context passed to each action's Do method is the tab's, so
+	// [chromedp.FromContext] and the cdproto commands work with it. An
+	// action that fails fails the conversion.
+	Actions []chromedp.Action
+
+	// HeaderFooterData holds values for the placeholders in the page's
+	// header and footer templates (see [PageConfig].HeaderTemplate): each
+	// {{key}} is replaced with the HTML-escaped value of key, e.g. a
+	// customer name or document ID, so that one PageConfig serves every
+	// document. Chrome's own classes, such as pageNumber, are unaffected.
+	HeaderFooterData map[string]string
+
+	// Selector, if set, prints only the first element matching this CSS
+	// selector, e.g. "#invoice". Everything outside it is hidden once
+	// the Scripts and Actions have run; the element keeps the styles it
+	// inherits from its ancestors. The conversion fails if nothing
+	// matches.
+	Selector string
+
+	// IgnoreHTTPErrors prints the page even when it responds with a
+	// non-2xx status. By default the conversion fails with an
+	// [*HTTPError] instead of printing the server's error page.
+	IgnoreHTTPErrors bool
+
+	// FailOnRequestFailure makes the conversion fail with a
+	// [*RequestFailureError] if any request made by the page fails, such
+	// as a missing image or font, instead of producing a PDF with broken
+	// content. Failures are always reported by [Result.FailedRequests].
+	FailOnRequestFailure bool
+
+	// RecordNetwork records every request the page makes and its
+	// response, for [Result.NetworkLog] and [Result.HAR], e.g. to find
+	// out why an asset is missing or slow.
+	RecordNetwork bool
+
+	// CaptureDiagnostics captures a screenshot, the page's HTML, its
+	// console output and the browser's errors when the conversion fails,
+	// returned as a [DiagnosticBundle] in a *[DiagnosticError]. Capturing
+	// keeps the tab open past the timeout for up to a few seconds.
+	CaptureDiagnostics bool
+
+	// RouteTitles are the titles of the routes converted by
+	// [Converter.ConvertRoutes], in order. Each non-empty title adds a
+	// top-level bookmark at the route's first page.
+	RouteTitles []string
+}
+
+// DefaultConvertOptions returns the ConvertOptions used when none are
+// given: no waits, and failing on HTTP error responses. It is the zero
+// value.
+func DefaultConvertOptions() ConvertOptions {
+	return ConvertOptions{}
+}
+
+// Credentials are a username and password for HTTP authentication.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// resolved returns a copy of the options, substituting defaults for nil.
+func (o *ConvertOptions) resolved() ConvertOptions {
+	if o == nil {
+		return ConvertOptions{}
+	}
+	return *o
+}
+
+// firstConvertOptions returns the first non-nil element of opts, or nil.
+// Convert methods accept options variadically so that they may be omitted.
+func firstConvertOptions(opts []*ConvertOptions) *ConvertOptions {
+	for _, o := range opts {
+		if o != nil {
+			return o
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// heading is an HTML heading as measured in the rendered page.
+type heading struct {
+	Level int     `json:"level"`
+	Text  string  `json:"text"`
+	Pos   float64 `json:"pos"` // top edge as a fraction of the document height
+}
+
+// headingsScript lists the visible, non-empty h1–h6 elements of the page
+// in document order.
+const headingsScript = `(() => {
+	const height = Math.max(1, document.documentElement.scrollHeight);
+	return Array.from(document.querySelectorAll("h1, h2, h3, h4, h5, h6"))
+		.filter(h => h.getClientRects().length > 0)
+		.map(h => ({
+			level: Number(h.tagName.substring(1)),
+			text: h.innerText.replace(/\s+/g, " ").trim(),
+			pos: (h.getBoundingClientRect().top + window.scrollY) / height,
+		}))
+		.filter(h => h.text !== "");
+})()`
+
+// addHeadingBookmarks returns pdf with an outline built from headings
+// appended as an incremental update. Each bookmark points at the page
+// the heading's text is found on.
+func addHeadingBookmarks(pdf []byte, headings []heading) ([]byte, error) {
+	if len(headings) == 0 {
+		return pdf, nil
+	}
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return pdf, nil
+	}
+	texts, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+	onPage := locateHeadings(headings, texts)
+	items := make([]outlineItem, len(headings))
+	for i, h := range headings {
+		items[i] = outlineItem{title: h.Text, level: h.Level, page: pages[onPage[i]]}
+	}
+	if err := writeOutline(u, items); err != nil {
+		return nil, err
+	}
+	return u.bytes(), nil
+}
+
+// locateHeadings returns the 0-based page index of each heading. Headings
+// are searched for in order in the extracted page texts, so repeated
+// titles resolve to successive occurrences. A heading whose text cannot
+// be found (e.g. rendered as an image) falls back to the page its
+// relative position suggests, never before the previous heading's page.
+func locateHeadings(headings []heading, pageTexts []string) []int {
+	texts := make([]string, len(pageTexts))
+	for i, t := range pageTexts {
+		texts[i] = normalizeForSearch(t)
+	}
+
+	result := make([]int, len(headings))
+	page, offset := 0, 0
+	for i, h := range headings {
+		needle := normalizeForSearch(h.Text)
+		found := false
+		for p := page; p < len(texts) && needle != ""; p++ {
+			from := 0
+			if p == page {
+				from = offset
+			}
+			if idx := strings.Index(texts[p][from:], needle); idx >= 0 {
+				result[i] = p
+				page, offset = p, from+idx+len(needle)
+				found = true
+				break
+			}
+		}
+		if !found {
+			est := int(h.Pos * float64(len(texts)))
+			result[i] = min(max(est, page), len(texts)-1)
+		}
+	}
+	return result
+}
+
+// normalizeForSearch lower-cases s and drops whitespace, which text
+// extraction does not reproduce faithfully.
+func normalizeForSearch(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// outlineItem is one bookmark before it is placed in the outline tree.
+type outlineItem struct {
+	title string
+	level int // nesting depth; items nest under the closest lower level
+	page  Reference
+}
+
+// outlineNode is a bookmark with its children in the outline tree.
+type outlineNode struct {
+	item     outlineItem
+	children []*outlineNode
+}
+
+// buildOutlineTree nests items by level. Skipped levels (an h3 directly
+// under an h1) nest under the nearest shallower item.
+func buildOutlineTree(items []outlineItem) []*outlineNode {
+	var roots []*outlineNode
+	var stack []*outlineNode
+	for _, it := range items {
+		n := &outlineNode{item: it}
+		for len(stack) > 0 && stack[len(stack)-1].item.level >= it.level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, n)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+		}
+		stack = append(stack, n)
+	}
+	return roots
+}
+
+// writeOutline adds an outline tree for items to u and points the
+// catalog at it, replacing any existing outline. Bookmarks are shown
+// expanded, and the viewer is asked to open the bookmarks panel.
+func writeOutline(u *pdfUpdate, items []outlineItem) error {
+	catRef, cat, err := u.catalog()
+	if err != nil {
+		return fmt.Errorf("writing outline: %w", err)
+	}
+	cat["Outlines"] = refObj(addOutline(u, items))
+	cat["PageMode"] = nameObj("UseOutlines")
+	u.set(catRef, dictObj(cat))
+	return nil
+}
+
+// addOutline adds the outline tree for items, which must not be empty,
+// to u and returns the reference of its root.
+func addOutline(u objectSink, items []outlineItem) Reference {
+	roots := buildOutlineTree(items)
+	rootRef := u.reserve()
+	first, last, count := writeOutlineLevel(u, rootRef, roots)
+	u.set(rootRef, dictObj(Dict{
+		"Type":  nameObj("Outlines"),
+		"First": refObj(first),
+		"Last":  refObj(last),
+		"Count": intObj(count),
+	}))
+	return rootRef
+}
+
+// writeOutlineLevel writes the sibling nodes under parent and returns the
+// first and last sibling and the number of visible descendants of parent.
+func writeOutlineLevel(u objectSink, parent Reference, nodes []*outlineNode) (first, last Reference, count int) {
+	refs := make([]Reference, len(nodes))
+	for i := range nodes {
+		refs[i] = u.reserve()
+	}
+	for i, n := range nodes {
+		d := Dict{
+			"Title":  textStringObj(n.item.title),
+			"Parent": refObj(parent),
+			"Dest":   arrayObj(refObj(n.item.page), nameObj("Fit")),
+		}
+		if i > 0 {
+			d["Prev"] = refObj(refs[i-1])
+		}
+		if i < len(nodes)-1 {
+			d["Next"] = refObj(refs[i+1])
+		}
+		if len(n.children) > 0 {
+			cf, cl, cc := writeOutlineLevel(u, refs[i], n.children)
+			d["First"] = refObj(cf)
+			d["Last"] = refObj(cl)
+			d["Count"] = intObj(cc)
+			count += cc
+		}
+		u.set(refs[i], dictObj(d))
+		count++
+	}
+	return refs[0], refs[len(refs)-1], count
+}
@@ -0,0 +1,107 @@
+package htmlpdf
+
+import (
+	"testing"
+)
+
+func TestLocateHeadings(t *testing.T) {
+	pages := []string{
+		"Annual Report\nIntroduction\nSome text",
+		"Results\nMore text\nSummary",
+		"Appendix\nSummary",
+	}
+	headings := []heading{
+		{Level: 1, Text: "Annual  Report", Pos: 0},
+		{Level: 2, Text: "INTRODUCTION", Pos: 0.05},
+		{Level: 2, Text: "Results", Pos: 0.4},
+		{Level: 3, Text: "Summary", Pos: 0.6},
+		{Level: 2, Text: "Summary", Pos: 0.9},  // second occurrence
+		{Level: 2, Text: "Chart", Pos: 0.95},   // not in the text layer
+		{Level: 2, Text: "Missing", Pos: 0.01}, // estimate before cursor
+	}
+	got := locateHeadings(headings, pages)
+	want := []int{0, 0, 1, 1, 2, 2, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("heading %d (%q) on page %d, want %d", i, headings[i].Text, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildOutlineTree(t *testing.T) {
+	items := []outlineItem{
+		{title: "A", level: 1},
+		{title: "A.1", level: 2},
+		{title: "A.1.a", level: 4}, // skipped level nests under A.1
+		{title: "A.2", level: 2},
+		{title: "B", level: 1},
+		{title: "B.1", level: 3},
+	}
+	roots := buildOutlineTree(items)
+	if len(roots) != 2 || roots[0].item.title != "A" || roots[1].item.title != "B" {
+		t.Fatalf("roots = %v", roots)
+	}
+	a := roots[0]
+	if len(a.children) != 2 || a.children[0].item.title != "A.1" || a.children[1].item.title != "A.2" {
+		t.Fatalf("A children = %v", a.children)
+	}
+	if len(a.children[0].children) != 1 || a.children[0].children[0].item.title != "A.1.a" {
+		t.Errorf("A.1 children = %v", a.children[0].children)
+	}
+	if len(roots[1].children) != 1 {
+		t.Errorf("B children = %v", roots[1].children)
+	}
+}
+
+func TestAddHeadingBookmarks(t *testing.T) {
+	pdf := buildTestPDF([][]byte{
+		[]byte("BT /F1 24 Tf 72 700 Td (Overview) Tj ET"),
+		[]byte("BT /F1 18 Tf 72 700 Td (Details) Tj ET"),
+	})
+	out, err := addHeadingBookmarks(pdf, []heading{
+		{Level: 1, Text: "Overview", Pos: 0},
+		{Level: 2, Text: "Details", Pos: 0.5},
+	})
+	if err != nil {
+		t.Fatalf("addHeadingBookmarks: %v", err)
+	}
+
+	doc := mustLoad(t, out)
+	cat, _ := doc.Catalog()
+	if mode, _ := cat.GetName("PageMode"); mode != "UseOutlines" {
+		t.Errorf("PageMode = %q, want UseOutlines", mode)
+	}
+	root, _ := doc.Resolve(cat["Outlines"])
+	if root == nil || root.Type != ObjDict {
+		t.Fatalf("/Outlines = %v", root)
+	}
+	if n, _ := root.Dict.GetInt("Count"); n != 2 {
+		t.Errorf("outline Count = %d, want 2", n)
+	}
+
+	first, _ := doc.Resolve(root.Dict["First"])
+	if got := string(first.Dict["Title"].Str); got != "Overview" {
+		t.Errorf("first bookmark = %q, want Overview", got)
+	}
+	child, _ := doc.Resolve(first.Dict["First"])
+	if got := string(child.Dict["Title"].Str); got != "Details" {
+		t.Errorf("child bookmark = %q, want Details", got)
+	}
+
+	refs, _ := doc.pageRefs()
+	dest, _ := doc.Resolve(child.Dict["Dest"])
+	if dest.Array[0].Ref != refs[1] {
+		t.Errorf("Details points at %v, want page 2 (%v)", dest.Array[0].Ref, refs[1])
+	}
+}
+
+func TestAddHeadingBookmarks_NoHeadings(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	out, err := addHeadingBookmarks(pdf, nil)
+	if err != nil {
+		t.Fatalf("addHeadingBookmarks: %v", err)
+	}
+	if len(out) != len(pdf) {
+		t.Error("document changed without headings")
+	}
+}
@@ -0,0 +1,111 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// OverlayOptions configures [Overlay].
+type OverlayOptions struct {
+	// Above draws the stamp over the page content instead of under it,
+	// e.g. for an approval stamp rather than a letterhead.
+	Above bool
+
+	// Pages selects the pages of the base document to draw on as
+	// comma-separated page numbers and ranges counted from 1, such as
+	// "1-3, 5, 8-". Empty draws on every page.
+	Pages string
+}
+
+// Overlay draws the first page of stamp, such as a letterhead, under (or,
+// with Above, over) the pages of base and returns the result. The stamp
+// is scaled to fit each page if their sizes differ, keeping its aspect
+// ratio, and centred.
+//
+// The stamp is added to base as an incremental update. Under the page
+// content it only shows through where the page is transparent: pages
+// converted with a background colour or image on the html or body
+// element hide it.
+func Overlay(base, stamp *Document, opts OverlayOptions) ([]byte, error) {
+	if _, ok := stamp.trailer["Encrypt"]; ok {
+		return nil, errors.New("cannot overlay an encrypted document")
+	}
+	stampRefs, err := stamp.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	if len(stampRefs) == 0 {
+		return nil, errors.New("overlay document has no pages")
+	}
+	stampPage, err := stamp.ResolveRef(stampRefs[0])
+	if err != nil || stampPage.Type != ObjDict {
+		return nil, errors.New("overlay page is not a dictionary")
+	}
+
+	refs, err := base.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	selected, err := parsePageRanges(opts.Pages, len(refs))
+	if err != nil {
+		return nil, err
+	}
+	u, err := newPDFUpdate(base)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := base.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	var pagesRef Reference
+	if obj := cat["Pages"]; obj != nil && obj.Type == ObjRef {
+		pagesRef = obj.Ref
+	}
+	c := &objectCopier{doc: stamp, m: u, refs: make(map[Reference]Reference), pagesRef: pagesRef}
+	stampForm, err := pageForm(c, stampPage.Dict)
+	if err != nil {
+		return nil, err
+	}
+	form := u.add(stampForm)
+	upright, sw, sh := uprightMatrix(pageBox(stamp, stampPage.Dict), pageRotation(stamp, stampPage.Dict))
+
+	var qRef Reference // a stream holding just "q", shared by every page
+	for i, ref := range refs {
+		if !selected[i] {
+			continue
+		}
+		page, err := base.ResolveRef(ref)
+		if err != nil || page.Type != ObjDict {
+			return nil, fmt.Errorf("page %d: not a dictionary", i+1)
+		}
+		d := copyDict(page.Dict)
+		res := copyDict(resolveDict(base, pageAttr(base, d, "Resources")))
+		name := addResource(base, res, "XObject", "Overlay", form)
+		d["Resources"] = dictObj(res)
+
+		// Fit the upright stamp into the page as displayed, then turn it
+		// back into the page's unrotated user space.
+		pageUpright, pw, ph := uprightMatrix(pageBox(base, d), pageRotation(base, d))
+		scale := min(pw/sw, ph/sh)
+		place := upright.
+			mul(matrix{scale, 0, 0, scale, (pw - sw*scale) / 2, (ph - sh*scale) / 2}).
+			mul(pageUpright.invert())
+
+		var buf bytes.Buffer
+		buf.WriteString("q ")
+		writeReals(&buf, place[:]...)
+		buf.WriteString(" cm ")
+		writeName(&buf, name)
+		buf.WriteString(" Do Q\n")
+		if opts.Above {
+			d["Contents"] = drawOver(u, base, d, &qRef, buf.Bytes())
+		} else {
+			draw := u.add(&Object{Type: ObjStream, Dict: Dict{}, Stream: buf.Bytes()})
+			d["Contents"] = arrayObj(append([]*Object{refObj(draw)}, pageContents(base, d)...)...)
+		}
+		u.set(ref, dictObj(d))
+	}
+	return u.bytes(), nil
+}
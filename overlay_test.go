@@ -0,0 +1,123 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// letterhead returns a one-page PDF of the given size showing
+// "Letterhead".
+func letterhead(t *testing.T, w, h int) *Document {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 10 Tf 72 760 Td (Letterhead) Tj ET")}))
+	u, _ := newPDFUpdate(doc)
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	page.Dict["MediaBox"] = arrayObj(intObj(0), intObj(0), intObj(w), intObj(h))
+	u.set(Reference{Number: 3}, page)
+	return mustLoad(t, u.bytes())
+}
+
+// overlayContents returns the content streams of the pages of pdf, one
+// string per stream.
+func overlayContents(t *testing.T, pdf []byte) [][]string {
+	t.Helper()
+	doc := mustLoad(t, pdf)
+	pages, err := doc.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out [][]string
+	for _, page := range pages {
+		var streams []string
+		for _, item := range pageContents(doc, page) {
+			s, _ := doc.Resolve(item)
+			data, _ := DecompressStream(s.Dict, s.Stream)
+			streams = append(streams, string(data))
+		}
+		out = append(out, streams)
+	}
+	return out
+}
+
+func TestOverlay(t *testing.T) {
+	base := mustLoad(t, buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 72 720 Td (First) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Second) Tj ET"),
+	}))
+	out, err := Overlay(base, letterhead(t, 612, 792), OverlayOptions{})
+	if err != nil {
+		t.Fatalf("Overlay: %v", err)
+	}
+	if !bytes.HasPrefix(out, base.data) {
+		t.Error("overlay is not an incremental update")
+	}
+	for i, streams := range overlayContents(t, out) {
+		// The letterhead is drawn first, at its own size.
+		if len(streams) != 2 || streams[0] != "q 1 0 0 1 0 0 cm /Overlay Do Q\n" {
+			t.Errorf("page %d contents = %q", i+1, streams)
+		}
+	}
+
+	doc := mustLoad(t, out)
+	pages, _ := doc.Pages()
+	res := resolveDict(doc, pages[1]["Resources"])
+	if _, ok := resolveDict(doc, res["Font"])["F1"]; !ok {
+		t.Error("page lost its own font")
+	}
+	form, err := doc.Resolve(resolveDict(doc, res["XObject"])["Overlay"])
+	if err != nil || form.Type != ObjStream {
+		t.Fatalf("Overlay = %v, %v", form, err)
+	}
+	data, _ := DecompressStream(form.Dict, form.Stream)
+	if !strings.Contains(string(data), "(Letterhead) Tj") {
+		t.Errorf("form content = %q", data)
+	}
+	font, _ := doc.Resolve(resolveDict(doc, resolveDict(doc, form.Dict["Resources"])["Font"])["F1"])
+	if name, _ := font.Dict.GetName("BaseFont"); name == "" {
+		t.Error("form font was not copied")
+	}
+}
+
+func TestOverlay_AboveAndScaled(t *testing.T) {
+	base := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET"), []byte("BT ET")}))
+	// A half-size stamp is scaled up to fill the page.
+	out, err := Overlay(base, letterhead(t, 306, 396), OverlayOptions{Above: true, Pages: "2"})
+	if err != nil {
+		t.Fatalf("Overlay: %v", err)
+	}
+	contents := overlayContents(t, out)
+	if len(contents[0]) != 1 {
+		t.Errorf("page 1 contents = %q, want it untouched", contents[0])
+	}
+	streams := contents[1]
+	if len(streams) != 3 || streams[0] != "q\n" || streams[2] != "Q\nq 2 0 0 2 0 0 cm /Overlay Do Q\n" {
+		t.Errorf("page 2 contents = %q", streams)
+	}
+}
+
+func TestOverlay_RotatedPage(t *testing.T) {
+	e, err := NewEditDocument(mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RotatePages("", 90); err != nil {
+		t.Fatal(err)
+	}
+	// A landscape stamp on a portrait page shown turned to landscape is
+	// drawn turned the other way, so it appears upright.
+	out, err := Overlay(mustLoad(t, e.Bytes()), letterhead(t, 792, 612), OverlayOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := overlayContents(t, out)[0][0]; got != "q 0 1 -1 0 612 0 cm /Overlay Do Q\n" {
+		t.Errorf("contents = %q", got)
+	}
+}
+
+func TestMatrixInvert(t *testing.T) {
+	m := matrix{0, -1, 2, 0, 5, 7}
+	if got := m.mul(m.invert()); got != identity {
+		t.Errorf("m × m⁻¹ = %v, want identity", got)
+	}
+}
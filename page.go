@@ -1,5 +1,14 @@
 package htmlpdf
 
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
 // PageSize represents paper dimensions in centimeters.
 type PageSize struct {
 	Width  float64 // Width in centimeters.
@@ -14,8 +23,83 @@ var (
 	Letter  = PageSize{Width: 21.59, Height: 27.94}
 	Legal   = PageSize{Width: 21.59, Height: 35.56}
 	Tabloid = PageSize{Width: 27.94, Height: 43.18}
+
+	// ISO 216 B series.
+	B4 = PageSize{Width: 25.0, Height: 35.3}
+	B5 = PageSize{Width: 17.6, Height: 25.0}
+
+	// JIS P 0138 B series, common in Japan, slightly larger than ISO B.
+	JISB4 = PageSize{Width: 25.7, Height: 36.4}
+	JISB5 = PageSize{Width: 18.2, Height: 25.7}
+
+	// North American sizes.
+	Executive = PageSize{Width: 18.415, Height: 26.67} // 7.25 × 10.5 in
+	Ledger    = PageSize{Width: 43.18, Height: 27.94}  // Tabloid in landscape
+
+	// Envelopes (ISO 269 and US #10).
+	EnvelopeDL = PageSize{Width: 11.0, Height: 22.0}
+	EnvelopeC4 = PageSize{Width: 22.9, Height: 32.4}
+	EnvelopeC5 = PageSize{Width: 16.2, Height: 22.9}
+	EnvelopeC6 = PageSize{Width: 11.4, Height: 16.2}
+	Envelope10 = PageSize{Width: 10.4775, Height: 24.13} // 4.125 × 9.5 in
 )
 
+// pageSizes is the registry behind PageSizeByName, keyed by normalized
+// name.
+var (
+	pageSizesMu sync.RWMutex
+	pageSizes   = map[string]PageSize{}
+)
+
+func init() {
+	for name, size := range map[string]PageSize{
+		"A3": A3, "A4": A4, "A5": A5,
+		"Letter": Letter, "Legal": Legal, "Tabloid": Tabloid,
+		"B4": B4, "B5": B5, "JIS-B4": JISB4, "JIS-B5": JISB5,
+		"Executive": Executive, "Ledger": Ledger,
+		"DL": EnvelopeDL, "C4": EnvelopeC4, "C5": EnvelopeC5, "C6": EnvelopeC6,
+		"Envelope-10": Envelope10,
+	} {
+		RegisterPageSize(name, size)
+	}
+}
+
+// RegisterPageSize makes size available to [PageSizeByName] and
+// [ParsePageSize] under name, replacing any size registered under the
+// same name, including built-in ones. It panics if name is empty or a
+// dimension is not positive.
+func RegisterPageSize(name string, size PageSize) {
+	key := normalizePageSizeName(name)
+	if key == "" || size.Width <= 0 || size.Height <= 0 {
+		panic(fmt.Sprintf("htmlpdf: invalid page size registration %q: %v", name, size))
+	}
+	pageSizesMu.Lock()
+	defer pageSizesMu.Unlock()
+	pageSizes[key] = size
+}
+
+// PageSizeByName returns the paper size registered under name. Names are
+// matched ignoring case, spaces, hyphens and underscores, so "JIS B5",
+// "jis-b5" and "JISB5" are equivalent. The built-in names are those of
+// the package variables, with envelopes as "DL", "C4", "C5", "C6" and
+// "Envelope-10".
+func PageSizeByName(name string) (PageSize, bool) {
+	pageSizesMu.RLock()
+	defer pageSizesMu.RUnlock()
+	size, ok := pageSizes[normalizePageSizeName(name)]
+	return size, ok
+}
+
+func normalizePageSizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
 // Orientation represents the page orientation.
 type Orientation int
 
@@ -26,6 +110,17 @@ const (
 	Landscape
 )
 
+// Media is the CSS media type a page is rendered with.
+type Media int
+
+const (
+	// MediaPrint applies the page's print stylesheets. This is the default.
+	MediaPrint Media = iota
+	// MediaScreen applies the page's screen stylesheets, reproducing the
+	// on-screen layout instead of the print layout.
+	MediaScreen
+)
+
 // Margin represents page margins in centimeters.
 type Margin struct {
 	Top    float64
@@ -57,6 +152,14 @@ type PageConfig struct {
 	// Scale of the webpage rendering. Must be between 0.1 and 2.0. Defaults to 1.0.
 	Scale float64
 
+	// DeviceScaleFactor is the device pixel ratio the page is rendered
+	// with, e.g. 2 to emulate a high-DPI display. Pages that pick images
+	// by resolution (srcset, image-set()) or draw canvases at
+	// window.devicePixelRatio then embed sharper raster content. Unlike
+	// Scale, it does not change the layout. Zero keeps the browser's
+	// default of 1.
+	DeviceScaleFactor float64
+
 	// PrintBackground enables printing of background colors and images.
 	// Defaults to true.
 	PrintBackground bool
@@ -66,16 +169,87 @@ type PageConfig struct {
 
 	// HeaderTemplate is an HTML template for the print header.
 	// It uses the same format as Chrome's print header template, supporting
-	// the classes: date, title, url, pageNumber, totalPages.
+	// the classes: date, title, url, pageNumber, totalPages. Placeholders
+	// such as {{customer}} are replaced with the values of
+	// [ConvertOptions].HeaderFooterData.
 	HeaderTemplate string
 
 	// FooterTemplate is an HTML template for the print footer.
-	// It uses the same format as Chrome's print footer template.
+	// It uses the same format as Chrome's print footer template, and
+	// placeholders as HeaderTemplate does.
 	FooterTemplate string
 
 	// PreferCSSPageSize gives precedence to any CSS @page size declared
 	// in the document over the Size field.
 	PreferCSSPageSize bool
+
+	// EmulateMedia selects the CSS media type used to render the page.
+	// Use MediaScreen for sites whose print stylesheets strip content.
+	// Defaults to MediaPrint.
+	EmulateMedia Media
+
+	// GenerateTaggedPDF produces a tagged PDF whose structure tree
+	// (headings, paragraphs, lists, tables, alt text) is exposed to
+	// assistive technology, as accessibility requirements such as PDF/UA
+	// and Section 508 demand. Tagging follows the HTML semantics, so
+	// well-structured markup produces well-structured PDFs.
+	GenerateTaggedPDF bool
+
+	// GenerateDocumentOutline embeds a document outline (bookmarks) built
+	// by Chrome from the HTML headings.
+	GenerateDocumentOutline bool
+
+	// Bookmarks adds a PDF outline built from the page's h1–h6 headings,
+	// nested by heading level, in a post-processing step. Each bookmark
+	// opens the page the heading appears on. Unlike
+	// GenerateDocumentOutline, it does not depend on Chrome's outline
+	// support and replaces any outline Chrome produced.
+	Bookmarks bool
+
+	// FillableForms turns the page's <input>, <select> and <textarea>
+	// elements into interactive PDF form fields (an AcroForm) placed over
+	// the printed controls, holding their current values, so the PDF can
+	// be filled in and saved in a viewer. Text inputs of any textual type
+	// become text fields, checkboxes and radio buttons become buttons
+	// (radio buttons grouped by name) and selects become combo or list
+	// boxes. Other inputs, such as buttons and file pickers, are printed
+	// as usual. Field names come from the name attribute, or the id,
+	// made unique.
+	FillableForms bool
+
+	// Metadata is written to the PDF's document information dictionary
+	// and XMP metadata in a post-processing step. Otherwise only what
+	// Chrome writes is present: the HTML <title>, Chrome as creator and
+	// producer, and the dates.
+	Metadata Metadata
+
+	// PDFA post-processes the output toward PDF/A-2b (ISO 19005-2, level
+	// B) for long-term archiving: XMP metadata identifying the
+	// conformance level and an sRGB output intent are added, and link
+	// annotations are made printable. Conversion fails with a *PDFAError
+	// if the document contains constructs that cannot be made conformant.
+	PDFA bool
+
+	// Images re-encodes the embedded images in a post-processing step to
+	// shrink the output, e.g. screenshots that are embedded at several
+	// times the resolution they are printed at. See [ImageCompression].
+	Images ImageCompression
+
+	// Linearize rewrites the output as a linearized ("fast web view") PDF
+	// as the last post-processing step, so that viewers reading it over
+	// HTTP range requests can show the first page before the rest has
+	// downloaded. Encrypting the result (see [Result.Encrypt]) undoes
+	// the linearization.
+	Linearize bool
+
+	// Reproducible normalizes the output so that converting the same
+	// HTML with the same browser yields byte-identical PDFs, e.g. for
+	// content-addressed storage or golden-file tests: the creation and
+	// modification dates are left out, objects are written in a stable
+	// order, and the file identifier is derived from the content. It
+	// applies to the other post-processing steps too, such as Metadata
+	// and PDFA, which then record no dates.
+	Reproducible bool
 }
 
 // DefaultPageConfig returns a PageConfig with sensible defaults.
@@ -136,3 +310,22 @@ func (p *PageConfig) marginInches() (top, right, bottom, left float64) {
 		cmToInches(r.Margin.Bottom),
 		cmToInches(r.Margin.Left)
 }
+
+// templateVar matches a {{name}} placeholder in a header or footer
+// template, allowing spaces inside the braces.
+var templateVar = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// expandTemplate replaces the placeholders in a header or footer template
+// with the HTML-escaped values in data. Placeholders without a value are
+// left as they are.
+func expandTemplate(tmpl string, data map[string]string) string {
+	if len(data) == 0 {
+		return tmpl
+	}
+	return templateVar.ReplaceAllStringFunc(tmpl, func(m string) string {
+		if v, ok := data[templateVar.FindStringSubmatch(m)[1]]; ok {
+			return html.EscapeString(v)
+		}
+		return m
+	})
+}
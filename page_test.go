@@ -78,10 +78,11 @@ func TestPageConfigResolved_ZeroValues(t *testing.T) {
 
 func TestPageConfigResolved_PreservesExplicit(t *testing.T) {
 	pc := &PageConfig{
-		Size:        Letter,
-		Orientation: Landscape,
-		Scale:       0.5,
-		Margin:      Margin{Top: 2, Right: 3, Bottom: 2, Left: 3},
+		Size:         Letter,
+		Orientation:  Landscape,
+		Scale:        0.5,
+		Margin:       Margin{Top: 2, Right: 3, Bottom: 2, Left: 3},
+		EmulateMedia: MediaScreen,
 	}
 	r := pc.resolved()
 	if r.Size != Letter {
@@ -96,6 +97,9 @@ func TestPageConfigResolved_PreservesExplicit(t *testing.T) {
 	if r.Margin.Top != 2 {
 		t.Errorf("margin top = %v, want 2", r.Margin.Top)
 	}
+	if r.EmulateMedia != MediaScreen {
+		t.Errorf("emulate media = %v, want MediaScreen", r.EmulateMedia)
+	}
 }
 
 func TestPaperDimensions_Portrait(t *testing.T) {
@@ -142,3 +146,65 @@ func TestMarginInches(t *testing.T) {
 		t.Errorf("left = %v, want 2.0", left)
 	}
 }
+
+func TestPageSizeByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want PageSize
+	}{
+		{"A4", A4},
+		{"letter", Letter},
+		{"JIS B4", JISB4},
+		{"jis_b5", JISB5},
+		{"Envelope #10", PageSize{}},
+		{"envelope-10", Envelope10},
+		{"DL", EnvelopeDL},
+	}
+	for _, tt := range tests {
+		got, ok := PageSizeByName(tt.name)
+		if ok != (tt.want != PageSize{}) || got != tt.want {
+			t.Errorf("PageSizeByName(%q) = %v, %v, want %v", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRegisterPageSize(t *testing.T) {
+	custom := PageSize{Width: 10, Height: 15}
+	RegisterPageSize("Postcard 4x6", custom)
+	t.Cleanup(func() {
+		pageSizesMu.Lock()
+		delete(pageSizes, normalizePageSizeName("Postcard 4x6"))
+		pageSizesMu.Unlock()
+	})
+	if got, ok := PageSizeByName("postcard-4x6"); !ok || got != custom {
+		t.Errorf("PageSizeByName after RegisterPageSize = %v, %v", got, ok)
+	}
+	if got, err := ParsePageSize("Postcard 4x6"); err != nil || got != custom {
+		t.Errorf("ParsePageSize(registered name) = %v, %v", got, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterPageSize with an empty name did not panic")
+		}
+	}()
+	RegisterPageSize(" ", custom)
+}
+
+func TestExpandTemplate(t *testing.T) {
+	data := map[string]string{"customer": "Smith & Sons <Ltd>", "doc.id": "INV-42"}
+	tests := []struct{ in, want string }{
+		{`<span>{{customer}}</span>`, `<span>Smith &amp; Sons &lt;Ltd&gt;</span>`},
+		{`{{ doc.id }} / {{doc.id}}`, `INV-42 / INV-42`},
+		{`{{unknown}} <span class="pageNumber"></span>`, `{{unknown}} <span class="pageNumber"></span>`},
+		{`{customer}`, `{customer}`},
+	}
+	for _, tt := range tests {
+		if got := expandTemplate(tt.in, data); got != tt.want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := expandTemplate(`{{customer}}`, nil); got != `{{customer}}` {
+		t.Errorf("expandTemplate without data = %q, want the template unchanged", got)
+	}
+}
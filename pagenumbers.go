@@ -0,0 +1,171 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// PageNumberPosition is where [PageNumbers] are drawn on the page.
+type PageNumberPosition int
+
+const (
+	// BottomCenter centres the page numbers at the bottom of the page.
+	// This is the default.
+	BottomCenter PageNumberPosition = iota
+	// BottomLeft aligns the page numbers to the bottom left corner.
+	BottomLeft
+	// BottomRight aligns the page numbers to the bottom right corner.
+	BottomRight
+	// TopLeft aligns the page numbers to the top left corner.
+	TopLeft
+	// TopCenter centres the page numbers at the top of the page.
+	TopCenter
+	// TopRight aligns the page numbers to the top right corner.
+	TopRight
+)
+
+// PageNumbers configures the page numbers drawn onto a finished PDF by
+// [Result.NumberPages] and [Document.NumberPages], such as a document
+// combined from several conversions, where each part's header and footer
+// templates count only its own pages.
+type PageNumbers struct {
+	// Format is the text drawn on each page. {pageNumber} and
+	// {totalPages} are replaced by the page number and the document's
+	// page count, like the classes of Chrome's header and footer
+	// templates. Defaults to "Page {pageNumber} of {totalPages}".
+	Format string
+
+	// Position is the corner or edge of the page the text is aligned to,
+	// as the page is displayed. Defaults to BottomCenter.
+	Position PageNumberPosition
+
+	// Margin is the distance of the text from the edges of the page, in
+	// centimeters. Defaults to 1 cm.
+	Margin float64
+
+	// Font is the name of a standard font: "Helvetica",
+	// "Helvetica-Bold", "Courier" or "Courier-Bold". Characters outside
+	// the Windows-1252 character set are drawn as "?". Defaults to
+	// Helvetica.
+	Font string
+
+	// FontSize is the size of the text in points. Defaults to 10.
+	FontSize float64
+
+	// Color of the text. Defaults to black.
+	Color color.Color
+
+	// Pages selects the pages to number as comma-separated page numbers
+	// and ranges counted from 1, such as "2-" to leave out a cover page.
+	// Pages keep their place in the count. Empty numbers every page.
+	Pages string
+}
+
+// NumberPages returns the PDF with page numbers drawn over the selected
+// pages as n describes, appended to the document as an incremental
+// update. The document itself is not modified. It fails for encrypted
+// documents.
+func (doc *Document) NumberPages(n PageNumbers) ([]byte, error) {
+	return numberPages(doc, n)
+}
+
+// numberPages implements [Document.NumberPages].
+func numberPages(doc *Document, n PageNumbers) ([]byte, error) {
+	font, fontName, err := lookupStandardFont(n.Font)
+	if err != nil {
+		return nil, err
+	}
+	if n.Position < BottomCenter || n.Position > TopRight {
+		return nil, fmt.Errorf("invalid page number position %d", n.Position)
+	}
+	if n.FontSize < 0 || n.Margin < 0 {
+		return nil, fmt.Errorf("page number font size and margin must not be negative")
+	}
+	format := n.Format
+	if format == "" {
+		format = "Page {pageNumber} of {totalPages}"
+	}
+	size := n.FontSize
+	if size == 0 {
+		size = 10
+	}
+	margin := 72 * cmToInches(1)
+	if n.Margin > 0 {
+		margin = 72 * cmToInches(n.Margin)
+	}
+
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	selected, err := parsePageRanges(n.Pages, len(refs))
+	if err != nil {
+		return nil, err
+	}
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+	fontRef := u.add(standardFontDict(fontName))
+	total := strconv.Itoa(len(refs))
+	r, g, b := rgb(n.Color, 0)
+
+	var qRef Reference // a stream holding just "q", shared by every page
+	for i, ref := range refs {
+		if !selected[i] {
+			continue
+		}
+		page, err := doc.ResolveRef(ref)
+		if err != nil || page.Type != ObjDict {
+			return nil, fmt.Errorf("page %d: not a dictionary", i+1)
+		}
+		d := copyDict(page.Dict)
+		res := copyDict(resolveDict(doc, pageAttr(doc, d, "Resources")))
+		name := addResource(doc, res, "Font", "PageNumber", fontRef)
+		d["Resources"] = dictObj(res)
+
+		text := winAnsiEncode(strings.NewReplacer(
+			"{pageNumber}", strconv.Itoa(i+1),
+			"{totalPages}", total,
+		).Replace(format))
+		width := font.width(text) * size
+
+		// Place the text on the page as displayed, then turn it back into
+		// the page's unrotated user space.
+		upright, w, h := uprightMatrix(pageBox(doc, d), pageRotation(doc, d))
+		var x, y float64
+		switch n.Position {
+		case BottomLeft, TopLeft:
+			x = margin
+		case BottomRight, TopRight:
+			x = w - margin - width
+		default:
+			x = (w - width) / 2
+		}
+		if n.Position >= TopLeft {
+			y = h - margin - float64(font.capHeight)/1000*size
+		} else {
+			y = margin
+		}
+		place := matrix{1, 0, 0, 1, x, y}.mul(upright.invert())
+
+		var buf bytes.Buffer
+		buf.WriteString("q ")
+		writeReals(&buf, r, g, b)
+		buf.WriteString(" rg ")
+		writeReals(&buf, place[:]...)
+		buf.WriteString(" cm BT ")
+		writeName(&buf, name)
+		buf.WriteByte(' ')
+		writeReal(&buf, size)
+		buf.WriteString(" Tf ")
+		writeString(&buf, text)
+		buf.WriteString(" Tj ET Q\n")
+		d["Contents"] = drawOver(u, doc, d, &qRef, buf.Bytes())
+		u.set(ref, dictObj(d))
+	}
+	return u.bytes(), nil
+}
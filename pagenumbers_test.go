@@ -0,0 +1,92 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestNumberPages(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 72 720 Td (Cover) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Body) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (End) Tj ET"),
+	}))
+	out, err := numberPages(doc, PageNumbers{Pages: "2-"})
+	if err != nil {
+		t.Fatalf("numberPages: %v", err)
+	}
+	if !bytes.HasPrefix(out, doc.data) {
+		t.Error("page numbers are not an incremental update")
+	}
+	doc = mustLoad(t, out)
+	texts, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts[0] != "Cover" {
+		t.Errorf("page 1 = %q, want it unnumbered", texts[0])
+	}
+	for i, want := range []string{"Page 2 of 3", "Page 3 of 3"} {
+		if !strings.Contains(texts[i+1], want) {
+			t.Errorf("page %d = %q, want %q", i+2, texts[i+1], want)
+		}
+	}
+
+	// "Page 2 of 3" is 5.115 wide at 1 point: 51.15 at 10, centred on the
+	// 612-point page 1 cm (28.35 points) from the bottom.
+	pages, _ := doc.Pages()
+	content, _ := doc.ContentStreams(pages[1])
+	if want := "q 0 0 0 rg 1 0 0 1 280.425 28.3465 cm BT /PageNumber 10 Tf (Page 2 of 3) Tj ET Q"; !bytes.Contains(content, []byte(want)) {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestNumberPages_Position(t *testing.T) {
+	e, err := NewEditDocument(mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RotatePages("", 90); err != nil {
+		t.Fatal(err)
+	}
+	out, err := numberPages(mustLoad(t, e.Bytes()), PageNumbers{
+		Format:   "{pageNumber}",
+		Position: TopRight,
+		Margin:   2.54,
+		Font:     "Courier",
+		FontSize: 20,
+		Color:    color.Gray{Y: 0xff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := mustLoad(t, out)
+	pages, _ := doc.Pages()
+	content, _ := doc.ContentStreams(pages[0])
+	// Displayed as 792×612, the digit is 12 points wide and its capitals
+	// 11.24 high: placed at (792-72-12, 612-72-11.24) = (708, 528.76),
+	// which is (83.24, 708) in the page's unrotated space.
+	if want := "q 1 1 1 rg 0 1 -1 0 83.24 708 cm BT /PageNumber 20 Tf (1) Tj ET Q"; !bytes.Contains(content, []byte(want)) {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	font, _ := doc.Resolve(resolveDict(doc, resolveDict(doc, pages[0]["Resources"])["Font"])["PageNumber"])
+	if name, _ := font.Dict.GetName("BaseFont"); name != "Courier" {
+		t.Errorf("BaseFont = %q, want Courier", name)
+	}
+}
+
+func TestNumberPages_Errors(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	for _, n := range []PageNumbers{
+		{Font: "Arial"},
+		{Position: TopRight + 1},
+		{FontSize: -1},
+		{Pages: "3"},
+	} {
+		if _, err := numberPages(doc, n); err == nil {
+			t.Errorf("numberPages(%+v): expected error", n)
+		}
+	}
+}
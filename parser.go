@@ -2,6 +2,7 @@ package htmlpdf
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strconv"
 )
@@ -104,9 +105,32 @@ const maxNesting = 100
 
 // Parser is a recursive-descent PDF object parser.
 type Parser struct {
-	data  []byte
-	pos   int
-	depth int
+	data    []byte
+	pos     int
+	depth   int
+	partial bool // data is a window ending before the end of the file
+}
+
+// errTruncated reports that an object continues past the end of a
+// parser's window of the file.
+var errTruncated = errors.New("object extends past the data read")
+
+// truncated reports whether obj, just parsed, may have been cut short by
+// the end of a partial window: parsing reached the end of the data, or a
+// stream has less data than its /Length.
+func (p *Parser) truncated(obj *Object) bool {
+	if !p.partial {
+		return false
+	}
+	if p.pos >= len(p.data) {
+		return true
+	}
+	if obj.Type == ObjStream {
+		if n, ok := obj.Dict.GetInt("Length"); ok && int64(len(obj.Stream)) < n {
+			return true
+		}
+	}
+	return false
 }
 
 // NewParser creates a parser for the given data at the given start position.
@@ -0,0 +1,361 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// pdfaIdentification is the XMP schema declaring PDF/A-2b conformance
+// (ISO 19005-2, 6.6.4).
+const pdfaIdentification = `  <rdf:Description rdf:about=""
+    xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+   <pdfaid:part>2</pdfaid:part>
+   <pdfaid:conformance>B</pdfaid:conformance>
+  </rdf:Description>
+`
+
+// Annotation flags (ISO 32000-1, 12.5.3).
+const (
+	annotInvisible = 1 << 0
+	annotHidden    = 1 << 1
+	annotPrint     = 1 << 2
+	annotNoView    = 1 << 5
+)
+
+// makePDFA returns pdf post-processed toward PDF/A-2b: metadata from md
+// is written with the PDF/A identification schema, an sRGB output intent
+// is added, annotations are made printable and a file identifier is
+// added if missing. Constructs that cannot be fixed this way are
+// reported as a [*PDFAError].
+func makePDFA(pdf []byte, md Metadata, now time.Time) ([]byte, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	if v := pdfaViolations(doc); len(v) > 0 {
+		return nil, &PDFAError{Violations: v}
+	}
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeDocInfo(u, mergeDocInfo(doc, md, now), []byte(pdfaIdentification)); err != nil {
+		return nil, err
+	}
+
+	catRef, cat, err := u.catalog()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cat["OutputIntents"]; !ok {
+		profile := &Object{Type: ObjStream, Dict: Dict{"N": intObj(3)}, Stream: srgbProfile()}
+		cat["OutputIntents"] = arrayObj(dictObj(Dict{
+			"Type":                      nameObj("OutputIntent"),
+			"S":                         nameObj("GTS_PDFA1"),
+			"OutputConditionIdentifier": textStringObj("sRGB IEC61966-2.1"),
+			"Info":                      textStringObj("sRGB IEC61966-2.1"),
+			"RegistryName":              textStringObj("http://www.color.org"),
+			"DestOutputProfile":         refObj(u.add(profile)),
+		}))
+		u.set(catRef, dictObj(cat))
+	}
+
+	if err := makeAnnotationsPrintable(u); err != nil {
+		return nil, err
+	}
+
+	if _, ok := doc.trailer["ID"]; !ok {
+		sum := md5.Sum(pdf)
+		id := &Object{Type: ObjString, Str: sum[:]}
+		u.trailer["ID"] = arrayObj(id, id)
+	}
+	return u.bytes(), nil
+}
+
+// pdfaViolations lists the constructs in doc that PDF/A-2b forbids and
+// that cannot be removed without changing the document's content.
+func pdfaViolations(doc *Document) []string {
+	var violations []string
+	seen := make(map[string]bool)
+	flag := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		if !seen[msg] {
+			seen[msg] = true
+			violations = append(violations, msg)
+		}
+	}
+
+	if header, _ := doc.window(0, 64); !hasBinaryComment(header) {
+		flag("header is not followed by a binary comment")
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		flag("document is encrypted")
+	}
+
+	cat, err := doc.Catalog()
+	if err != nil {
+		flag("unreadable catalog: %v", err)
+		return violations
+	}
+	if names := resolveDict(doc, cat["Names"]); names != nil {
+		if _, ok := names["JavaScript"]; ok {
+			flag("document contains JavaScript")
+		}
+		if _, ok := names["EmbeddedFiles"]; ok {
+			flag("document contains embedded files")
+		}
+	}
+	if forbiddenAction(doc, cat["OpenAction"]) {
+		flag("document contains JavaScript")
+	}
+	if _, ok := cat["AA"]; ok {
+		flag("document has additional actions")
+	}
+
+	pages, err := doc.Pages()
+	if err != nil {
+		flag("unreadable page tree: %v", err)
+		return violations
+	}
+	for _, page := range pages {
+		if _, ok := page["AA"]; ok {
+			flag("page has additional actions")
+		}
+		fonts, _ := doc.PageFonts(page)
+		for _, font := range fonts {
+			if font.Type == ObjDict && !fontEmbedded(doc, font.Dict) {
+				name, _ := font.Dict.GetName("BaseFont")
+				flag("font %s is not embedded", name)
+			}
+		}
+		annots, _ := doc.Resolve(page["Annots"])
+		if annots == nil || annots.Type != ObjArray {
+			continue
+		}
+		for _, a := range annots.Array {
+			annot := resolveDict(doc, a)
+			if annot == nil {
+				continue
+			}
+			if forbiddenAction(doc, annot["A"]) {
+				flag("link runs JavaScript or launches an application")
+			}
+			if st, _ := annot.GetName("Subtype"); st == "FileAttachment" || st == "Sound" || st == "Movie" {
+				flag("document contains a %s annotation", st)
+			}
+		}
+	}
+	return violations
+}
+
+// hasBinaryComment reports whether the header line is followed by a
+// comment of at least four bytes above 127, which marks the file as
+// binary for transfer tools (ISO 19005-2, 6.1.2).
+func hasBinaryComment(data []byte) bool {
+	i := bytes.IndexAny(data, "\r\n")
+	if i < 0 {
+		return false
+	}
+	rest := bytes.TrimLeft(data[i:], "\r\n")
+	if len(rest) < 5 || rest[0] != '%' {
+		return false
+	}
+	for _, c := range rest[1:5] {
+		if c <= 127 {
+			return false
+		}
+	}
+	return true
+}
+
+// forbiddenAction reports whether obj is an action dictionary running
+// JavaScript or launching an application.
+func forbiddenAction(doc *Document, obj *Object) bool {
+	action := resolveDict(doc, obj)
+	if action == nil {
+		return false
+	}
+	s, _ := action.GetName("S")
+	return s == "JavaScript" || s == "Launch"
+}
+
+// fontEmbedded reports whether the font program of font is embedded.
+// Type 3 fonts are defined by content streams and always count as
+// embedded.
+func fontEmbedded(doc *Document, font Dict) bool {
+	switch st, _ := font.GetName("Subtype"); st {
+	case "Type3":
+		return true
+	case "Type0":
+		desc, _ := doc.Resolve(font["DescendantFonts"])
+		if desc == nil || desc.Type != ObjArray || len(desc.Array) == 0 {
+			return false
+		}
+		if font = resolveDict(doc, desc.Array[0]); font == nil {
+			return false
+		}
+	}
+	fd := resolveDict(doc, font["FontDescriptor"])
+	if fd == nil {
+		return false
+	}
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if _, ok := fd[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// makeAnnotationsPrintable sets the Print flag and clears the hiding
+// flags of every annotation, as PDF/A requires (ISO 19005-2, 6.3.2).
+// Chrome writes link annotations without flags.
+func makeAnnotationsPrintable(u *pdfUpdate) error {
+	doc := u.doc
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return err
+	}
+	fix := func(annot Dict) (Dict, bool) {
+		flags, _ := annot.GetInt("F")
+		want := flags&^(annotInvisible|annotHidden|annotNoView) | annotPrint
+		if st, _ := annot.GetName("Subtype"); st == "Popup" || flags == want {
+			return annot, false
+		}
+		cp := make(Dict, len(annot)+1)
+		for k, v := range annot {
+			cp[k] = v
+		}
+		cp["F"] = intObj(int(want))
+		return cp, true
+	}
+
+	for _, ref := range refs {
+		page, err := doc.ResolveRef(ref)
+		if err != nil || page == nil || page.Type != ObjDict {
+			continue
+		}
+		annots, _ := doc.Resolve(page.Dict["Annots"])
+		if annots == nil || annots.Type != ObjArray {
+			continue
+		}
+		items := make([]*Object, len(annots.Array))
+		pageChanged := false
+		for i, a := range annots.Array {
+			items[i] = a
+			annot := resolveDict(doc, a)
+			if annot == nil {
+				continue
+			}
+			fixed, changed := fix(annot)
+			switch {
+			case !changed:
+			case a.Type == ObjRef:
+				u.set(a.Ref, dictObj(fixed))
+			default:
+				items[i] = dictObj(fixed)
+				pageChanged = true
+			}
+		}
+		if pageChanged {
+			cp := make(Dict, len(page.Dict))
+			for k, v := range page.Dict {
+				cp[k] = v
+			}
+			cp["Annots"] = arrayObj(items...)
+			u.set(ref, dictObj(cp))
+		}
+	}
+	return nil
+}
+
+// resolveDict resolves obj and returns its dictionary, or nil if it is
+// not a dictionary or stream.
+func resolveDict(doc *Document, obj *Object) Dict {
+	v, err := doc.Resolve(obj)
+	if err != nil || v == nil || (v.Type != ObjDict && v.Type != ObjStream) {
+		return nil
+	}
+	return v.Dict
+}
+
+// srgbProfile returns an ICC version 2 display profile for the sRGB
+// colour space, used as the PDF/A output intent. It is built rather than
+// shipped so that the library carries no binary assets.
+var srgbProfile = sync.OnceValue(func() []byte {
+	// sRGB primaries adapted to the D50 profile connection space.
+	xyz := func(x, y, z float64) []byte {
+		b := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range []float64{x, y, z} {
+			b = binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v*65536))))
+		}
+		return b
+	}
+	desc := []byte("desc\x00\x00\x00\x00")
+	name := "sRGB IEC61966-2.1\x00"
+	desc = binary.BigEndian.AppendUint32(desc, uint32(len(name)))
+	desc = append(desc, name...)
+	desc = append(desc, make([]byte, 4+4+2+1+67)...) // empty Unicode and ScriptCode descriptions
+
+	const points = 1024
+	trc := []byte("curv\x00\x00\x00\x00")
+	trc = binary.BigEndian.AppendUint32(trc, points)
+	for i := range points {
+		v := float64(i) / (points - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		trc = binary.BigEndian.AppendUint16(trc, uint16(math.Round(v*65535)))
+	}
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", desc},
+		{"cprt", []byte("text\x00\x00\x00\x00No copyright, use freely\x00")},
+		{"wtpt", xyz(0.9505, 1, 1.0891)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", trc},
+		{"gTRC", nil}, // shares rTRC's data
+		{"bTRC", nil},
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[8:], 0x02100000) // version 2.1
+	copy(header[12:], "mntrRGB XYZ ")
+	for i, v := range []uint16{2024, 1, 1} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	copy(header[68:], xyz(0.9642, 1, 0.8249)[8:]) // D50 illuminant
+
+	var table, data bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	dataStart := len(header) + 4 + 12*len(tags)
+	var offset, size int
+	for _, t := range tags {
+		if t.data != nil {
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+			offset, size = dataStart+data.Len(), len(t.data)
+			data.Write(t.data)
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, [2]uint32{uint32(offset), uint32(size)})
+	}
+
+	profile := append(append(header, table.Bytes()...), data.Bytes()...)
+	binary.BigEndian.PutUint32(profile, uint32(len(profile)))
+	return profile
+})
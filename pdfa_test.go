@@ -0,0 +1,130 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// buildPDFAInput returns a one-page PDF like Chrome's output: an embedded
+// font and a link annotation without flags.
+func buildPDFAInput(t *testing.T) []byte {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Hello) Tj ET")}))
+	u, _ := newPDFUpdate(doc)
+	fontFile := u.add(&Object{Type: ObjStream, Dict: Dict{}, Stream: []byte("font program")})
+	u.set(Reference{Number: 5}, dictObj(Dict{
+		"Type":     nameObj("Font"),
+		"Subtype":  nameObj("TrueType"),
+		"BaseFont": nameObj("AAAAAA+Roboto"),
+		"FontDescriptor": dictObj(Dict{
+			"Type":      nameObj("FontDescriptor"),
+			"FontFile2": refObj(fontFile),
+		}),
+	}))
+	link := u.add(dictObj(Dict{
+		"Type":    nameObj("Annot"),
+		"Subtype": nameObj("Link"),
+		"A":       dictObj(Dict{"S": nameObj("URI"), "URI": textStringObj("https://example.com")}),
+	}))
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	page.Dict["Annots"] = arrayObj(refObj(link))
+	u.set(Reference{Number: 3}, page)
+	return u.bytes()
+}
+
+func TestMakePDFA(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	out, err := makePDFA(buildPDFAInput(t), Metadata{Title: "Archive"}, now)
+	if err != nil {
+		t.Fatalf("makePDFA: %v", err)
+	}
+	doc := mustLoad(t, out)
+	cat, _ := doc.Catalog()
+
+	xmp, _ := doc.Resolve(cat["Metadata"])
+	if xmp == nil || !bytes.Contains(xmp.Stream, []byte("<pdfaid:part>2</pdfaid:part>")) ||
+		!bytes.Contains(xmp.Stream, []byte("<pdfaid:conformance>B</pdfaid:conformance>")) {
+		t.Error("XMP does not identify PDF/A-2b")
+	}
+	if info := readDocInfo(doc); info.title != "Archive" {
+		t.Errorf("Title = %q, want Archive", info.title)
+	}
+
+	intents, _ := doc.Resolve(cat["OutputIntents"])
+	if intents == nil || intents.Type != ObjArray || len(intents.Array) != 1 {
+		t.Fatalf("/OutputIntents = %v", intents)
+	}
+	intent := resolveDict(doc, intents.Array[0])
+	if s, _ := intent.GetName("S"); s != "GTS_PDFA1" {
+		t.Errorf("output intent /S = %q, want GTS_PDFA1", s)
+	}
+	profile, _ := doc.Resolve(intent["DestOutputProfile"])
+	if profile == nil || profile.Type != ObjStream || !bytes.Equal(profile.Stream, srgbProfile()) {
+		t.Error("output intent does not embed the sRGB profile")
+	}
+
+	page, _ := doc.ResolveRef(Reference{Number: 3})
+	annots, _ := doc.Resolve(page.Dict["Annots"])
+	link := resolveDict(doc, annots.Array[0])
+	if f, _ := link.GetInt("F"); f != annotPrint {
+		t.Errorf("link annotation /F = %d, want %d", f, annotPrint)
+	}
+
+	if id, ok := doc.trailer.GetArray("ID"); !ok || len(id) != 2 {
+		t.Errorf("trailer /ID = %v", doc.trailer["ID"])
+	}
+}
+
+func TestMakePDFA_Violations(t *testing.T) {
+	// buildTestPDF uses the standard Helvetica font without embedding it.
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Hello) Tj ET")}))
+	u, _ := newPDFUpdate(doc)
+	catRef, cat, _ := u.catalog()
+	cat["OpenAction"] = dictObj(Dict{"S": nameObj("JavaScript"), "JS": textStringObj("print()")})
+	u.set(catRef, dictObj(cat))
+
+	_, err := makePDFA(u.bytes(), Metadata{}, time.Now())
+	var pdfaErr *PDFAError
+	if !errors.As(err, &pdfaErr) {
+		t.Fatalf("makePDFA error = %v, want *PDFAError", err)
+	}
+	want := []string{"document contains JavaScript", "font Helvetica is not embedded"}
+	if len(pdfaErr.Violations) != len(want) {
+		t.Fatalf("Violations = %q, want %q", pdfaErr.Violations, want)
+	}
+	for i := range want {
+		if pdfaErr.Violations[i] != want[i] {
+			t.Errorf("Violations[%d] = %q, want %q", i, pdfaErr.Violations[i], want[i])
+		}
+	}
+}
+
+func TestHasBinaryComment(t *testing.T) {
+	if !hasBinaryComment([]byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj")) {
+		t.Error("binary comment not recognised")
+	}
+	if hasBinaryComment([]byte("%PDF-1.4\n1 0 obj")) {
+		t.Error("missing binary comment not detected")
+	}
+}
+
+func TestSRGBProfile(t *testing.T) {
+	p := srgbProfile()
+	if got := binary.BigEndian.Uint32(p); int(got) != len(p) {
+		t.Errorf("header size = %d, want %d", got, len(p))
+	}
+	if string(p[36:40]) != "acsp" || string(p[12:24]) != "mntrRGB XYZ " {
+		t.Errorf("bad profile header % x", p[:40])
+	}
+	count := int(binary.BigEndian.Uint32(p[128:]))
+	for i := range count {
+		e := p[132+12*i:]
+		off, size := binary.BigEndian.Uint32(e[4:]), binary.BigEndian.Uint32(e[8:])
+		if off%4 != 0 || int(off+size) > len(p) {
+			t.Errorf("tag %s at %d+%d out of bounds or unaligned", e[:4], off, size)
+		}
+	}
+}
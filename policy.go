@@ -0,0 +1,145 @@
+package htmlpdf
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// urlPolicy decides which URLs a conversion may load (see WithURLPolicy).
+type urlPolicy struct {
+	allow, deny []urlRule
+
+	// lookup resolves host names for address rules.
+	lookup func(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// urlRule matches a URL by host name glob or, if prefix is valid, by the
+// address of its host.
+type urlRule struct {
+	glob   string
+	prefix netip.Prefix
+}
+
+// newURLPolicy parses allow and deny rules. It returns nil if there are
+// none.
+func newURLPolicy(allow, deny []string) (*urlPolicy, error) {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+	p := &urlPolicy{lookup: net.DefaultResolver.LookupNetIP}
+	var err error
+	if p.allow, err = parseURLRules(allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = parseURLRules(deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseURLRules(rules []string) ([]urlRule, error) {
+	parsed := make([]urlRule, len(rules))
+	for i, r := range rules {
+		if strings.Contains(r, "/") {
+			prefix, err := netip.ParsePrefix(r)
+			if err != nil {
+				return nil, fmt.Errorf("htmlpdf: invalid URL policy rule %q: %w", r, err)
+			}
+			parsed[i].prefix = prefix.Masked()
+			continue
+		}
+		if _, err := path.Match(r, ""); err != nil {
+			return nil, fmt.Errorf("htmlpdf: invalid URL policy rule %q: %w", r, err)
+		}
+		parsed[i].glob = strings.ToLower(r)
+	}
+	return parsed, nil
+}
+
+// checked reports whether the policy applies to u. Only http and https
+// URLs are checked: data:, blob: and file: URLs do not reach a server,
+// and WebSocket handshakes are not paused by request interception, so
+// ws: and wss: URLs could not be refused.
+func (p *urlPolicy) checked(u *url.URL) bool {
+	switch u.Scheme {
+	case "http", "https":
+		return true
+	}
+	return false
+}
+
+// allows reports whether u may be loaded: it matches no deny rule and, if
+// there are allow rules, at least one of them. Host names are resolved to
+// check address rules; a host name that cannot be resolved when an
+// address rule needs it is refused, since the browser may well resolve it
+// where Go cannot.
+func (p *urlPolicy) allows(ctx context.Context, u *url.URL) bool {
+	if p == nil || !p.checked(u) {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	var addrs []netip.Addr
+	var lookupErr error
+	resolved := false
+	matches := func(rules []urlRule) bool {
+		for _, r := range rules {
+			if !r.prefix.IsValid() {
+				if ok, _ := path.Match(r.glob, host); ok {
+					return true
+				}
+				continue
+			}
+			if !resolved {
+				addrs, lookupErr = p.addrs(ctx, host)
+				resolved = true
+			}
+			for _, a := range addrs {
+				if r.prefix.Contains(a) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if matches(p.deny) || lookupErr != nil {
+		return false
+	}
+	return (len(p.allow) == 0 || matches(p.allow)) && lookupErr == nil
+}
+
+// checkURL returns an error wrapping [ErrURLNotAllowed] if the page src
+// navigates to is refused by the converter's URL policy. Redirects and
+// subresources are checked as they are requested (see interceptor).
+func (c *Converter) checkURL(ctx context.Context, src source) error {
+	if c.cfg.policy == nil || src.document != nil {
+		return nil
+	}
+	u, err := url.Parse(src.url)
+	if err != nil {
+		return err
+	}
+	if !c.cfg.policy.allows(ctx, u) {
+		return fmt.Errorf("%w: %s", ErrURLNotAllowed, src.url)
+	}
+	return nil
+}
+
+// addrs returns the addresses of host, which may be an IP literal.
+func (p *urlPolicy) addrs(ctx context.Context, host string) ([]netip.Addr, error) {
+	if a, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{a.Unmap()}, nil
+	}
+	found, err := p.lookup(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range found {
+		found[i] = a.Unmap()
+	}
+	return found, nil
+}
@@ -0,0 +1,118 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+)
+
+// stubLookup resolves host names from a fixed table.
+func stubLookup(hosts map[string]string) func(context.Context, string, string) ([]netip.Addr, error) {
+	return func(_ context.Context, _, host string) ([]netip.Addr, error) {
+		a, ok := hosts[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return []netip.Addr{netip.MustParseAddr(a)}, nil
+	}
+}
+
+func TestURLPolicy(t *testing.T) {
+	p, err := newURLPolicy(
+		[]string{"*.example.com", "example.com", "203.0.113.0/24"},
+		[]string{"internal.example.com", "169.254.0.0/16", "10.0.0.0/8"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.lookup = stubLookup(map[string]string{
+		"example.com":         "93.184.216.34",
+		"www.example.com":     "93.184.216.34",
+		"sneaky.example.com":  "10.1.2.3",
+		"partner.example.net": "203.0.113.7",
+	})
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.example.com/report", true},
+		{"https://EXAMPLE.com/", true},
+		{"https://internal.example.com/", false},
+		{"https://sneaky.example.com/", false}, // resolves into 10.0.0.0/8
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://partner.example.net/", true}, // resolves into 203.0.113.0/24
+		{"http://203.0.113.9:8080/", true},
+		{"https://other.org/", false},
+		{"https://new.example.com/", false}, // cannot be resolved for the deny rules
+		{"data:text/plain,hi", true},
+		{"file:///etc/passwd", true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := p.allows(context.Background(), u); got != tt.want {
+			t.Errorf("allows(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestURLPolicy_DenyOnly(t *testing.T) {
+	p, err := newURLPolicy(nil, []string{"169.254.169.254/32", "::1/128", "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.lookup = stubLookup(map[string]string{"example.org": "93.184.216.34"})
+	for raw, want := range map[string]bool{
+		"https://example.org/":       true,
+		"https://unresolvable.test/": false, // its address cannot be checked
+		"http://169.254.169.254/":    false,
+		"http://[::1]:8080/":         false,
+		"http://localhost:9222/":     false,
+		"http://[::ffff:a9fe:a9fe]/": false, // 169.254.169.254 mapped to IPv6
+	} {
+		u, _ := url.Parse(raw)
+		if got := p.allows(context.Background(), u); got != want {
+			t.Errorf("allows(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestNewURLPolicy(t *testing.T) {
+	if p, err := newURLPolicy(nil, nil); p != nil || err != nil {
+		t.Errorf("newURLPolicy(nil, nil) = %v, %v, want nil, nil", p, err)
+	}
+	for _, rule := range []string{"[", "10.0.0.0/33", "example.com/path"} {
+		if _, err := newURLPolicy(nil, []string{rule}); err == nil {
+			t.Errorf("newURLPolicy accepted invalid rule %q", rule)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	p, _ := newURLPolicy(nil, []string{"169.254.0.0/16"})
+	c := &Converter{cfg: converterConfig{policy: p}}
+
+	err := c.checkURL(context.Background(), source{url: "http://169.254.169.254/"})
+	if !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("checkURL = %v, want ErrURLNotAllowed", err)
+	}
+	if err := c.checkURL(context.Background(), source{document: []byte("x")}); err != nil {
+		t.Errorf("checkURL(in-memory document) = %v", err)
+	}
+}
+
+func TestInterceptor_URLPolicy(t *testing.T) {
+	p, _ := newURLPolicy(nil, []string{"169.254.0.0/16"})
+	ic := newInterceptor(&converterConfig{policy: p}, source{document: []byte("x")}, ConvertOptions{})
+
+	if _, ok := ic.respond(context.Background(), pausedRequest(assetOrigin)).(*fetch.FulfillRequestParams); !ok {
+		t.Error("in-memory document was not fulfilled")
+	}
+	if _, ok := ic.respond(context.Background(), pausedRequest("http://169.254.169.254/")).(*fetch.FailRequestParams); !ok {
+		t.Error("denied URL was not blocked")
+	}
+}
@@ -0,0 +1,240 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxConsecutiveFailures is the number of back-to-back conversions failed
+// by the browser after which a pool member is considered unhealthy and
+// replaced.
+const maxConsecutiveFailures = 3
+
+// ConverterPool distributes conversions across several [Converter]
+// instances, each backed by its own browser process.
+//
+// Conversions are assigned round-robin. A member whose browser has exited,
+// or whose browser failed its last few conversions, is closed and replaced
+// with a fresh [Converter] the next time it is selected. Errors of the
+// page, such as an [*HTTPError] or a URL refused by policy, do not count
+// against a member. It is safe for concurrent use.
+//
+// Call [ConverterPool.Close] when the pool is no longer needed to release
+// all browser processes.
+type ConverterPool struct {
+	opts []Option
+
+	mu      sync.Mutex
+	members []*poolMember
+	next    int
+	closed  bool
+}
+
+// poolMember wraps a pooled Converter with its health bookkeeping.
+type poolMember struct {
+	conv     *Converter
+	failures int
+	inflight sync.WaitGroup
+	replaced chan struct{} // closed once a replacement under way is done
+}
+
+// NewConverterPool starts size browser instances configured with opts.
+//
+// If any instance fails to start, the ones already started are closed and
+// the error is returned.
+func NewConverterPool(size int, opts ...Option) (*ConverterPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("htmlpdf: pool size must be at least 1, got %d", size)
+	}
+	p := &ConverterPool{opts: opts}
+	for i := 0; i < size; i++ {
+		conv, err := NewConverter(opts...)
+		if err != nil {
+			for _, m := range p.members {
+				m.conv.Close()
+			}
+			return nil, err
+		}
+		p.members = append(p.members, &poolMember{conv: conv})
+	}
+	return p, nil
+}
+
+// Size returns the number of browser instances in the pool.
+func (p *ConverterPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.members)
+}
+
+// Close releases every browser instance in the pool. Conversions still in
+// progress are allowed to finish before their browser is shut down.
+// Close is idempotent.
+func (p *ConverterPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	members := p.members
+	p.members = nil
+	p.mu.Unlock()
+
+	for _, m := range members {
+		m.inflight.Wait()
+		m.conv.Close()
+	}
+	return nil
+}
+
+// ConvertHTML converts an HTML string to a PDF document using the next
+// available browser instance. See [Converter.ConvertHTML].
+func (p *ConverterPool) ConvertHTML(ctx context.Context, html string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertHTML(ctx, html, pg, opts...)
+	})
+}
+
+// ConvertHTMLBytes converts HTML held in a byte slice to a PDF document
+// using the next available browser instance. See
+// [Converter.ConvertHTMLBytes].
+func (p *ConverterPool) ConvertHTMLBytes(ctx context.Context, html []byte, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertHTMLBytes(ctx, html, pg, opts...)
+	})
+}
+
+// ConvertReader converts HTML read from r to a PDF document using the next
+// available browser instance. See [Converter.ConvertReader].
+func (p *ConverterPool) ConvertReader(ctx context.Context, r io.Reader, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertReader(ctx, r, pg, opts...)
+	})
+}
+
+// ConvertHTMLWithAssets converts an HTML string with an in-memory asset
+// bundle using the next available browser instance. See
+// [Converter.ConvertHTMLWithAssets].
+func (p *ConverterPool) ConvertHTMLWithAssets(ctx context.Context, html string, assets map[string][]byte, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertHTMLWithAssets(ctx, html, assets, pg, opts...)
+	})
+}
+
+// ConvertURL converts the web page at rawURL to a PDF document using the
+// next available browser instance. See [Converter.ConvertURL].
+func (p *ConverterPool) ConvertURL(ctx context.Context, rawURL string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertURL(ctx, rawURL, pg, opts...)
+	})
+}
+
+// ConvertFile converts a local HTML file to a PDF document using the next
+// available browser instance. See [Converter.ConvertFile].
+func (p *ConverterPool) ConvertFile(ctx context.Context, path string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertFile(ctx, path, pg, opts...)
+	})
+}
+
+// ConvertRoutes converts several routes of a single-page app into one PDF
+// using the next available browser instance. See [Converter.ConvertRoutes].
+func (p *ConverterPool) ConvertRoutes(ctx context.Context, baseURL string, routes []string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	return p.do(ctx, func(c *Converter) (*Result, error) {
+		return c.ConvertRoutes(ctx, baseURL, routes, pg, opts...)
+	})
+}
+
+// do runs fn on the next pool member and records the outcome.
+func (p *ConverterPool) do(ctx context.Context, fn func(*Converter) (*Result, error)) (*Result, error) {
+	m, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer m.inflight.Done()
+
+	res, err := fn(m.conv)
+
+	// Only failures of the browser itself count: errors of the page, and
+	// failures caused by the caller giving up, say nothing about its
+	// health.
+	var transient *transientError
+	p.mu.Lock()
+	switch {
+	case err == nil:
+		m.failures = 0
+	case ctx.Err() == nil && errors.As(err, &transient):
+		m.failures++
+	}
+	p.mu.Unlock()
+
+	return res, err
+}
+
+// acquire selects the next member round-robin, replacing it first if it
+// is unhealthy. The returned member has its in-flight counter incremented.
+//
+// The replacement browser is launched without holding p.mu, so that
+// callers routed to other members are not held up; callers routed to the
+// same slot wait for it.
+func (p *ConverterPool) acquire() (*poolMember, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrClosed
+	}
+	idx := p.next % len(p.members)
+	p.next = (idx + 1) % len(p.members)
+
+	for {
+		m := p.members[idx]
+		if m.replaced != nil {
+			done := m.replaced
+			p.mu.Unlock()
+			<-done
+			p.mu.Lock()
+			if p.closed {
+				p.mu.Unlock()
+				return nil, ErrClosed
+			}
+			continue
+		}
+		if m.failures < maxConsecutiveFailures && m.conv.healthy() {
+			m.inflight.Add(1)
+			p.mu.Unlock()
+			return m, nil
+		}
+
+		m.replaced = make(chan struct{})
+		p.mu.Unlock()
+		m.conv.cfg.logger.Warn("htmlpdf: replacing pool member", "member", idx, "failures", m.failures)
+		conv, err := NewConverter(p.opts...)
+		p.mu.Lock()
+		close(m.replaced)
+		m.replaced = nil
+		switch {
+		case err != nil:
+			p.mu.Unlock()
+			return nil, fmt.Errorf("htmlpdf: replacing pool member: %w", err)
+		case p.closed:
+			// Close has taken the old member with the others.
+			p.mu.Unlock()
+			conv.Close()
+			return nil, ErrClosed
+		}
+		old := m
+		go func() {
+			old.inflight.Wait()
+			old.conv.Close()
+		}()
+		m = &poolMember{conv: conv}
+		m.inflight.Add(1)
+		p.members[idx] = m
+		p.mu.Unlock()
+		return m, nil
+	}
+}
@@ -0,0 +1,139 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testPool returns a pool of one member with a stand-in browser.
+// Replacing the member would launch a real browser at a path that does
+// not exist, and so fails.
+func testPool() (*ConverterPool, *Converter) {
+	c := idleTestConverter(time.Hour)
+	p := &ConverterPool{
+		opts:    []Option{WithChromePath("/nonexistent/chrome")},
+		members: []*poolMember{{conv: c}},
+	}
+	return p, c
+}
+
+func TestConverterPool_PageErrorsKeepMember(t *testing.T) {
+	p, c := testPool()
+	ctx := context.Background()
+	for i := 0; i < 2*maxConsecutiveFailures; i++ {
+		_, err := p.do(ctx, func(*Converter) (*Result, error) {
+			return nil, &HTTPError{URL: "https://example.com/missing", StatusCode: 404}
+		})
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("conversion %d: err = %v, want the *HTTPError", i, err)
+		}
+	}
+	if got := p.members[0]; got.conv != c || got.failures != 0 {
+		t.Errorf("member replaced or failures counted (%d) after HTTP errors", got.failures)
+	}
+}
+
+func TestConverterPool_BrowserFailuresReplaceMember(t *testing.T) {
+	p, c := testPool()
+	ctx := context.Background()
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		if _, err := p.do(ctx, func(*Converter) (*Result, error) {
+			return nil, &transientError{errBrowserExited}
+		}); err == nil {
+			t.Fatalf("conversion %d succeeded, want the browser failure", i)
+		}
+	}
+	_, err := p.do(ctx, func(*Converter) (*Result, error) { return &Result{}, nil })
+	if err == nil {
+		t.Fatal("conversion used the failing member, want it replaced")
+	}
+	if p.members[0].conv != c || p.members[0].replaced != nil {
+		t.Error("failed replacement left the slot changed or marked as being replaced")
+	}
+}
+
+func TestConverterPool_WaitsForReplacement(t *testing.T) {
+	p, _ := testPool()
+	other := &poolMember{conv: idleTestConverter(time.Hour)}
+	p.members = append(p.members, other)
+	replacing := p.members[0]
+	replacing.replaced = make(chan struct{})
+
+	// A slot being replaced holds up only the callers routed to it.
+	p.next = 1
+	if m, err := p.acquire(); err != nil || m != other {
+		t.Fatalf("acquire = %p, %v, want the other member", m, err)
+	}
+	acquired := make(chan *poolMember)
+	go func() {
+		m, err := p.acquire()
+		if err != nil {
+			t.Errorf("acquire: %v", err)
+		}
+		acquired <- m
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquire returned while its slot was being replaced")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.mu.Lock()
+	close(replacing.replaced)
+	replacing.replaced = nil
+	p.mu.Unlock()
+	select {
+	case m := <-acquired:
+		if m != replacing {
+			t.Errorf("acquire = %p, want the member of its slot", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire did not return once the replacement was done")
+	}
+}
+
+func TestConverterPool_KeepsIdleMember(t *testing.T) {
+	p, c := testPool()
+	c.stopIdleBrowser()
+	if !c.healthy() {
+		t.Fatal("idle-stopped converter reported unhealthy")
+	}
+	if m, err := p.acquire(); err != nil || m.conv != c {
+		t.Fatalf("acquire = %v, %v, want the idle-stopped member kept", m, err)
+	}
+
+	// A browser that exited by itself is replaced.
+	c.browserCancel()
+	c.mu.Lock()
+	c.stopped = false
+	c.launchable.Store(false)
+	c.mu.Unlock()
+	if c.healthy() {
+		t.Error("converter whose browser exited reported healthy")
+	}
+}
+
+func TestConverterPool_HealthTakesNoConverterLock(t *testing.T) {
+	p, c := testPool()
+
+	// A member busy relaunching its browser holds its own lock, not the
+	// pool's.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	acquired := make(chan error, 1)
+	go func() {
+		_, err := p.acquire()
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire blocked on the member's lock")
+	}
+}
@@ -0,0 +1,119 @@
+package htmlpdf
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithReflow makes the extractor's plain text ([Extractor.ExtractPage],
+// [Extractor.ExtractAll] and [Extractor.ExtractPageDict]) one paragraph
+// per line, separated by blank lines, instead of one line of text per
+// line on the page, so that it can be fed to language processing without
+// re-wrapping it.
+//
+// A line continues the paragraph of the line above it unless it is set
+// apart by more space than the page's usual line spacing, is indented
+// from the line above, starts with a list bullet or number, or is set in
+// a different font size, or the line above stops short of the right edge
+// of the text and ends a sentence or is followed by a capital letter.
+// Words hyphenated across lines are joined without their hyphen.
+func WithReflow() ExtractorOption {
+	return func(e *Extractor) {
+		e.reflow = true
+	}
+}
+
+// reflowText returns the text of lines as paragraphs separated by blank
+// lines.
+func reflowText(lines []textLine) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	// The usual line spacing is the median gap between baselines, and the
+	// right edge of the text the furthest any line reaches.
+	var gaps []float64
+	right := lineRight(lines[0])
+	for i := 1; i < len(lines); i++ {
+		if gap := lines[i-1].y - lines[i].y; gap > 0 {
+			gaps = append(gaps, gap)
+		}
+		right = max(right, lineRight(lines[i]))
+	}
+	spacing := 0.0
+	if len(gaps) > 0 {
+		slices.Sort(gaps)
+		spacing = gaps[len(gaps)/2]
+	}
+
+	var sb strings.Builder
+	var para string
+	for i, l := range lines {
+		text := strings.TrimSpace(l.text())
+		if text == "" {
+			continue
+		}
+		if i == 0 || para == "" || paragraphBreak(lines[i-1], l, para, text, spacing, right) {
+			if para != "" {
+				sb.WriteString(para)
+				sb.WriteString("\n\n")
+			}
+			para = text
+			continue
+		}
+		para = joinWrapped(para, text)
+	}
+	sb.WriteString(para)
+	return strings.TrimSpace(sb.String())
+}
+
+// paragraphBreak reports whether the line cur, with the text text,
+// starts a new paragraph after the line prev, which ends the paragraph
+// para so far.
+func paragraphBreak(prev, cur textLine, para, text string, spacing, right float64) bool {
+	size := averageFontSize(prev.spans)
+	curSize := averageFontSize(cur.spans)
+	switch {
+	case curSize > size*1.15 || size > curSize*1.15:
+		return true // a heading, or the text after one
+	case spacing > 0 && prev.y-cur.y > spacing*1.4:
+		return true // a blank line or paragraph spacing
+	case cur.spans[0].x-prev.spans[0].x > size && !listMarker.MatchString(para):
+		return true // a first-line indent, unlike a list item's hanging indent
+	case listMarker.MatchString(text):
+		return true
+	}
+	// A line stopping short of the right edge ends a paragraph where it
+	// ends a sentence, or where the next line starts with a capital, as a
+	// heading or an address line would.
+	if right-lineRight(prev) > 2*size {
+		last, _ := utf8.DecodeLastRuneInString(strings.TrimRight(para, `"')]”’»`))
+		first, _ := utf8.DecodeRuneInString(text)
+		return strings.ContainsRune(".!?:;", last) || unicode.IsUpper(first) || unicode.IsDigit(first)
+	}
+	return false
+}
+
+// joinWrapped joins the next line of a paragraph to it, removing the
+// hyphen of a word hyphenated across the lines: a soft hyphen, or a
+// hyphen between letters followed by a lowercase letter.
+func joinWrapped(para, text string) string {
+	last, n := utf8.DecodeLastRuneInString(para)
+	first, _ := utf8.DecodeRuneInString(text)
+	before, _ := utf8.DecodeLastRuneInString(para[:len(para)-n])
+	switch {
+	case last == '\u00ad': // a soft hyphen
+		return para[:len(para)-n] + text
+	case last == '-' && unicode.IsLetter(before) && unicode.IsLower(first):
+		return para[:len(para)-n] + text
+	}
+	return para + " " + text
+}
+
+// lineRight returns the x coordinate where a line's text ends.
+func lineRight(l textLine) float64 {
+	sp := l.spans[len(l.spans)-1]
+	return sp.x + sp.width
+}
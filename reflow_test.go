@@ -0,0 +1,64 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExtractPage_Reflow(t *testing.T) {
+	y := 720.0
+	line := func(x float64, s string) string {
+		y -= 12
+		return fmt.Sprintf("BT /F1 10 Tf %g %g Td (%s) Tj ET ", x, y, s)
+	}
+	cs := []byte(
+		"BT /F1 16 Tf 72 730 Td (Introduction) Tj ET " +
+			// An indented paragraph wrapped over three lines, one hyphenated.
+			line(90, "The quarterly results were better than expected in all of our") +
+			line(72, "regions, and the board has decided to reinvest the sur-") +
+			line(72, "plus in the well-known programmes.") +
+			// The next paragraph is indented; its first line is short.
+			line(90, "Costs fell.") +
+			line(72, "Prices were stable over the period, with small changes only") +
+			line(72, "in energy.") +
+			// A list with a wrapped item, and a paragraph after a blank line.
+			line(72, "- First item") +
+			line(72, "- Second item, which is long enough to wrap onto the") +
+			line(80, "following line") +
+			line(72, "") + line(72, "Thank you."))
+	doc := mustLoad(t, buildTestPDF([][]byte{cs}))
+
+	got, err := NewExtractor(doc, WithReflow()).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	want := "Introduction\n\n" +
+		"The quarterly results were better than expected in all of our regions, and the board has decided to reinvest the surplus in the well-known programmes.\n\n" +
+		"Costs fell.\n\n" +
+		"Prices were stable over the period, with small changes only in energy.\n\n" +
+		"- First item\n\n" +
+		"- Second item, which is long enough to wrap onto the following line\n\n" +
+		"Thank you."
+	if got != want {
+		t.Errorf("reflowed text =\n%s\nwant\n%s", got, want)
+	}
+
+	// Without the option, lines are kept.
+	if got, _ := NewExtractor(doc).ExtractPage(0); got[:len("Introduction\nThe")] != "Introduction\nThe" {
+		t.Errorf("text without reflow = %q", got)
+	}
+}
+
+func TestJoinWrapped(t *testing.T) {
+	for _, tt := range []struct{ para, text, want string }{
+		{"wrapped", "line", "wrapped line"},
+		{"sur-", "plus", "surplus"},
+		{"well-", "Known", "well- Known"},
+		{"1990-", "2000", "1990- 2000"},
+		{"hyphen\u00ad", "ation", "hyphenation"},
+	} {
+		if got := joinWrapped(tt.para, tt.text); got != tt.want {
+			t.Errorf("joinWrapped(%q, %q) = %q, want %q", tt.para, tt.text, got, tt.want)
+		}
+	}
+}
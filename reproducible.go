@@ -0,0 +1,65 @@
+package htmlpdf
+
+import (
+	"crypto/md5"
+	"time"
+)
+
+// makeReproducible rewrites pdf so that converting the same HTML again
+// yields the same bytes, for [PageConfig].Reproducible: the creation and
+// modification dates are removed from the document information
+// dictionary, objects are written in object number order with a fresh
+// cross-reference table, and the file identifier, which Chrome makes
+// unique per file, is replaced with an MD5 digest of the rest of the
+// file.
+func makeReproducible(pdf []byte) ([]byte, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	replaced := make(map[int]*Object)
+	if info := doc.trailer["Info"]; info != nil && info.Type == ObjRef {
+		obj, err := doc.ResolveRef(info.Ref)
+		if err != nil {
+			return nil, err
+		}
+		if obj != nil && obj.Type == ObjDict {
+			d := make(Dict, len(obj.Dict))
+			for k, v := range obj.Dict {
+				if k != "CreationDate" && k != "ModDate" {
+					d[k] = v
+				}
+			}
+			replaced[info.Ref.Number] = dictObj(d)
+		}
+	}
+
+	// The identifier is derived from the file written without one, so
+	// the document is written twice. The trailer is copied, as a file
+	// whose trailer is not a dictionary loads without one.
+	trailer := make(Dict, len(doc.trailer)+1)
+	for k, v := range doc.trailer {
+		if k != "ID" {
+			trailer[k] = v
+		}
+	}
+	doc.trailer = trailer
+	out, err := rewritePDF(doc, replaced)
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.Sum(out)
+	id := &Object{Type: ObjString, Str: sum[:]}
+	trailer["ID"] = arrayObj(id, id)
+	return rewritePDF(doc, replaced)
+}
+
+// postProcessTime returns the time post-processing records as the
+// document's modification date: now, or the zero time, which records
+// none, for reproducible output.
+func postProcessTime(reproducible bool) time.Time {
+	if reproducible {
+		return time.Time{}
+	}
+	return time.Now()
+}
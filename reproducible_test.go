@@ -0,0 +1,111 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// chromeLikePDF returns a test PDF with an Info dictionary carrying the
+// given dates and a file identifier, as Chrome writes them.
+func chromeLikePDF(t *testing.T, date, id string) []byte {
+	t.Helper()
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Hello) Tj ET")}))
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		t.Fatalf("newPDFUpdate: %v", err)
+	}
+	u.trailer["Info"] = refObj(u.add(dictObj(Dict{
+		"Producer":     textStringObj("Skia/PDF m126"),
+		"CreationDate": textStringObj(date),
+		"ModDate":      textStringObj(date),
+	})))
+	fileID := &Object{Type: ObjString, Str: []byte(id)}
+	u.trailer["ID"] = arrayObj(fileID, fileID)
+	return u.bytes()
+}
+
+func TestMakeReproducible(t *testing.T) {
+	a, err := makeReproducible(chromeLikePDF(t, "D:20240102030405+01'00'", "first"))
+	if err != nil {
+		t.Fatalf("makeReproducible: %v", err)
+	}
+	b, err := makeReproducible(chromeLikePDF(t, "D:20250607080910Z", "second"))
+	if err != nil {
+		t.Fatalf("makeReproducible: %v", err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("outputs differ:\n%s\n---\n%s", a, b)
+	}
+
+	doc := mustLoad(t, a)
+	info := readDocInfo(doc)
+	if !info.created.IsZero() || !info.modified.IsZero() {
+		t.Errorf("dates = %v, %v, want none", info.created, info.modified)
+	}
+	if info.producer != "Skia/PDF m126" {
+		t.Errorf("Producer = %q, want it kept", info.producer)
+	}
+	id, _ := doc.Resolve(doc.trailer["ID"])
+	if id == nil || id.Type != ObjArray || len(id.Array) != 2 || len(id.Array[0].Str) != 16 {
+		t.Errorf("/ID = %v, want two MD5 digests", id)
+	}
+
+	// Different content gets a different identifier.
+	other := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Bye) Tj ET")}))
+	c, err := makeReproducible(other.data)
+	if err != nil {
+		t.Fatalf("makeReproducible: %v", err)
+	}
+	otherDoc := mustLoad(t, c)
+	otherID, _ := otherDoc.Resolve(otherDoc.trailer["ID"])
+	if bytes.Equal(otherID.Array[0].Str, id.Array[0].Str) {
+		t.Error("documents with different content share a file identifier")
+	}
+}
+
+func TestMakeReproducible_WithMetadata(t *testing.T) {
+	var outs [][]byte
+	for _, date := range []string{"D:20240102030405Z", "D:20250102030405Z"} {
+		pdf, err := makeReproducible(chromeLikePDF(t, date, date))
+		if err != nil {
+			t.Fatalf("makeReproducible: %v", err)
+		}
+		pdf, err = setMetadata(pdf, Metadata{Title: "Report"}, postProcessTime(true))
+		if err != nil {
+			t.Fatalf("setMetadata: %v", err)
+		}
+		outs = append(outs, pdf)
+	}
+	if !bytes.Equal(outs[0], outs[1]) {
+		t.Error("outputs with metadata differ")
+	}
+	if bytes.Contains(outs[0], []byte("Date")) {
+		t.Errorf("output records a date:\n%s", outs[0])
+	}
+}
+
+func TestMakeReproducible_NoTrailerDict(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Hello) Tj ET")})
+	pdf = regexp.MustCompile(`(?s)trailer\s*<<.*?>>\s*startxref`).ReplaceAll(pdf, []byte("trailer\nnull\nstartxref"))
+	if doc, err := Load(pdf); err != nil || doc.trailer != nil {
+		t.Fatalf("Load = %v, want a document without a trailer", err)
+	}
+	out, err := makeReproducible(pdf)
+	if err != nil {
+		t.Fatalf("makeReproducible: %v", err)
+	}
+	if !bytes.Contains(out, []byte("/ID [")) {
+		t.Errorf("output has no file identifier:\n%s", out)
+	}
+}
+
+func TestPostProcessTime(t *testing.T) {
+	if !postProcessTime(true).IsZero() {
+		t.Error("postProcessTime(true) is not the zero time")
+	}
+	if time.Since(postProcessTime(false)) > time.Minute {
+		t.Error("postProcessTime(false) is not now")
+	}
+}
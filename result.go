@@ -3,8 +3,12 @@ package htmlpdf
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 // Result holds a generated PDF and provides helpers for common output
@@ -13,7 +17,12 @@ import (
 // A Result is returned by every conversion method. It is safe to call
 // its methods multiple times — the underlying data is never modified.
 type Result struct {
-	data []byte
+	data       []byte
+	streamed   int // bytes written to the caller's writer, for ConvertHTMLTo
+	failed     []RequestFailure
+	console    []ConsoleMessage
+	pageErrors []PageError
+	network    []NetworkEntry
 }
 
 // Bytes returns the raw PDF content.
@@ -46,7 +55,233 @@ func (r *Result) WriteToFile(path string, perm os.FileMode) error {
 	return os.WriteFile(path, r.data, perm)
 }
 
-// Len returns the size of the PDF in bytes.
+// WriteToFileAtomic writes the PDF to the file at path so that readers
+// see either the previous file or the complete PDF, never part of it,
+// even if the process crashes while writing. The PDF is written to a
+// temporary file in the same directory, synced to disk and renamed over
+// path. The temporary file is removed if writing fails. It fails, leaving
+// path alone, for a Result from [Converter.ConvertHTMLTo], which holds no
+// data.
+func (r *Result) WriteToFileAtomic(path string, perm os.FileMode) (err error) {
+	if r.data == nil {
+		return errors.New("htmlpdf: result holds no PDF data")
+	}
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(r.data); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	// Sync the directory so the rename itself survives a crash. Not every
+	// platform can open a directory for this, so failing to is ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// Len returns the size of the PDF in bytes. For a Result from
+// [Converter.ConvertHTMLTo], which holds no data, it is the number of
+// bytes written.
 func (r *Result) Len() int {
+	if r.data == nil {
+		return r.streamed
+	}
 	return len(r.data)
 }
+
+// Metadata parses the PDF and returns its document information, page
+// sizes and encryption status, for assertions on the output or audit
+// logs. It fails for a Result from [Converter.ConvertHTMLTo], which holds
+// no data.
+func (r *Result) Metadata() (*DocumentInfo, error) {
+	if r.data == nil {
+		return nil, errors.New("htmlpdf: result holds no PDF data")
+	}
+	info, err := readDocumentInfo(r.data)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: reading PDF: %w", err)
+	}
+	return info, nil
+}
+
+// Document parses the PDF with the package's reader, for inspecting or
+// post-processing the output without writing it to a file. It fails for
+// a Result from [Converter.ConvertHTMLTo], which holds no data.
+func (r *Result) Document() (*Document, error) {
+	if r.data == nil {
+		return nil, errors.New("htmlpdf: result holds no PDF data")
+	}
+	doc, err := Load(r.data)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: reading PDF: %w", err)
+	}
+	return doc, nil
+}
+
+// ExtractText returns the text of each page of the PDF, as
+// [Extractor.ExtractAll] does, e.g. to assert on the rendered content in
+// tests.
+func (r *Result) ExtractText() ([]string, error) {
+	doc, err := r.Document()
+	if err != nil {
+		return nil, err
+	}
+	pages, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: extracting text: %w", err)
+	}
+	return pages, nil
+}
+
+// FailedRequests returns the requests made by the page that failed with a
+// network error or an HTTP error status (4xx/5xx), such as missing images
+// or fonts. It returns nil if every request succeeded.
+func (r *Result) FailedRequests() []RequestFailure {
+	return r.failed
+}
+
+// ConsoleLogs returns the console messages logged by the page while it
+// was being converted, in order. It returns nil if there were none.
+func (r *Result) ConsoleLogs() []ConsoleMessage {
+	return r.console
+}
+
+// PageErrors returns the uncaught JavaScript exceptions thrown by the page
+// while it was being converted, in order. It returns nil if there were
+// none.
+func (r *Result) PageErrors() []PageError {
+	return r.pageErrors
+}
+
+// NetworkLog returns the requests made by the page while it was being
+// converted and their responses, in the order they were sent. It is only
+// recorded when [ConvertOptions].RecordNetwork is set, and nil otherwise.
+func (r *Result) NetworkLog() []NetworkEntry {
+	return r.network
+}
+
+// HAR returns the [Result.NetworkLog] as an HTTP Archive (HAR 1.2)
+// document, which browser developer tools and HAR viewers can open.
+// Response bodies are not included.
+func (r *Result) HAR() ([]byte, error) {
+	data, err := marshalHAR(r.network)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: encoding HAR: %w", err)
+	}
+	return data, nil
+}
+
+// Encrypt returns a copy of the result with the PDF encrypted using
+// AES-256 (PDF 2.0 standard security handler). Readers must enter
+// userPassword to open it, or nothing if it is empty, and may then only
+// perform the operations in perms. ownerPassword grants full access; if
+// empty, a random one is used so the restrictions cannot be lifted.
+//
+// The document is rewritten in full, so no unencrypted copy of its
+// content remains in the file. It fails for a Result from
+// [Converter.ConvertHTMLTo], which holds no data.
+func (r *Result) Encrypt(ownerPassword, userPassword string, perms Permissions) (*Result, error) {
+	if r.data == nil {
+		return nil, errors.New("htmlpdf: result holds no PDF data")
+	}
+	data, err := encryptPDF(r.data, ownerPassword, userPassword, perms)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: encrypting PDF: %w", err)
+	}
+	cp := *r
+	cp.data = data
+	return &cp, nil
+}
+
+// Attach returns a copy of the result with data embedded in the PDF as a
+// file attachment named name, such as the source HTML or the invoice XML
+// of a ZUGFeRD or Factur-X workflow. Viewers list it in their attachments
+// panel. mime, e.g. "text/xml", is recorded as the file's type if not
+// empty. An existing attachment with the same name is replaced.
+//
+// Attachments are added as an incremental update, which undoes
+// linearization (see [PageConfig].Linearize); PDF/A-2 (see
+// [PageConfig].PDFA) allows only PDF/A files to be attached.
+func (r *Result) Attach(name string, data []byte, mime string) (*Result, error) {
+	if r.data == nil {
+		return nil, errors.New("htmlpdf: result holds no PDF data")
+	}
+	pdf, err := attachFile(r.data, name, data, mime, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: attaching %q: %w", name, err)
+	}
+	cp := *r
+	cp.data = pdf
+	return &cp, nil
+}
+
+// Watermark returns a copy of the result with w, such as "DRAFT" text or
+// a logo, stamped over the selected pages (see [Watermark]).
+//
+// The stamp is added as an incremental update, which undoes
+// linearization (see [PageConfig].Linearize).
+func (r *Result) Watermark(w Watermark) (*Result, error) {
+	if r.data == nil {
+		return nil, errors.New("htmlpdf: result holds no PDF data")
+	}
+	doc, err := Load(r.data)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: reading PDF: %w", err)
+	}
+	pdf, err := watermarkPDF(doc, w)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: watermarking PDF: %w", err)
+	}
+	cp := *r
+	cp.data = pdf
+	return &cp, nil
+}
+
+// NumberPages returns a copy of the result with page numbers, such as
+// "Page 3 of 7", drawn over the selected pages (see [PageNumbers]). Use it
+// on a document combined from several conversions, where Chrome's footer
+// templates number each part on its own.
+//
+// The page numbers are added as an incremental update, which undoes
+// linearization (see [PageConfig].Linearize).
+func (r *Result) NumberPages(n PageNumbers) (*Result, error) {
+	if r.data == nil {
+		return nil, errors.New("htmlpdf: result holds no PDF data")
+	}
+	doc, err := Load(r.data)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: reading PDF: %w", err)
+	}
+	pdf, err := numberPages(doc, n)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: numbering pages: %w", err)
+	}
+	cp := *r
+	cp.data = pdf
+	return &cp, nil
+}
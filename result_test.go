@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -76,6 +78,67 @@ func TestResult_WriteToFile(t *testing.T) {
 	}
 }
 
+func TestResult_WriteToFileAtomic(t *testing.T) {
+	r := newResult()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.pdf")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteToFileAtomic(path, 0o644); err != nil {
+		t.Fatalf("WriteToFileAtomic: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading written file: %v", err)
+	}
+	if !bytes.Equal(data, samplePDF) {
+		t.Error("WriteToFileAtomic produced different content")
+	}
+	if fi, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && fi.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %v, want 0644", fi.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the PDF", len(entries))
+	}
+}
+
+func TestResult_WriteToFileAtomic_Fails(t *testing.T) {
+	r := newResult()
+	dir := t.TempDir()
+	// Renaming a file over a directory fails after the temporary file
+	// has been written.
+	path := filepath.Join(dir, "out.pdf")
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "keep"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteToFileAtomic(path, 0o644); err == nil {
+		t.Fatal("WriteToFileAtomic over a directory succeeded")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want the temporary file removed", len(entries))
+	}
+}
+
+func TestResult_WriteToFileAtomic_NoData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.pdf")
+	if err := os.WriteFile(path, samplePDF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := (&Result{streamed: 10}).WriteToFileAtomic(path, 0o644)
+	if err == nil || !strings.Contains(err.Error(), "no PDF data") {
+		t.Errorf("WriteToFileAtomic of a streamed result = %v, want the no-data error", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, samplePDF) {
+		t.Error("WriteToFileAtomic replaced the previous file")
+	}
+}
+
 func TestResult_Len(t *testing.T) {
 	r := newResult()
 	if r.Len() != len(samplePDF) {
@@ -91,3 +154,96 @@ func TestResult_ReaderMultipleCalls(t *testing.T) {
 		t.Error("multiple Reader() calls return different lengths")
 	}
 }
+
+func TestResult_Metadata(t *testing.T) {
+	r := &Result{data: buildTestPDF([][]byte{[]byte("BT ET")})}
+	info, err := r.Metadata()
+	if err != nil {
+		t.Fatalf("Metadata: %v", err)
+	}
+	if len(info.Pages) != 1 {
+		t.Errorf("got %d pages, want 1", len(info.Pages))
+	}
+	if _, err := newResult().Metadata(); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+	if _, err := (&Result{streamed: 100}).Metadata(); err == nil {
+		t.Error("expected error for a streamed result")
+	}
+}
+
+func TestResult_Document(t *testing.T) {
+	r := &Result{data: buildTestPDF([][]byte{[]byte("BT ET"), []byte("BT ET")})}
+	doc, err := r.Document()
+	if err != nil {
+		t.Fatalf("Document: %v", err)
+	}
+	if pages, _ := doc.Pages(); len(pages) != 2 {
+		t.Errorf("got %d pages, want 2", len(pages))
+	}
+	if _, err := newResult().Document(); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+	if _, err := (&Result{streamed: 100}).Document(); err == nil {
+		t.Error("expected error for a streamed result")
+	}
+}
+
+func TestResult_ExtractText(t *testing.T) {
+	r := &Result{data: buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 100 700 Td (Invoice #123) Tj ET"),
+		[]byte("BT /F1 12 Tf 100 700 Td (Total due) Tj ET"),
+	})}
+	pages, err := r.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if len(pages) != 2 || !strings.Contains(pages[0], "Invoice #123") || !strings.Contains(pages[1], "Total due") {
+		t.Errorf("ExtractText() = %q", pages)
+	}
+	if _, err := (&Result{streamed: 100}).ExtractText(); err == nil {
+		t.Error("expected error for a streamed result")
+	}
+}
+
+func TestResult_Attach(t *testing.T) {
+	orig := &Result{data: buildTestPDF([][]byte{[]byte("BT ET")})}
+	res, err := orig.Attach("source.html", []byte("<p>Hi</p>"), "text/html")
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if len(res.Bytes()) <= len(orig.Bytes()) || !bytes.HasPrefix(res.Bytes(), orig.Bytes()) {
+		t.Error("Attach did not append to the PDF")
+	}
+	if _, err := (&Result{streamed: 10}).Attach("a.txt", nil, ""); err == nil {
+		t.Error("expected error for a streamed result")
+	}
+}
+
+func TestResult_Watermark(t *testing.T) {
+	orig := &Result{data: buildTestPDF([][]byte{[]byte("BT ET")})}
+	res, err := orig.Watermark(Watermark{Text: "DRAFT", Rotation: 45})
+	if err != nil {
+		t.Fatalf("Watermark: %v", err)
+	}
+	if len(res.Bytes()) <= len(orig.Bytes()) || !bytes.HasPrefix(res.Bytes(), orig.Bytes()) {
+		t.Error("Watermark did not append to the PDF")
+	}
+	if _, err := orig.Watermark(Watermark{}); err == nil {
+		t.Error("expected error for an empty watermark")
+	}
+}
+
+func TestResult_NumberPages(t *testing.T) {
+	orig := &Result{data: buildTestPDF([][]byte{[]byte("BT ET")})}
+	res, err := orig.NumberPages(PageNumbers{})
+	if err != nil {
+		t.Fatalf("NumberPages: %v", err)
+	}
+	if len(res.Bytes()) <= len(orig.Bytes()) || !bytes.HasPrefix(res.Bytes(), orig.Bytes()) {
+		t.Error("NumberPages did not append to the PDF")
+	}
+	if _, err := (&Result{streamed: 10}).NumberPages(PageNumbers{}); err == nil {
+		t.Error("expected error for a streamed result")
+	}
+}
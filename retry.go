@@ -0,0 +1,111 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transientError marks a conversion failure that a retry may not repeat.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// errPageCrashed is reported when the renderer of a conversion tab
+// crashes, e.g. from running out of memory.
+var errPageCrashed = errors.New("page crashed")
+
+// errBrowserExited is reported when the browser exits or loses its
+// connection during a conversion. The next attempt relaunches it.
+var errBrowserExited = errors.New("browser exited")
+
+// targetClosedMessages are DevTools and chromedp error messages for a tab
+// or its connection going away mid-conversion.
+var targetClosedMessages = []string{
+	"target closed",
+	"target crashed",
+	"inspected target navigated or closed",
+	"session with given id not found",
+	"no target with given id found",
+}
+
+// isTargetClosed reports whether err says the tab went away.
+func isTargetClosed(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, m := range targetClosedMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// convert runs a conversion once a tab is free under [WithMaxConcurrent],
+// retrying transient failures as configured with [WithRetry], reporting
+// it to the configured [Metrics] and tracing it as an htmlpdf.Convert
+// span.
+func (c *Converter) convert(ctx context.Context, src source, pg *PageConfig, opts *ConvertOptions) (*Result, error) {
+	ctx, span := c.cfg.tracer.Start(ctx, spanConvert, convertAttributes(src))
+	if err := c.checkClosed(); err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	if err := c.checkURL(ctx, src); err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	defer c.enterBusy()()
+	release, err := c.acquireTab(ctx)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	defer release()
+	res, err := c.observe(func() (*Result, error) {
+		attempt := 0
+		return retry(ctx, c.cfg.logger, c.cfg.retries, c.cfg.retryBackoff, func() (*Result, error) {
+			if attempt++; attempt > 1 {
+				span.AddEvent("retry", trace.WithAttributes(attribute.Int("htmlpdf.attempt", attempt)))
+			}
+			return c.convertOnce(ctx, src, pg, opts)
+		})
+	})
+	if err == nil {
+		span.SetAttributes(attribute.Int("htmlpdf.pdf.bytes", res.Len()))
+	}
+	endSpan(span, err)
+	return res, err
+}
+
+// retry calls fn until it succeeds, fails with an error that is not a
+// *transientError, or has been retried retries times. The wait between
+// attempts starts at backoff and doubles. Retrying stops when ctx is
+// done, returning the last error. Each retry is logged to log.
+func retry(ctx context.Context, log *slog.Logger, retries int, backoff time.Duration, fn func() (*Result, error)) (*Result, error) {
+	for i := 0; ; i++ {
+		res, err := fn()
+		var transient *transientError
+		if err == nil || i >= retries || !errors.As(err, &transient) || ctx.Err() != nil {
+			return res, err
+		}
+		log.Info("htmlpdf: retrying conversion", "attempt", i+2, "error", err, "backoff", backoff)
+		if backoff > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return nil, err
+			case <-t.C:
+			}
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	transient := fmt.Errorf("htmlpdf: conversion failed: %w", &transientError{errPageCrashed})
+	permanent := errors.New("htmlpdf: conversion failed: boom")
+
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error // returned by successive attempts; then success
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", 2, nil, 1, nil},
+		{"recovers", 2, []error{transient, transient}, 3, nil},
+		{"exhausted", 1, []error{transient, transient, transient}, 2, transient},
+		{"permanent", 2, []error{permanent}, 1, permanent},
+		{"disabled", 0, []error{transient}, 1, transient},
+	}
+	for _, tt := range tests {
+		calls := 0
+		res, err := retry(context.Background(), slog.New(slog.DiscardHandler), tt.retries, time.Millisecond, func() (*Result, error) {
+			calls++
+			if calls <= len(tt.errs) {
+				return nil, tt.errs[calls-1]
+			}
+			return &Result{}, nil
+		})
+		if calls != tt.wantCalls {
+			t.Errorf("%s: %d attempts, want %d", tt.name, calls, tt.wantCalls)
+		}
+		if err != tt.wantErr {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if (err == nil) != (res != nil) {
+			t.Errorf("%s: result = %v with error %v", tt.name, res, err)
+		}
+	}
+}
+
+func TestRetry_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, err := retry(ctx, slog.New(slog.DiscardHandler), 5, time.Hour, func() (*Result, error) {
+		calls++
+		cancel()
+		return nil, &transientError{errPageCrashed}
+	})
+	if calls != 1 || !errors.Is(err, errPageCrashed) {
+		t.Errorf("%d attempts, error %v; want 1 attempt failing with errPageCrashed", calls, err)
+	}
+}
+
+func TestRetry_LogsRetries(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+	calls := 0
+	_, err := retry(context.Background(), log, 2, 0, func() (*Result, error) {
+		if calls++; calls == 1 {
+			return nil, &transientError{errBrowserExited}
+		}
+		return &Result{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "retrying conversion") || !strings.Contains(out, "attempt=2") || !strings.Contains(out, "browser exited") {
+		t.Errorf("log = %q, want a retry of attempt 2 with the error", out)
+	}
+}
+
+func TestIsTargetClosed(t *testing.T) {
+	for _, msg := range []string{
+		"Target closed",
+		"Session with given id not found.",
+		"Inspected target navigated or closed",
+	} {
+		if !isTargetClosed(errors.New(msg)) {
+			t.Errorf("isTargetClosed(%q) = false", msg)
+		}
+	}
+	if isTargetClosed(errors.New("net::ERR_NAME_NOT_RESOLVED")) {
+		t.Error("navigation error treated as target closed")
+	}
+}
@@ -0,0 +1,100 @@
+package htmlpdf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ConvertRoutes converts several routes of a single-page app into one PDF,
+// such as every view of a dashboard section. Each route is resolved
+// against baseURL, e.g. "/reports/sales" or "#/reports/sales", and must be
+// on the same origin.
+//
+// The first route is loaded in a tab as [Converter.ConvertURL] would. The
+// others are then shown in the same tab by client-side navigation, so the
+// app starts only once: a route that differs only in its fragment is set
+// as location.hash, any other is pushed onto the history followed by a
+// popstate event, which the routers of common frameworks listen for.
+// After each route change the waits, Scripts and Selector of the
+// [ConvertOptions] are applied again before the route is printed, and
+// [ConvertOptions].WaitForReadySignal expects window.__htmlpdfReady() to
+// be called once more.
+//
+// The routes' pages are concatenated in order, with a bookmark for each
+// route given a title in [ConvertOptions].RouteTitles. Heading bookmarks
+// are not supported; the other settings of pg apply to the combined
+// document.
+func (c *Converter) ConvertRoutes(ctx context.Context, baseURL string, routes []string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	urls, err := resolveRoutes(baseURL, routes)
+	if err != nil {
+		return nil, err
+	}
+	o := firstConvertOptions(opts)
+	if o != nil && len(o.RouteTitles) > len(routes) {
+		return nil, fmt.Errorf("htmlpdf: %d route titles for %d routes", len(o.RouteTitles), len(routes))
+	}
+	return c.convert(ctx, source{url: urls[0], routes: urls}, pg, o)
+}
+
+// resolveRoutes returns routes resolved against baseURL, checking that
+// they stay on its origin.
+func resolveRoutes(baseURL string, routes []string) ([]string, error) {
+	if len(routes) == 0 {
+		return nil, errors.New("htmlpdf: no routes to convert")
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() || base.Host == "" {
+		return nil, fmt.Errorf("htmlpdf: invalid URL %q", baseURL)
+	}
+	urls := make([]string, len(routes))
+	for i, route := range routes {
+		ref, err := url.Parse(route)
+		if err != nil {
+			return nil, fmt.Errorf("htmlpdf: invalid route %q: %w", route, err)
+		}
+		u := base.ResolveReference(ref)
+		if u.Scheme != base.Scheme || u.Host != base.Host {
+			return nil, fmt.Errorf("htmlpdf: route %q is not on the origin of %q", route, baseURL)
+		}
+		urls[i] = u.String()
+	}
+	return urls, nil
+}
+
+// showRouteScript switches a single-page app to the route at the absolute
+// URL it is called with.
+const showRouteScript = `(url => {
+	const next = new URL(url), here = new URL(location.href);
+	if (next.pathname === here.pathname && next.search === here.search) {
+		location.hash = next.hash;
+	} else {
+		history.pushState(null, "", url);
+		dispatchEvent(new PopStateEvent("popstate"));
+	}
+})`
+
+// showRoute returns an action that switches the page to route. With
+// rearm, window.__htmlpdfReady is defined afresh first, so the wait for
+// the ready signal applies to the new route.
+func showRoute(route string, rearm bool) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if rearm {
+			if err := chromedp.Evaluate(readySignalScript, nil).Do(ctx); err != nil {
+				return err
+			}
+		}
+		lit, _ := json.Marshal(route)
+		if err := chromedp.Evaluate(showRouteScript+"("+string(lit)+")", nil).Do(ctx); err != nil {
+			return fmt.Errorf("showing route %s: %w", route, err)
+		}
+		return nil
+	})
+}
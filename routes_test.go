@@ -0,0 +1,58 @@
+package htmlpdf
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestResolveRoutes(t *testing.T) {
+	got, err := resolveRoutes("https://app.example.com/dashboard/", []string{
+		"", "sales", "/reports/q3?year=2024", "#/settings", "https://app.example.com/help",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"https://app.example.com/dashboard/",
+		"https://app.example.com/dashboard/sales",
+		"https://app.example.com/reports/q3?year=2024",
+		"https://app.example.com/dashboard/#/settings",
+		"https://app.example.com/help",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("resolveRoutes = %q, want %q", got, want)
+	}
+}
+
+func TestResolveRoutes_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, base string
+		routes     []string
+	}{
+		{"no routes", "https://app.example.com/", nil},
+		{"relative base", "/dashboard", []string{"sales"}},
+		{"other host", "https://app.example.com/", []string{"https://evil.example.com/"}},
+		{"other scheme", "https://app.example.com/", []string{"http://app.example.com/"}},
+		{"bad route", "https://app.example.com/", []string{"%zz"}},
+	} {
+		if _, err := resolveRoutes(tc.base, tc.routes); err == nil {
+			t.Errorf("%s: resolveRoutes succeeded", tc.name)
+		}
+	}
+}
+
+func TestConvertRoutes_Closed(t *testing.T) {
+	c := &Converter{cfg: defaultConfig(), closed: true}
+	if _, err := c.ConvertRoutes(context.Background(), "https://example.com/", []string{"/"}, nil); err != ErrClosed {
+		t.Errorf("err = %v, want ErrClosed", err)
+	}
+}
+
+func TestConvertRoutes_TooManyTitles(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	opts := &ConvertOptions{RouteTitles: []string{"Sales", "Costs"}}
+	if _, err := c.ConvertRoutes(context.Background(), "https://example.com/", []string{"/sales"}, nil, opts); err == nil {
+		t.Error("ConvertRoutes succeeded with more titles than routes")
+	}
+}
@@ -0,0 +1,67 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"io/fs"
+	"log/slog"
+	"os"
+	"runtime"
+)
+
+// sandboxProbe is what sandboxBlocker inspects, so tests can stub it.
+type sandboxProbe struct {
+	goos string
+	euid int
+	fsys fs.FS // the root filesystem
+}
+
+// hostSandboxProbe describes the current process and host.
+func hostSandboxProbe() sandboxProbe {
+	return sandboxProbe{goos: runtime.GOOS, euid: os.Geteuid(), fsys: os.DirFS("/")}
+}
+
+// containerCgroups are cgroup path fragments that identify a container.
+var containerCgroups = [][]byte{[]byte("docker"), []byte("kubepods"), []byte("containerd"), []byte("libpod"), []byte("lxc")}
+
+// sandboxBlocker returns why Chrome's sandbox cannot run in the
+// environment p describes, or "" if it can. On Linux the sandbox needs
+// unprivileged user namespaces, which are unavailable to root, when
+// disabled by sysctl, and under the default seccomp profiles of
+// container runtimes.
+func (p sandboxProbe) sandboxBlocker() string {
+	if p.goos != "linux" {
+		return ""
+	}
+	if p.euid == 0 {
+		return "running as root"
+	}
+	for _, name := range []string{"proc/sys/kernel/unprivileged_userns_clone", "proc/sys/user/max_user_namespaces"} {
+		if b, err := fs.ReadFile(p.fsys, name); err == nil && string(bytes.TrimSpace(b)) == "0" {
+			return "unprivileged user namespaces are disabled"
+		}
+	}
+	for _, name := range []string{".dockerenv", "run/.containerenv"} {
+		if _, err := fs.Stat(p.fsys, name); err == nil {
+			return "running in a container"
+		}
+	}
+	if b, err := fs.ReadFile(p.fsys, "proc/1/cgroup"); err == nil {
+		for _, c := range containerCgroups {
+			if bytes.Contains(b, c) {
+				return "running in a container"
+			}
+		}
+	}
+	return ""
+}
+
+// autoSandbox reports whether the sandbox must be disabled on this host
+// for [WithAutoSandbox], logging the decision.
+func autoSandbox(p sandboxProbe, log *slog.Logger) (noSandbox bool) {
+	if reason := p.sandboxBlocker(); reason != "" {
+		log.Info("htmlpdf: disabling Chrome sandbox", "reason", reason)
+		return true
+	}
+	log.Info("htmlpdf: Chrome sandbox enabled")
+	return false
+}
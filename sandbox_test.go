@@ -0,0 +1,66 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSandboxBlocker(t *testing.T) {
+	file := func(data string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(data)} }
+	tests := []struct {
+		name  string
+		probe sandboxProbe
+		want  string
+	}{
+		{"macOS root", sandboxProbe{goos: "darwin", euid: 0, fsys: fstest.MapFS{}}, ""},
+		{"root", sandboxProbe{goos: "linux", euid: 0, fsys: fstest.MapFS{}}, "running as root"},
+		{"user", sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{
+			"proc/sys/kernel/unprivileged_userns_clone": file("1\n"),
+			"proc/sys/user/max_user_namespaces":         file("63569\n"),
+			"proc/1/cgroup":                             file("0::/init.scope\n"),
+		}}, ""},
+		{"userns_clone off", sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{
+			"proc/sys/kernel/unprivileged_userns_clone": file("0\n"),
+		}}, "unprivileged user namespaces are disabled"},
+		{"no user namespaces", sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{
+			"proc/sys/user/max_user_namespaces": file("0\n"),
+		}}, "unprivileged user namespaces are disabled"},
+		{"docker", sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{
+			".dockerenv": file(""),
+		}}, "running in a container"},
+		{"podman", sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{
+			"run/.containerenv": file(""),
+		}}, "running in a container"},
+		{"kubernetes", sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{
+			"proc/1/cgroup": file("0::/kubepods/besteffort/pod1234\n"),
+		}}, "running in a container"},
+	}
+	for _, tt := range tests {
+		if got := tt.probe.sandboxBlocker(); got != tt.want {
+			t.Errorf("%s: sandboxBlocker = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAutoSandbox_Logs(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+
+	if !autoSandbox(sandboxProbe{goos: "linux", euid: 0, fsys: fstest.MapFS{}}, log) {
+		t.Error("autoSandbox = false for root, want true")
+	}
+	if !strings.Contains(buf.String(), `reason="running as root"`) {
+		t.Errorf("log %q does not give the reason", buf.String())
+	}
+
+	buf.Reset()
+	if autoSandbox(sandboxProbe{goos: "linux", euid: 1000, fsys: fstest.MapFS{}}, log) {
+		t.Error("autoSandbox = true for an unconfined user, want false")
+	}
+	if !strings.Contains(buf.String(), "sandbox enabled") {
+		t.Errorf("log %q does not record the decision", buf.String())
+	}
+}
@@ -0,0 +1,121 @@
+package htmlpdf
+
+import (
+	"context"
+	"math"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ImageFormat is the encoding of a screenshot.
+type ImageFormat int
+
+const (
+	// PNG is lossless. This is the default.
+	PNG ImageFormat = iota
+	// JPEG is lossy and smaller, with its quality set by
+	// [ScreenshotOptions.Quality].
+	JPEG
+)
+
+// ScreenshotOptions controls [Converter.Screenshot]. A nil
+// ScreenshotOptions uses the defaults of each field.
+type ScreenshotOptions struct {
+	// Format is the image encoding. Defaults to PNG.
+	Format ImageFormat
+
+	// Quality is the JPEG quality, from 1 to 100. Defaults to 90. It is
+	// ignored for PNG.
+	Quality int
+
+	// Width and Height are the viewport size in CSS pixels. Default to
+	// 1280×800.
+	Width, Height int
+
+	// Scale is the device scale factor: 2 renders at twice the
+	// resolution, as on a high-density display. Defaults to 1.
+	Scale float64
+
+	// FullPage captures the whole scrollable page instead of only the
+	// viewport.
+	FullPage bool
+}
+
+// resolved returns o with defaults applied.
+func (o *ScreenshotOptions) resolved() ScreenshotOptions {
+	var r ScreenshotOptions
+	if o != nil {
+		r = *o
+	}
+	if r.Quality <= 0 || r.Quality > 100 {
+		r.Quality = 90
+	}
+	if r.Width <= 0 {
+		r.Width = 1280
+	}
+	if r.Height <= 0 {
+		r.Height = 800
+	}
+	if r.Scale <= 0 {
+		r.Scale = 1
+	}
+	return r
+}
+
+// Screenshot renders the input as [Converter.ConvertMany] would and
+// returns a PNG or JPEG image of the page instead of a PDF, for
+// thumbnails and previews. The page is rendered with screen styles unless
+// in.Page sets EmulateMedia; other page settings do not apply. Wait
+// conditions and other request-scoped behaviour come from in.Options.
+func (c *Converter) Screenshot(ctx context.Context, in ConvertInput, opts *ScreenshotOptions) ([]byte, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	src, err := in.source()
+	if err != nil {
+		return nil, err
+	}
+	shot := opts.resolved()
+	src.shot = &shot
+
+	pg := &PageConfig{EmulateMedia: MediaScreen}
+	if in.Page != nil {
+		pg.EmulateMedia = in.Page.EmulateMedia
+	}
+	res, err := c.convert(ctx, src, pg, in.Options)
+	if err != nil {
+		return nil, err
+	}
+	return res.data, nil
+}
+
+// setViewport sizes the tab's viewport for a screenshot.
+func setViewport(o *ScreenshotOptions) chromedp.Action {
+	return emulation.SetDeviceMetricsOverride(int64(o.Width), int64(o.Height), o.Scale, false)
+}
+
+// captureScreenshot captures the page as configured by o into buf.
+func captureScreenshot(o *ScreenshotOptions, buf *[]byte) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		params := page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormatPng)
+		if o.Format == JPEG {
+			params = params.WithFormat(page.CaptureScreenshotFormatJpeg).WithQuality(int64(o.Quality))
+		}
+		if o.FullPage {
+			_, _, _, _, _, content, err := page.GetLayoutMetrics().Do(ctx)
+			if err != nil {
+				return err
+			}
+			params = params.WithCaptureBeyondViewport(true).WithClip(&page.Viewport{
+				Width:  math.Ceil(content.Width),
+				Height: math.Ceil(content.Height),
+				Scale:  1,
+			})
+		}
+		var err error
+		*buf, err = params.Do(ctx)
+		return err
+	})
+}
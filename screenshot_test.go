@@ -0,0 +1,30 @@
+package htmlpdf
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScreenshotOptionsResolved(t *testing.T) {
+	got := (*ScreenshotOptions)(nil).resolved()
+	want := ScreenshotOptions{Format: PNG, Quality: 90, Width: 1280, Height: 800, Scale: 1}
+	if got != want {
+		t.Errorf("nil resolved = %+v, want %+v", got, want)
+	}
+
+	o := &ScreenshotOptions{Format: JPEG, Quality: 50, Width: 320, Height: 240, Scale: 2, FullPage: true}
+	if got := o.resolved(); got != *o {
+		t.Errorf("resolved = %+v, want it unchanged", got)
+	}
+
+	if got := (&ScreenshotOptions{Quality: 101}).resolved().Quality; got != 90 {
+		t.Errorf("out-of-range quality resolved to %d, want 90", got)
+	}
+}
+
+func TestScreenshot_InvalidInput(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	if _, err := c.Screenshot(context.Background(), ConvertInput{}, nil); err != errInvalidInput {
+		t.Errorf("err = %v, want errInvalidInput", err)
+	}
+}
@@ -0,0 +1,312 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// Permissions are the operations a reader who opened an encrypted PDF
+// with the user password may perform. The owner password grants all of
+// them. Viewers enforce permissions voluntarily; they are not a
+// substitute for the user password.
+type Permissions uint32
+
+// Permission bits (ISO 32000-2, Table 22).
+const (
+	PermitPrint            Permissions = 1 << 2  // print, possibly at low resolution
+	PermitModify           Permissions = 1 << 3  // modify the contents
+	PermitCopy             Permissions = 1 << 4  // copy or extract text and graphics
+	PermitAnnotate         Permissions = 1 << 5  // add or modify annotations and fill forms
+	PermitFillForms        Permissions = 1 << 8  // fill in existing form fields
+	PermitAccessibility    Permissions = 1 << 9  // extract text for accessibility
+	PermitAssemble         Permissions = 1 << 10 // insert, rotate or delete pages
+	PermitPrintHighQuality Permissions = 1 << 11 // print at full resolution
+
+	PermitAll = PermitPrint | PermitModify | PermitCopy | PermitAnnotate |
+		PermitFillForms | PermitAccessibility | PermitAssemble | PermitPrintHighQuality
+)
+
+// encryptPDF returns pdf rewritten with every string and stream
+// encrypted by the standard security handler, revision 6 (AES-256,
+// ISO 32000-2, 7.6.4). An empty owner password is replaced with a random
+// one, so that the permissions cannot be lifted.
+func encryptPDF(pdf []byte, ownerPassword, userPassword string, perms Permissions) ([]byte, error) {
+	doc, err := Load(pdf)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("document is already encrypted")
+	}
+	if ownerPassword == "" {
+		ownerPassword = string(randomBytes(32))
+	}
+	sec := newSecurityHandler([]byte(ownerPassword), []byte(userPassword), perms)
+
+	ids := make([]int, 0, len(doc.xref))
+	for id, e := range doc.xref {
+		if e.InUse && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make(map[int]int64, len(ids))
+	gens := make(map[int]int, len(ids))
+	size := 1
+	for _, id := range ids {
+		obj, err := doc.ResolveRef(Reference{Number: id})
+		if err != nil {
+			return nil, err
+		}
+		if obj.Type == ObjStream {
+			// Object and xref streams are unpacked into plain objects
+			// and a classic xref table.
+			if t, _ := obj.Dict.GetName("Type"); t == "ObjStm" || t == "XRef" {
+				continue
+			}
+		}
+		if rootObj := doc.trailer["Root"]; rootObj != nil && rootObj.Ref.Number == id && obj.Type == ObjDict {
+			obj = withAESV3Extension(obj)
+		}
+		gen := doc.xref[id].Generation
+		if doc.xref[id].Compressed {
+			gen = 0
+		}
+		offsets[id], gens[id] = int64(buf.Len()), gen
+		fmt.Fprintf(&buf, "%d %d obj\n", id, gen)
+		writeObject(&buf, sec.encryptObject(obj))
+		buf.WriteString("\nendobj\n")
+		size = id + 1
+	}
+
+	encRef := size
+	offsets[encRef], gens[encRef] = int64(buf.Len()), 0
+	fmt.Fprintf(&buf, "%d 0 obj\n", encRef)
+	writeObject(&buf, sec.dict())
+	buf.WriteString("\nendobj\n")
+	size++
+
+	trailer := Dict{
+		"Size":    intObj(size),
+		"Root":    doc.trailer["Root"],
+		"Encrypt": refObj(Reference{Number: encRef}),
+	}
+	if info := doc.trailer["Info"]; info != nil {
+		trailer["Info"] = info
+	}
+	if id := doc.trailer["ID"]; id != nil {
+		trailer["ID"] = id
+	} else {
+		fileID := &Object{Type: ObjString, Str: randomBytes(16)}
+		trailer["ID"] = arrayObj(fileID, fileID)
+	}
+
+	xrefOffset := buf.Len()
+	buf.WriteString("xref\n")
+	fmt.Fprintf(&buf, "0 %d\n", size)
+	buf.WriteString("0000000000 65535 f \n")
+	for id := 1; id < size; id++ {
+		if off, ok := offsets[id]; ok {
+			fmt.Fprintf(&buf, "%010d %05d n \n", off, gens[id])
+		} else {
+			buf.WriteString("0000000000 00001 f \n")
+		}
+	}
+	buf.WriteString("trailer\n")
+	writeDict(&buf, trailer)
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes(), nil
+}
+
+// withAESV3Extension returns a copy of the catalog declaring the Adobe
+// extension level that introduced AES-256 encryption, for PDF 1.7
+// readers.
+func withAESV3Extension(cat *Object) *Object {
+	d := make(Dict, len(cat.Dict)+1)
+	for k, v := range cat.Dict {
+		d[k] = v
+	}
+	d["Extensions"] = dictObj(Dict{
+		"ADBE": dictObj(Dict{
+			"BaseVersion":    nameObj("1.7"),
+			"ExtensionLevel": intObj(8),
+		}),
+	})
+	return dictObj(d)
+}
+
+// securityHandler holds the file encryption key and the password
+// verification entries of the standard security handler, revision 6.
+type securityHandler struct {
+	key          []byte // 32-byte file encryption key
+	o, u, oe, ue []byte
+	perms        []byte
+	p            int32
+}
+
+func newSecurityHandler(owner, user []byte, perms Permissions) *securityHandler {
+	// Passwords are prepared with SASLprep and limited to 127 UTF-8
+	// bytes.
+	owner = truncatePassword([]byte(saslPrep(string(owner))))
+	user = truncatePassword([]byte(saslPrep(string(user))))
+	h := &securityHandler{key: randomBytes(32)}
+	// Bits 7, 8 and 13–32 must be set; bits 1–2 must be clear.
+	h.p = int32(uint32(perms&PermitAll) | 0xFFFFF0C0)
+
+	salts := randomBytes(16) // validation salt, key salt
+	h.u = append(hash2B(user, salts[:8], nil), salts...)
+	h.ue = aesCBCNoPad(hash2B(user, salts[8:], nil), h.key)
+
+	salts = randomBytes(16)
+	h.o = append(hash2B(owner, salts[:8], h.u), salts...)
+	h.oe = aesCBCNoPad(hash2B(owner, salts[8:], h.u), h.key)
+
+	perm := make([]byte, 16)
+	binary.LittleEndian.PutUint32(perm, uint32(h.p))
+	copy(perm[4:], "\xff\xff\xff\xffTadb")
+	copy(perm[12:], randomBytes(4))
+	block, _ := aes.NewCipher(h.key)
+	block.Encrypt(perm, perm) // a single block, so ECB
+	h.perms = perm
+	return h
+}
+
+// dict returns the encryption dictionary.
+func (h *securityHandler) dict() *Object {
+	str := func(b []byte) *Object { return &Object{Type: ObjString, Str: b} }
+	return dictObj(Dict{
+		"Filter": nameObj("Standard"),
+		"V":      intObj(5),
+		"R":      intObj(6),
+		"Length": intObj(256),
+		"CF": dictObj(Dict{
+			"StdCF": dictObj(Dict{
+				"CFM":       nameObj("AESV3"),
+				"AuthEvent": nameObj("DocOpen"),
+				"Length":    intObj(32),
+			}),
+		}),
+		"StmF":  nameObj("StdCF"),
+		"StrF":  nameObj("StdCF"),
+		"O":     str(h.o),
+		"U":     str(h.u),
+		"OE":    str(h.oe),
+		"UE":    str(h.ue),
+		"Perms": str(h.perms),
+		"P":     intObj(int(h.p)),
+	})
+}
+
+// encryptObject returns a copy of obj with its strings and stream data
+// encrypted. Objects resolved from a Document are shared with its cache
+// and must not be modified in place.
+func (h *securityHandler) encryptObject(obj *Object) *Object {
+	if obj == nil {
+		return nil
+	}
+	switch obj.Type {
+	case ObjString:
+		return &Object{Type: ObjString, Str: h.encrypt(obj.Str)}
+	case ObjArray:
+		items := make([]*Object, len(obj.Array))
+		for i, item := range obj.Array {
+			items[i] = h.encryptObject(item)
+		}
+		return arrayObj(items...)
+	case ObjDict, ObjStream:
+		d := make(Dict, len(obj.Dict))
+		for k, v := range obj.Dict {
+			d[k] = h.encryptObject(v)
+		}
+		if obj.Type == ObjDict {
+			return dictObj(d)
+		}
+		return &Object{Type: ObjStream, Dict: d, Stream: h.encrypt(obj.Stream)}
+	}
+	return obj
+}
+
+// encrypt encrypts data with AES-256 in CBC mode under a random IV,
+// which is prepended, with PKCS#7 padding (ISO 32000-2, 7.6.3.2).
+func (h *securityHandler) encrypt(data []byte) []byte {
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+pad)
+	copy(out, randomBytes(aes.BlockSize))
+	copy(out[aes.BlockSize:], data)
+	for i := len(out) - pad; i < len(out); i++ {
+		out[i] = byte(pad)
+	}
+	block, _ := aes.NewCipher(h.key)
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], out[aes.BlockSize:])
+	return out
+}
+
+// hash2B is the revision 6 password hash (ISO 32000-2, algorithm 2.B).
+// udata is the 48-byte /U entry when hashing the owner password, and nil
+// otherwise.
+func hash2B(password, salt, udata []byte) []byte {
+	sum := sha256.Sum256(append(append(append([]byte(nil), password...), salt...), udata...))
+	k := sum[:]
+	for round := 0; ; {
+		seq := append(append(append([]byte(nil), password...), k...), udata...)
+		k1 := bytes.Repeat(seq, 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		var mod int
+		for _, b := range e[:16] {
+			mod += int(b)
+		}
+		var hf hash.Hash
+		switch mod % 3 {
+		case 0:
+			hf = sha256.New()
+		case 1:
+			hf = sha512.New384()
+		default:
+			hf = sha512.New()
+		}
+		hf.Write(e)
+		k = hf.Sum(nil)
+
+		round++
+		if round >= 64 && int(e[len(e)-1]) <= round-32 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+// aesCBCNoPad encrypts data, a multiple of the block size, with AES-256
+// in CBC mode under a zero IV, as used for the /OE and /UE entries.
+func aesCBCNoPad(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func truncatePassword(p []byte) []byte {
+	if len(p) > 127 {
+		return p[:127]
+	}
+	return p
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,119 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestEncryptPDF(t *testing.T) {
+	content := []byte("BT /F1 12 Tf (Secret invoice) Tj ET")
+	doc := mustLoad(t, buildTestPDF([][]byte{content}))
+	u, _ := newPDFUpdate(doc)
+	u.trailer["Info"] = refObj(u.add(dictObj(Dict{"Title": textStringObj("Invoice 42")})))
+
+	out, err := encryptPDF(u.bytes(), "owner", "user", PermitPrint|PermitAccessibility)
+	if err != nil {
+		t.Fatalf("encryptPDF: %v", err)
+	}
+	for _, plain := range []string{"Secret invoice", "Invoice 42"} {
+		if bytes.Contains(out, []byte(plain)) {
+			t.Errorf("output contains plaintext %q", plain)
+		}
+	}
+
+	enc, err := LoadWithPassword(out, "user")
+	if err != nil {
+		t.Fatalf("LoadWithPassword: %v", err)
+	}
+	encDict := resolveDict(enc, enc.trailer["Encrypt"])
+	if encDict == nil {
+		t.Fatal("no /Encrypt dictionary in trailer")
+	}
+	if _, ok := enc.trailer.GetArray("ID"); !ok {
+		t.Error("no /ID in trailer")
+	}
+	uEntry, oEntry := encDict["U"].Str, encDict["O"].Str
+
+	// Algorithms 11 and 12: password validation.
+	if !bytes.Equal(hash2B([]byte("user"), uEntry[32:40], nil), uEntry[:32]) {
+		t.Error("user password does not validate")
+	}
+	if !bytes.Equal(hash2B([]byte("owner"), oEntry[32:40], uEntry), oEntry[:32]) {
+		t.Error("owner password does not validate")
+	}
+	if bytes.Equal(hash2B([]byte("wrong"), uEntry[32:40], nil), uEntry[:32]) {
+		t.Error("wrong password validates")
+	}
+
+	// Algorithm 2.A: recover the file key from /UE and check /Perms.
+	key := aesCBCDecrypt(t, hash2B([]byte("user"), uEntry[40:48], nil), make([]byte, 16), encDict["UE"].Str)
+	perms := make([]byte, 16)
+	block, _ := aes.NewCipher(key)
+	block.Decrypt(perms, encDict["Perms"].Str)
+	if string(perms[8:12]) != "Tadb" {
+		t.Errorf("/Perms does not decrypt with the file key: % x", perms)
+	}
+	p, _ := encDict.GetInt("P")
+	if got := binary.LittleEndian.Uint32(perms); got != uint32(p) {
+		t.Errorf("/Perms P = %#x, /P = %#x", got, uint32(p))
+	}
+	if Permissions(p)&PermitAll != PermitPrint|PermitAccessibility {
+		t.Errorf("/P grants %#x", Permissions(p)&PermitAll)
+	}
+
+	// Streams and strings decrypt to the original content.
+	pages, _ := enc.Pages()
+	cs, _ := enc.Resolve(pages[0]["Contents"])
+	if !bytes.Equal(cs.Stream, content) {
+		t.Errorf("content stream = %q, want %q", cs.Stream, content)
+	}
+	info := resolveDict(enc, enc.trailer["Info"])
+	if got := info["Title"].Str; string(got) != "Invoice 42" {
+		t.Errorf("Title = %q, want Invoice 42", got)
+	}
+}
+
+func TestEncryptPDF_RejectsEncrypted(t *testing.T) {
+	out, err := encryptPDF(buildTestPDF([][]byte{[]byte("BT ET")}), "", "", PermitAll)
+	if err != nil {
+		t.Fatalf("encryptPDF: %v", err)
+	}
+	if _, err := encryptPDF(out, "", "", PermitAll); err == nil {
+		t.Error("expected error encrypting an encrypted document")
+	}
+}
+
+func TestResult_Encrypt(t *testing.T) {
+	orig := &Result{data: buildTestPDF([][]byte{[]byte("BT ET")}), console: []ConsoleMessage{{Type: "log", Text: "hi"}}}
+	enc, err := orig.Encrypt("", "secret", PermitPrint)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if bytes.Equal(enc.Bytes(), orig.Bytes()) {
+		t.Error("Encrypt did not change the data")
+	}
+	if len(enc.ConsoleLogs()) != 1 {
+		t.Error("Encrypt dropped the console logs")
+	}
+	if _, err := (&Result{data: []byte("not a pdf")}).Encrypt("", "", PermitAll); err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+	if _, err := (&Result{streamed: 10}).Encrypt("", "", PermitAll); err == nil || !strings.Contains(err.Error(), "no PDF data") {
+		t.Errorf("Encrypt of a streamed result = %v, want the no-data error", err)
+	}
+}
+
+func aesCBCDecrypt(t *testing.T, key, iv, data []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes.NewCipher: %v", err)
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	return out
+}
@@ -0,0 +1,82 @@
+package htmlpdf
+
+import "context"
+
+// Shutdown closes the Converter gracefully. New conversions fail with
+// [ErrClosed] at once, while those in progress are given until ctx is done
+// to finish; the browser is then shut down as by [Converter.Close]. If
+// conversions were still running when ctx was done, they fail and
+// Shutdown returns ctx's error. A Close call meanwhile ends the wait.
+func (c *Converter) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.retired.Store(true)
+	var drained chan struct{}
+	if c.busy > 0 && !c.released {
+		if c.drained == nil {
+			c.drained = make(chan struct{})
+		}
+		drained = c.drained
+	}
+	c.mu.Unlock()
+
+	var err error
+	if drained != nil {
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.release()
+	return err
+}
+
+// release shuts the browser down unless that is already done. The caller
+// must hold c.mu.
+func (c *Converter) release() {
+	if c.released {
+		return
+	}
+	c.released = true
+	if c.drained != nil {
+		close(c.drained)
+		c.drained = nil
+	}
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+	}
+	// With a remote browser, chromedp closes only the tabs it opened and
+	// the connection; the browser and other clients' tabs are left alone.
+	c.browserCancel()
+	c.allocCancel()
+}
+
+// enterBusy records that a conversion is under way, holding off the idle
+// shutdown set by [WithIdleTimeout] and a [Converter.Shutdown]. It returns
+// a func that records the conversion's end.
+func (c *Converter) enterBusy() (leave func()) {
+	c.mu.Lock()
+	c.busy++
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+	}
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.busy--; c.busy > 0 {
+			return
+		}
+		if c.drained != nil {
+			close(c.drained)
+			c.drained = nil
+		}
+		if c.cfg.idleTimeout > 0 {
+			c.armIdleTimer()
+		}
+	}
+}
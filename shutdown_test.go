@@ -0,0 +1,102 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// shutdownTestConverter returns a Converter with a stand-in browser.
+func shutdownTestConverter() *Converter {
+	c := &Converter{cfg: defaultConfig()}
+	c.allocCtx, c.allocCancel = context.WithCancel(context.Background())
+	c.browserCtx, c.browserCancel = context.WithCancel(c.allocCtx)
+	return c
+}
+
+func TestShutdown_Idle(t *testing.T) {
+	c := shutdownTestConverter()
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.browserCtx.Err() == nil {
+		t.Error("browser still running after Shutdown")
+	}
+	if err := c.checkClosed(); !errors.Is(err, ErrClosed) {
+		t.Errorf("checkClosed = %v, want ErrClosed", err)
+	}
+}
+
+func TestShutdown_WaitsForConversions(t *testing.T) {
+	c := shutdownTestConverter()
+	leave := c.enterBusy()
+
+	done := make(chan error, 1)
+	go func() { done <- c.Shutdown(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := c.checkClosed(); !errors.Is(err, ErrClosed) {
+		t.Errorf("checkClosed during Shutdown = %v, want ErrClosed", err)
+	}
+	if _, err := c.browser(context.Background()); err != nil {
+		t.Errorf("browser during Shutdown = %v, want the running browser", err)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v with a conversion in progress", err)
+	default:
+	}
+
+	leave()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return once the conversion finished")
+	}
+	if c.browserCtx.Err() == nil {
+		t.Error("browser still running after Shutdown")
+	}
+	if _, err := c.browser(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("browser after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestShutdown_Deadline(t *testing.T) {
+	c := shutdownTestConverter()
+	defer c.enterBusy()()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+	if c.browserCtx.Err() == nil {
+		t.Error("browser still running after the Shutdown deadline")
+	}
+}
+
+func TestShutdown_CloseCutsShort(t *testing.T) {
+	c := shutdownTestConverter()
+	defer c.enterBusy()()
+
+	done := make(chan error, 1)
+	go func() { done <- c.Shutdown(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	if c.browserCtx.Err() == nil {
+		t.Error("browser still running after Close")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown = %v, want nil once Close released the browser", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown kept waiting after Close")
+	}
+}
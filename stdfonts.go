@@ -0,0 +1,107 @@
+package htmlpdf
+
+import "fmt"
+
+// standardFont holds the metrics of one of the standard Type 1 fonts that
+// every PDF viewer provides (ISO 32000-1, 9.6.2.2), so text can be drawn
+// without embedding a font.
+type standardFont struct {
+	// widths holds the advance widths of the printable ASCII characters,
+	// from space to tilde, in thousandths of the font size (Adobe's AFM
+	// files). Other characters are measured as defaultWidth, which is
+	// close enough to align the text.
+	widths       [95]int
+	defaultWidth int
+	capHeight    int // height of capital letters, in thousandths
+}
+
+// monospace returns the metrics of a font whose glyphs are all w wide.
+func monospace(w, capHeight int) *standardFont {
+	f := &standardFont{defaultWidth: w, capHeight: capHeight}
+	for i := range f.widths {
+		f.widths[i] = w
+	}
+	return f
+}
+
+// standardFonts are the standard fonts text can be drawn in, by PostScript
+// name.
+var standardFonts = map[string]*standardFont{
+	"Helvetica": {defaultWidth: 556, capHeight: 718, widths: [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space-/
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0-?
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @-O
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P-_
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // `-o
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p-~
+	}},
+	"Helvetica-Bold": {defaultWidth: 556, capHeight: 718, widths: [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // space-/
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0-?
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // @-O
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // P-_
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // `-o
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // p-~
+	}},
+	"Courier":      monospace(600, 562),
+	"Courier-Bold": monospace(600, 562),
+}
+
+// lookupStandardFont returns the metrics of the standard font name,
+// defaulting to Helvetica.
+func lookupStandardFont(name string) (*standardFont, string, error) {
+	if name == "" {
+		name = "Helvetica"
+	}
+	f, ok := standardFonts[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported font %q: use Helvetica, Helvetica-Bold, Courier or Courier-Bold", name)
+	}
+	return f, name, nil
+}
+
+// width returns the width of WinAnsiEncoding text at a size of 1 point.
+func (f *standardFont) width(text []byte) float64 {
+	total := 0
+	for _, c := range text {
+		if c >= 0x20 && c < 0x7f {
+			total += f.widths[c-0x20]
+		} else {
+			total += f.defaultWidth
+		}
+	}
+	return float64(total) / 1000
+}
+
+// standardFontDict returns a font dictionary for the standard font name
+// with WinAnsiEncoding (see winAnsiEncode).
+func standardFontDict(name string) *Object {
+	return dictObj(Dict{
+		"Type":     nameObj("Font"),
+		"Subtype":  nameObj("Type1"),
+		"BaseFont": nameObj(name),
+		"Encoding": nameObj("WinAnsiEncoding"),
+	})
+}
+
+// winAnsiEncode encodes s in WinAnsiEncoding, replacing characters it
+// lacks with "?".
+func winAnsiEncode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7f:
+			b = append(b, byte(r))
+		default:
+			c := byte('?')
+			for i, u := range winAnsiUpper128 {
+				if u == r && r != 0 {
+					c = byte(128 + i)
+					break
+				}
+			}
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,38 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWinAnsiEncode(t *testing.T) {
+	if got, want := winAnsiEncode("Café €5 ✓"), []byte("Caf\xe9 \x805 ?"); !bytes.Equal(got, want) {
+		t.Errorf("winAnsiEncode = %q, want %q", got, want)
+	}
+}
+
+func TestStandardFont_Width(t *testing.T) {
+	tests := []struct {
+		font, text string
+		want       float64
+	}{
+		{"Helvetica", "Page 1", 0.667 + 0.556 + 0.556 + 0.556 + 0.278 + 0.556},
+		{"Helvetica-Bold", "DRAFT", 0.722 + 0.722 + 0.722 + 0.611 + 0.611},
+		{"Courier", "é~", 1.2},
+	}
+	for _, tt := range tests {
+		f, _, err := lookupStandardFont(tt.font)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.width(winAnsiEncode(tt.text)); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%s width of %q = %g, want %g", tt.font, tt.text, got, tt.want)
+		}
+	}
+	if _, name, err := lookupStandardFont(""); err != nil || name != "Helvetica" {
+		t.Errorf("default font = %q, %v; want Helvetica", name, err)
+	}
+	if _, _, err := lookupStandardFont("Comic Sans"); err == nil {
+		t.Error("expected error for a non-standard font")
+	}
+}
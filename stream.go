@@ -0,0 +1,80 @@
+package htmlpdf
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpio "github.com/chromedp/cdproto/io"
+)
+
+// streamChunkSize is the number of bytes requested per IO.read call when
+// streaming a PDF out of the browser.
+const streamChunkSize = 1 << 20
+
+// ConvertHTMLTo converts an HTML string to a PDF document, as
+// [Converter.ConvertHTML] does, writing it to w as the browser produces
+// it instead of holding the whole document in memory. This suits very
+// large documents.
+//
+// The returned Result carries the diagnostics and [Result.Len] but no
+// data. A conversion that fails after writing part of the PDF to w is not
+// retried. Options that rewrite the finished document, such as
+// [PageConfig.Metadata], [PageConfig.PDFA] and [PageConfig.Bookmarks],
+// need it in full and disable streaming.
+func (c *Converter) ConvertHTMLTo(ctx context.Context, w io.Writer, html string, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	src := source{document: []byte(html), sink: w}
+	return c.convert(ctx, src, pg, firstConvertOptions(opts))
+}
+
+// copyStream copies the DevTools stream handle to w and closes it. It
+// adds the bytes written to written as it goes.
+func copyStream(ctx context.Context, handle cdpio.StreamHandle, w io.Writer, written *atomic.Int64) error {
+	defer cdpio.Close(handle).Do(ctx)
+	for {
+		// IO.read's result says whether the chunk is base64-encoded,
+		// which ReadParams.Do drops.
+		var res cdpio.ReadReturns
+		params := cdpio.Read(handle).WithSize(streamChunkSize)
+		if err := cdp.Execute(ctx, cdpio.CommandRead, params, &res); err != nil {
+			return err
+		}
+		chunk := []byte(res.Data)
+		if res.Base64encoded {
+			var err error
+			if chunk, err = base64.StdEncoding.DecodeString(res.Data); err != nil {
+				return fmt.Errorf("decoding PDF stream: %w", err)
+			}
+		}
+		n, err := w.Write(chunk)
+		written.Add(int64(n))
+		if err != nil {
+			return fmt.Errorf("writing PDF: %w", err)
+		}
+		if res.EOF {
+			return nil
+		}
+	}
+}
+
+// limitWriter passes writes to w until they would take the total past
+// remaining, and fails with ErrOutputTooLarge from then on.
+type limitWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		l.remaining = 0
+		return 0, ErrOutputTooLarge
+	}
+	l.remaining -= int64(len(p))
+	return l.w.Write(p)
+}
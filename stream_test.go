@@ -0,0 +1,114 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpio "github.com/chromedp/cdproto/io"
+)
+
+// fakeStream serves IO.read from chunks and records IO.close.
+type fakeStream struct {
+	chunks []cdpio.ReadReturns
+	closed bool
+}
+
+func (f *fakeStream) Execute(_ context.Context, method string, params, res any) error {
+	switch method {
+	case cdpio.CommandRead:
+		*res.(*cdpio.ReadReturns) = f.chunks[0]
+		f.chunks = f.chunks[1:]
+	case cdpio.CommandClose:
+		f.closed = true
+	}
+	return nil
+}
+
+func TestCopyStream(t *testing.T) {
+	fake := &fakeStream{chunks: []cdpio.ReadReturns{
+		{Base64encoded: true, Data: base64.StdEncoding.EncodeToString([]byte("%PDF-1.4\n"))},
+		{Data: "body\n"},
+		{Base64encoded: true, Data: base64.StdEncoding.EncodeToString([]byte("%%EOF\n")), EOF: true},
+	}}
+	ctx := cdp.WithExecutor(context.Background(), fake)
+
+	var out bytes.Buffer
+	var written atomic.Int64
+	if err := copyStream(ctx, "stream", &out, &written); err != nil {
+		t.Fatal(err)
+	}
+	if want := "%PDF-1.4\nbody\n%%EOF\n"; out.String() != want {
+		t.Errorf("wrote %q, want %q", out.String(), want)
+	}
+	if written.Load() != int64(out.Len()) {
+		t.Errorf("written = %d, want %d", written.Load(), out.Len())
+	}
+	if !fake.closed {
+		t.Error("stream was not closed")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestCopyStream_WriteError(t *testing.T) {
+	fake := &fakeStream{chunks: []cdpio.ReadReturns{{Data: "x"}}}
+	ctx := cdp.WithExecutor(context.Background(), fake)
+
+	var written atomic.Int64
+	if err := copyStream(ctx, "stream", failingWriter{}, &written); err == nil {
+		t.Error("copyStream succeeded with a failing writer")
+	}
+	if !fake.closed {
+		t.Error("stream was not closed")
+	}
+}
+
+func TestResultLen_Streamed(t *testing.T) {
+	if n := (&Result{streamed: 42}).Len(); n != 42 {
+		t.Errorf("Len() = %d, want 42", n)
+	}
+}
+
+func TestCopyStream_Limit(t *testing.T) {
+	fake := &fakeStream{chunks: []cdpio.ReadReturns{
+		{Data: "%PDF-1.4\n"},
+		{Data: strings.Repeat("x", 100)},
+		{Data: "%%EOF\n", EOF: true},
+	}}
+	ctx := cdp.WithExecutor(context.Background(), fake)
+
+	var out bytes.Buffer
+	var written atomic.Int64
+	err := copyStream(ctx, "stream", &limitWriter{w: &out, remaining: 50}, &written)
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("copyStream = %v, want ErrOutputTooLarge", err)
+	}
+	if out.String() != "%PDF-1.4\n" {
+		t.Errorf("wrote %q, want only the chunk within the limit", out.String())
+	}
+	if len(fake.chunks) != 1 {
+		t.Errorf("%d chunks left unread, want reading to stop at the limit", len(fake.chunks))
+	}
+	if !fake.closed {
+		t.Error("stream was not closed")
+	}
+}
+
+func TestLimitWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &limitWriter{w: &out, remaining: 4}
+	if _, err := w.Write([]byte("abcd")); err != nil {
+		t.Fatalf("Write within the limit: %v", err)
+	}
+	if _, err := w.Write([]byte("e")); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("Write past the limit = %v, want ErrOutputTooLarge", err)
+	}
+}
@@ -0,0 +1,242 @@
+package htmlpdf
+
+import "strings"
+
+// WithStructureOrder makes the extractor's plain text follow the logical
+// structure of a Tagged PDF, the /StructTreeRoot written by authoring
+// tools for accessibility, instead of the text's position on the page:
+// the columns of a page are read one after the other, a sidebar is read
+// where it belongs, and artifacts such as running headers, footers and
+// page numbers are left out. Each structure element, such as a paragraph
+// or a heading, starts a new line (a new paragraph with [WithReflow]),
+// and text not in the structure follows in geometric order.
+//
+// Pages of documents without a structure tree, and pages without
+// content in it, are extracted in geometric order.
+func WithStructureOrder() ExtractorOption {
+	return func(e *Extractor) {
+		e.structureOrder = true
+	}
+}
+
+// inlineElements are the standard structure types of inline content,
+// which continue the block of the element containing them.
+var inlineElements = map[string]bool{
+	"Span": true, "Quote": true, "Note": true, "Reference": true, "BibEntry": true,
+	"Code": true, "Link": true, "Annot": true, "Ruby": true, "RB": true, "RT": true,
+	"RP": true, "Warichu": true, "WT": true, "WP": true, "Em": true, "Strong": true,
+	"Sub": true, "Lbl": true,
+}
+
+// structureWalk collects the marked-content identifiers of a structure
+// tree in reading order.
+type structureWalk struct {
+	doc     *Document
+	roleMap Dict
+	pages   map[Reference]int // page object -> page index
+	blocks  map[int][][]int   // page index -> MCIDs of each block
+	block   int               // number of the current block
+	last    map[int]int       // page index -> block its last MCIDs belong to
+	seen    map[Reference]bool
+}
+
+// readStructure returns the marked-content identifiers of each page in
+// the order of the document's structure tree, grouped into blocks such
+// as paragraphs, or nil if the document has no structure tree.
+func readStructure(doc *Document) map[int][][]int {
+	cat, err := doc.Catalog()
+	if err != nil {
+		return nil
+	}
+	root := resolveDict(doc, cat["StructTreeRoot"])
+	if root == nil {
+		return nil
+	}
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil
+	}
+	w := &structureWalk{
+		doc:     doc,
+		roleMap: resolveDict(doc, root["RoleMap"]),
+		pages:   make(map[Reference]int, len(refs)),
+		blocks:  make(map[int][][]int),
+		last:    make(map[int]int),
+		seen:    make(map[Reference]bool),
+	}
+	for i, ref := range refs {
+		w.pages[ref] = i
+	}
+	w.kids(root["K"], -1, 0)
+	return w.blocks
+}
+
+// kids walks the kids k of a structure element, whose content is on the
+// page with index page (or -1 if not known) unless a kid says otherwise.
+func (w *structureWalk) kids(k *Object, page, depth int) {
+	if depth > maxNesting || k == nil {
+		return
+	}
+	if k.Type == ObjRef {
+		if w.seen[k.Ref] {
+			return
+		}
+		w.seen[k.Ref] = true
+	}
+	obj, err := w.doc.Resolve(k)
+	if err != nil || obj == nil {
+		return
+	}
+	switch obj.Type {
+	case ObjArray:
+		for _, kid := range obj.Array {
+			w.kids(kid, page, depth+1)
+		}
+	case ObjInt:
+		w.add(page, int(obj.Int))
+	case ObjDict:
+		d := obj.Dict
+		if pg, ok := d["Pg"]; ok && pg.Type == ObjRef {
+			if i, ok := w.pages[pg.Ref]; ok {
+				page = i
+			}
+		}
+		switch typ, _ := d.GetName("Type"); typ {
+		case "MCR":
+			// Content in a form XObject's own stream is numbered apart
+			// from the page's.
+			if _, ok := d["Stm"]; !ok {
+				if mcid, ok := d.GetInt("MCID"); ok {
+					w.add(page, int(mcid))
+				}
+			}
+		case "OBJR":
+			// An annotation or XObject, which shows no page content.
+		default:
+			w.element(d, page, depth)
+		}
+	}
+}
+
+// element walks a structure element. A block element, such as a
+// paragraph, starts a new block, and the content of its parent after it
+// another.
+func (w *structureWalk) element(d Dict, page, depth int) {
+	typ, _ := d.GetName("S")
+	if typ == "Artifact" {
+		return
+	}
+	inline := inlineElements[w.role(typ)]
+	if !inline {
+		w.block++
+	}
+	w.kids(d["K"], page, depth+1)
+	if !inline {
+		w.block++
+	}
+}
+
+// role maps a structure type through the role map to a standard type.
+func (w *structureWalk) role(typ string) string {
+	for range 8 {
+		mapped, ok := w.roleMap.GetName(typ)
+		if !ok || mapped == typ {
+			break
+		}
+		typ = mapped
+	}
+	return typ
+}
+
+// add appends a marked-content identifier on a page to the current
+// block.
+func (w *structureWalk) add(page, mcid int) {
+	if page < 0 {
+		return
+	}
+	if b, ok := w.last[page]; !ok || b != w.block {
+		w.blocks[page] = append(w.blocks[page], nil)
+		w.last[page] = w.block
+	}
+	blocks := w.blocks[page]
+	blocks[len(blocks)-1] = append(blocks[len(blocks)-1], mcid)
+}
+
+// structuredText returns the text of spans in the order of blocks of
+// marked-content identifiers, one block per line, or per paragraph if
+// reflow is set. Artifacts are left out; the spans of no block follow in
+// geometric order.
+func structuredText(spans []textSpan, blocks [][]int, reflow bool) string {
+	byMCID := make(map[int][]textSpan)
+	for _, sp := range spans {
+		if !sp.artifact && sp.mcid >= 0 {
+			byMCID[sp.mcid] = append(byMCID[sp.mcid], sp)
+		}
+	}
+	var parts []string
+	addText := func(text string) {
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	for _, block := range blocks {
+		// A block continued higher up the page, such as a paragraph
+		// running on into the next column, is read in parts so that the
+		// parts are not sorted into each other's lines.
+		var segments [][]textSpan
+		bottom := 0.0 // of the last segment
+		for _, mcid := range block {
+			mcSpans := byMCID[mcid]
+			delete(byMCID, mcid)
+			if len(mcSpans) == 0 {
+				continue
+			}
+			top := mcSpans[0].y
+			for _, sp := range mcSpans {
+				top = max(top, sp.y)
+			}
+			if len(segments) == 0 || top > bottom+mcSpans[0].fontSize {
+				segments = append(segments, nil)
+				bottom = top
+			}
+			segments[len(segments)-1] = append(segments[len(segments)-1], mcSpans...)
+			for _, sp := range mcSpans {
+				bottom = min(bottom, sp.y)
+			}
+		}
+		var texts []string
+		for _, seg := range segments {
+			if reflow {
+				texts = append(texts, reflowText(groupLines(seg)))
+			} else {
+				texts = append(texts, spansToText(seg))
+			}
+		}
+		if reflow {
+			addText(strings.Join(texts, " "))
+		} else {
+			addText(strings.Join(texts, "\n"))
+		}
+	}
+
+	var rest []textSpan
+	for _, sp := range spans {
+		if sp.artifact {
+			continue
+		}
+		if _, ok := byMCID[sp.mcid]; ok || sp.mcid < 0 {
+			rest = append(rest, sp)
+		}
+	}
+	if reflow {
+		addText(reflowText(groupLines(rest)))
+	} else {
+		addText(spansToText(rest))
+	}
+
+	sep := "\n"
+	if reflow {
+		sep = "\n\n"
+	}
+	return strings.Join(parts, sep)
+}
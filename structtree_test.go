@@ -0,0 +1,72 @@
+package htmlpdf
+
+import "testing"
+
+// buildTaggedPDF returns a two-column page with a running header and a
+// structure tree reading the heading at its foot first.
+func buildTaggedPDF(t *testing.T) []byte {
+	t.Helper()
+	cs := []byte(
+		"/Artifact <</Type /Pagination>> BDC BT /F1 10 Tf 72 760 Td (Running header) Tj ET EMC " +
+			"/P <</MCID 0>> BDC BT /F1 10 Tf 72 700 Td (Left one) Tj 0 -12 Td (Left two) Tj ET EMC " +
+			"/P <</MCID 1>> BDC BT /F1 10 Tf 320 700 Td (Right one) Tj 0 -12 Td (Right two) Tj ET EMC " +
+			// A paragraph running from the foot of the left column to the
+			// top of the right one.
+			"/P <</MCID 3>> BDC BT /F1 10 Tf 72 640 Td (continues) Tj ET EMC " +
+			"/P <</MCID 4>> BDC BT /F1 10 Tf 320 720 Td (here) Tj ET EMC " +
+			"/H1 <</MCID 2>> BDC BT /F1 10 Tf 72 600 Td (Title) Tj ET EMC " +
+			"/Span <</MCID 5>> BDC BT /F1 10 Tf 110 600 Td (Part) Tj ET EMC " +
+			"BT /F1 10 Tf 72 100 Td (Untagged) Tj ET")
+	doc := mustLoad(t, buildTestPDF([][]byte{cs}))
+	u, _ := newPDFUpdate(doc)
+	page := refObj(Reference{Number: 3})
+	elem := func(s string, kids ...*Object) *Object {
+		return refObj(u.add(dictObj(Dict{"Type": nameObj("StructElem"), "S": nameObj(s), "Pg": page, "K": arrayObj(kids...)})))
+	}
+	doc0 := elem("Document",
+		elem("Heading", intObj(2), elem("Emphasis", intObj(5))),
+		elem("Body", intObj(0)),
+		elem("Body", intObj(1)),
+		elem("Body", intObj(3), dictObj(Dict{"Type": nameObj("MCR"), "MCID": intObj(4)})),
+	)
+	root := u.add(dictObj(Dict{
+		"Type":    nameObj("StructTreeRoot"),
+		"K":       doc0,
+		"RoleMap": dictObj(Dict{"Heading": nameObj("H1"), "Body": nameObj("P"), "Emphasis": nameObj("Span")}),
+	}))
+	cat, _ := doc.Catalog()
+	cat["StructTreeRoot"] = refObj(root)
+	u.set(Reference{Number: 1}, dictObj(cat))
+	return u.bytes()
+}
+
+func TestExtractPage_StructureOrder(t *testing.T) {
+	doc := mustLoad(t, buildTaggedPDF(t))
+
+	got, err := NewExtractor(doc, WithStructureOrder()).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	want := "Title Part\nLeft one\nLeft two\nRight one\nRight two\ncontinues\nhere\nUntagged"
+	if got != want {
+		t.Errorf("text in structure order =\n%s\nwant\n%s", got, want)
+	}
+
+	got, _ = NewExtractor(doc, WithStructureOrder(), WithReflow()).ExtractPage(0)
+	want = "Title Part\n\nLeft one Left two\n\nRight one Right two\n\ncontinues here\n\nUntagged"
+	if got != want {
+		t.Errorf("reflowed text in structure order =\n%s\nwant\n%s", got, want)
+	}
+
+	// Geometric order reads across the columns and keeps the header.
+	got, _ = NewExtractor(doc).ExtractPage(0)
+	if want := "Running header\nhere\nLeft one Right one"; got[:len(want)] != want {
+		t.Errorf("text in geometric order = %q", got)
+	}
+
+	// Without a structure tree, the option changes nothing.
+	plain := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 10 Tf 72 700 Td (A) Tj 100 0 Td (B) Tj ET")}))
+	if got, _ := NewExtractor(plain, WithStructureOrder()).ExtractPage(0); got != "A B" {
+		t.Errorf("text without structure tree = %q, want %q", got, "A B")
+	}
+}
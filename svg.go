@@ -0,0 +1,215 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgCSS makes the SVG fill the printable area of the page. Overflow is
+// hidden so that rounding cannot spill an empty second page.
+const svgCSS = `html, body { margin: 0; padding: 0; width: 100%; height: 100%; overflow: hidden; }
+svg { display: block; width: 100%; height: 100%; }`
+
+// svgInfo holds the dimensions declared on an SVG's root element.
+type svgInfo struct {
+	start         int     // offset of the root element's "<"
+	width, height float64 // in centimeters; 0 if not declared
+	viewBox       []float64
+}
+
+// ConvertSVG converts an SVG image, such as a diagram, to a PDF document.
+// The image is scaled to fit the printable area of the page, keeping its
+// aspect ratio. If page is nil, [DefaultPageConfig] values are used.
+//
+// If page sets PreferCSSPageSize, the page is instead sized to the image,
+// with no margins, as reported by [SVGPageSize]. Images without a viewBox
+// attribute are given one matching their width and height so that they
+// scale.
+func (c *Converter) ConvertSVG(ctx context.Context, svg []byte, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	doc, size, err := svgDocument(svg, pg != nil && pg.PreferCSSPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: %w", err)
+	}
+	if size != (PageSize{}) {
+		page := *pg
+		page.Size, page.Orientation = size, Portrait
+		pg = &page
+	}
+	return c.ConvertHTML(ctx, doc, pg, opts...)
+}
+
+// ConvertSVG converts an SVG image to PDF using a temporary [Converter].
+func ConvertSVG(ctx context.Context, svg []byte, pg *PageConfig, opts ...Option) (*Result, error) {
+	c, err := NewConverter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.ConvertSVG(ctx, svg, pg)
+}
+
+// SVGPageSize returns the size of an SVG image, from the width and height
+// attributes of its root element or, failing those, its viewBox, whose
+// units are taken to be CSS pixels. It fails if the image declares
+// neither, or only relative (percentage) dimensions.
+func SVGPageSize(svg []byte) (PageSize, error) {
+	info, err := parseSVG(svg)
+	if err != nil {
+		return PageSize{}, fmt.Errorf("htmlpdf: %w", err)
+	}
+	size, err := info.size()
+	if err != nil {
+		return PageSize{}, fmt.Errorf("htmlpdf: %w", err)
+	}
+	return size, nil
+}
+
+// svgDocument wraps svg in an HTML document. If fit is set, the document
+// declares a page the size of the image, which is also returned.
+func svgDocument(svg []byte, fit bool) (string, PageSize, error) {
+	info, err := parseSVG(svg)
+	if err != nil {
+		return "", PageSize{}, err
+	}
+	var size PageSize
+	if fit {
+		if size, err = info.size(); err != nil {
+			return "", PageSize{}, err
+		}
+	}
+
+	// The XML declaration and doctype are dropped; HTML does not need
+	// them.
+	root := svg[info.start:]
+	if info.viewBox == nil && info.width > 0 && info.height > 0 {
+		vb := fmt.Sprintf(` viewBox="0 0 %s %s"`, formatPx(info.width), formatPx(info.height))
+		n := len("<svg")
+		root = append(append(append([]byte{}, root[:n]...), vb...), root[n:]...)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<style>\n")
+	if fit {
+		fmt.Fprintf(&b, "@page { size: %scm %scm; margin: 0; }\n", formatCm(size.Width), formatCm(size.Height))
+	}
+	b.WriteString(svgCSS + "\n</style>\n</head>\n<body>\n")
+	b.Write(root)
+	b.WriteString("\n</body>\n</html>\n")
+	return b.String(), size, nil
+}
+
+// parseSVG reads the dimensions declared on the root element of svg.
+func parseSVG(svg []byte) (*svgInfo, error) {
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	d.Strict = false
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("not an SVG image: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing SVG: %w", err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if el.Name.Local != "svg" || !bytes.HasPrefix(svg[offset:], []byte("<svg")) {
+			return nil, fmt.Errorf("not an SVG image: root element is <%s>", el.Name.Local)
+		}
+
+		info := &svgInfo{start: offset}
+		for _, a := range el.Attr {
+			if a.Name.Space != "" {
+				continue
+			}
+			switch a.Name.Local {
+			case "width":
+				info.width = svgLength(a.Value)
+			case "height":
+				info.height = svgLength(a.Value)
+			case "viewBox":
+				info.viewBox = parseViewBox(a.Value)
+			}
+		}
+		return info, nil
+	}
+}
+
+// svgLength parses an SVG width or height in centimeters. Unitless values
+// are CSS pixels. It returns 0 for relative or invalid lengths.
+func svgLength(s string) float64 {
+	if strings.HasSuffix(strings.TrimSpace(s), "%") {
+		return 0
+	}
+	v, err := parseLength(s, "px")
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// parseViewBox parses a viewBox attribute, returning nil unless it holds
+// four numbers with a positive width and height.
+func parseViewBox(s string) []float64 {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) != 4 {
+		return nil
+	}
+	vb := make([]float64, 4)
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil
+		}
+		vb[i] = v
+	}
+	if vb[2] <= 0 || vb[3] <= 0 {
+		return nil
+	}
+	return vb
+}
+
+// size returns the image size. A missing width or height is derived from
+// the other and the viewBox's aspect ratio.
+func (info *svgInfo) size() (PageSize, error) {
+	w, h := info.width, info.height
+	if vb := info.viewBox; vb != nil {
+		switch {
+		case w == 0 && h == 0:
+			w, h = Pixels(vb[2]), Pixels(vb[3])
+		case w == 0:
+			w = h * vb[2] / vb[3]
+		case h == 0:
+			h = w * vb[3] / vb[2]
+		}
+	}
+	if w == 0 || h == 0 {
+		return PageSize{}, errors.New("SVG image has no absolute width and height or viewBox")
+	}
+	return PageSize{Width: w, Height: h}, nil
+}
+
+// formatPx formats a length in centimeters as CSS pixels.
+func formatPx(cm float64) string {
+	return formatCm(cm / Pixels(1))
+}
+
+// formatCm formats a length to four decimal places, dropping trailing
+// zeros.
+func formatCm(cm float64) string {
+	return strconv.FormatFloat(math.Round(cm*1e4)/1e4, 'f', -1, 64)
+}
@@ -0,0 +1,81 @@
+package htmlpdf
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSVGPageSize(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		want PageSize // in centimeters
+	}{
+		{"pixels", `<svg xmlns="http://www.w3.org/2000/svg" width="96" height="192"/>`, PageSize{2.54, 5.08}},
+		{"units", `<svg width="10cm" height="50mm"></svg>`, PageSize{10, 5}},
+		{"viewBox", `<svg viewBox="0 0 960 480"></svg>`, PageSize{25.4, 12.7}},
+		{"width and viewBox", `<svg width="10cm" viewBox="0,0,200,100"></svg>`, PageSize{10, 5}},
+		{"percent falls back to viewBox", `<svg width="100%" height="100%" viewBox="0 0 96 96"></svg>`, PageSize{2.54, 2.54}},
+		{"prolog", "<?xml version=\"1.0\"?>\n<!DOCTYPE svg>\n<!-- diagram -->\n<svg width=\"1in\" height=\"2in\"/>", PageSize{2.54, 5.08}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SVGPageSize([]byte(tt.svg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got.Width-tt.want.Width) > 1e-9 || math.Abs(got.Height-tt.want.Height) > 1e-9 {
+				t.Errorf("SVGPageSize = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSVGPageSize_Errors(t *testing.T) {
+	for _, svg := range []string{
+		``,
+		`<html></html>`,
+		`<svg></svg>`,
+		`<svg width="100%" height="100%"></svg>`,
+		`<svg viewBox="0 0 0 10"></svg>`,
+	} {
+		if _, err := SVGPageSize([]byte(svg)); err == nil {
+			t.Errorf("SVGPageSize(%q) succeeded, want an error", svg)
+		}
+	}
+}
+
+func TestSVGDocument(t *testing.T) {
+	svg := "<?xml version=\"1.0\"?>\n<svg width=\"10cm\" height=\"5cm\"><rect/></svg>"
+	doc, size, err := svgDocument([]byte(svg), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != (PageSize{}) {
+		t.Errorf("size = %v, want zero when not fitting", size)
+	}
+	if strings.Contains(doc, "<?xml") {
+		t.Error("document keeps the XML declaration")
+	}
+	if strings.Contains(doc, "@page") {
+		t.Error("document declares a page size when not fitting")
+	}
+	if !strings.Contains(doc, `<svg viewBox="0 0 377.9528 188.9764" width="10cm" height="5cm"><rect/></svg>`) {
+		t.Errorf("document does not embed the SVG with a viewBox:\n%s", doc)
+	}
+
+	doc, size, err = svgDocument([]byte(`<svg viewBox="0 0 96 48"/>`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != (PageSize{Width: 2.54, Height: 1.27}) {
+		t.Errorf("size = %v, want 2.54×1.27", size)
+	}
+	if !strings.Contains(doc, "@page { size: 2.54cm 1.27cm; margin: 0; }") {
+		t.Errorf("document does not declare the page size:\n%s", doc)
+	}
+	if !strings.Contains(doc, `<svg viewBox="0 0 96 48"/>`) {
+		t.Errorf("document altered an SVG that has a viewBox:\n%s", doc)
+	}
+}
@@ -0,0 +1,423 @@
+package htmlpdf
+
+import (
+	"cmp"
+	"encoding/csv"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Table is a table found on a page by [Extractor.ExtractTables]. Rows
+// run from the top of the table down and hold one string per column,
+// empty for empty cells.
+type Table struct {
+	Page int        `json:"page"` // 0-indexed
+	BBox [4]float64 `json:"bbox"`
+	Rows [][]string `json:"rows"`
+}
+
+// WriteCSV writes the table's rows to w as CSV (RFC 4180).
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// CSV returns the table's rows as CSV (RFC 4180).
+func (t *Table) CSV() string {
+	var sb strings.Builder
+	t.WriteCSV(&sb) // writing to a strings.Builder does not fail
+	return sb.String()
+}
+
+// ExtractTables returns the tables found on a single page (0-indexed),
+// top to bottom, or nil if there is no such page.
+//
+// Tables are found by heuristics. Where horizontal and vertical lines
+// drawn on the page cross to form a grid, text is placed in the grid's
+// cells. Elsewhere, consecutive lines of text that are split into
+// several cells by gaps wider than the font size form a table, with
+// columns where the cells of its rows overlap horizontally; a line or
+// two with a single cell, such as a section label in a financial
+// statement, may sit between its rows. The wrapped lines of a cell
+// become separate rows.
+func (e *Extractor) ExtractTables(pageIndex int) ([]Table, error) {
+	page, ok, err := e.doc.page(pageIndex)
+	if err != nil || !ok {
+		return nil, err
+	}
+	out := textOutput{withRulings: true}
+	if err := e.extractPage(page, &out); err != nil {
+		return nil, err
+	}
+	var lines []TextLine
+	for _, l := range groupLines(out.spans) {
+		if line := layoutLine(l); len(line.Spans) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	var tables []Table
+	for _, g := range rulingGrids(out.rulings) {
+		if t, ok := g.table(lines); ok {
+			t.Page = pageIndex
+			tables = append(tables, t)
+			lines = slices.DeleteFunc(lines, func(l TextLine) bool { return g.contains(l.BBox) })
+		}
+	}
+	for _, t := range alignedTables(lines) {
+		t.Page = pageIndex
+		tables = append(tables, t)
+	}
+	slices.SortStableFunc(tables, func(a, b Table) int {
+		return -cmp.Compare(a.BBox[3], b.BBox[3])
+	})
+	return tables, nil
+}
+
+// ---- Ruling lines ----
+
+// ruling is a horizontal or vertical line drawn on the page, such as the
+// border of a table cell, from (x0, y0) to (x1, y1) with x0 <= x1 and
+// y0 <= y1.
+type ruling struct {
+	x0, y0, x1, y1 float64
+}
+
+func (r ruling) horizontal() bool { return r.y1-r.y0 < r.x1-r.x0 }
+
+// pathState is a path under construction, in page coordinates: its straight
+// segments and rectangles.
+type pathState struct {
+	segments     [][2][2]float64
+	rects        [][4][2]float64
+	start, point [2]float64
+}
+
+// rulingTolerance is the distance in points within which lines are taken
+// to be straight, to touch, or to be the same line.
+const rulingTolerance = 2.0
+
+// pathOperator handles a path construction or painting operator, adding
+// the horizontal and vertical lines painted to out.
+func (ts *textState) pathOperator(op string, args []*Object, out *textOutput) {
+	pt := func(i int) [2]float64 {
+		return ts.ctm.apply(floatArg(args[i]), floatArg(args[i+1]))
+	}
+	p := &ts.path
+	switch op {
+	case "m":
+		if len(args) >= 2 {
+			p.start = pt(len(args) - 2)
+			p.point = p.start
+		}
+	case "l":
+		if len(args) >= 2 {
+			to := pt(len(args) - 2)
+			p.segments = append(p.segments, [2][2]float64{p.point, to})
+			p.point = to
+		}
+	case "c", "v", "y": // curves end at their last point
+		if len(args) >= 2 {
+			p.point = pt(len(args) - 2)
+		}
+	case "h":
+		p.segments = append(p.segments, [2][2]float64{p.point, p.start})
+		p.point = p.start
+	case "re":
+		if len(args) >= 4 {
+			x, y, w, h := floatArg(args[0]), floatArg(args[1]), floatArg(args[2]), floatArg(args[3])
+			corners := [4][2]float64{}
+			for i, c := range [4][2]float64{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}} {
+				corners[i] = ts.ctm.apply(c[0], c[1])
+			}
+			p.rects = append(p.rects, corners)
+			p.start, p.point = corners[0], corners[0]
+		}
+	case "S", "s", "B", "B*", "b", "b*": // stroked: each straight edge
+		for _, s := range p.segments {
+			out.addRuling(s[0], s[1])
+		}
+		for _, r := range p.rects {
+			for i := range r {
+				out.addRuling(r[i], r[(i+1)%4])
+			}
+		}
+		ts.path = pathState{}
+	case "f", "F", "f*": // filled: rectangles thin enough to be lines
+		for _, r := range p.rects {
+			x0, y0 := math.Min(r[0][0], r[2][0]), math.Min(r[0][1], r[2][1])
+			x1, y1 := math.Max(r[0][0], r[2][0]), math.Max(r[0][1], r[2][1])
+			switch {
+			case y1-y0 <= rulingTolerance && x1-x0 > y1-y0:
+				out.addRuling([2]float64{x0, (y0 + y1) / 2}, [2]float64{x1, (y0 + y1) / 2})
+			case x1-x0 <= rulingTolerance && y1-y0 > x1-x0:
+				out.addRuling([2]float64{(x0 + x1) / 2, y0}, [2]float64{(x0 + x1) / 2, y1})
+			}
+		}
+		ts.path = pathState{}
+	case "n":
+		ts.path = pathState{}
+	}
+}
+
+// apply returns the point (x, y) transformed by m.
+func (m matrix) apply(x, y float64) [2]float64 {
+	return [2]float64{x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]}
+}
+
+// addRuling adds the line from a to b if it is horizontal or vertical.
+func (out *textOutput) addRuling(a, b [2]float64) {
+	r := ruling{math.Min(a[0], b[0]), math.Min(a[1], b[1]), math.Max(a[0], b[0]), math.Max(a[1], b[1])}
+	dx, dy := r.x1-r.x0, r.y1-r.y0
+	if (dy < rulingTolerance/4 || dx < rulingTolerance/4) && math.Max(dx, dy) > rulingTolerance {
+		out.rulings = append(out.rulings, r)
+	}
+}
+
+// rulingGrid is a grid of crossing ruling lines: the positions of its
+// vertical lines left to right and of its horizontal lines top to
+// bottom.
+type rulingGrid struct {
+	xs, ys []float64
+}
+
+// rulingGrids returns the grids formed by rulings: the groups of lines
+// that cross or touch, with at least two distinct horizontal and two
+// distinct vertical lines.
+func rulingGrids(rulings []ruling) []rulingGrid {
+	// Union-find over the lines, joining horizontal and vertical lines
+	// that meet.
+	parent := make([]int, len(rulings))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	const tol = rulingTolerance
+	for i, a := range rulings {
+		for j, b := range rulings[:i] {
+			h, v := a, b
+			if !h.horizontal() {
+				h, v = b, a
+			}
+			if !h.horizontal() || v.horizontal() {
+				continue
+			}
+			if v.x0 >= h.x0-tol && v.x0 <= h.x1+tol && h.y0 >= v.y0-tol && h.y0 <= v.y1+tol {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int]*rulingGrid)
+	var roots []int
+	for i, r := range rulings {
+		root := find(i)
+		g, ok := groups[root]
+		if !ok {
+			g = &rulingGrid{}
+			groups[root] = g
+			roots = append(roots, root)
+		}
+		if r.horizontal() {
+			g.ys = append(g.ys, r.y0)
+		} else {
+			g.xs = append(g.xs, r.x0)
+		}
+	}
+	var grids []rulingGrid
+	for _, root := range roots {
+		g := groups[root]
+		g.xs = mergePositions(g.xs)
+		g.ys = mergePositions(g.ys)
+		slices.Reverse(g.ys)
+		if len(g.xs) >= 2 && len(g.ys) >= 2 {
+			grids = append(grids, *g)
+		}
+	}
+	return grids
+}
+
+// mergePositions sorts positions and merges those within the ruling
+// tolerance of each other.
+func mergePositions(ps []float64) []float64 {
+	slices.Sort(ps)
+	var merged []float64
+	for _, p := range ps {
+		if n := len(merged); n > 0 && p-merged[n-1] <= rulingTolerance {
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// contains reports whether the centre of box lies inside the grid.
+func (g rulingGrid) contains(box [4]float64) bool {
+	cx, cy := (box[0]+box[2])/2, (box[1]+box[3])/2
+	return cx > g.xs[0] && cx < g.xs[len(g.xs)-1] && cy < g.ys[0] && cy > g.ys[len(g.ys)-1]
+}
+
+// table returns the table of the text of lines set inside the grid, or
+// false if none is.
+func (g rulingGrid) table(lines []TextLine) (Table, bool) {
+	rows := make([][]string, len(g.ys)-1)
+	for i := range rows {
+		rows[i] = make([]string, len(g.xs)-1)
+	}
+	found := false
+	for _, l := range lines {
+		if !g.contains(l.BBox) {
+			continue
+		}
+		for _, sp := range l.Spans {
+			cx, cy := (sp.BBox[0]+sp.BBox[2])/2, (sp.BBox[1]+sp.BBox[3])/2
+			col, _ := slices.BinarySearch(g.xs, cx)
+			row := slices.IndexFunc(g.ys, func(y float64) bool { return y < cy })
+			if col < 1 || col >= len(g.xs) || row < 1 {
+				continue
+			}
+			cell := &rows[row-1][col-1]
+			if *cell != "" {
+				*cell += " "
+			}
+			*cell += sp.Text
+			found = true
+		}
+	}
+	bbox := [4]float64{g.xs[0], g.ys[len(g.ys)-1], g.xs[len(g.xs)-1], g.ys[0]}
+	return Table{BBox: bbox, Rows: rows}, found
+}
+
+// ---- Tables from aligned text ----
+
+// tableCell is the text of a line between two wide gaps, and its extent.
+type tableCell struct {
+	text   string
+	x0, x1 float64
+}
+
+// lineCells splits a line into cells at gaps wider than the font size.
+func lineCells(l TextLine) []tableCell {
+	var cells []tableCell
+	for i, sp := range l.Spans {
+		if i > 0 {
+			prev := l.Spans[i-1]
+			gap := sp.BBox[0] - prev.BBox[2]
+			if gap <= (sp.Size+prev.Size)/2 {
+				c := &cells[len(cells)-1]
+				if gap > (sp.Size+prev.Size)/2*0.3 {
+					c.text += " "
+				}
+				c.text += sp.Text
+				c.x1 = sp.BBox[2]
+				continue
+			}
+		}
+		cells = append(cells, tableCell{text: sp.Text, x0: sp.BBox[0], x1: sp.BBox[2]})
+	}
+	return cells
+}
+
+// alignedTables returns the tables formed by lines of text split into
+// cells, top to bottom.
+func alignedTables(lines []TextLine) []Table {
+	var tables []Table
+	var run []TextLine // lines of the table being collected
+	singles := 0       // single-cell lines at the end of run
+	flush := func() {
+		run = run[:len(run)-singles]
+		multi := 0
+		for _, l := range run {
+			if len(lineCells(l)) > 1 {
+				multi++
+			}
+		}
+		if multi >= 2 {
+			if t, ok := alignedTable(run); ok {
+				tables = append(tables, t)
+			}
+		}
+		run, singles = nil, 0
+	}
+	for _, l := range lines {
+		cells := lineCells(l)
+		if len(run) > 0 {
+			prev := run[len(run)-1]
+			size := prev.BBox[3] - prev.BBox[1]
+			if prev.BBox[1]-l.BBox[1] > 3*size || len(cells) == 1 && singles == 2 {
+				flush()
+			}
+		}
+		if len(run) == 0 && len(cells) == 1 {
+			continue
+		}
+		run = append(run, l)
+		if len(cells) == 1 {
+			singles++
+		} else {
+			singles = 0
+		}
+	}
+	if len(run) > 0 {
+		flush()
+	}
+	return tables
+}
+
+// alignedTable lays out the cells of lines in columns where the cells of
+// the lines with several cells overlap horizontally, or returns false if
+// they do not form at least two columns.
+func alignedTable(lines []TextLine) (Table, bool) {
+	var spans [][2]float64
+	for _, l := range lines {
+		if cells := lineCells(l); len(cells) > 1 {
+			for _, c := range cells {
+				spans = append(spans, [2]float64{c.x0, c.x1})
+			}
+		}
+	}
+	slices.SortFunc(spans, func(a, b [2]float64) int { return cmp.Compare(a[0], b[0]) })
+	var cols [][2]float64
+	for _, s := range spans {
+		if n := len(cols); n > 0 && s[0] <= cols[n-1][1] {
+			cols[n-1][1] = math.Max(cols[n-1][1], s[1])
+			continue
+		}
+		cols = append(cols, s)
+	}
+	if len(cols) < 2 {
+		return Table{}, false
+	}
+
+	t := Table{BBox: lines[0].BBox}
+	for _, l := range lines {
+		t.BBox = unionBox(t.BBox, l.BBox)
+		row := make([]string, len(cols))
+		for _, c := range lineCells(l) {
+			// The column the cell overlaps most.
+			best, overlap := 0, math.Inf(-1)
+			for i, col := range cols {
+				if o := math.Min(c.x1, col[1]) - math.Max(c.x0, col[0]); o > overlap {
+					best, overlap = i, o
+				}
+			}
+			if row[best] != "" {
+				row[best] += " "
+			}
+			row[best] += c.text
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, true
+}
@@ -0,0 +1,70 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractTables_Aligned(t *testing.T) {
+	text := func(x, y float64, s string) string {
+		return fmt.Sprintf("BT /F1 10 Tf %g %g Td (%s) Tj ET ", x, y, s)
+	}
+	cs := []byte(
+		text(72, 720, "Balance sheet, in thousands of euros.") +
+			// Amounts are right-aligned at 300 and 400.
+			text(72, 700, "") + text(276.66, 700, "2024") + text(376.66, 700, "2023") +
+			text(72, 686, "Assets") +
+			text(72, 672, "Cash") + text(275.54, 672, "1,250") + text(281.10, 672, "") + text(381.10, 672, "980") +
+			text(72, 658, "Receivables") + text(264.43, 658, "12,400") + text(370.54, 658, "9,875") +
+			text(72, 620, "Figures are unaudited."))
+	tables, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractTables(0)
+	if err != nil {
+		t.Fatalf("ExtractTables: %v", err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("found %d tables, want 1: %+v", len(tables), tables)
+	}
+	want := [][]string{{"", "2024", "2023"}, {"Assets", "", ""}, {"Cash", "1,250", "980"}, {"Receivables", "12,400", "9,875"}}
+	if got := tables[0].Rows; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	if got, want := tables[0].CSV(), ",2024,2023\nAssets,,\nCash,\"1,250\",980\nReceivables,\"12,400\",\"9,875\"\n"; got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+	if b := tables[0].BBox; b[1] != 658 || b[3] != 710 {
+		t.Errorf("bbox = %v", b)
+	}
+}
+
+func TestExtractTables_Ruled(t *testing.T) {
+	var cs strings.Builder
+	// A 2×2 grid: stroked vertical lines, horizontal lines as thin
+	// filled rectangles, and the cell text set close together.
+	for _, x := range []int{100, 200, 300} {
+		fmt.Fprintf(&cs, "%d 600 m %d 660 l S ", x, x)
+	}
+	for _, y := range []int{600, 630, 660} {
+		fmt.Fprintf(&cs, "100 %d 200 0.5 re f ", y)
+	}
+	cs.WriteString("BT /F1 10 Tf 105 640 Td (Item) Tj 100 0 Td (Price) Tj ET ")
+	cs.WriteString("BT /F1 10 Tf 105 610 Td (Tea) Tj 100 0 Td (3.50) Tj ET ")
+	// Lines that are not part of the grid, and text outside it.
+	cs.WriteString("100 500 m 300 500 l S 0 0 612 792 re f BT /F1 10 Tf 72 400 Td (Below) Tj ET")
+
+	tables, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{[]byte(cs.String())}))).ExtractTables(0)
+	if err != nil {
+		t.Fatalf("ExtractTables: %v", err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("found %d tables, want 1: %+v", len(tables), tables)
+	}
+	want := [][]string{{"Item", "Price"}, {"Tea", "3.50"}}
+	if got := tables[0].Rows; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	if b := tables[0].BBox; b != [4]float64{100, 600.25, 300, 660.25} {
+		t.Errorf("bbox = %v", b)
+	}
+}
@@ -0,0 +1,52 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+)
+
+// ConvertTemplate executes tmpl with data and converts the resulting HTML
+// to a PDF document. If page is nil, [DefaultPageConfig] values are used.
+//
+// The template is executed into memory before the browser is involved, so
+// template errors are reported without opening a tab.
+func (c *Converter) ConvertTemplate(ctx context.Context, tmpl *template.Template, data any, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("htmlpdf: executing template: %w", err)
+	}
+	return c.ConvertHTMLBytes(ctx, buf.Bytes(), pg, opts...)
+}
+
+// ConvertTemplateFS parses the templates matching patterns in fsys,
+// executes the one called name with data, and converts the result to a
+// PDF document. Partials referenced with {{template "..."}} are resolved
+// from the same set of parsed files, so layouts and shared fragments can
+// live alongside the report templates, for example in an [embed.FS]:
+//
+//	//go:embed templates
+//	var templates embed.FS
+//
+//	res, err := c.ConvertTemplateFS(ctx, templates,
+//	    []string{"templates/*.html", "templates/partials/*.html"},
+//	    "invoice.html", invoice, nil)
+func (c *Converter) ConvertTemplateFS(ctx context.Context, fsys fs.FS, patterns []string, name string, data any, pg *PageConfig, opts ...*ConvertOptions) (*Result, error) {
+	if err := c.checkClosed(); err != nil {
+		return nil, err
+	}
+	tmpl, err := template.ParseFS(fsys, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("htmlpdf: parsing templates: %w", err)
+	}
+	named := tmpl.Lookup(name)
+	if named == nil {
+		return nil, fmt.Errorf("htmlpdf: template %q not found", name)
+	}
+	return c.ConvertTemplate(ctx, named, data, pg, opts...)
+}
@@ -0,0 +1,157 @@
+package htmlpdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// ExtractALTO returns the text of all pages as an ALTO 4 XML document,
+// the format of the Library of Congress used by libraries and archives to
+// ingest digitized text. Each [TextBlock] becomes a TextBlock, each line
+// a TextLine and each word a String, with a TextStyle for each font and
+// size. Positions are measured in 1/1200 inch (MeasurementUnit
+// "inch1200") from the top-left corner of the page. Words are split from
+// the text shown by an operator in proportion to their length, so their
+// boxes are approximate for proportional fonts.
+func (e *Extractor) ExtractALTO() (string, error) {
+	layouts, err := e.ExtractLayoutAll()
+	if err != nil {
+		return "", err
+	}
+
+	var styles []altoStyle
+	styleIDs := make(map[altoStyle]string)
+	var layout strings.Builder
+	for _, p := range layouts {
+		writeALTOPage(&layout, &p, func(sp TextSpan) string {
+			s := altoStyle{font: sp.Font, size: roundSize(sp.Size)}
+			id, ok := styleIDs[s]
+			if !ok {
+				id = fmt.Sprintf("TS%d", len(styles)+1)
+				styleIDs[s] = id
+				styles = append(styles, s)
+			}
+			return id
+		})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	sb.WriteString("<alto xmlns=\"http://www.loc.gov/standards/alto/ns-v4#\"" +
+		" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\"" +
+		" xsi:schemaLocation=\"http://www.loc.gov/standards/alto/ns-v4# http://www.loc.gov/standards/alto/v4/alto-4-2.xsd\">\n")
+	sb.WriteString("<Description>\n<MeasurementUnit>inch1200</MeasurementUnit>\n</Description>\n")
+	if len(styles) > 0 {
+		sb.WriteString("<Styles>\n")
+		for _, s := range styles {
+			fmt.Fprintf(&sb, "<TextStyle ID=\"%s\" FONTFAMILY=\"%s\" FONTSIZE=\"%s\"", styleIDs[s], altoAttr(s.font), formatPt(s.size))
+			bold, italic := fontStyle(s.font)
+			switch {
+			case bold && italic:
+				sb.WriteString(" FONTSTYLE=\"bold italics\"")
+			case bold:
+				sb.WriteString(" FONTSTYLE=\"bold\"")
+			case italic:
+				sb.WriteString(" FONTSTYLE=\"italics\"")
+			}
+			sb.WriteString("/>\n")
+		}
+		sb.WriteString("</Styles>\n")
+	}
+	sb.WriteString("<Layout>\n")
+	sb.WriteString(layout.String())
+	sb.WriteString("</Layout>\n</alto>\n")
+	return sb.String(), nil
+}
+
+// altoStyle is a font and size, written as an ALTO TextStyle.
+type altoStyle struct {
+	font string
+	size float64
+}
+
+// altoWord is a word of a line, with its box in points.
+type altoWord struct {
+	text string
+	box  [4]float64
+	span TextSpan // the span the word starts in, for its style
+}
+
+// writeALTOPage writes a page's Page element, taking the ID of each
+// span's TextStyle from style.
+func writeALTOPage(sb *strings.Builder, p *PageLayout, style func(TextSpan) string) {
+	n := p.Page + 1
+	fmt.Fprintf(sb, "<Page ID=\"P%d\" PHYSICAL_IMG_NR=\"%d\" WIDTH=\"%s\" HEIGHT=\"%s\">\n",
+		n, n, altoUnits(p.Width), altoUnits(p.Height))
+	fmt.Fprintf(sb, "<PrintSpace HPOS=\"0\" VPOS=\"0\" WIDTH=\"%s\" HEIGHT=\"%s\">\n", altoUnits(p.Width), altoUnits(p.Height))
+	for bi, b := range p.Blocks {
+		fmt.Fprintf(sb, "<TextBlock ID=\"P%d_B%d\" %s>\n", n, bi+1, altoBox(p, b.BBox))
+		for li, l := range b.Lines {
+			fmt.Fprintf(sb, "<TextLine ID=\"P%d_B%d_L%d\" %s>\n", n, bi+1, li+1, altoBox(p, l.BBox))
+			for wi, w := range lineWords(l) {
+				if wi > 0 {
+					sb.WriteString("<SP/>\n")
+				}
+				fmt.Fprintf(sb, "<String %s STYLEREFS=\"%s\" CONTENT=\"%s\"/>\n", altoBox(p, w.box), style(w.span), altoAttr(w.text))
+			}
+			sb.WriteString("</TextLine>\n")
+		}
+		sb.WriteString("</TextBlock>\n")
+	}
+	sb.WriteString("</PrintSpace>\n</Page>\n")
+}
+
+// lineWords splits a line into words. A span's width is shared among its
+// characters evenly; spans set close together continue the word before
+// them, as in [TextLine.Text].
+func lineWords(l TextLine) []altoWord {
+	var words []altoWord
+	joined := false // whether the next text continues the last word
+	for si, sp := range l.Spans {
+		if si > 0 {
+			prev := l.Spans[si-1]
+			if sp.BBox[0]-prev.BBox[2] > (sp.Size+prev.Size)/2*0.3 {
+				joined = false
+			}
+		}
+		runes := []rune(sp.Text)
+		step := (sp.BBox[2] - sp.BBox[0]) / float64(len(runes))
+		for i, r := range runes {
+			if r == ' ' {
+				joined = false
+				continue
+			}
+			box := sp.BBox
+			box[0] += step * float64(i)
+			box[2] = box[0] + step
+			if joined {
+				w := &words[len(words)-1]
+				w.text += string(r)
+				w.box = unionBox(w.box, box)
+				continue
+			}
+			words = append(words, altoWord{text: string(r), box: box, span: sp})
+			joined = true
+		}
+	}
+	return words
+}
+
+// altoBox returns the position attributes of a box in points.
+func altoBox(p *PageLayout, box [4]float64) string {
+	return fmt.Sprintf("HPOS=\"%s\" VPOS=\"%s\" WIDTH=\"%s\" HEIGHT=\"%s\"",
+		altoUnits(box[0]-p.origin[0]), altoUnits(p.top(box)), altoUnits(box[2]-box[0]), altoUnits(box[3]-box[1]))
+}
+
+// altoUnits converts a length in points to 1/1200 inch.
+func altoUnits(pt float64) string {
+	return formatPt(pt * 1200 / 72)
+}
+
+// altoAttr escapes s for an attribute value.
+func altoAttr(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,66 @@
+package htmlpdf
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestExtractALTO(t *testing.T) {
+	cs := []byte(
+		"BT /F1 18 Tf 72 700 Td (Title) Tj ET " +
+			// Two spans set close together are one word.
+			"BT /F1 10 Tf 72 660 Td (Fish & chips) Tj ET BT /F1 10 Tf 132 660 Td (bar) Tj ET BT /F1 10 Tf 148.68 660 Td (n) Tj ET")
+	got, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractALTO()
+	if err != nil {
+		t.Fatalf("ExtractALTO: %v", err)
+	}
+
+	var alto struct {
+		XMLName xml.Name `xml:"http://www.loc.gov/standards/alto/ns-v4# alto"`
+		Unit    string   `xml:"Description>MeasurementUnit"`
+		Styles  []struct {
+			ID   string `xml:"ID,attr"`
+			Size string `xml:"FONTSIZE,attr"`
+		} `xml:"Styles>TextStyle"`
+		Pages []struct {
+			Width  string `xml:"WIDTH,attr"`
+			Blocks []struct {
+				Lines []struct {
+					Strings []struct {
+						HPOS    string `xml:"HPOS,attr"`
+						VPOS    string `xml:"VPOS,attr"`
+						Style   string `xml:"STYLEREFS,attr"`
+						Content string `xml:"CONTENT,attr"`
+					} `xml:"String"`
+				} `xml:"TextLine"`
+			} `xml:"PrintSpace>TextBlock"`
+		} `xml:"Layout>Page"`
+	}
+	if err := xml.Unmarshal([]byte(got), &alto); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, got)
+	}
+	if alto.Unit != "inch1200" || len(alto.Pages) != 1 || alto.Pages[0].Width != "10200" {
+		t.Fatalf("ALTO = %+v", alto)
+	}
+	if len(alto.Styles) != 2 || alto.Styles[0].ID != "TS1" || alto.Styles[0].Size != "18" || alto.Styles[1].Size != "10" {
+		t.Errorf("styles = %+v", alto.Styles)
+	}
+
+	var words []string
+	for _, b := range alto.Pages[0].Blocks {
+		for _, l := range b.Lines {
+			for _, s := range l.Strings {
+				words = append(words, s.Content)
+			}
+		}
+	}
+	if got, want := strings.Join(words, "|"), "Title|Fish|&|chips|barn"; got != want {
+		t.Errorf("words = %q, want %q", got, want)
+	}
+	// The title is 18 points below the top of the page at 700.
+	title := alto.Pages[0].Blocks[0].Lines[0].Strings[0]
+	if title.HPOS != "1200" || title.VPOS != "1233.33" || title.Style != "TS1" {
+		t.Errorf("title = %+v", title)
+	}
+}
@@ -0,0 +1,127 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HTMLLayout is how [Extractor.ExtractHTML] places the text of a page.
+type HTMLLayout int
+
+const (
+	// HTMLFlow renders each block of text as a heading or a paragraph in
+	// reading order, for previews that reflow with the browser window.
+	// This is the default.
+	HTMLFlow HTMLLayout = iota
+	// HTMLPositioned places each span of text where it is drawn on the
+	// page, on a box the size of the page.
+	HTMLPositioned
+)
+
+// HTMLOptions controls [Extractor.ExtractHTML]. A nil HTMLOptions uses
+// the defaults of each field.
+type HTMLOptions struct {
+	// Layout is how the text of each page is placed. Defaults to
+	// HTMLFlow.
+	Layout HTMLLayout
+
+	// Title is the title of the HTML document. Defaults to the document's
+	// /Title, if any.
+	Title string
+}
+
+// ExtractHTML returns the text of all pages as an HTML document, with a
+// <section class="page"> per page, so that extracted documents can be
+// previewed in a browser. Headings are inferred from font sizes, and
+// bold and italic text from the fonts' names, as in [PageLayout]
+// structures. Images and vector graphics are left out.
+func (e *Extractor) ExtractHTML(opts *HTMLOptions) (string, error) {
+	if opts == nil {
+		opts = &HTMLOptions{}
+	}
+	layouts, err := e.ExtractLayoutAll()
+	if err != nil {
+		return "", err
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = readDocInfo(e.doc).title
+	}
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(title))
+	if opts.Layout == HTMLPositioned {
+		sb.WriteString("<style>\n.page { position: relative; margin: 1em auto; border: 1px solid #ccc; overflow: hidden; }\n" +
+			".page span { position: absolute; white-space: pre; line-height: 1; }\n</style>\n")
+	}
+	sb.WriteString("</head>\n<body>\n")
+	_, levels := headingSizes(layouts)
+	for _, layout := range layouts {
+		if opts.Layout == HTMLPositioned {
+			writePositionedHTML(&sb, layout)
+		} else {
+			writeFlowHTML(&sb, layout, levels)
+		}
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String(), nil
+}
+
+// writeFlowHTML writes a page's blocks as headings and paragraphs.
+func writeFlowHTML(sb *strings.Builder, layout PageLayout, levels map[float64]int) {
+	fmt.Fprintf(sb, "<section class=\"page\" id=\"page-%d\">\n", layout.Page+1)
+	for _, b := range layout.Blocks {
+		tag := "p"
+		if level := headingLevel(b, levels); level > 0 {
+			tag = "h" + strconv.Itoa(level)
+		}
+		fmt.Fprintf(sb, "<%s>", tag)
+		for _, run := range blockRuns(b) {
+			// Headings are bold already.
+			text := html.EscapeString(run.text)
+			if run.bold && tag == "p" {
+				text = "<b>" + text + "</b>"
+			}
+			if run.italic {
+				text = "<i>" + text + "</i>"
+			}
+			sb.WriteString(text)
+		}
+		fmt.Fprintf(sb, "</%s>\n", tag)
+	}
+	sb.WriteString("</section>\n")
+}
+
+// writePositionedHTML writes a page as a box of its size with each span
+// placed at its position, measured from the top-left corner of the
+// page's visible area.
+func writePositionedHTML(sb *strings.Builder, layout PageLayout) {
+	fmt.Fprintf(sb, "<section class=\"page\" id=\"page-%d\" style=\"width: %spt; height: %spt\">\n",
+		layout.Page+1, formatPt(layout.Width), formatPt(layout.Height))
+	for _, b := range layout.Blocks {
+		for _, l := range b.Lines {
+			for _, sp := range l.Spans {
+				style := fmt.Sprintf("left: %spt; top: %spt; font-size: %spt",
+					formatPt(sp.BBox[0]-layout.origin[0]), formatPt(layout.top(sp.BBox)), formatPt(sp.Size))
+				bold, italic := fontStyle(sp.Font)
+				if bold {
+					style += "; font-weight: bold"
+				}
+				if italic {
+					style += "; font-style: italic"
+				}
+				fmt.Fprintf(sb, "<span style=\"%s\">%s</span>\n", style, html.EscapeString(sp.Text))
+			}
+		}
+	}
+	sb.WriteString("</section>\n")
+}
+
+// formatPt formats a length to at most two decimals.
+func formatPt(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,67 @@
+package htmlpdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractHTML(t *testing.T) {
+	cs := []byte(
+		"BT /F1 18 Tf 72 700 Td (Report) Tj ET " +
+			"BT /F1 14 Tf 72 660 Td (Summary) Tj ET " +
+			"BT /F1 10 Tf 72 630 Td (Sales grew <5%) Tj ET " +
+			"BT /F1 10 Tf 72 618 Td (this year.) Tj ET " +
+			"BT /F1 10 Tf 72 590 Td (Costs fell.) Tj ET")
+	ext := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs, []byte("BT /F1 10 Tf 72 700 Td (Next page) Tj ET")})))
+
+	got, err := ext.ExtractHTML(nil)
+	if err != nil {
+		t.Fatalf("ExtractHTML: %v", err)
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<section class=\"page\" id=\"page-1\">\n<h1>Report</h1>\n<h2>Summary</h2>\n" +
+			"<p>Sales grew &lt;5% this year.</p>\n<p>Costs fell.</p>\n</section>",
+		"<section class=\"page\" id=\"page-2\">\n<p>Next page</p>\n</section>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("flow HTML does not contain %q:\n%s", want, got)
+		}
+	}
+
+	got, err = ext.ExtractHTML(&HTMLOptions{Layout: HTMLPositioned, Title: "A & B"})
+	if err != nil {
+		t.Fatalf("ExtractHTML: %v", err)
+	}
+	for _, want := range []string{
+		"<title>A &amp; B</title>",
+		"<section class=\"page\" id=\"page-1\" style=\"width: 612pt; height: 792pt\">",
+		// The title's baseline is at 700, 18 points below its top.
+		"<span style=\"left: 72pt; top: 74pt; font-size: 18pt\">Report</span>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("positioned HTML does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestBlockRuns(t *testing.T) {
+	span := func(text, font string, x0, x1 float64) TextSpan {
+		return TextSpan{BBox: [4]float64{x0, 0, x1, 10}, Text: text, Font: font, Size: 10}
+	}
+	b := TextBlock{Lines: []TextLine{
+		{Text: "Plain Bold", Spans: []TextSpan{span("Plain", "Times-Roman", 0, 20), span("Bold", "ABCDEF+Arial,Bold", 25, 40)}}, // set apart
+		{Text: "Oblique re-", Spans: []TextSpan{span("Oblique re-", "Helvetica-Oblique", 0, 50)}},
+		{Text: "sumes", Spans: []TextSpan{span("sumes", "Helvetica-Oblique", 0, 20)}},
+	}}
+	got := blockRuns(b)
+	want := []styledRun{{text: "Plain"}, {text: " Bold", bold: true}, {text: " Oblique re-sumes", italic: true}}
+	if len(got) != len(want) {
+		t.Fatalf("blockRuns = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("run %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
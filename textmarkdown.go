@@ -0,0 +1,146 @@
+package htmlpdf
+
+import (
+	"regexp"
+	"strings"
+)
+
+// listMarker matches the bullet or number starting a list item, such as
+// "• ", "- " or "2. ".
+var listMarker = regexp.MustCompile(`^(?:[•◦▪‣∙●○■□–*-]|(\d{1,3}[.)]))\s+`)
+
+// ExtractMarkdown returns the text of all pages as Markdown. Headings are
+// inferred as in [Extractor.ExtractHTML]: text larger than the body text
+// becomes "#" to "######" headings by size, and a single line set
+// entirely in bold a heading below those. Lines starting with a bullet
+// or a number become list items, bold and italic text is marked with
+// "**" and "*", and the lines of a paragraph wrapped on the page are
+// joined. Pages are separated by a blank line like paragraphs.
+func (e *Extractor) ExtractMarkdown() (string, error) {
+	layouts, err := e.ExtractLayoutAll()
+	if err != nil {
+		return "", err
+	}
+	_, levels := headingSizes(layouts)
+	var paras []string
+	for _, layout := range layouts {
+		for _, b := range layout.Blocks {
+			paras = append(paras, markdownBlock(b, levels)...)
+		}
+	}
+	if len(paras) == 0 {
+		return "", nil
+	}
+	return strings.Join(paras, "\n\n") + "\n", nil
+}
+
+// markdownBlock returns a block as a Markdown heading, paragraph or list,
+// one element per paragraph. A list is returned as a single element so
+// that its items are not separated by blank lines.
+func markdownBlock(b TextBlock, levels map[float64]int) []string {
+	if level := headingLevel(b, levels); level > 0 {
+		var sb strings.Builder
+		for _, run := range blockRuns(b) {
+			sb.WriteString(run.text)
+		}
+		return []string{strings.Repeat("#", level) + " " + escapeMarkdown(sb.String())}
+	}
+
+	// Split the block at its list items; lines before the first item are
+	// a paragraph of their own, lines after an item wrap it.
+	var paras []string
+	var items []string
+	start, marker := 0, ""
+	flush := func(end int) {
+		if end == start {
+			return
+		}
+		part := TextBlock{Lines: b.Lines[start:end]}
+		if marker == "" {
+			paras = append(paras, markdownRuns(blockRuns(part)))
+			return
+		}
+		item := markdownRuns(trimRuns(blockRuns(part), len(marker)))
+		if m := listMarker.FindStringSubmatch(marker); m[1] != "" {
+			items = append(items, m[1]+" "+item)
+		} else {
+			items = append(items, "- "+item)
+		}
+	}
+	for i, l := range b.Lines {
+		if m := listMarker.FindString(l.Text); m != "" {
+			flush(i)
+			start, marker = i, m
+		}
+	}
+	flush(len(b.Lines))
+	if len(items) > 0 {
+		paras = append(paras, strings.Join(items, "\n"))
+	}
+	return paras
+}
+
+// markdownRuns returns runs of text with bold and italic runs marked.
+// Spaces at the edges of a run are kept outside of the markers, where
+// Markdown requires them.
+func markdownRuns(runs []styledRun) string {
+	var sb strings.Builder
+	for _, run := range runs {
+		text := strings.TrimSpace(run.text)
+		if text == "" {
+			sb.WriteString(run.text)
+			continue
+		}
+		mark := ""
+		if run.bold {
+			mark += "**"
+		}
+		if run.italic {
+			mark += "*"
+		}
+		lead := run.text[:strings.Index(run.text, text)]
+		trail := run.text[len(lead)+len(text):]
+		sb.WriteString(lead + mark + escapeMarkdown(text) + mark + trail)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// trimRuns removes the first n bytes of text from runs, such as a list
+// marker, and the spaces following them.
+func trimRuns(runs []styledRun, n int) []styledRun {
+	for len(runs) > 0 {
+		if n < len(runs[0].text) {
+			runs[0].text = strings.TrimLeft(runs[0].text[n:], " ")
+			if runs[0].text != "" {
+				return runs
+			}
+			n = 0
+		} else {
+			n -= len(runs[0].text)
+		}
+		runs = runs[1:]
+	}
+	return runs
+}
+
+// markdownEscaper escapes the characters that Markdown would take for
+// emphasis, code or links anywhere in a line.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+// escapeMarkdown escapes text so that Markdown renders it as is,
+// including text that would start a heading, quote or list.
+func escapeMarkdown(text string) string {
+	text = markdownEscaper.Replace(text)
+	switch {
+	case strings.HasPrefix(text, "#"), strings.HasPrefix(text, ">"),
+		strings.HasPrefix(text, "+ "), strings.HasPrefix(text, "- "):
+		return `\` + text
+	case listMarker.MatchString(text):
+		// A number followed by a period or parenthesis.
+		i := strings.IndexAny(text, ".)")
+		return text[:i] + `\` + text[i:]
+	}
+	return text
+}
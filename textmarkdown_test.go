@@ -0,0 +1,43 @@
+package htmlpdf
+
+import "testing"
+
+func TestExtractMarkdown(t *testing.T) {
+	cs := []byte(
+		"BT /F1 18 Tf 72 700 Td (Report) Tj ET " +
+			"BT /F1 14 Tf 72 660 Td (Summary) Tj ET " +
+			// A paragraph wrapped over three lines, one hyphenated.
+			"BT /F1 10 Tf 72 630 Td (Sales grew in every) Tj ET " +
+			"BT /F1 10 Tf 72 618 Td (region, with the sur-) Tj ET " +
+			"BT /F1 10 Tf 72 606 Td (plus *reinvested*.) Tj ET " +
+			// A list introduced by a line, its second item wrapped.
+			"BT /F1 10 Tf 72 570 Td (Next steps:) Tj ET " +
+			"BT /F1 10 Tf 72 558 Td (\\225 Hire) Tj ET " +
+			"BT /F1 10 Tf 72 546 Td (\\225 Open an office) Tj ET " +
+			"BT /F1 10 Tf 80 534 Td (in Lyon) Tj ET " +
+			"BT /F1 10 Tf 72 500 Td (1. Budget) Tj ET " +
+			"BT /F1 10 Tf 72 488 Td (2. Plan) Tj ET")
+	ext := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs, []byte("BT /F1 10 Tf 72 700 Td (# 5 of 9) Tj ET")})))
+
+	got, err := ext.ExtractMarkdown()
+	if err != nil {
+		t.Fatalf("ExtractMarkdown: %v", err)
+	}
+	want := "# Report\n\n" +
+		"## Summary\n\n" +
+		"Sales grew in every region, with the sur-plus \\*reinvested\\*.\n\n" +
+		"Next steps:\n\n" +
+		"- Hire\n- Open an office in Lyon\n\n" +
+		"1. Budget\n2. Plan\n\n" +
+		"\\# 5 of 9\n"
+	if got != want {
+		t.Errorf("ExtractMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarkdownRuns(t *testing.T) {
+	runs := []styledRun{{text: "Plain"}, {text: " Bold ", bold: true}, {text: "both_", bold: true, italic: true}}
+	if got, want := markdownRuns(runs), `Plain **Bold** ***both\_***`; got != want {
+		t.Errorf("markdownRuns = %q, want %q", got, want)
+	}
+}
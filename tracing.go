@@ -0,0 +1,71 @@
+package htmlpdf
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope of the spans a Converter emits.
+const tracerName = "github.com/porticus-lab/go-html-pdf"
+
+// Span names. A conversion is traced as an htmlpdf.Convert span with a
+// child per phase; waiting for a tab under WithMaxConcurrent and a browser
+// relaunched mid-conversion get their own children.
+const (
+	spanNewConverter = "htmlpdf.NewConverter"
+	spanConvert      = "htmlpdf.Convert"
+	spanQueue        = "htmlpdf.queue"
+	spanRelaunch     = "htmlpdf.relaunch"
+	spanNavigate     = "htmlpdf.navigate"
+	spanWait         = "htmlpdf.wait"
+	spanPrint        = "htmlpdf.print"
+	spanCapture      = "htmlpdf.capture"
+)
+
+// defaultTracer discards all spans.
+func defaultTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(tracerName)
+}
+
+// kind describes the source for the htmlpdf.source span attribute.
+func (s source) kind() string {
+	switch {
+	case s.document != nil:
+		return "html"
+	case strings.HasPrefix(s.url, "file://"):
+		return "file"
+	default:
+		return "url"
+	}
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traced runs fn in a child span of ctx named name.
+func traced(ctx context.Context, tracer trace.Tracer, name string, fn func() error) error {
+	_, span := tracer.Start(ctx, name)
+	err := fn()
+	endSpan(span, err)
+	return err
+}
+
+// convertAttributes describes a conversion on its htmlpdf.Convert span.
+func convertAttributes(src source) trace.SpanStartOption {
+	attrs := []attribute.KeyValue{attribute.String("htmlpdf.source", src.kind())}
+	if src.kind() == "url" {
+		attrs = append(attrs, attribute.String("url.full", src.url))
+	}
+	return trace.WithAttributes(attrs...)
+}
@@ -0,0 +1,93 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestTracer() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	rec := tracetest.NewSpanRecorder()
+	return rec, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+}
+
+func TestConvert_Span(t *testing.T) {
+	rec, tp := newTestTracer()
+	cfg := defaultConfig()
+	WithTracerProvider(tp)(&cfg)
+	c := &Converter{cfg: cfg, closed: true}
+
+	_, err := c.convert(context.Background(), source{url: "https://example.com"}, nil, nil)
+	if !errors.Is(err, ErrClosed) {
+		t.Fatalf("err = %v, want ErrClosed", err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != spanConvert {
+		t.Errorf("name = %q, want %q", s.Name(), spanConvert)
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v, want Error", s.Status())
+	}
+	want := map[attribute.Key]string{"htmlpdf.source": "url", "url.full": "https://example.com"}
+	for _, kv := range s.Attributes() {
+		if v, ok := want[kv.Key]; ok {
+			if kv.Value.AsString() != v {
+				t.Errorf("%s = %q, want %q", kv.Key, kv.Value.AsString(), v)
+			}
+			delete(want, kv.Key)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("missing attributes %v", want)
+	}
+}
+
+func TestTraced(t *testing.T) {
+	rec, tp := newTestTracer()
+	tracer := tp.Tracer(tracerName)
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	boom := errors.New("boom")
+	if err := traced(ctx, tracer, spanWait, func() error { return boom }); err != boom {
+		t.Errorf("err = %v, want %v", err, boom)
+	}
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child := spans[0]
+	if child.Name() != spanWait || child.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q with parent %v, want %q under the parent", child.Name(), child.Parent().SpanID(), spanWait)
+	}
+	if child.Status().Code != codes.Error || len(child.Events()) != 1 {
+		t.Errorf("status %v with %d events, want the error recorded", child.Status(), len(child.Events()))
+	}
+}
+
+func TestSourceKind(t *testing.T) {
+	tests := []struct {
+		src  source
+		want string
+	}{
+		{source{document: []byte("<p>x</p>")}, "html"},
+		{source{url: "file:///tmp/a.html"}, "file"},
+		{source{url: "https://example.com"}, "url"},
+	}
+	for _, tt := range tests {
+		if got := tt.src.kind(); got != tt.want {
+			t.Errorf("kind(%+v) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package htmlpdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lengths in [PageSize] and [Margin] are in centimeters. These helpers
+// convert from other units:
+//
+//	htmlpdf.PageSize{Width: htmlpdf.Inches(8.5), Height: htmlpdf.Inches(11)}
+//	htmlpdf.UniformMargin(htmlpdf.Millimeters(15))
+
+// Centimeters returns x centimeters. It exists for symmetry with the
+// other unit helpers.
+func Centimeters(x float64) float64 { return x }
+
+// Millimeters converts x millimeters to centimeters.
+func Millimeters(x float64) float64 { return x / 10 }
+
+// Inches converts x inches to centimeters.
+func Inches(x float64) float64 { return x * 2.54 }
+
+// Points converts x PostScript points (1/72 inch) to centimeters.
+func Points(x float64) float64 { return x * 2.54 / 72 }
+
+// Pixels converts x CSS pixels (1/96 inch) to centimeters.
+func Pixels(x float64) float64 { return x * 2.54 / 96 }
+
+// lengthUnits maps unit suffixes to their size in centimeters.
+var lengthUnits = map[string]float64{
+	"cm": 1,
+	"mm": 0.1,
+	"in": 2.54,
+	"pt": 2.54 / 72,
+	"pc": 2.54 / 6,
+	"px": 2.54 / 96,
+}
+
+// ParseLength parses a length with a unit, such as "21cm", "0.5in",
+// "12pt" or "15 mm", and returns it in centimeters. Supported units are
+// cm, mm, in, pt, pc and px (CSS pixels). A unit is required except for
+// zero.
+func ParseLength(s string) (float64, error) {
+	v, err := parseLength(s, "")
+	if err != nil {
+		return 0, fmt.Errorf("htmlpdf: %w", err)
+	}
+	return v, nil
+}
+
+// parseLength parses s in centimeters, using defaultUnit if s has none.
+func parseLength(s, defaultUnit string) (float64, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	unit := defaultUnit
+	for u := range lengthUnits {
+		if strings.HasSuffix(t, u) {
+			unit, t = u, strings.TrimSpace(strings.TrimSuffix(t, u))
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(t, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("invalid length %q", s)
+	}
+	if unit == "" && v != 0 {
+		return 0, fmt.Errorf("length %q has no unit", s)
+	}
+	return v * lengthUnits[unit], nil
+}
+
+// lengthUnit returns the unit suffix of s, or "" if it has none.
+func lengthUnit(s string) string {
+	t := strings.ToLower(strings.TrimSpace(s))
+	for u := range lengthUnits {
+		if strings.HasSuffix(t, u) {
+			return u
+		}
+	}
+	return ""
+}
+
+// ParsePageSize parses paper dimensions written as "width x height", such
+// as "210mm x 297mm" or "8.5in × 11in", or the name of a registered size
+// such as "A4" (see [PageSizeByName]). If only the height has a unit, it
+// applies to both ("8.5 x 11in").
+func ParsePageSize(s string) (PageSize, error) {
+	// Names are tried first, as some ("Executive") contain an x.
+	if size, ok := PageSizeByName(s); ok {
+		return size, nil
+	}
+	sep, sepLen := -1, 0
+	for i, r := range s {
+		// The x of a "px" unit is not a separator.
+		if r == '×' || ((r == 'x' || r == 'X') && (i == 0 || (s[i-1] != 'p' && s[i-1] != 'P'))) {
+			sep, sepLen = i, utf8.RuneLen(r)
+			break
+		}
+	}
+	if sep < 0 {
+		return PageSize{}, fmt.Errorf("htmlpdf: invalid page size %q: want \"width x height\" or a known size name", s)
+	}
+	width, height := s[:sep], s[sep+sepLen:]
+	h, err := parseLength(height, "")
+	if err != nil {
+		return PageSize{}, fmt.Errorf("htmlpdf: invalid page size %q: %w", s, err)
+	}
+	w, err := parseLength(width, lengthUnit(height))
+	if err != nil {
+		return PageSize{}, fmt.Errorf("htmlpdf: invalid page size %q: %w", s, err)
+	}
+	if w <= 0 || h <= 0 {
+		return PageSize{}, fmt.Errorf("htmlpdf: invalid page size %q: dimensions must be positive", s)
+	}
+	return PageSize{Width: w, Height: h}, nil
+}
+
+// ParseMargin parses margins in CSS shorthand: one to four
+// space-separated lengths giving all sides; vertical and horizontal;
+// top, horizontal and bottom; or top, right, bottom and left. For
+// example "0.5in", "1cm 2cm" or "10mm 15mm 10mm 15mm".
+func ParseMargin(s string) (Margin, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 4 {
+		return Margin{}, fmt.Errorf("htmlpdf: invalid margin %q: want one to four lengths", s)
+	}
+	v := make([]float64, len(fields))
+	for i, f := range fields {
+		var err error
+		if v[i], err = parseLength(f, ""); err != nil {
+			return Margin{}, fmt.Errorf("htmlpdf: invalid margin %q: %w", s, err)
+		}
+	}
+	switch len(v) {
+	case 1:
+		return UniformMargin(v[0]), nil
+	case 2:
+		return Margin{Top: v[0], Right: v[1], Bottom: v[0], Left: v[1]}, nil
+	case 3:
+		return Margin{Top: v[0], Right: v[1], Bottom: v[2], Left: v[1]}, nil
+	default:
+		return Margin{Top: v[0], Right: v[1], Bottom: v[2], Left: v[3]}, nil
+	}
+}
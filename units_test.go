@@ -0,0 +1,106 @@
+package htmlpdf
+
+import "testing"
+
+func TestUnitHelpers(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Centimeters", Centimeters(21), 21},
+		{"Millimeters", Millimeters(297), 29.7},
+		{"Inches", Inches(8.5), 21.59},
+		{"Points", Points(72), 2.54},
+		{"Pixels", Pixels(96), 2.54},
+	}
+	for _, tt := range tests {
+		if !almostEqual(tt.got, tt.want, 1e-9) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"21cm", 21},
+		{"15 mm", 1.5},
+		{"0.5in", 1.27},
+		{"0.5IN", 1.27},
+		{"36pt", 1.27},
+		{"3pc", 1.27},
+		{"48px", 1.27},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseLength(tt.in)
+		if err != nil || !almostEqual(got, tt.want, 1e-9) {
+			t.Errorf("ParseLength(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "12", "cm", "-1cm", "1ft", "nancm", "infcm"} {
+		if _, err := ParseLength(in); err == nil {
+			t.Errorf("ParseLength(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParsePageSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PageSize
+	}{
+		{"210mm x 297mm", A4},
+		{"210mmx297mm", A4},
+		{"8.5in × 11in", Letter},
+		{"8.5 X 11in", Letter},
+		{"816px x 1056px", Letter},
+		{"A4", A4},
+		{" jis b5 ", JISB5},
+		{"Executive", Executive},
+	}
+	for _, tt := range tests {
+		got, err := ParsePageSize(tt.in)
+		if err != nil || !almostEqual(got.Width, tt.want.Width, 1e-9) || !almostEqual(got.Height, tt.want.Height, 1e-9) {
+			t.Errorf("ParsePageSize(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"A10", "210mm", "210mm x", "0mm x 297mm", "8.5in x 11"} {
+		if _, err := ParsePageSize(in); err == nil {
+			t.Errorf("ParsePageSize(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParseMargin(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Margin
+	}{
+		{"1cm", UniformMargin(1)},
+		{"1cm 2cm", Margin{Top: 1, Right: 2, Bottom: 1, Left: 2}},
+		{"1cm 2cm 3cm", Margin{Top: 1, Right: 2, Bottom: 3, Left: 2}},
+		{"10mm 20mm 30mm 40mm", Margin{Top: 1, Right: 2, Bottom: 3, Left: 4}},
+		{"0 1in", Margin{Right: 2.54, Left: 2.54}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMargin(tt.in)
+		if err != nil {
+			t.Errorf("ParseMargin(%q): %v", tt.in, err)
+			continue
+		}
+		for _, side := range [][2]float64{{got.Top, tt.want.Top}, {got.Right, tt.want.Right}, {got.Bottom, tt.want.Bottom}, {got.Left, tt.want.Left}} {
+			if !almostEqual(side[0], side[1], 1e-9) {
+				t.Errorf("ParseMargin(%q) = %+v, want %+v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+	for _, in := range []string{"", "1cm 2cm 3cm 4cm 5cm", "1"} {
+		if _, err := ParseMargin(in); err == nil {
+			t.Errorf("ParseMargin(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package htmlpdf
+
+import (
+	"strings"
+)
+
+// ContentReport is the outcome of [Result.AssertContains]: where each
+// expected string was found in the text of the PDF.
+type ContentReport struct {
+	Checks []ContentCheck // one per expected string, in order
+}
+
+// ContentCheck records the pages one expected string was found on.
+type ContentCheck struct {
+	Text  string
+	Pages []int // page numbers counted from 1; nil if the text is missing
+}
+
+// Found reports whether the text was found on any page.
+func (c ContentCheck) Found() bool {
+	return len(c.Pages) > 0
+}
+
+// Missing returns the expected strings that were not found, in order.
+func (r *ContentReport) Missing() []string {
+	var missing []string
+	for _, c := range r.Checks {
+		if !c.Found() {
+			missing = append(missing, c.Text)
+		}
+	}
+	return missing
+}
+
+// AssertContains extracts the text of the PDF and checks that each of the
+// expected strings appears in it, e.g. to catch template regressions in
+// CI. Runs of whitespace compare equal to a single space, since text
+// extraction does not reproduce the document's spacing exactly; each
+// string must be found within one page.
+//
+// The report lists the pages each string was found on. If any string is
+// missing, the report is returned together with a *[ContentError].
+func (r *Result) AssertContains(expected []string) (*ContentReport, error) {
+	pages, err := r.ExtractText()
+	if err != nil {
+		return nil, err
+	}
+	for i, text := range pages {
+		pages[i] = collapseSpace(text)
+	}
+	report := &ContentReport{Checks: make([]ContentCheck, len(expected))}
+	for i, want := range expected {
+		check := ContentCheck{Text: want}
+		needle := collapseSpace(want)
+		for n, text := range pages {
+			if strings.Contains(text, needle) {
+				check.Pages = append(check.Pages, n+1)
+			}
+		}
+		report.Checks[i] = check
+	}
+	if missing := report.Missing(); len(missing) > 0 {
+		return report, &ContentError{Missing: missing}
+	}
+	return report, nil
+}
+
+// collapseSpace replaces each run of whitespace in s with a single space
+// and trims it from both ends.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
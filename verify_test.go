@@ -0,0 +1,47 @@
+package htmlpdf
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestResult_AssertContains(t *testing.T) {
+	r := &Result{data: buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 100 700 Td (Invoice #123) Tj 0 -20 Td (Total due) Tj ET"),
+		[]byte("BT /F1 12 Tf 100 700 Td (Total due) Tj ET"),
+	})}
+
+	report, err := r.AssertContains([]string{"Invoice  #123", "Total due"})
+	if err != nil {
+		t.Fatalf("AssertContains: %v", err)
+	}
+	if len(report.Checks) != 2 || !slices.Equal(report.Checks[0].Pages, []int{1}) ||
+		!slices.Equal(report.Checks[1].Pages, []int{1, 2}) {
+		t.Errorf("report = %+v", report)
+	}
+	if missing := report.Missing(); missing != nil {
+		t.Errorf("Missing() = %q, want nil", missing)
+	}
+
+	report, err = r.AssertContains([]string{"Invoice #123", "Invoice #124", "Paid"})
+	var contentErr *ContentError
+	if !errors.As(err, &contentErr) {
+		t.Fatalf("err = %v, want *ContentError", err)
+	}
+	if !slices.Equal(contentErr.Missing, []string{"Invoice #124", "Paid"}) {
+		t.Errorf("Missing = %q", contentErr.Missing)
+	}
+	if got, want := err.Error(), `htmlpdf: PDF does not contain "Invoice #124" (and 1 more)`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if report == nil || !report.Checks[0].Found() || report.Checks[1].Found() {
+		t.Errorf("report = %+v, want it returned with the error", report)
+	}
+}
+
+func TestResult_AssertContains_NoData(t *testing.T) {
+	if _, err := (&Result{streamed: 10}).AssertContains([]string{"x"}); err == nil {
+		t.Error("expected error for a streamed result")
+	}
+}
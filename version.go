@@ -0,0 +1,100 @@
+package htmlpdf
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+)
+
+// minBrowserVersion is the oldest Chrome major version supported: the
+// first in which the headless mode local browsers are launched in is
+// the browser's regular one.
+const minBrowserVersion = 112
+
+// browserFeatures are the [PageConfig] fields whose PrintToPDF parameters
+// Chrome introduced after minBrowserVersion. Older browsers ignore them
+// without an error, so conversions using them are refused instead.
+var browserFeatures = []struct {
+	name  string
+	major int // the first Chrome major version supporting it
+	used  func(*PageConfig) bool
+}{
+	{"GenerateTaggedPDF", 119, func(p *PageConfig) bool { return p.GenerateTaggedPDF }},
+	{"GenerateDocumentOutline", 123, func(p *PageConfig) bool { return p.GenerateDocumentOutline }},
+}
+
+// browserVersion returns the product name and version of the browser,
+// e.g. "HeadlessChrome/126.0.6478.126", and its major version, or 0 if
+// the product does not carry a version in that form. ctx must carry the
+// browser's executor.
+func browserVersion(ctx context.Context) (string, int, error) {
+	_, product, _, _, _, err := browser.GetVersion().Do(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	return product, parseMajorVersion(product), nil
+}
+
+// parseMajorVersion returns the major version of a product string such
+// as "Chrome/126.0.6478.126", or 0 if it has none.
+func parseMajorVersion(product string) int {
+	_, version, ok := strings.Cut(product, "/")
+	if !ok {
+		return 0
+	}
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// BrowserVersion returns the product name and version the browser
+// reports, e.g. "HeadlessChrome/126.0.6478.126", for logging which
+// browser conversions run in. It reflects the browser running now, which
+// may have been relaunched since [NewConverter].
+func (c *Converter) BrowserVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.product
+}
+
+// checkBrowserVersion returns a *BrowserVersionError if the browser
+// reported as product, with major version major, is older than
+// minBrowserVersion, and logs a warning for each of the browserFeatures
+// it lacks. Browsers of unknown version are accepted.
+func (c *Converter) checkBrowserVersion(product string, major int) error {
+	if major == 0 {
+		c.cfg.logger.Warn("htmlpdf: cannot tell browser version", "product", product)
+		return nil
+	}
+	if major < minBrowserVersion {
+		return &BrowserVersionError{Product: product, Minimum: minBrowserVersion}
+	}
+	for _, f := range browserFeatures {
+		if major < f.major {
+			c.cfg.logger.Warn("htmlpdf: browser too old for option", "version", product, "option", f.name, "requires", f.major)
+		}
+	}
+	return nil
+}
+
+// checkBrowserFeatures returns a *BrowserVersionError if pg uses an
+// option the running browser is too old to apply.
+func (c *Converter) checkBrowserFeatures(pg *PageConfig) error {
+	c.mu.Lock()
+	product, major := c.product, c.major
+	c.mu.Unlock()
+	if major == 0 {
+		return nil
+	}
+	for _, f := range browserFeatures {
+		if major < f.major && f.used(pg) {
+			return &BrowserVersionError{Product: product, Minimum: f.major, Option: f.name}
+		}
+	}
+	return nil
+}
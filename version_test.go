@@ -0,0 +1,81 @@
+package htmlpdf
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+)
+
+// fakeVersion answers Browser.getVersion with product.
+type fakeVersion struct{ product string }
+
+func (f fakeVersion) Execute(_ context.Context, method string, _, res any) error {
+	if method != browser.CommandGetVersion {
+		return errors.New("unexpected " + method)
+	}
+	res.(*browser.GetVersionReturns).Product = f.product
+	return nil
+}
+
+func TestBrowserVersion(t *testing.T) {
+	ctx := cdp.WithExecutor(context.Background(), fakeVersion{"HeadlessChrome/126.0.6478.126"})
+	product, major, err := browserVersion(ctx)
+	if err != nil {
+		t.Fatalf("browserVersion: %v", err)
+	}
+	if product != "HeadlessChrome/126.0.6478.126" || major != 126 {
+		t.Errorf("browserVersion = %q, %d; want HeadlessChrome/126.0.6478.126, 126", product, major)
+	}
+}
+
+func TestParseMajorVersion(t *testing.T) {
+	for product, want := range map[string]int{
+		"HeadlessChrome/126.0.6478.126": 126,
+		"Chrome/99.0.4844.51":           99,
+		"Chrome/130":                    130,
+		"Chrome":                        0,
+		"Chrome/dev":                    0,
+		"":                              0,
+	} {
+		if got := parseMajorVersion(product); got != want {
+			t.Errorf("parseMajorVersion(%q) = %d, want %d", product, got, want)
+		}
+	}
+}
+
+func TestCheckBrowserVersion(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	var verErr *BrowserVersionError
+	if err := c.checkBrowserVersion("HeadlessChrome/100.0.4896.60", 100); !errors.As(err, &verErr) || verErr.Minimum != minBrowserVersion || verErr.Option != "" {
+		t.Errorf("checkBrowserVersion(100) = %v, want a *BrowserVersionError for the converter", err)
+	}
+	for _, major := range []int{minBrowserVersion, 118, 130, 0} {
+		if err := c.checkBrowserVersion("Chrome", major); err != nil {
+			t.Errorf("checkBrowserVersion(%d) = %v, want nil", major, err)
+		}
+	}
+}
+
+func TestCheckBrowserFeatures(t *testing.T) {
+	c := &Converter{cfg: defaultConfig(), product: "HeadlessChrome/118.0.5993.70", major: 118}
+	var verErr *BrowserVersionError
+	err := c.checkBrowserFeatures(&PageConfig{GenerateTaggedPDF: true})
+	if !errors.As(err, &verErr) || verErr.Option != "GenerateTaggedPDF" || verErr.Minimum != 119 {
+		t.Errorf("checkBrowserFeatures(tagged) = %v, want a *BrowserVersionError for GenerateTaggedPDF", err)
+	}
+	if err := c.checkBrowserFeatures(&PageConfig{}); err != nil {
+		t.Errorf("checkBrowserFeatures without new options = %v, want nil", err)
+	}
+
+	c.major = 123
+	if err := c.checkBrowserFeatures(&PageConfig{GenerateTaggedPDF: true, GenerateDocumentOutline: true}); err != nil {
+		t.Errorf("checkBrowserFeatures on Chrome 123 = %v, want nil", err)
+	}
+	c.major = 0
+	if err := c.checkBrowserFeatures(&PageConfig{GenerateDocumentOutline: true}); err != nil {
+		t.Errorf("checkBrowserFeatures of an unknown version = %v, want nil", err)
+	}
+}
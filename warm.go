@@ -0,0 +1,68 @@
+package htmlpdf
+
+import (
+	"context"
+
+	"github.com/chromedp/chromedp"
+)
+
+// warmTab is a blank tab created ahead of time by [WithWarmTabs].
+type warmTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newTab returns the tab for a conversion in browserCtx, taking a
+// pre-warmed one when available. Tabs that need their own browser
+// context, for a proxy or cookies, are always created fresh. Warm tabs
+// are used once, as a conversion leaves headers, overrides and listeners
+// behind, and a replacement is warmed in the background.
+func (c *Converter) newTab(browserCtx context.Context, o ConvertOptions) (context.Context, context.CancelFunc) {
+	opts := tabOptions(&c.cfg, o)
+	if opts == nil {
+		if t, ok := c.takeWarmTab(); ok {
+			go c.warmTab(browserCtx)
+			return t.ctx, t.cancel
+		}
+	}
+	return chromedp.NewContext(browserCtx, opts...)
+}
+
+// takeWarmTab returns a ready warm tab, discarding any left over from a
+// browser that has since exited or been closed.
+func (c *Converter) takeWarmTab() (*warmTab, bool) {
+	for {
+		select {
+		case t := <-c.warm:
+			if t.ctx.Err() == nil {
+				return t, true
+			}
+			t.cancel()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// warmTabs fills the warm tab pool for a newly launched browser.
+func (c *Converter) warmTabs(browserCtx context.Context) {
+	for range cap(c.warm) {
+		go c.warmTab(browserCtx)
+	}
+}
+
+// warmTab creates a blank tab in browserCtx and adds it to the pool,
+// closing it instead if the pool is already full.
+func (c *Converter) warmTab(browserCtx context.Context) {
+	ctx, cancel := chromedp.NewContext(browserCtx)
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		c.cfg.logger.Debug("htmlpdf: warming tab failed", "error", err)
+		return
+	}
+	select {
+	case c.warm <- &warmTab{ctx, cancel}:
+	default:
+		cancel()
+	}
+}
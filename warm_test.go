@@ -0,0 +1,33 @@
+package htmlpdf
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTakeWarmTab(t *testing.T) {
+	c := &Converter{cfg: defaultConfig(), warm: make(chan *warmTab, 3)}
+
+	stale, cancelStale := context.WithCancel(context.Background())
+	cancelStale()
+	live, cancelLive := context.WithCancel(context.Background())
+	defer cancelLive()
+
+	c.warm <- &warmTab{stale, cancelStale}
+	c.warm <- &warmTab{live, cancelLive}
+
+	tab, ok := c.takeWarmTab()
+	if !ok || tab.ctx != live {
+		t.Fatalf("takeWarmTab() = %v, %v; want the live tab", tab, ok)
+	}
+	if _, ok := c.takeWarmTab(); ok {
+		t.Error("takeWarmTab() returned a tab from an empty pool")
+	}
+}
+
+func TestTakeWarmTab_Disabled(t *testing.T) {
+	c := &Converter{cfg: defaultConfig()}
+	if _, ok := c.takeWarmTab(); ok {
+		t.Error("takeWarmTab() returned a tab without WithWarmTabs")
+	}
+}
@@ -0,0 +1,384 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register JPEG for image.Decode
+	_ "image/png"  // register PNG for image.Decode
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Watermark describes text, such as "DRAFT" or "CONFIDENTIAL", or an
+// image stamped across the pages of a PDF by [Result.Watermark] and
+// [Document.Watermark]. Exactly one of Text and Image must be set.
+type Watermark struct {
+	// Text is drawn in Helvetica Bold. Characters outside the Windows-1252
+	// character set are drawn as "?".
+	Text string
+
+	// Image is a PNG or JPEG image drawn instead of text. Its transparency
+	// is kept.
+	Image []byte
+
+	// FontSize is the size of Text in points. Zero sizes the text, and
+	// always the image, to span most of the page.
+	FontSize float64
+
+	// Color of Text. Defaults to gray.
+	Color color.Color
+
+	// Opacity of the stamp, from 0 (invisible) to 1 (opaque). Defaults
+	// to 0.3.
+	Opacity float64
+
+	// Rotation is the angle of the stamp in degrees, counter-clockwise
+	// from horizontal as the page is displayed; 45 runs it diagonally
+	// from the bottom left to the top right corner.
+	Rotation float64
+
+	// Pages selects the pages to stamp as comma-separated page numbers
+	// and ranges counted from 1, such as "1-3, 5, 8-". Empty stamps every
+	// page.
+	Pages string
+}
+
+// watermarkFill is the fraction of the page the stamp spans when sized
+// to fit.
+const watermarkFill = 0.8
+
+// Watermark returns the PDF with w stamped over the selected pages,
+// appended to the document as an incremental update. The document itself
+// is not modified. It fails for encrypted documents.
+func (doc *Document) Watermark(w Watermark) ([]byte, error) {
+	return watermarkPDF(doc, w)
+}
+
+// watermarkPDF returns the PDF of doc with w stamped on the selected
+// pages, appended as an incremental update.
+func watermarkPDF(doc *Document, w Watermark) ([]byte, error) {
+	if (w.Text == "") == (w.Image == nil) {
+		return nil, errors.New("watermark needs exactly one of Text and Image")
+	}
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return nil, fmt.Errorf("watermark opacity %g is outside [0, 1]", w.Opacity)
+	}
+	if w.FontSize < 0 {
+		return nil, fmt.Errorf("watermark font size %g is negative", w.FontSize)
+	}
+	opacity := w.Opacity
+	if opacity == 0 {
+		opacity = 0.3
+	}
+
+	refs, err := doc.pageRefs()
+	if err != nil {
+		return nil, err
+	}
+	selected, err := parsePageRanges(w.Pages, len(refs))
+	if err != nil {
+		return nil, err
+	}
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	gs := u.add(dictObj(Dict{
+		"Type": nameObj("ExtGState"),
+		"ca":   realObj(opacity),
+		"CA":   realObj(opacity),
+	}))
+	// The stamp is drawn as a box of boxW×boxH units, centred on the page.
+	var (
+		stamp      Reference
+		stampType  string // resource category of stamp
+		boxW, boxH float64
+		text       []byte
+	)
+	if w.Text != "" {
+		stamp = u.add(standardFontDict("Helvetica-Bold"))
+		stampType = "Font"
+		text = winAnsiEncode(w.Text)
+		font := standardFonts["Helvetica-Bold"]
+		boxW, boxH = font.width(text), float64(font.capHeight)/1000
+	} else {
+		img, err := addWatermarkImage(u, w.Image)
+		if err != nil {
+			return nil, err
+		}
+		stamp = img
+		stampType = "XObject"
+		width, _ := u.objects[img.Number].Dict.GetInt("Width")
+		height, _ := u.objects[img.Number].Dict.GetInt("Height")
+		boxW, boxH = float64(width)/float64(height), 1
+	}
+	var qRef Reference // a stream holding just "q", shared by every page
+
+	for i, ref := range refs {
+		if !selected[i] {
+			continue
+		}
+		page, err := doc.ResolveRef(ref)
+		if err != nil || page.Type != ObjDict {
+			return nil, fmt.Errorf("page %d: not a dictionary", i+1)
+		}
+		d := make(Dict, len(page.Dict)+1)
+		for k, v := range page.Dict {
+			d[k] = v
+		}
+
+		res := copyDict(resolveDict(doc, pageAttr(doc, d, "Resources")))
+		gsName := addResource(doc, res, "ExtGState", "WatermarkGS", gs)
+		stampName := addResource(doc, res, stampType, "Watermark", stamp)
+		d["Resources"] = dictObj(res)
+
+		box := pageBox(doc, d)
+		// Viewers turn the page clockwise by /Rotate.
+		angle := w.Rotation + float64(pageRotation(doc, d))
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		scale := w.FontSize
+		if scale == 0 || text == nil {
+			pw, ph := box[2]-box[0], box[3]-box[1]
+			scale = watermarkFill * min(
+				pw/(boxW*math.Abs(cos)+boxH*math.Abs(sin)),
+				ph/(boxW*math.Abs(sin)+boxH*math.Abs(cos)))
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString("q ")
+		writeName(&buf, gsName)
+		buf.WriteString(" gs ")
+		writeReals(&buf, cos, sin, -sin, cos, (box[0]+box[2])/2, (box[1]+box[3])/2)
+		buf.WriteString(" cm\n")
+		if text != nil {
+			r, g, b := rgb(w.Color, 0.5)
+			writeReals(&buf, r, g, b)
+			buf.WriteString(" rg BT ")
+			writeName(&buf, stampName)
+			buf.WriteByte(' ')
+			writeReals(&buf, scale)
+			buf.WriteString(" Tf ")
+			writeReals(&buf, -boxW*scale/2, -boxH*scale/2)
+			buf.WriteString(" Td ")
+			writeString(&buf, text)
+			buf.WriteString(" Tj ET")
+		} else {
+			writeReals(&buf, boxW*scale, 0, 0, boxH*scale, -boxW*scale/2, -boxH*scale/2)
+			buf.WriteString(" cm ")
+			writeName(&buf, stampName)
+			buf.WriteString(" Do")
+		}
+		buf.WriteString("\nQ\n")
+		d["Contents"] = drawOver(u, doc, d, &qRef, buf.Bytes())
+		u.set(ref, dictObj(d))
+	}
+	return u.bytes(), nil
+}
+
+// parsePageRanges returns which of n pages s selects. s holds
+// comma-separated page numbers and ranges counted from 1, such as
+// "1-3, 5, 8-", where a range without an end runs to the last page. An
+// empty s selects every page.
+func parsePageRanges(s string, n int) ([]bool, error) {
+	selected := make([]bool, n)
+	if strings.TrimSpace(s) == "" {
+		for i := range selected {
+			selected[i] = true
+		}
+		return selected, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil || from < 1 {
+			return nil, fmt.Errorf("invalid page range %q", part)
+		}
+		to := from
+		if isRange {
+			if last = strings.TrimSpace(last); last == "" {
+				to = n
+			} else if to, err = strconv.Atoi(last); err != nil || to < from {
+				return nil, fmt.Errorf("invalid page range %q", part)
+			}
+		}
+		if from > n || to > n {
+			return nil, fmt.Errorf("page range %q exceeds the document's %d pages", part, n)
+		}
+		for i := from; i <= to; i++ {
+			selected[i-1] = true
+		}
+	}
+	return selected, nil
+}
+
+// pageAttr returns the value of key in page, or inherited from its
+// ancestors.
+func pageAttr(doc *Document, page Dict, key string) *Object {
+	if v, ok := page[key]; ok {
+		return v
+	}
+	return inheritedAttr(doc, page, key)
+}
+
+// pageBox returns the visible region of page, as visibleBox does. It
+// defaults to US Letter.
+func pageBox(doc *Document, page Dict) [4]float64 {
+	if box, ok := visibleBox(doc, page); ok {
+		return box
+	}
+	return [4]float64{0, 0, 612, 792}
+}
+
+// visibleBox returns the region of page that viewers show: its crop box
+// clipped to its media box, or the media box if it has no crop box.
+// Either may be inherited. ok is false if the page has neither.
+func visibleBox(doc *Document, page Dict) (box [4]float64, ok bool) {
+	media, hasMedia := rectAttr(doc, page, "MediaBox")
+	crop, hasCrop := rectAttr(doc, page, "CropBox")
+	if !hasMedia || !hasCrop {
+		return media, hasMedia
+	}
+	box = [4]float64{max(crop[0], media[0]), max(crop[1], media[1]), min(crop[2], media[2]), min(crop[3], media[3])}
+	if box[0] >= box[2] || box[1] >= box[3] {
+		// A crop box outside the media box shows nothing: ignore it.
+		return media, true
+	}
+	return box, true
+}
+
+// rectAttr returns the rectangle key of page, or inherited from its
+// ancestors, normalized so that its lower-left corner comes first.
+func rectAttr(doc *Document, page Dict, key string) ([4]float64, bool) {
+	arr, err := doc.Resolve(pageAttr(doc, page, key))
+	if err != nil || arr == nil || arr.Type != ObjArray || len(arr.Array) != 4 {
+		return [4]float64{}, false
+	}
+	var r [4]float64
+	for i, v := range arr.Array {
+		if v, err := doc.Resolve(v); err == nil {
+			r[i] = floatFromObj(v)
+		}
+	}
+	return [4]float64{min(r[0], r[2]), min(r[1], r[3]), max(r[0], r[2]), max(r[1], r[3])}, true
+}
+
+// pageContents returns the content streams of page as a list, for adding
+// streams before or after them.
+func pageContents(doc *Document, page Dict) []*Object {
+	obj, err := doc.Resolve(page["Contents"])
+	if err != nil || obj == nil {
+		return nil
+	}
+	switch obj.Type {
+	case ObjArray:
+		return obj.Array
+	case ObjStream:
+		return []*Object{page["Contents"]}
+	}
+	return nil
+}
+
+// drawOver returns the contents of page with a stream holding content
+// appended. The page's own streams are wrapped in q/Q, so content is drawn
+// in default user space whatever graphics state the page leaves behind;
+// the stream holding "q" is added once and shared through q.
+func drawOver(u *pdfUpdate, doc *Document, page Dict, q *Reference, content []byte) *Object {
+	if q.Number == 0 {
+		*q = u.add(&Object{Type: ObjStream, Dict: Dict{}, Stream: []byte("q\n")})
+	}
+	draw := u.add(&Object{Type: ObjStream, Dict: Dict{}, Stream: append([]byte("Q\n"), content...)})
+	items := append([]*Object{refObj(*q)}, pageContents(doc, page)...)
+	return arrayObj(append(items, refObj(draw))...)
+}
+
+// copyDict returns a shallow copy of d, which may be nil.
+func copyDict(d Dict) Dict {
+	cp := make(Dict, len(d)+1)
+	for k, v := range d {
+		cp[k] = v
+	}
+	return cp
+}
+
+// addResource adds ref to the category sub-dictionary of the resource
+// dictionary res under a name starting with prefix that the page does not
+// use yet, and returns the name.
+func addResource(doc *Document, res Dict, category, prefix string, ref Reference) string {
+	sub := copyDict(resolveDict(doc, res[category]))
+	name := prefix
+	for i := 1; sub[name] != nil; i++ {
+		name = prefix + strconv.Itoa(i)
+	}
+	sub[name] = refObj(ref)
+	res[category] = dictObj(sub)
+	return name
+}
+
+// writeReals writes fs separated by spaces.
+func writeReals(buf *bytes.Buffer, fs ...float64) {
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		writeReal(buf, f)
+	}
+}
+
+// rgb returns c as RGB components from 0 to 1, or the shade of gray
+// given if c is nil.
+func rgb(c color.Color, gray float64) (r, g, b float64) {
+	if c == nil {
+		return gray, gray, gray
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return float64(n.R) / 255, float64(n.G) / 255, float64(n.B) / 255
+}
+
+// addWatermarkImage adds a PNG or JPEG image to u as an image XObject,
+// with its alpha channel, if any, as a soft mask.
+func addWatermarkImage(u *pdfUpdate, data []byte) (Reference, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Reference{}, fmt.Errorf("decoding watermark image: %w", err)
+	}
+	b := src.Bounds()
+	if b.Empty() {
+		return Reference{}, errors.New("watermark image is empty")
+	}
+	rgb := &rasterImage{w: b.Dx(), h: b.Dy(), n: 3, pix: make([]byte, 0, b.Dx()*b.Dy()*3)}
+	alpha := &rasterImage{w: b.Dx(), h: b.Dy(), n: 1, pix: make([]byte, 0, b.Dx()*b.Dy())}
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			rgb.pix = append(rgb.pix, c.R, c.G, c.B)
+			alpha.pix = append(alpha.pix, c.A)
+			opaque = opaque && c.A == 0xff
+		}
+	}
+	dict := Dict{
+		"Type":             nameObj("XObject"),
+		"Subtype":          nameObj("Image"),
+		"ColorSpace":       nameObj("DeviceRGB"),
+		"BitsPerComponent": intObj(8),
+	}
+	img, err := rgb.encode(dict, 0)
+	if err != nil {
+		return Reference{}, err
+	}
+	if !opaque {
+		dict["ColorSpace"] = nameObj("DeviceGray")
+		mask, err := alpha.encode(dict, 0)
+		if err != nil {
+			return Reference{}, err
+		}
+		img.Dict["SMask"] = refObj(u.add(mask))
+	}
+	return u.add(img), nil
+}
@@ -0,0 +1,146 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWatermarkPDF_Text(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{
+		[]byte("BT /F1 12 Tf 72 720 Td (First) Tj ET"),
+		[]byte("BT /F1 12 Tf 72 720 Td (Second) Tj ET"),
+	}))
+	out, err := watermarkPDF(doc, Watermark{
+		Text:     "DRAFT",
+		Color:    color.RGBA{R: 0xff, A: 0xff},
+		Rotation: 45,
+		Pages:    "2",
+	})
+	if err != nil {
+		t.Fatalf("watermarkPDF: %v", err)
+	}
+	doc = mustLoad(t, out)
+
+	texts, err := NewExtractor(doc).ExtractAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts[0] != "First" {
+		t.Errorf("page 1 = %q, want it unstamped", texts[0])
+	}
+	if !strings.Contains(texts[1], "Second") || !strings.Contains(texts[1], "DRAFT") {
+		t.Errorf("page 2 = %q, want the original text and the stamp", texts[1])
+	}
+
+	pages, _ := doc.Pages()
+	content, err := doc.ContentStreams(pages[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The page's content is wrapped in q/Q, then the stamp is rotated
+	// about the centre of the 612×792 page.
+	if !bytes.HasPrefix(content, []byte("q\n")) || !bytes.Contains(content, []byte("0.7071 0.7071 -0.7071 0.7071 306 396 cm")) {
+		t.Errorf("content = %q", content)
+	}
+	if !bytes.Contains(content, []byte("1 0 0 rg")) {
+		t.Errorf("content %q does not set the colour", content)
+	}
+
+	res := resolveDict(doc, pages[1]["Resources"])
+	if _, ok := resolveDict(doc, res["Font"])["F1"]; !ok {
+		t.Error("page lost its own font")
+	}
+	gs := resolveDict(doc, resolveDict(doc, res["ExtGState"])["WatermarkGS"])
+	if ca, ok := gs["ca"]; !ok || ca.Float != 0.3 {
+		t.Errorf("ExtGState = %v, want the default opacity 0.3", gs)
+	}
+}
+
+func TestWatermarkPDF_Image(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 0xff, A: 0x80})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	out, err := watermarkPDF(doc, Watermark{Image: buf.Bytes(), Opacity: 0.5})
+	if err != nil {
+		t.Fatalf("watermarkPDF: %v", err)
+	}
+	doc = mustLoad(t, out)
+	pages, _ := doc.Pages()
+	xobj, err := doc.Resolve(resolveDict(doc, resolveDict(doc, pages[0]["Resources"])["XObject"])["Watermark"])
+	if err != nil || xobj == nil || xobj.Type != ObjStream {
+		t.Fatalf("no watermark image: %v, %v", xobj, err)
+	}
+	if w, _ := xobj.Dict.GetInt("Width"); w != 4 {
+		t.Errorf("Width = %d, want 4", w)
+	}
+	mask, err := doc.Resolve(xobj.Dict["SMask"])
+	if err != nil || mask == nil || mask.Type != ObjStream {
+		t.Fatalf("no soft mask: %v, %v", mask, err)
+	}
+	alpha, err := DecompressStream(mask.Dict, mask.Stream)
+	if err != nil || len(alpha) != 8 || alpha[0] != 0x80 || alpha[1] != 0 {
+		t.Errorf("soft mask = %v, %v", alpha, err)
+	}
+
+	// A 2:1 image is sized to 80% of the page width: 489.6×244.8 points.
+	content, _ := doc.ContentStreams(pages[0])
+	if !bytes.Contains(content, []byte("489.6 0 0 244.8 -244.8 -122.4 cm /Watermark Do")) {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestWatermarkPDF_Errors(t *testing.T) {
+	pdf := buildTestPDF([][]byte{[]byte("BT ET")})
+	for name, w := range map[string]Watermark{
+		"nothing":    {},
+		"both":       {Text: "X", Image: []byte{1}},
+		"opacity":    {Text: "X", Opacity: 2},
+		"font size":  {Text: "X", FontSize: -1},
+		"page range": {Text: "X", Pages: "2"},
+		"image":      {Image: []byte("not an image")},
+	} {
+		if _, err := watermarkPDF(mustLoad(t, pdf), w); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	enc, err := encryptPDF(pdf, "owner", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watermarkPDF(mustLoad(t, enc), Watermark{Text: "X"}); err == nil {
+		t.Error("expected error for an encrypted document")
+	}
+}
+
+func TestParsePageRanges(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []bool
+	}{
+		{"", []bool{true, true, true, true}},
+		{"2", []bool{false, true, false, false}},
+		{"1, 3-4", []bool{true, false, true, true}},
+		{" 2- ", []bool{false, true, true, true}},
+	}
+	for _, tt := range tests {
+		got, err := parsePageRanges(tt.in, 4)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parsePageRanges(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"0", "5", "3-2", "1-x", "a", "1,,2"} {
+		if _, err := parsePageRanges(in, 4); err == nil {
+			t.Errorf("parsePageRanges(%q): expected error", in)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package htmlpdf
+
+import "strings"
+
+// glyphWidths holds the advance widths a font dictionary gives its
+// glyphs, in thousandths of the font size, for measuring shown text.
+type glyphWidths struct {
+	widths  map[uint32]float64 // by code (simple fonts) or CID (/W)
+	missing float64            // /MissingWidth, or /DW of a CID font
+	std     *standardFont      // a standard font without /Widths
+	twoByte bool               // codes are two-byte CIDs (Identity-H/V)
+}
+
+// loadGlyphWidths returns the widths of the glyphs of font, or nil if it
+// does not give them: Type 3 fonts, whose widths are in glyph space,
+// composite fonts with a CMap other than Identity-H/V, and simple fonts
+// without /Widths that are not among the standard fonts measured in
+// stdfonts.go.
+func loadGlyphWidths(doc *Document, font Dict) *glyphWidths {
+	switch subtype, _ := font.GetName("Subtype"); subtype {
+	case "Type3":
+		return nil
+	case "Type0":
+		return loadCIDWidths(doc, font)
+	}
+
+	g := &glyphWidths{widths: make(map[uint32]float64)}
+	if desc := resolveDict(doc, font["FontDescriptor"]); desc != nil {
+		g.missing = numberValue(doc, desc["MissingWidth"])
+	}
+	widths := resolveArray(doc, font["Widths"])
+	if widths == nil {
+		base, _ := font.GetName("BaseFont")
+		// Subset fonts are named with a tag, e.g. ABCDEF+Helvetica.
+		if i := strings.IndexByte(base, '+'); i == 6 {
+			base = base[i+1:]
+		}
+		if g.std = standardFonts[base]; g.std == nil {
+			return nil
+		}
+		return g
+	}
+	first, _ := font.GetInt("FirstChar")
+	for i, w := range widths {
+		g.widths[uint32(first)+uint32(i)] = numberValue(doc, w)
+	}
+	return g
+}
+
+// loadCIDWidths returns the widths of the glyphs of a composite font,
+// from the /W and /DW of its descendant CIDFont.
+func loadCIDWidths(doc *Document, font Dict) *glyphWidths {
+	if enc, _ := font.GetName("Encoding"); enc != "Identity-H" && enc != "Identity-V" {
+		return nil
+	}
+	descendants := resolveArray(doc, font["DescendantFonts"])
+	if len(descendants) == 0 {
+		return nil
+	}
+	cidFont := resolveDict(doc, descendants[0])
+	if cidFont == nil {
+		return nil
+	}
+	g := &glyphWidths{widths: make(map[uint32]float64), missing: 1000, twoByte: true}
+	if dw, ok := cidFont["DW"]; ok {
+		g.missing = numberValue(doc, dw)
+	}
+	// /W holds runs "c [w1 w2 …]", widths from CID c on, and ranges
+	// "cfirst clast w", one width for CIDs cfirst to clast.
+	w := resolveArray(doc, cidFont["W"])
+	for i := 0; i+1 < len(w); {
+		first := uint32(numberValue(doc, w[i]))
+		if run := resolveArray(doc, w[i+1]); run != nil {
+			for j, width := range run {
+				g.widths[first+uint32(j)] = numberValue(doc, width)
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			break
+		}
+		last, width := uint32(numberValue(doc, w[i+1])), numberValue(doc, w[i+2])
+		for cid := first; cid <= last && cid-first < 1<<16; cid++ {
+			g.widths[cid] = width
+		}
+		i += 3
+	}
+	return g
+}
+
+// measure returns the total width of the glyphs of the shown string s,
+// in thousandths of the font size, the number of glyphs, and the number
+// of single-byte codes 32, to which word spacing applies.
+func (g *glyphWidths) measure(s []byte) (width float64, glyphs, spaces int) {
+	if g.twoByte {
+		for i := 0; i+1 < len(s); i += 2 {
+			width += g.width(uint32(s[i])<<8 | uint32(s[i+1]))
+			glyphs++
+		}
+		return width, glyphs, 0
+	}
+	for _, c := range s {
+		width += g.width(uint32(c))
+		if c == ' ' {
+			spaces++
+		}
+	}
+	return width, len(s), spaces
+}
+
+func (g *glyphWidths) width(code uint32) float64 {
+	if g.std != nil {
+		if code >= 0x20 && code < 0x7f {
+			return float64(g.std.widths[code-0x20])
+		}
+		return float64(g.std.defaultWidth)
+	}
+	if w, ok := g.widths[code]; ok {
+		return w
+	}
+	return g.missing
+}
+
+// numberValue returns the number obj, resolving it if it is indirect,
+// or 0.
+func numberValue(doc *Document, obj *Object) float64 {
+	obj, err := doc.Resolve(obj)
+	if err != nil {
+		return 0
+	}
+	return floatArg(obj)
+}
+
+// resolveArray returns the array obj, resolving it if it is indirect, or
+// nil.
+func resolveArray(doc *Document, obj *Object) []*Object {
+	obj, err := doc.Resolve(obj)
+	if err != nil || obj == nil || obj.Type != ObjArray {
+		return nil
+	}
+	return obj.Array
+}
@@ -0,0 +1,90 @@
+package htmlpdf
+
+import "testing"
+
+func TestExtractGlyphWidths(t *testing.T) {
+	// Helvetica's W is 944 thousandths wide and its i and l 222, where
+	// estimateWidth measures every character at 500.
+	cs := []byte(
+		"BT /F1 10 Tf 72 700 Td (WWW) Tj ET BT /F1 10 Tf 101 700 Td (ORD) Tj ET " +
+			"BT /F1 10 Tf 72 680 Td (ill) Tj ET BT /F1 10 Tf 84 680 Td (fated) Tj ET " +
+			// The second string is shown where the first ends.
+			"BT /F1 10 Tf 72 660 Td (AB) Tj (CD) Tj ET BT /F1 10 Tf 100 660 Td (EF) Tj ET")
+	text, err := NewExtractor(mustLoad(t, buildTestPDF([][]byte{cs}))).ExtractPage(0)
+	if err != nil {
+		t.Fatalf("ExtractPage: %v", err)
+	}
+	if want := "WWWORD\nill fated\nABCDEF"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+}
+
+func TestLoadGlyphWidths(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	u, _ := newPDFUpdate(doc)
+	widths := u.add(arrayObj(intObj(1000), intObj(100)))
+	cidWidths := u.add(arrayObj(
+		intObj(1), arrayObj(intObj(500), intObj(600)),
+		intObj(10), intObj(20), intObj(1000),
+	))
+	doc = mustLoad(t, u.bytes())
+
+	tests := []struct {
+		name   string
+		font   Dict
+		text   string
+		width  float64
+		glyphs int
+		spaces int
+	}{
+		{
+			name: "widths",
+			font: Dict{
+				"Subtype":        nameObj("TrueType"),
+				"FirstChar":      intObj(65),
+				"Widths":         refObj(widths),
+				"FontDescriptor": dictObj(Dict{"MissingWidth": intObj(250)}),
+			},
+			text: "AB C", width: 1000 + 100 + 250 + 250, glyphs: 4, spaces: 1,
+		},
+		{
+			name: "subset standard font",
+			font: Dict{"Subtype": nameObj("Type1"), "BaseFont": nameObj("ABCDEF+Helvetica")},
+			text: "Wi", width: 944 + 222, glyphs: 2,
+		},
+		{
+			name: "identity CID font",
+			font: Dict{
+				"Subtype":  nameObj("Type0"),
+				"Encoding": nameObj("Identity-H"),
+				"DescendantFonts": arrayObj(dictObj(Dict{
+					"DW": intObj(250),
+					"W":  refObj(cidWidths),
+				})),
+			},
+			text: "\x00\x01\x00\x02\x00\x0f\x00\x63\x00\x20", width: 500 + 600 + 1000 + 250 + 250, glyphs: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := loadGlyphWidths(doc, tt.font)
+			if g == nil {
+				t.Fatal("no widths loaded")
+			}
+			width, glyphs, spaces := g.measure([]byte(tt.text))
+			if width != tt.width || glyphs != tt.glyphs || spaces != tt.spaces {
+				t.Errorf("measure = %v, %d, %d; want %v, %d, %d", width, glyphs, spaces, tt.width, tt.glyphs, tt.spaces)
+			}
+		})
+	}
+
+	for name, font := range map[string]Dict{
+		"Type 3":            {"Subtype": nameObj("Type3"), "Widths": refObj(widths)},
+		"non-identity CMap": {"Subtype": nameObj("Type0"), "Encoding": nameObj("UniJIS-UCS2-H")},
+		"no widths":         {"Subtype": nameObj("TrueType"), "BaseFont": nameObj("Arial")},
+	} {
+		if g := loadGlyphWidths(doc, font); g != nil {
+			t.Errorf("%s: got widths, want nil", name)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ---- Object construction ----
+
+func nameObj(name string) *Object  { return &Object{Type: ObjName, Name: name} }
+func intObj(n int) *Object         { return &Object{Type: ObjInt, Int: int64(n)} }
+func realObj(f float64) *Object    { return &Object{Type: ObjFloat, Float: f} }
+func refObj(ref Reference) *Object { return &Object{Type: ObjRef, Ref: ref} }
+func dictObj(d Dict) *Object       { return &Object{Type: ObjDict, Dict: d} }
+func arrayObj(items ...*Object) *Object {
+	return &Object{Type: ObjArray, Array: items}
+}
+
+// textStringObj returns s as a PDF text string: PDFDocEncoding (here,
+// printable ASCII) when possible, otherwise UTF-16BE with a byte order mark.
+func textStringObj(s string) *Object {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return &Object{Type: ObjString, Str: []byte(s)}
+	}
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2, 2+2*len(units))
+	b[0], b[1] = 0xfe, 0xff
+	for _, u := range units {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return &Object{Type: ObjString, Str: b}
+}
+
+// ---- Serialization ----
+
+// writeObject serializes obj in PDF syntax. Stream objects are written
+// with their raw (still encoded) data and a /Length matching it.
+func writeObject(buf *bytes.Buffer, obj *Object) {
+	if obj == nil {
+		buf.WriteString("null")
+		return
+	}
+	switch obj.Type {
+	case ObjNull:
+		buf.WriteString("null")
+	case ObjBool:
+		buf.WriteString(strconv.FormatBool(obj.Bool))
+	case ObjInt:
+		buf.WriteString(strconv.FormatInt(obj.Int, 10))
+	case ObjFloat:
+		writeReal(buf, obj.Float)
+	case ObjString:
+		writeString(buf, obj.Str)
+	case ObjName:
+		writeName(buf, obj.Name)
+	case ObjArray:
+		buf.WriteByte('[')
+		for i, item := range obj.Array {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			writeObject(buf, item)
+		}
+		buf.WriteByte(']')
+	case ObjDict:
+		writeDict(buf, obj.Dict)
+	case ObjStream:
+		d := make(Dict, len(obj.Dict)+1)
+		for k, v := range obj.Dict {
+			d[k] = v
+		}
+		d["Length"] = intObj(len(obj.Stream))
+		writeDict(buf, d)
+		buf.WriteString("\nstream\n")
+		buf.Write(obj.Stream)
+		buf.WriteString("\nendstream")
+	case ObjRef:
+		fmt.Fprintf(buf, "%d %d R", obj.Ref.Number, obj.Ref.Gen)
+	}
+}
+
+// writeDict writes d with its keys sorted, so output is deterministic.
+func writeDict(buf *bytes.Buffer, d Dict) {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf.WriteString("<<")
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		writeName(buf, k)
+		buf.WriteByte(' ')
+		writeObject(buf, d[k])
+	}
+	buf.WriteString(" >>")
+}
+
+// writeReal writes f without exponent notation, which PDF does not allow.
+func writeReal(buf *bytes.Buffer, f float64) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		f = 0
+	}
+	s := strconv.FormatFloat(f, 'f', 4, 64)
+	s = trimFraction(s)
+	buf.WriteString(s)
+}
+
+// trimFraction removes trailing zeros and a trailing point from a
+// fixed-point number.
+func trimFraction(s string) string {
+	if strings.IndexByte(s, '.') < 0 {
+		return s
+	}
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// writeString writes b as a literal string if it is printable ASCII and
+// as a hex string otherwise.
+func writeString(buf *bytes.Buffer, b []byte) {
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			fmt.Fprintf(buf, "<%x>", b)
+			return
+		}
+	}
+	buf.WriteByte('(')
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(c)
+	}
+	buf.WriteByte(')')
+}
+
+// writeName writes /name, escaping delimiters, whitespace and bytes
+// outside the printable ASCII range as #xx.
+func writeName(buf *bytes.Buffer, name string) {
+	buf.WriteByte('/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < '!' || c > '~' || c == '#' || isDelim(c) {
+			fmt.Fprintf(buf, "#%02X", c)
+			continue
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// ---- Incremental update ----
+
+// pdfUpdate collects new and replaced objects and appends them to a
+// document as an incremental update (ISO 32000-1, 7.5.6). The original
+// bytes are left untouched, so signatures and offsets stay valid.
+type pdfUpdate struct {
+	doc     *Document
+	objects map[int]*Object
+	trailer Dict // entries added to or replacing the trailer's
+	nextID  int
+}
+
+func newPDFUpdate(doc *Document) (*pdfUpdate, error) {
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("cannot update an encrypted document")
+	}
+	// The update is appended to the original bytes.
+	if _, err := doc.readAll(); err != nil {
+		return nil, err
+	}
+	size, _ := doc.trailer.GetInt("Size")
+	next := int(size)
+	for id := range doc.xref {
+		if id >= next {
+			next = id + 1
+		}
+	}
+	return &pdfUpdate{
+		doc:     doc,
+		objects: make(map[int]*Object),
+		trailer: make(Dict),
+		nextID:  next,
+	}, nil
+}
+
+// add allocates a new object number for obj and returns its reference.
+func (u *pdfUpdate) add(obj *Object) Reference {
+	ref := Reference{Number: u.nextID}
+	u.nextID++
+	u.objects[ref.Number] = obj
+	return ref
+}
+
+// reserve allocates an object number to be filled in later with set,
+// for objects that refer to each other.
+func (u *pdfUpdate) reserve() Reference {
+	return u.add(&Object{Type: ObjNull})
+}
+
+// set replaces the object ref refers to.
+func (u *pdfUpdate) set(ref Reference, obj *Object) {
+	u.objects[ref.Number] = obj
+}
+
+// catalog returns a copy of the document catalog and its reference, for
+// modification followed by set.
+func (u *pdfUpdate) catalog() (Reference, Dict, error) {
+	rootObj, ok := u.doc.trailer["Root"]
+	if !ok || rootObj.Type != ObjRef {
+		return Reference{}, nil, fmt.Errorf("no /Root reference in trailer")
+	}
+	if obj, ok := u.objects[rootObj.Ref.Number]; ok && obj.Type == ObjDict {
+		return rootObj.Ref, obj.Dict, nil
+	}
+	cat, err := u.doc.Catalog()
+	if err != nil {
+		return Reference{}, nil, err
+	}
+	cp := make(Dict, len(cat)+2)
+	for k, v := range cat {
+		cp[k] = v
+	}
+	return rootObj.Ref, cp, nil
+}
+
+// xrefStreamKeys are trailer entries that describe an xref stream itself
+// rather than the document, and must not be carried into a new trailer.
+var xrefStreamKeys = []string{"Type", "W", "Index", "Filter", "DecodeParms", "Length", "Prev", "XRefStm"}
+
+// bytes returns the document with the update appended. The update's
+// xref section matches the form of the document's newest one: a table
+// for classic files, a stream for files using cross-reference streams.
+func (u *pdfUpdate) bytes() []byte {
+	orig := u.doc.data
+	var buf bytes.Buffer
+	buf.Grow(len(orig) + 1024)
+	buf.Write(orig)
+	if len(orig) > 0 && orig[len(orig)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	ids := make([]int, 0, len(u.objects)+1)
+	for id := range u.objects {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	offsets := make(map[int]int64, len(ids)+1)
+	for _, id := range ids {
+		offsets[id] = int64(buf.Len())
+		fmt.Fprintf(&buf, "%d %d obj\n", id, u.generation(id))
+		writeObject(&buf, u.objects[id])
+		buf.WriteString("\nendobj\n")
+	}
+
+	trailer := make(Dict, len(u.doc.trailer)+len(u.trailer))
+	for k, v := range u.doc.trailer {
+		trailer[k] = v
+	}
+	for _, k := range xrefStreamKeys {
+		delete(trailer, k)
+	}
+	for k, v := range u.trailer {
+		trailer[k] = v
+	}
+	trailer["Prev"] = &Object{Type: ObjInt, Int: u.doc.startXRef}
+
+	xrefOffset := int64(buf.Len())
+	if u.doc.usesXRefStream() {
+		// The xref stream is itself an object and lists its own offset.
+		streamID := u.nextID
+		ids = append(ids, streamID)
+		offsets[streamID] = xrefOffset
+		trailer["Size"] = intObj(streamID + 1)
+		u.writeXRefStream(&buf, streamID, ids, offsets, trailer)
+	} else {
+		trailer["Size"] = intObj(u.nextID)
+		u.writeXRefTable(&buf, ids, offsets, trailer)
+	}
+	fmt.Fprintf(&buf, "startxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes()
+}
+
+// generation returns the generation number for object id: that of the
+// object it replaces, or 0 for new objects.
+func (u *pdfUpdate) generation(id int) int {
+	if e, ok := u.doc.xref[id]; ok && e.InUse && !e.Compressed {
+		return e.Generation
+	}
+	return 0
+}
+
+// xrefRuns splits sorted ids into runs of consecutive numbers.
+func xrefRuns(ids []int) [][]int {
+	var runs [][]int
+	for i := 0; i < len(ids); {
+		j := i + 1
+		for j < len(ids) && ids[j] == ids[j-1]+1 {
+			j++
+		}
+		runs = append(runs, ids[i:j])
+		i = j
+	}
+	return runs
+}
+
+func (u *pdfUpdate) writeXRefTable(buf *bytes.Buffer, ids []int, offsets map[int]int64, trailer Dict) {
+	buf.WriteString("xref\n")
+	for _, run := range xrefRuns(ids) {
+		fmt.Fprintf(buf, "%d %d\n", run[0], len(run))
+		for _, id := range run {
+			fmt.Fprintf(buf, "%010d %05d n \n", offsets[id], u.generation(id))
+		}
+	}
+	buf.WriteString("trailer\n")
+	writeDict(buf, trailer)
+	buf.WriteString("\n")
+}
+
+func (u *pdfUpdate) writeXRefStream(buf *bytes.Buffer, streamID int, ids []int, offsets map[int]int64, trailer Dict) {
+	var index []*Object
+	var data []byte
+	for _, run := range xrefRuns(ids) {
+		index = append(index, intObj(run[0]), intObj(len(run)))
+		for _, id := range run {
+			off := offsets[id]
+			gen := u.generation(id)
+			data = append(data, 1,
+				byte(off>>32), byte(off>>24), byte(off>>16), byte(off>>8), byte(off),
+				byte(gen>>8), byte(gen))
+		}
+	}
+	trailer["Type"] = nameObj("XRef")
+	trailer["W"] = arrayObj(intObj(1), intObj(5), intObj(2))
+	trailer["Index"] = arrayObj(index...)
+
+	fmt.Fprintf(buf, "%d 0 obj\n", streamID)
+	writeObject(buf, &Object{Type: ObjStream, Dict: trailer, Stream: data})
+	buf.WriteString("\nendobj\n")
+}
+
+// usesXRefStream reports whether the newest xref section is a
+// cross-reference stream rather than a classic table.
+func (doc *Document) usesXRefStream() bool {
+	data, _ := doc.window(doc.startXRef, 64)
+	p := NewParser(data, 0)
+	p.skipWhitespace()
+	return !p.match("xref")
+}
+
+// ---- Full rewrite ----
+
+// rewritePDF writes doc out afresh with a classic xref table, with the
+// objects in replaced substituted for the originals. Unlike an
+// incremental update, the replaced objects' old data is dropped, so the
+// file can shrink. Object and xref streams are unpacked into plain
+// objects.
+func rewritePDF(doc *Document, replaced map[int]*Object) ([]byte, error) {
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("cannot rewrite an encrypted document")
+	}
+	ids := make([]int, 0, len(doc.xref))
+	for id, e := range doc.xref {
+		if e.InUse && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	version := doc.Version()
+	if len(version) != 3 {
+		version = "1.4"
+	}
+	var buf bytes.Buffer
+	buf.Grow(int(doc.size))
+	fmt.Fprintf(&buf, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+	offsets := make(map[int]int64, len(ids))
+	gens := make(map[int]int, len(ids))
+	size := 1
+	for _, id := range ids {
+		obj, ok := replaced[id]
+		if !ok {
+			var err error
+			if obj, err = doc.ResolveRef(Reference{Number: id}); err != nil {
+				return nil, err
+			}
+		}
+		if obj.Type == ObjStream {
+			if t, _ := obj.Dict.GetName("Type"); t == "ObjStm" || t == "XRef" {
+				continue
+			}
+		}
+		gen := doc.xref[id].Generation
+		if doc.xref[id].Compressed {
+			gen = 0
+		}
+		offsets[id], gens[id] = int64(buf.Len()), gen
+		fmt.Fprintf(&buf, "%d %d obj\n", id, gen)
+		writeObject(&buf, obj)
+		buf.WriteString("\nendobj\n")
+		size = id + 1
+	}
+
+	trailer := Dict{"Size": intObj(size), "Root": doc.trailer["Root"]}
+	for _, k := range []string{"Info", "ID"} {
+		if v := doc.trailer[k]; v != nil {
+			trailer[k] = v
+		}
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", size)
+	buf.WriteString("0000000000 65535 f \n")
+	for id := 1; id < size; id++ {
+		if off, ok := offsets[id]; ok {
+			fmt.Fprintf(&buf, "%010d %05d n \n", off, gens[id])
+		} else {
+			buf.WriteString("0000000000 00001 f \n")
+		}
+	}
+	buf.WriteString("trailer\n")
+	writeDict(&buf, trailer)
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,216 @@
+package htmlpdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriteObject(t *testing.T) {
+	tests := []struct {
+		obj  *Object
+		want string
+	}{
+		{nil, "null"},
+		{&Object{Type: ObjBool, Bool: true}, "true"},
+		{intObj(-42), "-42"},
+		{&Object{Type: ObjFloat, Float: 0.5}, "0.5"},
+		{&Object{Type: ObjFloat, Float: 612}, "612"},
+		{&Object{Type: ObjFloat, Float: 1e-7}, "0"},
+		{&Object{Type: ObjString, Str: []byte(`a (b) \c`)}, `(a \(b\) \\c)`},
+		{&Object{Type: ObjString, Str: []byte{0xfe, 0xff, 0x00, 0xe9}}, "<feff00e9>"},
+		{nameObj("A B#/C"), "/A#20B#23#2FC"},
+		{arrayObj(intObj(1), refObj(Reference{Number: 3})), "[1 3 0 R]"},
+		{dictObj(Dict{"Type": nameObj("Page"), "A": intObj(1)}), "<< /A 1 /Type /Page >>"},
+		{&Object{Type: ObjStream, Dict: Dict{}, Stream: []byte("xyz")}, "<< /Length 3 >>\nstream\nxyz\nendstream"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeObject(&buf, tt.obj)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("writeObject = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestWriteObject_RoundTrip(t *testing.T) {
+	orig := dictObj(Dict{
+		"Title": textStringObj("Résumé (draft)"),
+		"Name":  nameObj("With Space"),
+		"Kids":  arrayObj(refObj(Reference{Number: 7, Gen: 1}), intObj(3)),
+	})
+	var buf bytes.Buffer
+	writeObject(&buf, orig)
+
+	got, err := NewParser(buf.Bytes(), 0).ParseObject()
+	if err != nil {
+		t.Fatalf("ParseObject: %v", err)
+	}
+	if s := got.Dict["Title"].Str; !bytes.Equal(s, orig.Dict["Title"].Str) {
+		t.Errorf("Title = %q, want %q", s, orig.Dict["Title"].Str)
+	}
+	if n := got.Dict["Name"].Name; n != "With Space" {
+		t.Errorf("Name = %q, want %q", n, "With Space")
+	}
+	if r := got.Dict["Kids"].Array[0].Ref; r != (Reference{Number: 7, Gen: 1}) {
+		t.Errorf("Kids[0] = %v, want 7 1 R", r)
+	}
+}
+
+func TestTextStringObj(t *testing.T) {
+	if got := textStringObj("Plain").Str; string(got) != "Plain" {
+		t.Errorf("ASCII text = %q, want Plain", got)
+	}
+	want := []byte{0xfe, 0xff, 0x00, 'R', 0x00, 0xe9}
+	if got := textStringObj("Ré").Str; !bytes.Equal(got, want) {
+		t.Errorf("non-ASCII text = % x, want % x", got, want)
+	}
+}
+
+func TestPDFUpdate_XRefTable(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT /F1 12 Tf (Hello) Tj ET")}))
+
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		t.Fatalf("newPDFUpdate: %v", err)
+	}
+	added := u.add(dictObj(Dict{"Marker": nameObj("Added")}))
+	catRef, cat, err := u.catalog()
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	cat["Extra"] = refObj(added)
+	u.set(catRef, dictObj(cat))
+	out := u.bytes()
+
+	if !bytes.HasPrefix(out, doc.data) {
+		t.Fatal("update modified the original bytes")
+	}
+	updated := mustLoad(t, out)
+	checkUpdated(t, updated)
+
+	// A second update chains onto the first.
+	u2, err := newPDFUpdate(updated)
+	if err != nil {
+		t.Fatalf("newPDFUpdate: %v", err)
+	}
+	u2.trailer["Info"] = refObj(u2.add(dictObj(Dict{"Title": textStringObj("Twice")})))
+	twice := mustLoad(t, u2.bytes())
+	checkUpdated(t, twice)
+	info, _ := twice.Resolve(twice.trailer["Info"])
+	if info == nil || string(info.Dict["Title"].Str) != "Twice" {
+		t.Errorf("Info after second update = %v", info)
+	}
+}
+
+func TestPDFUpdate_XRefStream(t *testing.T) {
+	doc := mustLoad(t, buildXRefStreamPDF())
+	if !doc.usesXRefStream() {
+		t.Fatal("test document does not use an xref stream")
+	}
+
+	u, err := newPDFUpdate(doc)
+	if err != nil {
+		t.Fatalf("newPDFUpdate: %v", err)
+	}
+	added := u.add(dictObj(Dict{"Marker": nameObj("Added")}))
+	catRef, cat, _ := u.catalog()
+	cat["Extra"] = refObj(added)
+	u.set(catRef, dictObj(cat))
+
+	updated := mustLoad(t, u.bytes())
+	if !updated.usesXRefStream() {
+		t.Error("update of an xref-stream file did not use an xref stream")
+	}
+	checkUpdated(t, updated)
+}
+
+func TestPDFUpdate_RejectsEncrypted(t *testing.T) {
+	doc := mustLoad(t, buildTestPDF([][]byte{[]byte("BT ET")}))
+	doc.trailer["Encrypt"] = refObj(Reference{Number: 99})
+	if _, err := newPDFUpdate(doc); err == nil {
+		t.Error("expected error for encrypted document")
+	}
+}
+
+func TestRewritePDF(t *testing.T) {
+	doc := mustLoad(t, buildXRefStreamPDF())
+	cat, _ := doc.Catalog()
+	cat["Extra"] = dictObj(Dict{"Marker": nameObj("Added")})
+
+	out, err := rewritePDF(doc, map[int]*Object{1: dictObj(cat)})
+	if err != nil {
+		t.Fatalf("rewritePDF: %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.5\n")) {
+		t.Errorf("header = %q, want the original version", out[:9])
+	}
+	rewritten := mustLoad(t, out)
+	if rewritten.usesXRefStream() {
+		t.Error("rewritten file kept an xref stream")
+	}
+	if _, ok := rewritten.xref[4]; ok {
+		t.Error("rewritten file kept the old xref stream object")
+	}
+	if bytes.Count(out, []byte("%%EOF")) != 1 {
+		t.Error("rewritten file has more than one revision")
+	}
+	checkUpdated(t, rewritten)
+}
+
+func mustLoad(t *testing.T, data []byte) *Document {
+	t.Helper()
+	doc, err := Load(data)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return doc
+}
+
+// checkUpdated verifies the catalog change made by the update tests and
+// that the original pages are still reachable.
+func checkUpdated(t *testing.T, doc *Document) {
+	t.Helper()
+	cat, err := doc.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	extra, _ := doc.Resolve(cat["Extra"])
+	if extra == nil || extra.Type != ObjDict {
+		t.Fatalf("catalog /Extra = %v, want dict", extra)
+	}
+	if m, _ := extra.Dict.GetName("Marker"); m != "Added" {
+		t.Errorf("/Marker = %q, want Added", m)
+	}
+	pages, err := doc.Pages()
+	if err != nil || len(pages) == 0 {
+		t.Errorf("Pages() = %d pages, %v", len(pages), err)
+	}
+}
+
+// buildXRefStreamPDF returns a one-page PDF whose cross-reference section
+// is an uncompressed xref stream.
+func buildXRefStreamPDF() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	offsets := make([]int, 4)
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	}
+	for i, o := range objs {
+		offsets[i+1] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xrefOff := buf.Len()
+	entries := []byte{0, 0, 0, 0xff}
+	for i := 1; i <= 3; i++ {
+		entries = append(entries, 1, byte(offsets[i]>>8), byte(offsets[i]), 0)
+	}
+	entries = append(entries, 1, byte(xrefOff>>8), byte(xrefOff), 0)
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Type /XRef /Size 5 /W [1 2 1] /Root 1 0 R /Length %d >>\nstream\n", len(entries))
+	buf.Write(entries)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOff)
+	return buf.Bytes()
+}